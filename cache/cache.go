@@ -78,6 +78,11 @@ const (
 	KeyTrustTreeChains            = "trust_tree_chains"
 	KeyTrustChainResolvedMetadata = "trustchain_resolved_metadata"
 	KeySubordinateListing         = "subordinate_listing"
+	KeyJWKS                       = "jwks"
+	KeySignedJWKS                 = "signed_jwks"
+	KeyTrustMarkStatus            = "trust_mark_status"
+	KeyTrustMarkedEntities        = "trust_marked_entities"
+	KeyDelegationStatus           = "delegation_status"
 )
 
 // Key combines a sub system prefix with the key to a cache key
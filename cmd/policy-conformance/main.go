@@ -0,0 +1,66 @@
+// Command policy-conformance runs metadata policy test vectors against the
+// metadata policy implementation of this library and prints a
+// machine-readable json report.
+//
+// Usage:
+//
+//	policy-conformance [-vectors file] [-o file] [-failed-only]
+//
+// The command exits with status 1 if at least one vector failed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lionick/oidfed-lib"
+)
+
+func main() {
+	vectorsPath := flag.String(
+		"vectors", "metadata-policy-test-vectors-2025-02-13.json", "path to the metadata policy test vectors",
+	)
+	outPath := flag.String("o", "", "write the report to this file instead of stdout")
+	failedOnly := flag.Bool("failed-only", false, "only include failed vectors and vectors with deviations in the report")
+	flag.Parse()
+
+	vectors, err := oidfed.LoadPolicyConformanceVectors(*vectorsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	report := oidfed.RunPolicyConformance(vectors)
+	if *failedOnly {
+		results := make([]oidfed.PolicyConformanceResult, 0)
+		for _, r := range report.Results {
+			if !r.Passed || len(r.Deviations) > 0 {
+				results = append(results, r)
+			}
+		}
+		report.Results = results
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	data = append(data, '\n')
+	if *outPath == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*outPath, data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Fprintf(
+		os.Stderr, "%d vectors: %d passed, %d failed, %d with deviations\n",
+		report.Total, report.Passed, report.Failed, report.WithDeviations,
+	)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
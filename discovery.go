@@ -74,11 +74,7 @@ func (e *CollectedEntity) setUIInfoField(
 
 	for i := 0; i < uiInfoType.NumField(); i++ {
 		structField := uiInfoType.Field(i)
-		tag := structField.Tag.Get("json")
-		tagName := tag
-		if commaIdx := len(tag); commaIdx != -1 {
-			tagName = tag[:commaIdx]
-		}
+		tagName, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
 
 		if tagName == jsonTag {
 			fieldValue := uiInfoValue.Field(i)
@@ -107,6 +103,53 @@ func (e *CollectedEntity) setUIInfoField(
 	return nil
 }
 
+// setLanguageTaggedUIInfoField sets all language variants of a claim in
+// UIInfo; the untagged value is set as the field, the language-tagged ones
+// end up in Extra
+func (e *CollectedEntity) setLanguageTaggedUIInfoField(entityType, claim string, value LanguageTaggedString) {
+	for _, tag := range value.Tags() {
+		if value[tag] == "" {
+			continue
+		}
+		if err := e.setUIInfoField(entityType, languageTaggedClaimName(claim, tag), value[tag]); err != nil {
+			log.Error(err.Error())
+		}
+	}
+}
+
+// LanguageTaggedClaim returns all language variants of a string claim of the
+// UIInfo, e.g. 'display_name' and 'display_name#de'; see LanguageTaggedString
+func (i UIInfo) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	var untagged string
+	switch claim {
+	case "display_name":
+		untagged = i.DisplayName
+	case "description":
+		untagged = i.Description
+	case "logo_uri":
+		untagged = i.LogoURI
+	case "policy_uri":
+		untagged = i.PolicyURI
+	case "information_uri":
+		untagged = i.InformationURI
+	}
+	return languageTaggedClaim(claim, untagged, i.Extra)
+}
+
+// PreferredDisplayName returns the display name in the language that best
+// matches the passed languages, which are ordered by preference; see
+// LanguageTaggedString.Preferred
+func (i UIInfo) PreferredDisplayName(languages ...string) string {
+	return i.LanguageTaggedClaim("display_name").Preferred(languages...)
+}
+
+// PreferredDescription returns the description in the language that best
+// matches the passed languages, which are ordered by preference; see
+// LanguageTaggedString.Preferred
+func (i UIInfo) PreferredDescription(languages ...string) string {
+	return i.LanguageTaggedClaim("description").Preferred(languages...)
+}
+
 // MarshalJSON implements the json.Marshaler interface
 func (e CollectedEntity) MarshalJSON() ([]byte, error) {
 	type Alias CollectedEntity
@@ -164,7 +207,21 @@ type EntityCollector interface {
 // SimpleEntityCollector is an EntityCollector that collects entities in a
 // federation
 type SimpleEntityCollector struct {
-	visitedEntities *mutexedStrSet
+	// UseTrustMarkLists enables a fast path for requests with TrustMarkTypes:
+	// instead of crawling the whole federation, the candidate entities are
+	// obtained from the trust mark list endpoints of the trust mark issuers
+	// that the trust anchor allows for these types. The trust marks of the
+	// candidates are still verified. Entities that are not part of the
+	// federation might be listed by a trust mark issuer; use the
+	// FilterableVerifiedChainsEntityCollector with this collector to only
+	// return entities with a valid trust chain.
+	// If the candidates cannot be obtained this way, the federation is crawled.
+	UseTrustMarkLists bool
+	// EnrichTrustMarks enables adding display information from the
+	// TrustMarkCatalogue of the trust anchor to the TrustMarks of the
+	// collected entities, see TrustMarkCatalogue.EnrichTrustMarks
+	EnrichTrustMarks bool
+	visitedEntities  *mutexedStrSet
 }
 
 type mutexedStrSet struct {
@@ -242,7 +299,80 @@ type FilterableVerifiedChainsEntityCollector struct {
 // CollectEntities implements the EntityCollector interface
 func (d *SimpleEntityCollector) CollectEntities(req apimodel.EntityCollectionRequest) (entities []*CollectedEntity) {
 	d.visitedEntities = newMutexedStrSet()
-	return d.collect(req, NewTrustAnchorsFromEntityIDs(req.TrustAnchor)...)
+	ok := false
+	if d.UseTrustMarkLists && len(req.TrustMarkTypes) > 0 {
+		if entities, ok = d.collectTrustMarked(req); !ok {
+			internal.Log("Could not use trust mark lists, crawling the federation")
+		}
+	}
+	if !ok {
+		entities = d.collect(req, NewTrustAnchorsFromEntityIDs(req.TrustAnchor)...)
+	}
+	if d.EnrichTrustMarks && slices.Contains(req.Claims, "trust_marks") {
+		enrichTrustMarks(req.TrustAnchor, entities)
+	}
+	return entities
+}
+
+func enrichTrustMarks(trustAnchor string, entities []*CollectedEntity) {
+	if !slices.ContainsFunc(
+		entities, func(e *CollectedEntity) bool {
+			return len(e.TrustMarks) > 0
+		},
+	) {
+		return
+	}
+	catalogue, err := GetTrustMarkCatalogue(trustAnchor)
+	if err != nil {
+		internal.Logf("Could not obtain trust mark catalogue: %s", err.Error())
+		return
+	}
+	for _, e := range entities {
+		catalogue.AddTrustMarkTypeDisplayInfo(e.TrustMarks)
+	}
+	for _, e := range entities {
+		catalogue.EnrichTrustMarks(e.TrustMarks)
+	}
+}
+
+// collectTrustMarked collects the entities holding the requested trust marks
+// from the trust mark list endpoints; the second return value is false if
+// the trust mark lists cannot be used for the request
+func (d *SimpleEntityCollector) collectTrustMarked(
+	req apimodel.EntityCollectionRequest,
+) (entities []*CollectedEntity, ok bool) {
+	ta := &lazyEntityConfiguration{entityID: req.TrustAnchor}
+	taConfig, err := ta.get()
+	if err != nil {
+		internal.Logf("Could not get trust anchor entity configuration: %s", err.Error())
+		return nil, false
+	}
+	candidates, ok := trustMarkedEntityCandidates(&taConfig.EntityStatementPayload, req.TrustMarkTypes)
+	if !ok {
+		return nil, false
+	}
+	internal.Logf("Obtained %d trust marked candidates", len(candidates))
+
+	sem := make(chan struct{}, maxCollectWorkers)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, entityID := range candidates {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			collectedEntity, _ := d.collectEntity(req, entityID, ta)
+			if collectedEntity == nil {
+				return
+			}
+			mutex.Lock()
+			entities = append(entities, collectedEntity)
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	return entities, true
 }
 
 const maxCollectWorkers = 128
@@ -256,9 +386,7 @@ func (d *SimpleEntityCollector) collect(
 	entityChan := make(chan *CollectedEntity)
 	doneChan := make(chan struct{})
 
-	var ta *EntityStatement
-	var taErr error
-	var taOnce sync.Once
+	ta := &lazyEntityConfiguration{entityID: req.TrustAnchor}
 
 	seen := make(map[string]bool)
 	var seenMu sync.Mutex
@@ -319,140 +447,11 @@ func (d *SimpleEntityCollector) collect(
 				for _, subordinateID := range subordinates {
 					run(
 						func() {
-							entityConfig, err := GetEntityConfiguration(subordinateID)
-							if err != nil {
-								internal.Logf("Failed to get entity config for %s: %s", subordinateID, err.Error())
-								return
-							}
-							if entityConfig.Metadata == nil {
-								internal.Log("No metadata present -> skipping")
+							collectedEntity, entityConfig := d.collectEntity(req, subordinateID, ta)
+							if entityConfig == nil {
 								return
 							}
-
-							et := entityConfig.Metadata.GuessEntityTypes()
-							displayNames := entityConfig.Metadata.GuessDisplayNames()
-
-							includeEntity := true
-							if req.EntityTypes != nil && len(arrays.Intersect(et, req.EntityTypes)) == 0 {
-								includeEntity = false
-							}
-							if req.NameQuery != "" && !matchDisplayName(req.NameQuery, displayNames, MatchModeFuzzy) {
-								includeEntity = false
-							}
-
-							for _, trustMarkType := range req.TrustMarkTypes {
-								trustMarkInfo := entityConfig.TrustMarks.FindByID(trustMarkType)
-								if trustMarkInfo == nil {
-									includeEntity = false
-									break
-								}
-								taOnce.Do(
-									func() {
-										ta, taErr = GetEntityConfiguration(req.TrustAnchor)
-									},
-								)
-								if taErr != nil || trustMarkInfo.VerifyFederation(&ta.EntityStatementPayload) != nil {
-									includeEntity = false
-									break
-								}
-							}
-
-							if includeEntity {
-								collectedEntity := &CollectedEntity{
-									EntityID: subordinateID,
-								}
-
-								if req.Claims == nil || slices.Contains(req.Claims, "entity_types") {
-									collectedEntity.EntityTypes = et
-								}
-
-								uiInfoClaims := []string{
-									"description",
-									"logo_uri",
-									"policy_uri",
-									"information_uri",
-								}
-								for _, c := range uiInfoClaims {
-									if req.Claims == nil || slices.Contains(req.Claims, c) {
-										entityConfig.Metadata.
-											IterateStringClaim(
-												c, func(entityType, value string) {
-													collectedEntity.setUIInfoField(
-														entityType, c, value,
-													)
-												},
-											)
-									}
-								}
-								keywordsTag := "keywords"
-								if req.Claims == nil || slices.Contains(req.Claims, keywordsTag) {
-									entityConfig.Metadata.
-										IterateStringSliceClaim(
-											keywordsTag,
-											func(entityType string, value []string) {
-												collectedEntity.setUIInfoField(
-													entityType, keywordsTag, value,
-												)
-											},
-										)
-								}
-
-								if req.Claims == nil || slices.Contains(req.Claims, "display_name") {
-									for entityType, displayName := range displayNames {
-										if displayName != "" {
-											if err = collectedEntity.setUIInfoField(
-												entityType, "display_name", displayName,
-											); err != nil {
-												log.Error(err.Error())
-											}
-										}
-									}
-								}
-
-								if slices.ContainsFunc(
-									req.Claims, func(c string) bool {
-										return c == "metadata" || c == "trust_chain"
-									},
-								) {
-									resolveRequest := apimodel.ResolveRequest{
-										Subject:     subordinateID,
-										TrustAnchor: []string{req.TrustAnchor},
-									}
-									var res ResolveResponsePayload
-									switch resolver := DefaultMetadataResolver.(type) {
-									case LocalMetadataResolver:
-										res, _, err = resolver.resolveResponsePayloadWithoutTrustMarks(resolveRequest)
-									default:
-										res, err = DefaultMetadataResolver.ResolveResponsePayload(resolveRequest)
-									}
-									if err == nil {
-										if res.TrustMarks != nil && slices.Contains(req.Claims, "trust_marks") {
-											collectedEntity.TrustMarks = res.TrustMarks
-										}
-										if slices.Contains(req.Claims, "metadata") {
-											collectedEntity.metadata = res.Metadata
-										}
-										if slices.Contains(req.Claims, "trust_chain") {
-											collectedEntity.TrustChain = res.TrustChain
-										}
-									} else {
-										internal.Logf(
-											"Trust chain resolution failed for %s: %s", subordinateID, err.Error(),
-										)
-									}
-								}
-
-								if collectedEntity.TrustMarks == nil && slices.Contains(req.Claims, "trust_marks") {
-									taOnce.Do(
-										func() {
-											ta, taErr = GetEntityConfiguration(req.TrustAnchor)
-										},
-									)
-									if taErr == nil {
-										collectedEntity.TrustMarks = entityConfig.TrustMarks.VerifiedFederation(&ta.EntityStatementPayload)
-									}
-								}
-
+							if collectedEntity != nil {
 								entityChan <- collectedEntity
 							}
 
@@ -480,6 +479,157 @@ func (d *SimpleEntityCollector) collect(
 	return
 }
 
+// collectEntity obtains the entity configuration of the passed entity and
+// evaluates it against the request; it returns the CollectedEntity if the
+// entity matches the request and the entity configuration if it could be
+// obtained
+func (*SimpleEntityCollector) collectEntity(
+	req apimodel.EntityCollectionRequest, entityID string, ta *lazyEntityConfiguration,
+) (*CollectedEntity, *EntityStatement) {
+	entityConfig, err := GetEntityConfiguration(entityID)
+	if err != nil {
+		internal.Logf("Failed to get entity config for %s: %s", entityID, err.Error())
+		return nil, nil
+	}
+	if entityConfig.Metadata == nil {
+		internal.Log("No metadata present -> skipping")
+		return nil, nil
+	}
+
+	et := entityConfig.Metadata.GuessEntityTypes()
+	displayNames := entityConfig.Metadata.GuessLanguageTaggedDisplayNames()
+
+	includeEntity := true
+	if req.EntityTypes != nil && len(arrays.Intersect(et, req.EntityTypes)) == 0 {
+		includeEntity = false
+	}
+	if req.NameQuery != "" && !matchDisplayName(req.NameQuery, displayNames, MatchModeFuzzy) {
+		includeEntity = false
+	}
+
+	for _, trustMarkType := range req.TrustMarkTypes {
+		trustMarkInfo := entityConfig.TrustMarks.FindByID(trustMarkType)
+		if trustMarkInfo == nil {
+			includeEntity = false
+			break
+		}
+		taConfig, err := ta.get()
+		if err != nil || trustMarkInfo.VerifyFederation(&taConfig.EntityStatementPayload) != nil {
+			includeEntity = false
+			break
+		}
+	}
+
+	if !includeEntity {
+		return nil, entityConfig
+	}
+
+	collectedEntity := &CollectedEntity{
+		EntityID: entityID,
+	}
+
+	if req.Claims == nil || slices.Contains(req.Claims, "entity_types") {
+		collectedEntity.EntityTypes = et
+	}
+
+	uiInfoClaims := []string{
+		"description",
+		"logo_uri",
+		"policy_uri",
+		"information_uri",
+	}
+	for _, c := range uiInfoClaims {
+		if req.Claims == nil || slices.Contains(req.Claims, c) {
+			entityConfig.Metadata.
+				IterateLanguageTaggedClaim(
+					c, func(entityType string, value LanguageTaggedString) {
+						collectedEntity.setLanguageTaggedUIInfoField(
+							entityType, c, value,
+						)
+					},
+				)
+		}
+	}
+	keywordsTag := "keywords"
+	if req.Claims == nil || slices.Contains(req.Claims, keywordsTag) {
+		entityConfig.Metadata.
+			IterateStringSliceClaim(
+				keywordsTag,
+				func(entityType string, value []string) {
+					collectedEntity.setUIInfoField(
+						entityType, keywordsTag, value,
+					)
+				},
+			)
+	}
+
+	if req.Claims == nil || slices.Contains(req.Claims, "display_name") {
+		for entityType, displayName := range displayNames {
+			collectedEntity.setLanguageTaggedUIInfoField(
+				entityType, "display_name", displayName,
+			)
+		}
+	}
+
+	if slices.ContainsFunc(
+		req.Claims, func(c string) bool {
+			return c == "metadata" || c == "trust_chain"
+		},
+	) {
+		resolveRequest := apimodel.ResolveRequest{
+			Subject:     entityID,
+			TrustAnchor: []string{req.TrustAnchor},
+		}
+		var res ResolveResponsePayload
+		switch resolver := DefaultMetadataResolver.(type) {
+		case LocalMetadataResolver:
+			res, _, err = resolver.resolveResponsePayloadWithoutTrustMarks(resolveRequest)
+		default:
+			res, err = DefaultMetadataResolver.ResolveResponsePayload(resolveRequest)
+		}
+		if err == nil {
+			if res.TrustMarks != nil && slices.Contains(req.Claims, "trust_marks") {
+				collectedEntity.TrustMarks = res.TrustMarks
+			}
+			if slices.Contains(req.Claims, "metadata") {
+				collectedEntity.metadata = res.Metadata
+			}
+			if slices.Contains(req.Claims, "trust_chain") {
+				collectedEntity.TrustChain = res.TrustChain
+			}
+		} else {
+			internal.Logf(
+				"Trust chain resolution failed for %s: %s", entityID, err.Error(),
+			)
+		}
+	}
+
+	if collectedEntity.TrustMarks == nil && slices.Contains(req.Claims, "trust_marks") {
+		if taConfig, err := ta.get(); err == nil {
+			collectedEntity.TrustMarks = entityConfig.TrustMarks.VerifiedFederation(&taConfig.EntityStatementPayload)
+		}
+	}
+
+	return collectedEntity, entityConfig
+}
+
+// lazyEntityConfiguration obtains an entity configuration on first use
+type lazyEntityConfiguration struct {
+	entityID string
+	once     sync.Once
+	ec       *EntityStatement
+	err      error
+}
+
+func (l *lazyEntityConfiguration) get() (*EntityStatement, error) {
+	l.once.Do(
+		func() {
+			l.ec, l.err = GetEntityConfiguration(l.entityID)
+		},
+	)
+	return l.ec, l.err
+}
+
 type matchMode string
 
 const (
@@ -490,12 +640,12 @@ const (
 	MatchModeFuzzy                    matchMode = "fuzzy"
 )
 
-func matchDisplayName(input string, names map[string]string, mode matchMode) bool {
-	collectedNames := make([]string, len(names))
-	i := 0
+// matchDisplayName checks if the input matches any language variant of the
+// display names
+func matchDisplayName(input string, names map[string]LanguageTaggedString, mode matchMode) bool {
+	var collectedNames []string
 	for _, name := range names {
-		collectedNames[i] = name
-		i++
+		collectedNames = append(collectedNames, name.Values()...)
 	}
 	return matchWithMode(input, collectedNames, mode)
 }
@@ -724,6 +874,66 @@ func EntityCollectionFilterOPs() EntityCollectionFilter {
 	)
 }
 
+// EntityCollectionFilterEntityTypes returns an EntityCollectionFilter that
+// filters to entities that have at least one of the passed entity types
+func EntityCollectionFilterEntityTypes(entityTypes ...string) EntityCollectionFilter {
+	return NewEntityCollectionFilter(
+		func(e *CollectedEntity) bool {
+			if e == nil {
+				return false
+			}
+			return slices.ContainsFunc(
+				e.EntityTypes, func(entityType string) bool {
+					return slices.Contains(entityTypes, entityType)
+				},
+			)
+		},
+	)
+}
+
+// EntityCollectionFilterCredentialIssuers returns an EntityCollectionFilter
+// that filters to OpenID4VC credential issuers
+func EntityCollectionFilterCredentialIssuers() EntityCollectionFilter {
+	return EntityCollectionFilterEntityTypes(oidfedconst.EntityTypeOpenIDCredentialIssuer)
+}
+
+// EntityCollectionFilterWalletProviders returns an EntityCollectionFilter
+// that filters to OpenID4VC wallet providers
+func EntityCollectionFilterWalletProviders() EntityCollectionFilter {
+	return EntityCollectionFilterEntityTypes(oidfedconst.EntityTypeOpenIDWalletProvider)
+}
+
+// EntityCollectionFilterCredentialVerifiers returns an EntityCollectionFilter
+// that filters to OpenID4VC credential verifiers
+func EntityCollectionFilterCredentialVerifiers() EntityCollectionFilter {
+	return EntityCollectionFilterEntityTypes(oidfedconst.EntityTypeOpenIDCredentialVerifier)
+}
+
+// EntityCollectionFilterCredentialIssuerSupportsCredentialConfigurations
+// returns an EntityCollectionFilter that filters to credential issuers that
+// support all the passed credential configuration ids
+func EntityCollectionFilterCredentialIssuerSupportsCredentialConfigurations(
+	trustAnchorIDs []string, neededConfigurationIDs ...string,
+) EntityCollectionFilter {
+	return NewEntityCollectionFilter(
+		func(e *CollectedEntity) bool {
+			if e == nil {
+				return false
+			}
+			metadata := getMetadataForCollectedEntity(e, trustAnchorIDs)
+			if metadata == nil || metadata.OpenIDCredentialIssuer == nil {
+				return false
+			}
+			for _, id := range neededConfigurationIDs {
+				if _, ok := metadata.OpenIDCredentialIssuer.CredentialConfigurationsSupported[id]; !ok {
+					return false
+				}
+			}
+			return true
+		},
+	)
+}
+
 func subordinateListingCacheSet(listingEndpoint string, ids []string) {
 	if err := cache.Set(
 		cache.Key(cache.KeySubordinateListing, listingEndpoint), ids,
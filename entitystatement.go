@@ -217,6 +217,10 @@ type TrustMarkOwners map[string]TrustMarkOwnerSpec
 type TrustMarkOwnerSpec struct {
 	ID   string    `json:"sub" yaml:"entity_id"`
 	JWKS jwks.JWKS `json:"jwks" yaml:"jwks"`
+	// DelegationStatusEndpoint is the trust mark owner's delegation status
+	// endpoint; if set, the status of delegations is checked when
+	// verifying trust marks
+	DelegationStatusEndpoint string `json:"delegation_status_endpoint,omitempty" yaml:"delegation_status_endpoint"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -0,0 +1,521 @@
+// Package extraoperators provides additional metadata policy operators that
+// are not defined by the OpenID Federation specification but are commonly
+// needed by federations.
+//
+// The operators are not active by default; they must be registered with
+// Register, or with RegisterWith for a oidfed.PolicyEngine other than the
+// oidfed.DefaultPolicyEngine. The operators are applied after the
+// value-modifying standard operators and before 'essential'. Registering also
+// registers the verifiers for them.
+//
+// Each operator of this package can be combined with the operators listed in
+// its MayCombineWith; registering it also allows the listed standard
+// operators to be combined with it.
+package extraoperators
+
+import (
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib"
+	"github.com/lionick/oidfed-lib/internal/utils"
+)
+
+// Constants for the PolicyOperatorNames of the operators in this package
+const (
+	PolicyOperatorRegexp     oidfed.PolicyOperatorName = "regexp"
+	PolicyOperatorIntersects oidfed.PolicyOperatorName = "intersects"
+	PolicyOperatorMinimum    oidfed.PolicyOperatorName = "minimum"
+	PolicyOperatorMaximum    oidfed.PolicyOperatorName = "maximum"
+	PolicyOperatorURLPrefix  oidfed.PolicyOperatorName = "url_prefix"
+)
+
+// standardOperators are the standard operators with which the checking
+// operators of this package may be combined; the checks are applied after
+// the value-modifying standard operators
+var standardOperators = []oidfed.PolicyOperatorName{
+	oidfed.PolicyOperatorValue,
+	oidfed.PolicyOperatorAdd,
+	oidfed.PolicyOperatorDefault,
+	oidfed.PolicyOperatorOneOf,
+	oidfed.PolicyOperatorSubsetOf,
+	oidfed.PolicyOperatorSupersetOf,
+	oidfed.PolicyOperatorEssential,
+}
+
+// IntersectsSets is the operator value of Intersects after merging; the value
+// must intersect each of the sets
+type IntersectsSets [][]any
+
+// Regexp is a PolicyOperator that checks that a string value (or all values
+// of a string array) match all regular expressions in the operator value.
+// Merging combines the expressions, so all of them must match.
+var Regexp = oidfed.NewPolicyOperator(
+	PolicyOperatorRegexp,
+	func(a, b any, pathInfo string) (any, error) {
+		as, err := toStrings(a, PolicyOperatorRegexp, pathInfo)
+		if err != nil {
+			return nil, err
+		}
+		bs, err := toStrings(b, PolicyOperatorRegexp, pathInfo)
+		if err != nil {
+			return nil, err
+		}
+		return union(as, bs), nil
+	},
+	func(value any, valueSet bool, policyValue any, essential bool, pathInfo string) (any, bool, error) {
+		if policyValue == nil || (!valueSet && !essential) {
+			return value, valueSet, nil
+		}
+		patterns, err := toStrings(policyValue, PolicyOperatorRegexp, pathInfo)
+		if err != nil {
+			return value, valueSet, err
+		}
+		if !valueSet || value == nil {
+			return value, valueSet, errors.Errorf(
+				"policy operator check failed: '%s' not set, but essential and must match '%v'", pathInfo, patterns,
+			)
+		}
+		values, err := valueStrings(value, pathInfo)
+		if err != nil {
+			return value, valueSet, err
+		}
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return value, valueSet, errors.Wrapf(err, "invalid regular expression in '%s'", pathInfo)
+			}
+			for _, v := range values {
+				if !re.MatchString(v) {
+					return value, valueSet, errors.Errorf(
+						"policy operator check failed for '%s': '%s' does not match '%s'", pathInfo, v, p,
+					)
+				}
+			}
+		}
+		return value, valueSet, nil
+	},
+	append([]oidfed.PolicyOperatorName{PolicyOperatorURLPrefix}, standardOperators...),
+)
+
+// Intersects is a PolicyOperator that checks that an array value contains at
+// least one of the values of the operator value.
+// Merging keeps the operator values of both policies as IntersectsSets, so
+// that the value must intersect each of them. An operator value whose values
+// are all arrays, e.g. marshalled IntersectsSets, is also interpreted as
+// IntersectsSets.
+var Intersects = oidfed.NewPolicyOperator(
+	PolicyOperatorIntersects,
+	func(a, b any, _ string) (any, error) {
+		if a == nil {
+			return b, nil
+		}
+		if b == nil {
+			return a, nil
+		}
+		var merged IntersectsSets
+		for _, set := range append(intersectsSets(a), intersectsSets(b)...) {
+			if !slices.ContainsFunc(
+				merged, func(m []any) bool {
+					return reflect.DeepEqual(m, set)
+				},
+			) {
+				merged = append(merged, set)
+			}
+		}
+		return merged, nil
+	},
+	func(value any, valueSet bool, policyValue any, essential bool, pathInfo string) (any, bool, error) {
+		if policyValue == nil || (!valueSet && !essential) {
+			return value, valueSet, nil
+		}
+		if !valueSet || value == nil {
+			return value, valueSet, errors.Errorf(
+				"policy operator check failed: '%s' not set, but essential and must intersect '%v'", pathInfo,
+				policyValue,
+			)
+		}
+		v := utils.Slicify(value)
+		for _, set := range intersectsSets(policyValue) {
+			if !slices.ContainsFunc(
+				set, func(p any) bool {
+					return utils.ReflectSliceContains(p, v)
+				},
+			) {
+				return value, valueSet, errors.Errorf(
+					"policy operator check failed for '%s': '%v' does not intersect '%v'", pathInfo, v, set,
+				)
+			}
+		}
+		return value, valueSet, nil
+	},
+	standardOperators,
+)
+
+// Minimum is a PolicyOperator that checks that a numeric value is greater
+// than or equal to the operator value.
+// Merging uses the larger of both values.
+var Minimum = oidfed.NewPolicyOperator(
+	PolicyOperatorMinimum,
+	func(a, b any, pathInfo string) (any, error) {
+		return mergeBound(a, b, math.Max, PolicyOperatorMinimum, pathInfo)
+	},
+	func(value any, valueSet bool, policyValue any, essential bool, pathInfo string) (any, bool, error) {
+		return applyBound(
+			value, valueSet, policyValue, essential, pathInfo, PolicyOperatorMinimum,
+			func(v, bound float64) bool { return v >= bound },
+		)
+	},
+	[]oidfed.PolicyOperatorName{
+		PolicyOperatorMaximum,
+		oidfed.PolicyOperatorValue,
+		oidfed.PolicyOperatorDefault,
+		oidfed.PolicyOperatorOneOf,
+		oidfed.PolicyOperatorEssential,
+	},
+)
+
+// Maximum is a PolicyOperator that checks that a numeric value is less
+// than or equal to the operator value.
+// Merging uses the smaller of both values.
+var Maximum = oidfed.NewPolicyOperator(
+	PolicyOperatorMaximum,
+	func(a, b any, pathInfo string) (any, error) {
+		return mergeBound(a, b, math.Min, PolicyOperatorMaximum, pathInfo)
+	},
+	func(value any, valueSet bool, policyValue any, essential bool, pathInfo string) (any, bool, error) {
+		return applyBound(
+			value, valueSet, policyValue, essential, pathInfo, PolicyOperatorMaximum,
+			func(v, bound float64) bool { return v <= bound },
+		)
+	},
+	[]oidfed.PolicyOperatorName{
+		PolicyOperatorMinimum,
+		oidfed.PolicyOperatorValue,
+		oidfed.PolicyOperatorDefault,
+		oidfed.PolicyOperatorOneOf,
+		oidfed.PolicyOperatorEssential,
+	},
+)
+
+// URLPrefix is a PolicyOperator that checks that a URL value (or all values
+// of a URL array, e.g. 'redirect_uris') start with one of the URL prefixes in
+// the operator value.
+// A URL starts with a prefix if scheme, userinfo, and host (including the
+// port) are equal and the path of the prefix matches the path of the URL on
+// whole path segments, i.e. the prefix 'https://rp.example.org/cb' matches
+// 'https://rp.example.org/cb/1', but neither 'https://rp.example.org/cb1'
+// nor 'https://rp.example.org.evil.com/cb'. URLs with dot segments in the
+// path are rejected.
+// Merging keeps only the prefixes that satisfy both operator values,
+// i.e. the longer prefix of each pair where one is a prefix of the other.
+var URLPrefix = oidfed.NewPolicyOperator(
+	PolicyOperatorURLPrefix,
+	func(a, b any, pathInfo string) (any, error) {
+		if a == nil {
+			return b, nil
+		}
+		if b == nil {
+			return a, nil
+		}
+		as, err := toStrings(a, PolicyOperatorURLPrefix, pathInfo)
+		if err != nil {
+			return nil, err
+		}
+		bs, err := toStrings(b, PolicyOperatorURLPrefix, pathInfo)
+		if err != nil {
+			return nil, err
+		}
+		var merged []string
+		for _, ap := range as {
+			for _, bp := range bs {
+				switch {
+				case urlHasPrefix(ap, bp):
+					merged = union(merged, []string{ap})
+				case urlHasPrefix(bp, ap):
+					merged = union(merged, []string{bp})
+				}
+			}
+		}
+		if len(merged) == 0 {
+			return nil, errors.Errorf(
+				"'%s' operator values '%v' and '%v' in '%s' have no common prefix", PolicyOperatorURLPrefix, as, bs,
+				pathInfo,
+			)
+		}
+		return merged, nil
+	},
+	func(value any, valueSet bool, policyValue any, essential bool, pathInfo string) (any, bool, error) {
+		if policyValue == nil || (!valueSet && !essential) {
+			return value, valueSet, nil
+		}
+		prefixes, err := toStrings(policyValue, PolicyOperatorURLPrefix, pathInfo)
+		if err != nil {
+			return value, valueSet, err
+		}
+		if !valueSet || value == nil {
+			return value, valueSet, errors.Errorf(
+				"policy operator check failed: '%s' not set, but essential and must start with one of '%v'",
+				pathInfo, prefixes,
+			)
+		}
+		values, err := valueStrings(value, pathInfo)
+		if err != nil {
+			return value, valueSet, err
+		}
+		for _, v := range values {
+			if !slices.ContainsFunc(
+				prefixes, func(p string) bool {
+					return urlHasPrefix(v, p)
+				},
+			) {
+				return value, valueSet, errors.Errorf(
+					"policy operator check failed for '%s': '%s' does not start with one of '%v'", pathInfo, v,
+					prefixes,
+				)
+			}
+		}
+		return value, valueSet, nil
+	},
+	append([]oidfed.PolicyOperatorName{PolicyOperatorRegexp}, standardOperators...),
+)
+
+// All returns all PolicyOperator of this package
+func All() []oidfed.PolicyOperator {
+	return []oidfed.PolicyOperator{
+		Regexp,
+		Intersects,
+		Minimum,
+		Maximum,
+		URLPrefix,
+	}
+}
+
+// Register registers the passed PolicyOperator of this package (or all of
+// them if none are passed) with the oidfed.DefaultPolicyEngine and registers
+// the PolicyVerifier for them, see RegisterWith.
+func Register(ops ...oidfed.PolicyOperator) error {
+	return RegisterWith(oidfed.DefaultPolicyEngine, ops...)
+}
+
+// RegisterWith registers the passed PolicyOperator of this package (or all of
+// them if none are passed) with the passed oidfed.PolicyEngine and registers
+// the PolicyVerifier for them; the verifiers are only registered once per
+// oidfed.PolicyEngine.
+func RegisterWith(engine *oidfed.PolicyEngine, ops ...oidfed.PolicyOperator) error {
+	if engine == nil {
+		engine = oidfed.DefaultPolicyEngine
+	}
+	if len(ops) == 0 {
+		ops = All()
+	}
+	for _, op := range ops {
+		if err := engine.RegisterPolicyOperator(op); err != nil {
+			return err
+		}
+	}
+	registerVerifiers(engine)
+	return nil
+}
+
+func toStrings(v any, operator oidfed.PolicyOperatorName, pathInfo string) ([]string, error) {
+	switch vv := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{vv}, nil
+	case []string:
+		return vv, nil
+	case []any:
+		out := make([]string, len(vv))
+		for i, e := range vv {
+			s, ok := e.(string)
+			if !ok {
+				return nil, errors.Errorf("'%s' operator value in '%s' must be a string array", operator, pathInfo)
+			}
+			out[i] = s
+		}
+		return out, nil
+	default:
+		return nil, errors.Errorf("'%s' operator value in '%s' must be a string array", operator, pathInfo)
+	}
+}
+
+func valueStrings(value any, pathInfo string) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.String {
+		return []string{rv.String()}, nil
+	}
+	if rv.Kind() != reflect.Slice {
+		return nil, errors.Errorf("value of '%s' is not a string or string array", pathInfo)
+	}
+	out := make([]string, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		e := rv.Index(i)
+		if e.Kind() == reflect.Interface {
+			e = e.Elem()
+		}
+		if e.Kind() != reflect.String {
+			return nil, errors.Errorf("value of '%s' is not a string or string array", pathInfo)
+		}
+		out[i] = e.String()
+	}
+	return out, nil
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func mergeBound(
+	a, b any, pick func(x, y float64) float64, operator oidfed.PolicyOperatorName, pathInfo string,
+) (any, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return nil, errors.Errorf("'%s' operator value in '%s' must be a number", operator, pathInfo)
+	}
+	return pick(af, bf), nil
+}
+
+func applyBound(
+	value any, valueSet bool, policyValue any, essential bool, pathInfo string,
+	operator oidfed.PolicyOperatorName, check func(v, bound float64) bool,
+) (any, bool, error) {
+	if policyValue == nil || (!valueSet && !essential) {
+		return value, valueSet, nil
+	}
+	bound, ok := toFloat(policyValue)
+	if !ok {
+		return value, valueSet, errors.Errorf("'%s' operator value in '%s' must be a number", operator, pathInfo)
+	}
+	if !valueSet || value == nil {
+		return value, valueSet, errors.Errorf(
+			"policy operator check failed: '%s' not set, but essential and must satisfy '%s' %v", pathInfo,
+			operator, policyValue,
+		)
+	}
+	v, ok := toFloat(value)
+	if !ok {
+		return value, valueSet, errors.Errorf("value of '%s' is not a number", pathInfo)
+	}
+	if !check(v, bound) {
+		return value, valueSet, errors.Errorf(
+			"policy operator check failed for '%s': %v does not satisfy '%s' %v", pathInfo, value, operator,
+			policyValue,
+		)
+	}
+	return value, valueSet, nil
+}
+
+func union(a, b []string) []string {
+	out := append([]string{}, a...)
+	for _, v := range b {
+		if !utils.SliceContains(v, out) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// intersectsSets returns the sets of an Intersects operator value
+func intersectsSets(v any) [][]any {
+	if v == nil {
+		return nil
+	}
+	if sets, ok := v.(IntersectsSets); ok {
+		return sets
+	}
+	rv := reflect.ValueOf(utils.Slicify(v))
+	sets := make([][]any, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		e := rv.Index(i)
+		if e.Kind() == reflect.Interface {
+			e = e.Elem()
+		}
+		if e.Kind() != reflect.Slice {
+			return [][]any{toAnySlice(rv)}
+		}
+		sets[i] = toAnySlice(e)
+	}
+	if len(sets) == 0 {
+		return [][]any{{}}
+	}
+	return sets
+}
+
+func toAnySlice(rv reflect.Value) []any {
+	out := make([]any, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// parseURLPrefix parses a prefix of the URLPrefix operator; a prefix must be
+// an absolute URL with a host and without query and fragment
+func parseURLPrefix(prefix string) (*url.URL, error) {
+	u, err := url.Parse(prefix)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.Scheme == "" || u.Host == "" || u.Opaque != "" {
+		return nil, errors.Errorf("'%s' is not an absolute url", prefix)
+	}
+	if u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return nil, errors.Errorf("'%s' must not have a query or fragment", prefix)
+	}
+	return u, nil
+}
+
+// urlHasPrefix checks if the URL s starts with the URL prefix, see URLPrefix
+func urlHasPrefix(s, prefix string) bool {
+	p, err := parseURLPrefix(prefix)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Opaque != "" {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, p.Scheme) || !strings.EqualFold(u.Host, p.Host) ||
+		u.User.String() != p.User.String() {
+		return false
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	prefixPath := strings.TrimSuffix(p.EscapedPath(), "/")
+	path := u.EscapedPath()
+	return prefixPath == "" || path == prefixPath || strings.HasPrefix(path, prefixPath+"/")
+}
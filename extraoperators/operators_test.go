@@ -0,0 +1,385 @@
+package extraoperators
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lionick/oidfed-lib"
+)
+
+func init() {
+	if err := Register(); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	essentialIndex := -1
+	for i, o := range oidfed.PolicyOperatorOrder() {
+		if o == oidfed.PolicyOperatorEssential {
+			essentialIndex = i
+		}
+	}
+	for _, op := range All() {
+		found := -1
+		for i, o := range oidfed.PolicyOperatorOrder() {
+			if o == op.Name() {
+				if found != -1 {
+					t.Errorf("operator '%s' is contained multiple times in OperatorOrder", op.Name())
+				}
+				found = i
+			}
+		}
+		if found == -1 {
+			t.Errorf("operator '%s' not in OperatorOrder", op.Name())
+		} else if found > essentialIndex {
+			t.Errorf("operator '%s' is ordered after '%s'", op.Name(), oidfed.PolicyOperatorEssential)
+		}
+	}
+	if err := Register(); err != nil {
+		t.Fatal(err)
+	}
+	if len(oidfed.PolicyOperatorOrder()) != 7+len(All()) {
+		t.Errorf("registering twice changed OperatorOrder: %v", oidfed.PolicyOperatorOrder())
+	}
+}
+
+func TestRegisterWith(t *testing.T) {
+	engine := oidfed.NewPolicyEngine()
+	if err := RegisterWith(engine, Minimum); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterWith(engine, Minimum); err != nil {
+		t.Fatal(err)
+	}
+	order := engine.PolicyOperatorOrder()
+	if len(order) != 8 || !slices.Contains(order, PolicyOperatorMinimum) {
+		t.Errorf("unexpected operator order of the PolicyEngine: %v", order)
+	}
+
+	valid := &oidfed.MetadataPolicies{
+		RelyingParty: oidfed.MetadataPolicy{
+			"default_max_age": {PolicyOperatorMinimum: 60},
+		},
+	}
+	if _, err := engine.MergeMetadataPolicies(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	invalid := &oidfed.MetadataPolicies{
+		RelyingParty: oidfed.MetadataPolicy{
+			"default_max_age": {PolicyOperatorMinimum: "sixty"},
+		},
+	}
+	if _, err := engine.MergeMetadataPolicies(invalid); err == nil {
+		t.Errorf("expected error from the verifier registered with the PolicyEngine")
+	}
+	if _, err := oidfed.NewPolicyEngine().MergeMetadataPolicies(invalid); err != nil {
+		t.Errorf("verifiers must only be registered with the passed PolicyEngine: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       oidfed.MetadataPolicyEntry
+		value       any
+		valueSet    bool
+		errExpected bool
+	}{
+		{
+			name:     "regexp match",
+			entry:    oidfed.MetadataPolicyEntry{PolicyOperatorRegexp: []any{"^https://", "example\\.org"}},
+			value:    "https://rp.example.org",
+			valueSet: true,
+		},
+		{
+			name:        "regexp no match",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorRegexp: "^https://"},
+			value:       []string{"https://rp.example.org", "http://rp.example.org"},
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:  "regexp not set",
+			entry: oidfed.MetadataPolicyEntry{PolicyOperatorRegexp: "^https://"},
+		},
+		{
+			name: "regexp not set but essential",
+			entry: oidfed.MetadataPolicyEntry{
+				PolicyOperatorRegexp:           "^https://",
+				oidfed.PolicyOperatorEssential: true,
+			},
+			errExpected: true,
+		},
+		{
+			name:     "intersects",
+			entry:    oidfed.MetadataPolicyEntry{PolicyOperatorIntersects: []any{"a", "b"}},
+			value:    []string{"c", "b"},
+			valueSet: true,
+		},
+		{
+			name:        "intersects not",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorIntersects: []any{"a", "b"}},
+			value:       []string{"c", "d"},
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name: "within bounds",
+			entry: oidfed.MetadataPolicyEntry{
+				PolicyOperatorMinimum: 1,
+				PolicyOperatorMaximum: 10.0,
+			},
+			value:    int64(5),
+			valueSet: true,
+		},
+		{
+			name:        "below minimum",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorMinimum: 6},
+			value:       5,
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:        "above maximum",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorMaximum: 4.5},
+			value:       5,
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:     "intersects sets",
+			entry:    oidfed.MetadataPolicyEntry{PolicyOperatorIntersects: IntersectsSets{{"a", "b"}, {"c"}}},
+			value:    []string{"b", "c"},
+			valueSet: true,
+		},
+		{
+			name:        "intersects sets not all",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorIntersects: IntersectsSets{{"a", "b"}, {"c"}}},
+			value:       []string{"b"},
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:     "intersects marshalled sets",
+			entry:    oidfed.MetadataPolicyEntry{PolicyOperatorIntersects: []any{[]any{"a"}, []any{"c"}}},
+			value:    []string{"a", "c"},
+			valueSet: true,
+		},
+		{
+			name: "intersects combined with subset_of",
+			entry: oidfed.MetadataPolicyEntry{
+				PolicyOperatorIntersects:      []any{"a", "b"},
+				oidfed.PolicyOperatorSubsetOf: []any{"a", "c"},
+			},
+			value:    []any{"a"},
+			valueSet: true,
+		},
+		{
+			name: "bounds combined with default",
+			entry: oidfed.MetadataPolicyEntry{
+				PolicyOperatorMinimum:        1,
+				oidfed.PolicyOperatorDefault: 5,
+			},
+			value:    3,
+			valueSet: true,
+		},
+		{
+			name:     "url prefix",
+			entry:    oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: []any{"https://rp.example.org/"}},
+			value:    []string{"https://rp.example.org/redirect", "https://rp.example.org/other"},
+			valueSet: true,
+		},
+		{
+			name:        "url prefix violated",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: []any{"https://rp.example.org/"}},
+			value:       []string{"https://rp.example.org/redirect", "https://evil.example.org/"},
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:     "url prefix path segment",
+			entry:    oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org/cb"},
+			value:    []string{"https://rp.example.org/cb", "https://RP.example.org/cb/1?state=x"},
+			valueSet: true,
+		},
+		{
+			name:        "url prefix partial path segment",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org/cb"},
+			value:       "https://rp.example.org/cb1",
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:        "url prefix dot segments",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org/cb"},
+			value:       "https://rp.example.org/cb/../evil",
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:        "url prefix host suffix",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org"},
+			value:       "https://rp.example.org.evil.com/cb",
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:        "url prefix userinfo",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org"},
+			value:       "https://rp.example.org@evil.com/",
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:        "url prefix port",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org"},
+			value:       "https://rp.example.org:8443/cb",
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name:        "url prefix scheme",
+			entry:       oidfed.MetadataPolicyEntry{PolicyOperatorURLPrefix: "https://rp.example.org"},
+			value:       "http://rp.example.org/cb",
+			valueSet:    true,
+			errExpected: true,
+		},
+		{
+			name: "url prefix combined with one_of",
+			entry: oidfed.MetadataPolicyEntry{
+				PolicyOperatorURLPrefix:    "https://rp.example.org",
+				oidfed.PolicyOperatorOneOf: []any{"https://rp.example.org/cb"},
+			},
+			value:    "https://rp.example.org/cb",
+			valueSet: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				if err := test.entry.Verify("openid_relying_party.claim"); err != nil {
+					t.Fatalf("policy entry not valid: %v", err)
+				}
+				value, err := test.entry.ApplyTo(test.value, test.valueSet, "openid_relying_party.claim")
+				if err != nil {
+					if test.errExpected {
+						return
+					}
+					t.Fatal(err)
+				}
+				if test.errExpected {
+					t.Fatalf("expected error, but no error returned")
+				}
+				if !reflect.DeepEqual(value, test.value) {
+					t.Errorf("value changed from %v to %v", test.value, value)
+				}
+			},
+		)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		parent      oidfed.MetadataPolicy
+		sub         oidfed.MetadataPolicy
+		expected    oidfed.MetadataPolicy
+		errExpected bool
+	}{
+		{
+			name:     "regexp",
+			parent:   oidfed.MetadataPolicy{"c": {PolicyOperatorRegexp: "a"}},
+			sub:      oidfed.MetadataPolicy{"c": {PolicyOperatorRegexp: []any{"b", "a"}}},
+			expected: oidfed.MetadataPolicy{"c": {PolicyOperatorRegexp: []string{"a", "b"}}},
+		},
+		{
+			name:   "intersects",
+			parent: oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: []any{"a", "b"}}},
+			sub:    oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: []any{"b", "c"}}},
+			expected: oidfed.MetadataPolicy{
+				"c": {PolicyOperatorIntersects: IntersectsSets{{"a", "b"}, {"b", "c"}}},
+			},
+		},
+		{
+			name:   "intersects disjoint",
+			parent: oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: []any{"a"}}},
+			sub:    oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: []any{"b"}}},
+			expected: oidfed.MetadataPolicy{
+				"c": {PolicyOperatorIntersects: IntersectsSets{{"a"}, {"b"}}},
+			},
+		},
+		{
+			name:     "intersects equal",
+			parent:   oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: []any{"a"}}},
+			sub:      oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: []any{"a"}}},
+			expected: oidfed.MetadataPolicy{"c": {PolicyOperatorIntersects: IntersectsSets{{"a"}}}},
+		},
+		{
+			name:     "bounds",
+			parent:   oidfed.MetadataPolicy{"c": {PolicyOperatorMinimum: 1, PolicyOperatorMaximum: 10}},
+			sub:      oidfed.MetadataPolicy{"c": {PolicyOperatorMinimum: 3, PolicyOperatorMaximum: 12}},
+			expected: oidfed.MetadataPolicy{"c": {PolicyOperatorMinimum: 3.0, PolicyOperatorMaximum: 10.0}},
+		},
+		{
+			name:        "bounds contradicting",
+			parent:      oidfed.MetadataPolicy{"c": {PolicyOperatorMinimum: 5}},
+			sub:         oidfed.MetadataPolicy{"c": {PolicyOperatorMaximum: 4}},
+			errExpected: true,
+		},
+		{
+			name:   "url prefix",
+			parent: oidfed.MetadataPolicy{"c": {PolicyOperatorURLPrefix: []any{"https://a.org/", "https://b.org/"}}},
+			sub:    oidfed.MetadataPolicy{"c": {PolicyOperatorURLPrefix: []any{"https://a.org/rp/"}}},
+			expected: oidfed.MetadataPolicy{
+				"c": {PolicyOperatorURLPrefix: []string{"https://a.org/rp/"}},
+			},
+		},
+		{
+			name:        "url prefix host suffix",
+			parent:      oidfed.MetadataPolicy{"c": {PolicyOperatorURLPrefix: []any{"https://a.org"}}},
+			sub:         oidfed.MetadataPolicy{"c": {PolicyOperatorURLPrefix: []any{"https://a.org.evil.com/"}}},
+			errExpected: true,
+		},
+		{
+			name:        "url prefix disjoint",
+			parent:      oidfed.MetadataPolicy{"c": {PolicyOperatorURLPrefix: []any{"https://a.org/"}}},
+			sub:         oidfed.MetadataPolicy{"c": {PolicyOperatorURLPrefix: []any{"https://b.org/"}}},
+			errExpected: true,
+		},
+		{
+			name:   "combined with standard operator",
+			parent: oidfed.MetadataPolicy{"c": {oidfed.PolicyOperatorOneOf: []any{"a"}}},
+			sub:    oidfed.MetadataPolicy{"c": {PolicyOperatorRegexp: "a"}},
+			expected: oidfed.MetadataPolicy{
+				"c": {oidfed.PolicyOperatorOneOf: []any{"a"}, PolicyOperatorRegexp: "a"},
+			},
+		},
+		{
+			name:        "not combinable with standard operator",
+			parent:      oidfed.MetadataPolicy{"c": {oidfed.PolicyOperatorAdd: []any{"a"}}},
+			sub:         oidfed.MetadataPolicy{"c": {PolicyOperatorMinimum: 1}},
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				merged, err := oidfed.CombineMetadataPolicy("openid_relying_party", test.parent, test.sub)
+				if err != nil {
+					if test.errExpected {
+						return
+					}
+					t.Fatal(err)
+				}
+				if test.errExpected {
+					t.Fatalf("expected error, but no error returned")
+				}
+				if !reflect.DeepEqual(merged, test.expected) {
+					t.Errorf("merged policy is %v, but %v expected", merged, test.expected)
+				}
+			},
+		)
+	}
+}
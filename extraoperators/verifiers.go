@@ -0,0 +1,93 @@
+package extraoperators
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib"
+)
+
+// verifiersRegistered holds the oidfed.PolicyEngine with which the
+// verifiers of this package are registered
+var verifiersRegistered = struct {
+	sync.Mutex
+	engines map[*oidfed.PolicyEngine]bool
+}{engines: make(map[*oidfed.PolicyEngine]bool)}
+
+func registerVerifiers(engine *oidfed.PolicyEngine) {
+	verifiersRegistered.Lock()
+	defer verifiersRegistered.Unlock()
+	if verifiersRegistered.engines[engine] {
+		return
+	}
+	engine.RegisterPolicyVerifier(policyVerifierRegexpValid)
+	engine.RegisterPolicyVerifier(policyVerifierMinimumMaximum)
+	engine.RegisterPolicyVerifier(policyVerifierURLPrefixValid)
+	verifiersRegistered.engines[engine] = true
+}
+
+func policyVerifierRegexpValid(p oidfed.MetadataPolicyEntry, pathInfo string) error {
+	v, set := p[PolicyOperatorRegexp]
+	if !set {
+		return nil
+	}
+	patterns, err := toStrings(v, PolicyOperatorRegexp, pathInfo)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		if _, err = regexp.Compile(pattern); err != nil {
+			return errors.Wrapf(
+				err, "invalid regular expression '%s' in '%s' operator in '%s'", pattern, PolicyOperatorRegexp,
+				pathInfo,
+			)
+		}
+	}
+	return nil
+}
+
+func policyVerifierMinimumMaximum(p oidfed.MetadataPolicyEntry, pathInfo string) error {
+	minV, minSet := p[PolicyOperatorMinimum]
+	maxV, maxSet := p[PolicyOperatorMaximum]
+	if minSet {
+		if _, ok := toFloat(minV); !ok {
+			return errors.Errorf("'%s' operator value in '%s' must be a number", PolicyOperatorMinimum, pathInfo)
+		}
+	}
+	if maxSet {
+		if _, ok := toFloat(maxV); !ok {
+			return errors.Errorf("'%s' operator value in '%s' must be a number", PolicyOperatorMaximum, pathInfo)
+		}
+	}
+	if !minSet || !maxSet {
+		return nil
+	}
+	minF, _ := toFloat(minV)
+	maxF, _ := toFloat(maxV)
+	if minF > maxF {
+		return errors.Errorf(
+			"after combining policies '%s' the '%s' operator value '%v' is greater than the '%s' operator value '%v'",
+			pathInfo, PolicyOperatorMinimum, minV, PolicyOperatorMaximum, maxV,
+		)
+	}
+	return nil
+}
+
+func policyVerifierURLPrefixValid(p oidfed.MetadataPolicyEntry, pathInfo string) error {
+	v, set := p[PolicyOperatorURLPrefix]
+	if !set {
+		return nil
+	}
+	prefixes, err := toStrings(v, PolicyOperatorURLPrefix, pathInfo)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		if _, err = parseURLPrefix(prefix); err != nil {
+			return errors.Wrapf(err, "invalid prefix in '%s' operator in '%s'", PolicyOperatorURLPrefix, pathInfo)
+		}
+	}
+	return nil
+}
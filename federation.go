@@ -28,6 +28,9 @@ type FederationEntity struct {
 	TrustMarkIssuers AllowedTrustMarkIssuers
 	TrustMarkOwners  TrustMarkOwners
 	Extra            map[string]any
+	// ValidateMetadata indicates that the Metadata must pass
+	// Metadata.Validate before an entity configuration is signed
+	ValidateMetadata bool
 }
 
 // FederationLeaf is a type for a leaf entity and holds all relevant information about it; it can also be used to
@@ -111,6 +114,11 @@ func (f FederationEntity) EntityConfigurationPayload() *EntityStatementPayload {
 // EntityConfigurationJWT creates and returns the signed jwt as a []byte for
 // the entity's entity configuration
 func (f FederationEntity) EntityConfigurationJWT() ([]byte, error) {
+	if f.ValidateMetadata && f.Metadata != nil {
+		if err := f.Metadata.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	return f.EntityStatementSigner.JWT(f.EntityConfigurationPayload())
 }
 
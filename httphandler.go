@@ -0,0 +1,49 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lionick/oidfed-lib/internal"
+)
+
+// writeJWTResponse writes a jwt with the passed content type as the response
+func writeJWTResponse(w http.ResponseWriter, contentType string, jwt []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(jwt); err != nil {
+		internal.Log(err)
+	}
+}
+
+// writeJSONResponse writes a json response with the passed status
+func writeJSONResponse(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		internal.Log(err)
+	}
+}
+
+// writeErrorResponse writes an Error as a json response with the passed
+// status
+func writeErrorResponse(w http.ResponseWriter, status int, e Error) {
+	writeJSONResponse(w, status, e)
+}
+
+// allowMethods checks that the request uses one of the passed methods;
+// if not, an error response is written and false is returned
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeErrorResponse(
+		w, http.StatusMethodNotAllowed, ErrorInvalidRequest(fmt.Sprintf("method '%s' not allowed", r.Method)),
+	)
+	return false
+}
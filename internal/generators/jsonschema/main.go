@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/lionick/oidfed-lib"
+	"github.com/lionick/oidfed-lib/jsonschema"
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// documents maps the schema names to the types they are generated from
+var documents = map[string]any{
+	jsonschema.EntityStatement:  oidfed.EntityStatementPayload{},
+	jsonschema.Metadata:         oidfed.Metadata{},
+	jsonschema.MetadataPolicies: oidfed.MetadataPolicies{},
+	jsonschema.TrustMark:        oidfed.TrustMark{},
+	jsonschema.DelegationJWT:    oidfed.DelegationJWT{},
+	jsonschema.ResolveResponse:  oidfed.ResolveResponse{},
+}
+
+// overrides holds the schemas for types with custom marshaling
+var overrides = map[reflect.Type]*jsonschema.Schema{
+	reflect.TypeFor[unixtime.Unixtime](): {
+		Type:        jsonschema.Types{jsonschema.TypeNumber},
+		Description: "Seconds since 1970-01-01T00:00:00Z UTC",
+	},
+	reflect.TypeFor[jwks.JWKS](): {
+		Type: jsonschema.Types{jsonschema.TypeObject},
+		Properties: map[string]*jsonschema.Schema{
+			"keys": {
+				Type: jsonschema.Types{jsonschema.TypeArray},
+				Items: &jsonschema.Schema{
+					Type: jsonschema.Types{jsonschema.TypeObject},
+					Properties: map[string]*jsonschema.Schema{
+						"kty": {Type: jsonschema.Types{jsonschema.TypeString}},
+						"kid": {Type: jsonschema.Types{jsonschema.TypeString}},
+						"use": {Type: jsonschema.Types{jsonschema.TypeString}},
+						"alg": {Type: jsonschema.Types{jsonschema.TypeString}},
+					},
+					Required: []string{"kty"},
+				},
+			},
+		},
+		Required: []string{"keys"},
+	},
+	reflect.TypeFor[oidfed.JWSMessages](): {
+		Type:        jsonschema.Types{jsonschema.TypeArray},
+		Description: "Compact serialized JWTs",
+		Items:       &jsonschema.Schema{Type: jsonschema.Types{jsonschema.TypeString}},
+	},
+	reflect.TypeFor[oidfed.MetadataPolicyEntry](): {
+		Type: jsonschema.Types{jsonschema.TypeObject},
+		Properties: map[string]*jsonschema.Schema{
+			string(oidfed.PolicyOperatorValue):      {},
+			string(oidfed.PolicyOperatorDefault):    {},
+			string(oidfed.PolicyOperatorAdd):        {},
+			string(oidfed.PolicyOperatorOneOf):      {Type: jsonschema.Types{jsonschema.TypeArray}},
+			string(oidfed.PolicyOperatorSubsetOf):   {Type: jsonschema.Types{jsonschema.TypeArray}},
+			string(oidfed.PolicyOperatorSupersetOf): {Type: jsonschema.Types{jsonschema.TypeArray}},
+			string(oidfed.PolicyOperatorEssential):  {Type: jsonschema.Types{jsonschema.TypeBoolean}},
+		},
+	},
+}
+
+func main() {
+	out := flag.String("out", "jsonschema/schemas", "the directory to write the schemas to")
+	flag.Parse()
+
+	reflector := jsonschema.Reflector{Overrides: overrides}
+	for name, v := range documents {
+		data, err := json.MarshalIndent(reflector.Reflect(v), "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		data = append(data, '\n')
+		path := filepath.Join(*out, name+jsonschema.FileExtension)
+		if err = os.WriteFile(path, data, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
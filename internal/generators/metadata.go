@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/token"
 	"os"
+	"reflect"
 	"strings"
 )
 
@@ -16,6 +18,9 @@ var tags = map[string]string{
 	"OAuthClientMetadata":              "oauth_client",
 	"OAuthProtectedResourceMetadata":   "oauth_resource",
 	"FederationEntityMetadata":         "federation_entity",
+	"OpenIDCredentialIssuerMetadata":   "openid_credential_issuer",
+	"OpenIDWalletProviderMetadata":     "openid_wallet_provider",
+	"OpenIDCredentialVerifierMetadata": "openid_credential_verifier",
 }
 
 func main() {
@@ -29,6 +34,8 @@ func main() {
 
 	var commonMetadata *ast.StructType
 	others := make(map[string]*ast.StructType)
+	// names keeps the declaration order, so the output is deterministic
+	var names []string
 
 	// Iterate over all declarations in the file
 	for _, decl := range node.Decls {
@@ -51,6 +58,7 @@ func main() {
 				commonMetadata = structType
 			} else {
 				others[typeSpec.Name.Name] = structType
+				names = append(names, typeSpec.Name.Name)
 			}
 		}
 	}
@@ -60,14 +68,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	out, err := os.Create("metadata_generated.go")
-	if err != nil {
-		fmt.Println("Error: could not open output file.")
-		os.Exit(1)
-	}
-
 	const header = `// Code generated by go generate; DO NOT EDIT.
-package pkg
+package oidfed
 
 import (
 	"encoding/json"
@@ -80,80 +82,98 @@ import (
 )
 
 `
-	_, _ = out.WriteString(header)
+	var out strings.Builder
+	out.WriteString(header)
 
 	// Generate the new structs that include commonMetadata
-	for name, other := range others {
+	for _, name := range names {
+		other := others[name]
 		withPtrName := name + "WithPtrs"
 		name = fmt.Sprintf("%s%s", strings.ToUpper(name[0:1]), name[1:])
-		_, _ = out.WriteString(generateCombinedStruct(name, withPtrName, other, commonMetadata))
-		_, _ = out.WriteString(generateMarshalUnmarshalFunctions(name, withPtrName))
-		_, _ = out.WriteString(generateApplyPolicyFunction(name))
+		fields := combineFields(name, other, commonMetadata)
+		out.WriteString(generateCombinedStruct(name, withPtrName, fields))
+		out.WriteString(generateMarshalUnmarshalFunctions(name, withPtrName))
+		out.WriteString(generateApplyPolicyFunction(name, fields))
+		out.WriteString(generateClaimFunctions(name, fields))
+	}
+
+	src, err := format.Source([]byte(out.String()))
+	if err != nil {
+		fmt.Println("Error formatting generated code:", err)
+		os.Exit(1)
+	}
+	if err = os.WriteFile("metadata_generated.go", src, 0644); err != nil {
+		fmt.Println("Error: could not write output file.")
+		os.Exit(1)
+	}
+}
+
+// combinedField is a field of a combined struct
+type combinedField struct {
+	name string
+	typ  ast.Expr
+	tag  *ast.BasicLit
+}
+
+// jsonName returns the name of the claim from the json tag; it returns an
+// empty string for fields that are not marshalled
+func (f combinedField) jsonName() string {
+	if f.tag == nil {
+		return ""
 	}
+	tag, ok := reflect.StructTag(strings.Trim(f.tag.Value, "`")).Lookup("json")
+	if !ok || tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// combineFields combines the fields from both input structs; fields from
+// struct A take precedence
+func combineFields(newStructName string, structA, structB *ast.StructType) []combinedField {
+	var fields []combinedField
+	seenFields := make(map[string]bool) // Keep track of field names
+
+	add := func(s *ast.StructType, skip func(name string) bool) {
+		for _, field := range s.Fields.List {
+			for _, fieldName := range field.Names {
+				if skip(fieldName.Name) || seenFields[fieldName.Name] {
+					continue
+				}
+				seenFields[fieldName.Name] = true
+				fields = append(fields, combinedField{name: fieldName.Name, typ: field.Type, tag: field.Tag})
+			}
+		}
+	}
+	add(structA, func(string) bool { return false })
+	add(
+		structB, func(name string) bool {
+			return newStructName == "FederationEntityMetadata" && strings.Contains(name, "JWKS")
+		},
+	)
+	return fields
 }
 
 // Generate a new struct that combines fields from both input structs
-func generateCombinedStruct(
-	newStructName, withPtrName string, structA,
-	structB *ast.StructType,
-) string {
+func generateCombinedStruct(newStructName, withPtrName string, fields []combinedField) string {
 	var builderForType strings.Builder
 	var builderForTypeWithPtrs strings.Builder
-	seenFields := make(map[string]bool) // Keep track of field names
 
 	builderForType.WriteString(fmt.Sprintf("type %s struct {\n", newStructName))
 	builderForTypeWithPtrs.WriteString(fmt.Sprintf("type %s struct {\n", withPtrName))
 
 	builderForType.WriteString("\twasSet map[string]bool\n")
 
-	// Add fields from struct A
-	for _, field := range structA.Fields.List {
-		for _, fieldName := range field.Names {
-			if !seenFields[fieldName.Name] {
-				seenFields[fieldName.Name] = true
-				builderForType.WriteString(fmt.Sprintf("    %s %s", fieldName.Name, fieldTypeAsString(field.Type)))
-				builderForTypeWithPtrs.WriteString(
-					fmt.Sprintf(
-						"    %s %s", fieldName.Name, fieldTypeAsPtrString(field.Type),
-					),
-				)
-				if field.Tag != nil {
-					builderForType.WriteString(fmt.Sprintf(" %s", field.Tag.Value))
-					builderForTypeWithPtrs.WriteString(fmt.Sprintf(" %s", field.Tag.Value))
-				}
-				builderForType.WriteString("\n")
-				builderForTypeWithPtrs.WriteString("\n")
-			}
-		}
-	}
-
-	// Add fields from struct B
-	for _, field := range structB.Fields.List {
-		for _, fieldName := range field.Names {
-			if newStructName == "FederationEntityMetadata" && strings.Contains(fieldName.Name, "JWKS") {
-				continue
-			}
-			if !seenFields[fieldName.Name] {
-				seenFields[fieldName.Name] = true
-				builderForType.WriteString(fmt.Sprintf("    %s %s", fieldName.Name, fieldTypeAsString(field.Type)))
-				builderForTypeWithPtrs.WriteString(
-					fmt.Sprintf(
-						"    %s %s", fieldName.Name, fieldTypeAsPtrString(field.Type),
-					),
-				)
-				if field.Tag != nil {
-					builderForType.WriteString(fmt.Sprintf(" %s", field.Tag.Value))
-					builderForTypeWithPtrs.WriteString(
-						fmt.Sprintf(
-							" %s",
-							field.Tag.Value,
-						),
-					)
-				}
-				builderForType.WriteString("\n")
-				builderForTypeWithPtrs.WriteString("\n")
-			}
+	for _, field := range fields {
+		builderForType.WriteString(fmt.Sprintf("    %s %s", field.name, fieldTypeAsString(field.typ)))
+		builderForTypeWithPtrs.WriteString(fmt.Sprintf("    %s %s", field.name, fieldTypeAsPtrString(field.typ)))
+		if field.tag != nil {
+			builderForType.WriteString(fmt.Sprintf(" %s", field.tag.Value))
+			builderForTypeWithPtrs.WriteString(fmt.Sprintf(" %s", field.tag.Value))
 		}
+		builderForType.WriteString("\n")
+		builderForTypeWithPtrs.WriteString("\n")
 	}
 	builderForType.WriteString("}\n")
 	builderForTypeWithPtrs.WriteString("}\n")
@@ -336,19 +356,119 @@ func (m *%s) UnmarshalMsgpack(data []byte) error {
 	return sb.String()
 }
 
-func generateApplyPolicyFunction(name string) string {
+func generateApplyPolicyFunction(name string, fields []combinedField) string {
 	var sb strings.Builder
 
-	sb.WriteString(
-		fmt.Sprintf(
-			`
+	_, _ = fmt.Fprintf(
+		&sb,
+		`
 // ApplyPolicy applies a MetadataPolicy to the %s
 func (m %s) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "%s")
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "%s", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
 }
 
-`, name, name, tags[name],
-		),
+// applyPolicy applies a MetadataPolicy to the %s using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *%s) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+`, name, name, tags[name], name, name,
+	)
+	for _, field := range fields {
+		claim := field.jsonName()
+		if claim == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(
+			&sb,
+			`	if err := applyClaimPolicy(
+		r, policy, ownTag, "%s", &m.%s,
+		m.wasSet["%s"], %s, explanation,
+	); err != nil {
+		return err
+	}
+`, claim, field.name, field.name, nonZeroExpr("m."+field.name, field.typ),
+		)
+	}
+	sb.WriteString("\treturn nil\n}\n")
+	return sb.String()
+}
+
+// nonZeroExpr returns an expression that checks if the value of the passed
+// expression is not the zero value of its type
+func nonZeroExpr(expr string, typ ast.Expr) string {
+	if ident, ok := typ.(*ast.Ident); ok {
+		switch ident.Name {
+		case "string":
+			return expr + ` != ""`
+		case "bool":
+			return expr
+		case "int", "int64":
+			return expr + " != 0"
+		}
+	}
+	return expr + " != nil"
+}
+
+// generateClaimFunctions generates functions returning claims of a certain
+// type by their name, used to iterate over claims without reflection
+func generateClaimFunctions(name string, fields []combinedField) string {
+	var sb strings.Builder
+
+	claimFunction := func(funcName, goType, description string, matches func(ast.Expr) bool) {
+		_, _ = fmt.Fprintf(
+			&sb,
+			`
+// %s returns the value of the %s claim with the passed name; the
+// second return value indicates if %s has such a claim
+func (m %s) %s(claim string) (%s, bool) {
+	switch claim {
+`, funcName, description, name, name, funcName, goType,
+		)
+		for _, field := range fields {
+			claim := field.jsonName()
+			if claim == "" || !matches(field.typ) {
+				continue
+			}
+			_, _ = fmt.Fprintf(&sb, "\tcase \"%s\":\n\t\treturn m.%s, true\n", claim, field.name)
+		}
+		_, _ = fmt.Fprintf(&sb, "\t}\n\tvar zero %s\n\treturn zero, false\n}\n", goType)
+	}
+
+	claimFunction(
+		"stringClaim", "string", "string", func(t ast.Expr) bool {
+			ident, ok := t.(*ast.Ident)
+			return ok && ident.Name == "string"
+		},
+	)
+	claimFunction(
+		"stringSliceClaim", "[]string", "[]string", func(t ast.Expr) bool {
+			array, ok := t.(*ast.ArrayType)
+			if !ok {
+				return false
+			}
+			ident, ok := array.Elt.(*ast.Ident)
+			return ok && ident.Name == "string"
+		},
+	)
+	_, _ = fmt.Fprintf(
+		&sb,
+		`
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m %s) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+`, name,
 	)
 	return sb.String()
 }
@@ -67,3 +67,17 @@ func Post(url string, req interface{}, res interface{}) (*resty.Response, *HttpE
 	}
 	return resp, nil, nil
 }
+
+// PostForm performs a http POST request with the passed form parameters and parses the response into the given
+// interface{}
+func PostForm(url string, params url.Values, res interface{}) (*resty.Response, *HttpError, error) {
+	resp, err := client.R().SetFormDataFromValues(params).SetError(&HttpError{}).SetResult(res).Post(url)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if errRes, ok := resp.Error().(*HttpError); ok && errRes != nil && errRes.Error != "" {
+		errRes.Status = resp.RawResponse.StatusCode
+		return nil, errRes, nil
+	}
+	return resp, nil, nil
+}
@@ -0,0 +1,82 @@
+// Package jsonschema provides JSON Schema documents for the entity statements,
+// metadata and other payloads of OpenID Federation as they are modelled by
+// the oidfed package, and a validator to check raw JSON payloads against them.
+//
+// The schemas are generated from the go types together with the metadata
+// types by running 'go generate' in the oidfed package.
+package jsonschema
+
+import (
+	"embed"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the provided schemas
+const (
+	EntityStatement  = "entity-statement"
+	Metadata         = "metadata"
+	MetadataPolicies = "metadata-policies"
+	TrustMark        = "trust-mark"
+	DelegationJWT    = "delegation-jwt"
+	ResolveResponse  = "resolve-response"
+)
+
+// FileExtension is the extension of the schema files
+const FileExtension = ".schema.json"
+
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+var parsedSchemas sync.Map
+
+// Names returns the names of all provided schemas
+func Names() []string {
+	entries, _ := schemaFiles.ReadDir("schemas")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), FileExtension))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Raw returns the JSON Schema document with the passed name
+func Raw(name string) ([]byte, error) {
+	data, err := schemaFiles.ReadFile("schemas/" + name + FileExtension)
+	if err != nil {
+		return nil, errors.Errorf("unknown schema '%s'", name)
+	}
+	return data, nil
+}
+
+// Get returns the parsed Schema with the passed name
+func Get(name string) (*Schema, error) {
+	if s, ok := parsedSchemas.Load(name); ok {
+		return s.(*Schema), nil
+	}
+	data, err := Raw(name)
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err = json.Unmarshal(data, &s); err != nil {
+		return nil, errors.Wrapf(err, "could not parse schema '%s'", name)
+	}
+	actual, _ := parsedSchemas.LoadOrStore(name, &s)
+	return actual.(*Schema), nil
+}
+
+// Validate checks the passed raw JSON against the Schema with the passed name;
+// if the JSON does not conform to the Schema, ValidationErrors are returned
+func Validate(name string, data []byte) error {
+	s, err := Get(name)
+	if err != nil {
+		return err
+	}
+	return s.Validate(data)
+}
@@ -0,0 +1,335 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib"
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+type reflectTestInner struct {
+	Value string `json:"value"`
+}
+
+type reflectTestEmbedded struct {
+	Embedded int `json:"embedded"`
+}
+
+type reflectTestStruct struct {
+	Name     string              `json:"name"`
+	Count    int                 `json:"count,omitempty"`
+	Ratio    float64             `json:"ratio"`
+	Enabled  *bool               `json:"enabled"`
+	Tags     []string            `json:"tags"`
+	Inner    reflectTestInner    `json:"inner"`
+	Inners   []*reflectTestInner `json:"inners,omitempty"`
+	Labels   map[string]string   `json:"labels,omitempty"`
+	Any      any                 `json:"any,omitempty"`
+	Extra    map[string]any      `json:"-"`
+	internal string
+	reflectTestEmbedded
+}
+
+func TestReflector_Reflect(t *testing.T) {
+	expected := &Schema{
+		Schema: Draft,
+		Title:  "reflectTestStruct",
+		Type:   Types{TypeObject},
+		Properties: map[string]*Schema{
+			"name":    {Type: Types{TypeString}},
+			"count":   {Type: Types{TypeInteger}},
+			"ratio":   {Type: Types{TypeNumber}},
+			"enabled": {Type: Types{TypeBoolean, TypeNull}},
+			"tags": {
+				Type:  Types{TypeArray, TypeNull},
+				Items: &Schema{Type: Types{TypeString}},
+			},
+			"inner": {Ref: "#/$defs/reflectTestInner"},
+			"inners": {
+				Type:  Types{TypeArray},
+				Items: &Schema{Ref: "#/$defs/reflectTestInner"},
+			},
+			"labels": {
+				Type:                 Types{TypeObject},
+				AdditionalProperties: &Schema{Type: Types{TypeString}},
+			},
+			"any":      {},
+			"embedded": {Type: Types{TypeInteger}},
+		},
+		Required: []string{"name", "ratio", "inner", "embedded"},
+		Defs: map[string]*Schema{
+			"reflectTestInner": {
+				Type:       Types{TypeObject},
+				Properties: map[string]*Schema{"value": {Type: Types{TypeString}}},
+				Required:   []string{"value"},
+			},
+		},
+	}
+	s := Reflector{}.Reflect(reflectTestStruct{})
+	if !reflect.DeepEqual(s, expected) {
+		actual, _ := json.MarshalIndent(s, "", "  ")
+		t.Errorf("unexpected schema: %s", actual)
+	}
+
+	override := &Schema{
+		Type:        Types{TypeNumber},
+		Description: "override",
+	}
+	s = Reflector{Overrides: map[reflect.Type]*Schema{reflect.TypeFor[reflectTestInner](): override}}.Reflect(
+		reflectTestStruct{},
+	)
+	if s.Properties["inner"].Description != "override" || !reflect.DeepEqual(
+		s.Properties["inners"].Items.Type, Types{TypeNumber},
+	) {
+		t.Errorf("override was not used: %+v", s.Properties["inner"])
+	}
+	if _, ok := s.Defs["reflectTestInner"]; ok {
+		t.Errorf("overridden type must not be placed in $defs")
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	s := Reflector{}.Reflect(reflectTestStruct{})
+	tests := []struct {
+		name          string
+		data          string
+		expectedPaths []string
+	}{
+		{
+			name: "valid",
+			data: `{"name":"test","ratio":1.5,"tags":["a"],"inner":{"value":"v"},"embedded":1,"unknown":true}`,
+		},
+		{
+			name: "valid with integer as number and null pointer",
+			data: `{"name":"test","ratio":1,"enabled":null,"inner":{"value":"v"},"embedded":1}`,
+		},
+		{
+			name:          "missing required",
+			data:          `{"ratio":1,"inner":{},"embedded":1}`,
+			expectedPaths: []string{"", "/inner"},
+		},
+		{
+			name:          "wrong types",
+			data:          `{"name":1,"ratio":"1","inner":{"value":"v"},"embedded":1.5,"tags":["a",2],"labels":{"a/b":false}}`,
+			expectedPaths: []string{"/embedded", "/labels/a~1b", "/name", "/ratio", "/tags/1"},
+		},
+		{
+			name:          "wrong item in referenced slice",
+			data:          `{"name":"test","ratio":1,"inner":{"value":"v"},"inners":[{"value":"v"},{"value":[]}],"embedded":1}`,
+			expectedPaths: []string{"/inners/1/value"},
+		},
+		{
+			name:          "not an object",
+			data:          `[]`,
+			expectedPaths: []string{""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := s.Validate([]byte(test.data))
+				if len(test.expectedPaths) == 0 {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				var errs ValidationErrors
+				if !errors.As(err, &errs) {
+					t.Fatalf("expected ValidationErrors, but got: %v", err)
+				}
+				paths := make([]string, len(errs))
+				for i, e := range errs {
+					paths[i] = e.Path
+				}
+				if !reflect.DeepEqual(paths, test.expectedPaths) {
+					t.Errorf("expected errors at %v, but got: %v", test.expectedPaths, errs)
+				}
+			},
+		)
+	}
+
+	if err := s.Validate([]byte(`{"name":`)); err == nil {
+		t.Errorf("expected error for invalid json")
+	}
+}
+
+func TestGet(t *testing.T) {
+	names := Names()
+	expected := []string{
+		DelegationJWT, EntityStatement, Metadata, MetadataPolicies, ResolveResponse, TrustMark,
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected schema names: %v", names)
+	}
+	for _, name := range names {
+		s, err := Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Schema != Draft || s.Title == "" {
+			t.Errorf("schema '%s' is not a generated schema", name)
+		}
+	}
+	if _, err := Get("unknown"); err == nil {
+		t.Errorf("expected error for unknown schema")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := unixtime.Unixtime{Time: time.Unix(1700000000, 0)}
+	exp := unixtime.Unixtime{Time: now.Add(time.Hour)}
+	entityConfiguration := oidfed.EntityStatementPayload{
+		Issuer:         "https://op.example.org",
+		Subject:        "https://op.example.org",
+		IssuedAt:       now,
+		ExpiresAt:      exp,
+		JWKS:           jwks.NewJWKS(),
+		AuthorityHints: []string{"https://ta.example.org"},
+		Metadata: &oidfed.Metadata{
+			OpenIDProvider: &oidfed.OpenIDProviderMetadata{
+				Issuer:                 "https://op.example.org",
+				AuthorizationEndpoint:  "https://op.example.org/authorize",
+				TokenEndpoint:          "https://op.example.org/token",
+				ResponseTypesSupported: []string{"code"},
+				Extra:                  map[string]any{"display_name#fi": "Esimerkki OP"},
+			},
+			FederationEntity: &oidfed.FederationEntityMetadata{
+				OrganizationName: "Example Organization",
+			},
+		},
+		MetadataPolicy: &oidfed.MetadataPolicies{
+			RelyingParty: oidfed.MetadataPolicy{
+				"grant_types": oidfed.MetadataPolicyEntry{
+					oidfed.PolicyOperatorSubsetOf: []string{"authorization_code"},
+				},
+			},
+		},
+		TrustMarks: oidfed.TrustMarkInfos{
+			{
+				TrustMarkType: "https://tm.example.org",
+				TrustMarkJWT:  "eyJ...",
+			},
+		},
+		Extra: map[string]any{"custom_claim": 1},
+	}
+	trustMark := oidfed.TrustMark{
+		Issuer:        "https://tmi.example.org",
+		Subject:       "https://op.example.org",
+		TrustMarkType: "https://tm.example.org",
+		IssuedAt:      now,
+		ExpiresAt:     &exp,
+	}
+
+	tests := []struct {
+		name     string
+		schema   string
+		value    any
+		data     string
+		expected []string
+	}{
+		{
+			name:   "entity configuration",
+			schema: EntityStatement,
+			value:  entityConfiguration,
+		},
+		{
+			name:   "metadata",
+			schema: Metadata,
+			value:  entityConfiguration.Metadata,
+		},
+		{
+			name:   "metadata policies",
+			schema: MetadataPolicies,
+			value:  entityConfiguration.MetadataPolicy,
+		},
+		{
+			name:   "trust mark",
+			schema: TrustMark,
+			value:  trustMark,
+		},
+		{
+			name:   "entity configuration without jwks and invalid metadata",
+			schema: EntityStatement,
+			data: `{
+				"iss": "https://op.example.org",
+				"sub": "https://op.example.org",
+				"iat": 1700000000,
+				"exp": 1700003600,
+				"metadata": {
+					"openid_provider": {"issuer": 1},
+					"federation_entity": {"contacts": "ops@example.org"}
+				}
+			}`,
+			expected: []string{
+				"",
+				"/metadata/federation_entity/contacts",
+				"/metadata/openid_provider",
+				"/metadata/openid_provider",
+				"/metadata/openid_provider/issuer",
+			},
+		},
+		{
+			name:     "trust mark with string iat",
+			schema:   TrustMark,
+			data:     `{"iss":"https://tmi.example.org","sub":"https://op.example.org","trust_mark_type":"x","iat":"now"}`,
+			expected: []string{"/iat"},
+		},
+		{
+			name:     "resolve response with non-string trust chain",
+			schema:   ResolveResponse,
+			data:     `{"iss":"https://ia.example.org","sub":"https://op.example.org","iat":1,"exp":2,"trust_chain":[{}]}`,
+			expected: []string{"/trust_chain/0"},
+		},
+		{
+			name:     "delegation without trust mark type",
+			schema:   DelegationJWT,
+			data:     `{"iss":"https://tmo.example.org","sub":"https://tmi.example.org","iat":1}`,
+			expected: []string{""},
+		},
+		{
+			name:     "metadata policy with invalid operator value",
+			schema:   MetadataPolicies,
+			data:     `{"openid_relying_party":{"grant_types":{"one_of":"authorization_code"}}}`,
+			expected: []string{"/openid_relying_party/grant_types/one_of"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				data := []byte(test.data)
+				if test.value != nil {
+					var err error
+					data, err = json.Marshal(test.value)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				err := Validate(test.schema, data)
+				if len(test.expected) == 0 {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				var errs ValidationErrors
+				if !errors.As(err, &errs) {
+					t.Fatalf("expected ValidationErrors, but got: %v", err)
+				}
+				paths := make([]string, len(errs))
+				for i, e := range errs {
+					paths[i] = e.Path
+				}
+				if !reflect.DeepEqual(paths, test.expected) {
+					t.Errorf("expected errors at %v, but got: %v", test.expected, errs)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,160 @@
+package jsonschema
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Reflector creates Schemas from go types by following the encoding/json
+// rules for struct fields.
+// Fields tagged with omitempty and fields of a pointer, slice, map or
+// interface type are optional, all other fields are required. Fields of a
+// pointer, slice or map type that are not tagged with omitempty also allow
+// null, since encoding/json marshals their nil values as such.
+// Named struct types are placed in the $defs of the root schema and
+// referenced from there.
+type Reflector struct {
+	// Overrides holds the Schemas to use for types that cannot be reflected,
+	// e.g. types that implement the json.Marshaler interface
+	Overrides map[reflect.Type]*Schema
+}
+
+// Reflect returns the Schema for the type of the passed value
+func (r Reflector) Reflect(v any) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	defs := make(map[string]*Schema)
+	var s *Schema
+	if o, ok := r.Overrides[t]; ok {
+		s = copySchema(o)
+	} else if t.Kind() == reflect.Struct {
+		s = r.reflectStruct(t, defs)
+	} else {
+		s = r.reflect(t, defs)
+	}
+	s.Schema = Draft
+	s.Title = t.Name()
+	if len(defs) > 0 {
+		s.Defs = defs
+	}
+	return s
+}
+
+func (r Reflector) reflect(t reflect.Type, defs map[string]*Schema) *Schema {
+	if o, ok := r.Overrides[t]; ok {
+		return copySchema(o)
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return r.reflect(t.Elem(), defs)
+	case reflect.Bool:
+		return &Schema{Type: Types{TypeBoolean}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{TypeInteger}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{TypeNumber}}
+	case reflect.String:
+		return &Schema{Type: Types{TypeString}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as base64 string
+			return &Schema{Type: Types{TypeString}}
+		}
+		return &Schema{
+			Type:  Types{TypeArray},
+			Items: r.reflect(t.Elem(), defs),
+		}
+	case reflect.Map:
+		return &Schema{
+			Type:                 Types{TypeObject},
+			AdditionalProperties: r.reflect(t.Elem(), defs),
+		}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return r.reflectStruct(t, defs)
+		}
+		if _, ok := defs[name]; !ok {
+			// register before reflecting the fields, so recursive types
+			// terminate
+			defs[name] = &Schema{}
+			*defs[name] = *r.reflectStruct(t, defs)
+		}
+		return &Schema{Ref: defsRefPrefix + name}
+	default:
+		// interfaces and everything else can hold any value
+		return &Schema{}
+	}
+}
+
+func (r Reflector) reflectStruct(t reflect.Type, defs map[string]*Schema) *Schema {
+	s := &Schema{
+		Type:       Types{TypeObject},
+		Properties: make(map[string]*Schema),
+	}
+	r.addStructFields(s, t, defs)
+	return s
+}
+
+func (r Reflector) addStructFields(s *Schema, t reflect.Type, defs map[string]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addStructFields(s, ft, defs)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := r.reflect(f.Type, defs)
+		if isNilable(f.Type) && !hasOmitEmpty(opts) && len(fs.Type) > 0 {
+			fs.Type = append(fs.Type, TypeNull)
+		}
+		s.Properties[name] = fs
+		if !hasOmitEmpty(opts) && !isNilable(f.Type) && f.Type.Kind() != reflect.Interface {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func hasOmitEmpty(opts string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == "omitempty" || o == "omitzero" {
+			return true
+		}
+	}
+	return false
+}
+
+func isNilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+func copySchema(s *Schema) *Schema {
+	c := *s
+	c.Type = slices.Clone(s.Type)
+	return &c
+}
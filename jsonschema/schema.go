@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Draft is the JSON Schema dialect used by the generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a type for holding a JSON Schema; it only supports the subset of
+// keywords that is used by the schemas generated from the go types
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// JSON Schema types
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// defsRefPrefix is the prefix of references to definitions in the $defs of
+// the root schema
+const defsRefPrefix = "#/$defs/"
+
+// Types holds the JSON types allowed by a Schema; a single type is
+// represented as a string, multiple types as an array
+type Types []string
+
+// MarshalJSON implements the json.Marshaler interface
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.WithStack(err)
+	}
+	*t = multiple
+	return nil
+}
@@ -0,0 +1,180 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ValidationError describes a single violation of a Schema
+type ValidationError struct {
+	// Path is the JSON pointer to the offending value
+	Path    string
+	Message string
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
+
+// ValidationErrors holds all ValidationError found when validating a value
+type ValidationErrors []ValidationError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks the passed raw JSON against the Schema; if the JSON does not
+// conform to the Schema, ValidationErrors are returned
+func (s *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+	if decoder.More() {
+		return errors.New("invalid json: unexpected data after top-level value")
+	}
+	return s.ValidateValue(value)
+}
+
+// ValidateValue checks the passed value, as obtained by unmarshalling JSON,
+// against the Schema; if the value does not conform to the Schema,
+// ValidationErrors are returned
+func (s *Schema) ValidateValue(value any) error {
+	v := validator{root: s}
+	v.validate(s, value, "")
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+type validator struct {
+	root *Schema
+	errs ValidationErrors
+}
+
+func (v *validator) addError(path, format string, args ...any) {
+	v.errs = append(
+		v.errs, ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		},
+	)
+}
+
+func (v *validator) validate(s *Schema, value any, path string) {
+	if s.Ref != "" {
+		ref, ok := v.resolve(s.Ref)
+		if !ok {
+			v.addError(path, "cannot resolve schema reference '%s'", s.Ref)
+			return
+		}
+		v.validate(ref, value, path)
+	}
+	if len(s.Type) > 0 {
+		if t := typeOf(value); !s.Type.matches(t) {
+			v.addError(path, "expected %s, but got %s", strings.Join(s.Type, " or "), t)
+			return
+		}
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		v.validateObject(s, value, path)
+	case []any:
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, value map[string]any, path string) {
+	for _, r := range s.Required {
+		if _, ok := value[r]; !ok {
+			v.addError(path, "required property '%s' is missing", r)
+		}
+	}
+	for _, k := range sortedKeys(value) {
+		p := path + "/" + escapePointer(k)
+		if ps, ok := s.Properties[k]; ok {
+			v.validate(ps, value[k], p)
+		} else if s.AdditionalProperties != nil {
+			v.validate(s.AdditionalProperties, value[k], p)
+		}
+	}
+}
+
+func (v *validator) resolve(ref string) (*Schema, bool) {
+	name, ok := strings.CutPrefix(ref, defsRefPrefix)
+	if !ok {
+		return nil, false
+	}
+	s, ok := v.root.Defs[name]
+	return s, ok
+}
+
+func typeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case json.Number:
+		f, err := value.Float64()
+		if err == nil && f == math.Trunc(f) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case float64:
+		if value == math.Trunc(value) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case string:
+		return TypeString
+	case []any:
+		return TypeArray
+	case map[string]any:
+		return TypeObject
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func (t Types) matches(actual string) bool {
+	for _, expected := range t {
+		if expected == actual || (expected == TypeNumber && actual == TypeInteger) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// escapePointer escapes a property name for the use in a JSON pointer
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
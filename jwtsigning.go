@@ -81,6 +81,23 @@ func (s *GeneralJWTSigner) ResolveResponseSigner() *ResolveResponseSigner {
 	return &ResolveResponseSigner{s}
 }
 
+// JWKSSigner returns a JWKSSigner using the same crypto.Signer
+func (s *GeneralJWTSigner) JWKSSigner() *JWKSSigner {
+	return &JWKSSigner{s}
+}
+
+// TrustMarkStatusResponseSigner returns a TrustMarkStatusResponseSigner using
+// the same crypto.Signer
+func (s *GeneralJWTSigner) TrustMarkStatusResponseSigner() *TrustMarkStatusResponseSigner {
+	return &TrustMarkStatusResponseSigner{s}
+}
+
+// DelegationStatusResponseSigner returns a DelegationStatusResponseSigner
+// using the same crypto.Signer
+func (s *GeneralJWTSigner) DelegationStatusResponseSigner() *DelegationStatusResponseSigner {
+	return &DelegationStatusResponseSigner{s}
+}
+
 // ResolveResponseSigner is a JWTSigner for oidfedconst.JWTTypeResolveResponse
 type ResolveResponseSigner struct {
 	*GeneralJWTSigner
@@ -102,6 +119,23 @@ type EntityStatementSigner struct {
 	*GeneralJWTSigner
 }
 
+// JWKSSigner is a JWTSigner for oidfedconst.JWTTypeJWKS
+type JWKSSigner struct {
+	*GeneralJWTSigner
+}
+
+// TrustMarkStatusResponseSigner is a JWTSigner for
+// oidfedconst.JWTTypeTrustMarkStatusResponse
+type TrustMarkStatusResponseSigner struct {
+	*GeneralJWTSigner
+}
+
+// DelegationStatusResponseSigner is a JWTSigner for
+// oidfedconst.JWTTypeDelegationStatusResponse
+type DelegationStatusResponseSigner struct {
+	*GeneralJWTSigner
+}
+
 // JWT implements the JWTSigner interface
 func (s ResolveResponseSigner) JWT(i any) (jwt []byte, err error) {
 	return s.GeneralJWTSigner.JWT(i, oidfedconst.JWTTypeResolveResponse)
@@ -122,6 +156,21 @@ func (s EntityStatementSigner) JWT(i any) (jwt []byte, err error) {
 	return s.GeneralJWTSigner.JWT(i, oidfedconst.JWTTypeEntityStatement)
 }
 
+// JWT implements the JWTSigner interface
+func (s JWKSSigner) JWT(i any) (jwt []byte, err error) {
+	return s.GeneralJWTSigner.JWT(i, oidfedconst.JWTTypeJWKS)
+}
+
+// JWT implements the JWTSigner interface
+func (s TrustMarkStatusResponseSigner) JWT(i any) (jwt []byte, err error) {
+	return s.GeneralJWTSigner.JWT(i, oidfedconst.JWTTypeTrustMarkStatusResponse)
+}
+
+// JWT implements the JWTSigner interface
+func (s DelegationStatusResponseSigner) JWT(i any) (jwt []byte, err error) {
+	return s.GeneralJWTSigner.JWT(i, oidfedconst.JWTTypeDelegationStatusResponse)
+}
+
 // NewEntityStatementSigner creates a new EntityStatementSigner
 func NewEntityStatementSigner(key crypto.Signer, alg jwa.SignatureAlgorithm) *EntityStatementSigner {
 	return &EntityStatementSigner{
@@ -150,6 +199,32 @@ func NewTrustMarkDelegationSigner(key crypto.Signer, alg jwa.SignatureAlgorithm)
 	}
 }
 
+// NewJWKSSigner creates a new JWKSSigner
+func NewJWKSSigner(key crypto.Signer, alg jwa.SignatureAlgorithm) *JWKSSigner {
+	return &JWKSSigner{
+		GeneralJWTSigner: NewGeneralJWTSigner(key, alg),
+	}
+}
+
+// NewTrustMarkStatusResponseSigner creates a new TrustMarkStatusResponseSigner
+func NewTrustMarkStatusResponseSigner(
+	key crypto.Signer, alg jwa.SignatureAlgorithm,
+) *TrustMarkStatusResponseSigner {
+	return &TrustMarkStatusResponseSigner{
+		GeneralJWTSigner: NewGeneralJWTSigner(key, alg),
+	}
+}
+
+// NewDelegationStatusResponseSigner creates a new
+// DelegationStatusResponseSigner
+func NewDelegationStatusResponseSigner(
+	key crypto.Signer, alg jwa.SignatureAlgorithm,
+) *DelegationStatusResponseSigner {
+	return &DelegationStatusResponseSigner{
+		GeneralJWTSigner: NewGeneralJWTSigner(key, alg),
+	}
+}
+
 // TypedJWTSigner is a JWTSigner for a specific header type
 type TypedJWTSigner struct {
 	*GeneralJWTSigner
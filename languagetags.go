@@ -0,0 +1,162 @@
+package oidfed
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// LanguageTaggedString holds the language variants of a human-readable claim.
+// Such claims can be represented in multiple languages by appending a BCP47
+// language tag to the claim name, separated by '#', e.g. 'display_name#fi'.
+// The keys of a LanguageTaggedString are the language tags, the untagged
+// value is stored under the empty tag.
+type LanguageTaggedString map[string]string
+
+// languageTagSeparator separates the claim name and the language tag
+const languageTagSeparator = "#"
+
+// splitLanguageTag splits a (possibly language-tagged) claim name into the
+// claim and the language tag
+func splitLanguageTag(name string) (claim, tag string) {
+	claim, tag, _ = strings.Cut(name, languageTagSeparator)
+	return
+}
+
+// languageTaggedClaimName returns the claim name for the passed claim and
+// language tag
+func languageTaggedClaimName(claim, tag string) string {
+	if tag == "" {
+		return claim
+	}
+	return claim + languageTagSeparator + tag
+}
+
+// languageTaggedClaim collects the language variants of a claim from its
+// untagged value and the language-tagged members in extra
+func languageTaggedClaim(claim, untagged string, extra map[string]any) LanguageTaggedString {
+	var s LanguageTaggedString
+	set := func(tag, value string) {
+		if value == "" {
+			return
+		}
+		if s == nil {
+			s = make(LanguageTaggedString)
+		}
+		s[tag] = value
+	}
+	set("", untagged)
+	for k, v := range extra {
+		c, tag := splitLanguageTag(k)
+		if c != claim || tag == "" {
+			continue
+		}
+		if value, ok := v.(string); ok {
+			set(tag, value)
+		}
+	}
+	return s
+}
+
+// Tags returns the sorted language tags for which a variant is set; the
+// empty tag of the untagged value comes first
+func (s LanguageTaggedString) Tags() []string {
+	return sortedKeys(s)
+}
+
+// Values returns all variants ordered by their language tag
+func (s LanguageTaggedString) Values() []string {
+	values := make([]string, 0, len(s))
+	for _, tag := range s.Tags() {
+		values = append(values, s[tag])
+	}
+	return values
+}
+
+// Preferred returns the variant that best matches the passed languages, which
+// are ordered by preference. If no variant matches, the untagged value is
+// returned, or if it is not set, the variant with the first language tag.
+func (s LanguageTaggedString) Preferred(languages ...string) string {
+	var preferred []language.Tag
+	for _, l := range languages {
+		if tag, err := language.Parse(l); err == nil {
+			preferred = append(preferred, tag)
+		}
+	}
+	return s.preferred(preferred)
+}
+
+// PreferredForAcceptLanguage is like Preferred, but takes the language
+// preferences from an Accept-Language header value
+func (s LanguageTaggedString) PreferredForAcceptLanguage(acceptLanguage string) string {
+	preferred, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	return s.preferred(preferred)
+}
+
+func (s LanguageTaggedString) preferred(preferred []language.Tag) string {
+	if len(s) == 0 {
+		return ""
+	}
+	fallback, ok := s[""]
+	if !ok {
+		fallback = s[s.Tags()[0]]
+	}
+	if len(preferred) == 0 {
+		return fallback
+	}
+	var tags []string
+	var supported []language.Tag
+	for _, t := range s.Tags() {
+		if t == "" {
+			continue
+		}
+		tag, err := language.Parse(t)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, t)
+		supported = append(supported, tag)
+	}
+	if len(supported) == 0 {
+		return fallback
+	}
+	_, i, confidence := language.NewMatcher(supported).Match(preferred...)
+	if confidence == language.No {
+		return fallback
+	}
+	return s[tags[i]]
+}
+
+// ParseAcceptLanguage parses an Accept-Language header value and returns the
+// language tags ordered by preference; it can be used to obtain the languages
+// for LanguageTaggedString.Preferred
+func ParseAcceptLanguage(acceptLanguage string) []string {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	languages := make([]string, len(tags))
+	for i, tag := range tags {
+		languages[i] = tag.String()
+	}
+	return languages
+}
+
+// LanguageTaggedDisplayNameGuesser is an interface for types to return the
+// language variants of a (guessed) display name
+type LanguageTaggedDisplayNameGuesser interface {
+	GuessLanguageTaggedDisplayName() LanguageTaggedString
+}
+
+// languageTaggedClaimer is implemented by the metadata types
+type languageTaggedClaimer interface {
+	LanguageTaggedClaim(claim string) LanguageTaggedString
+}
+
+// guessLanguageTaggedDisplayName returns the language variants of the first
+// of the passed claims that is set in the metadata
+func guessLanguageTaggedDisplayName[M languageTaggedClaimer](m M, claims ...string) LanguageTaggedString {
+	for _, claim := range claims {
+		if s := m.LanguageTaggedClaim(claim); len(s) > 0 {
+			return s
+		}
+	}
+	return nil
+}
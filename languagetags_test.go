@@ -0,0 +1,153 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLanguageTaggedString_Preferred(t *testing.T) {
+	names := LanguageTaggedString{
+		"":      "Example University",
+		"de":    "Beispieluniversität",
+		"fi":    "Esimerkkiyliopisto",
+		"sv-FI": "Exempeluniversitetet",
+	}
+	tests := []struct {
+		name      string
+		s         LanguageTaggedString
+		languages []string
+		expected  string
+	}{
+		{
+			name:     "no preference",
+			s:        names,
+			expected: "Example University",
+		},
+		{
+			name:      "exact match",
+			s:         names,
+			languages: []string{"fi"},
+			expected:  "Esimerkkiyliopisto",
+		},
+		{
+			name:      "regional preference",
+			s:         names,
+			languages: []string{"de-AT", "fi"},
+			expected:  "Beispieluniversität",
+		},
+		{
+			name:      "base language preference",
+			s:         names,
+			languages: []string{"sv"},
+			expected:  "Exempeluniversitetet",
+		},
+		{
+			name:      "no match",
+			s:         names,
+			languages: []string{"ja"},
+			expected:  "Example University",
+		},
+		{
+			name:      "no match and no untagged value",
+			s:         LanguageTaggedString{"fi": "Esimerkkiyliopisto", "de": "Beispieluniversität"},
+			languages: []string{"ja"},
+			expected:  "Beispieluniversität",
+		},
+		{
+			name:      "empty",
+			languages: []string{"de"},
+			expected:  "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				if preferred := test.s.Preferred(test.languages...); preferred != test.expected {
+					t.Errorf("expected '%s', but got '%s'", test.expected, preferred)
+				}
+			},
+		)
+	}
+
+	if preferred := names.PreferredForAcceptLanguage("en;q=0.9, fi;q=0.8, de;q=0.5"); preferred != "Esimerkkiyliopisto" {
+		t.Errorf("unexpected preferred name for accept-language: '%s'", preferred)
+	}
+	if languages := ParseAcceptLanguage("de;q=0.5, fi, en;q=0.8"); !reflect.DeepEqual(
+		languages, []string{"fi", "en", "de"},
+	) {
+		t.Errorf("unexpected languages: %v", languages)
+	}
+}
+
+func TestMetadata_LanguageTaggedClaims(t *testing.T) {
+	data := []byte(`{
+		"openid_provider": {
+			"issuer": "https://op.example.org",
+			"display_name#fi": "Esimerkki OP",
+			"display_name#sv": "Exempel OP",
+			"organization_name": "Example Organization",
+			"description": "An example OP",
+			"description#de": "Ein Beispiel-OP"
+		},
+		"federation_entity": {
+			"organization_name#fi": "Esimerkkiorganisaatio"
+		}
+	}`)
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedNames := map[string]LanguageTaggedString{
+		"openid_provider":   {"fi": "Esimerkki OP", "sv": "Exempel OP"},
+		"federation_entity": {"fi": "Esimerkkiorganisaatio"},
+	}
+	if names := m.GuessLanguageTaggedDisplayNames(); !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("unexpected display names: %v", names)
+	}
+	if name := m.OpenIDProvider.GuessDisplayName(); name != "Esimerkki OP" {
+		t.Errorf("unexpected display name: '%s'", name)
+	}
+
+	descriptions := make(map[string]LanguageTaggedString)
+	m.IterateLanguageTaggedClaim(
+		"description", func(entityType string, value LanguageTaggedString) {
+			descriptions[entityType] = value
+		},
+	)
+	expectedDescriptions := map[string]LanguageTaggedString{
+		"openid_provider": {"": "An example OP", "de": "Ein Beispiel-OP"},
+	}
+	if !reflect.DeepEqual(descriptions, expectedDescriptions) {
+		t.Errorf("unexpected descriptions: %v", descriptions)
+	}
+
+	if !matchDisplayName("Exempel", m.GuessLanguageTaggedDisplayNames(), MatchModeSubstringCaseSensitive) {
+		t.Errorf("name query must match any language variant")
+	}
+}
+
+func TestUIInfo_LanguageTaggedClaim(t *testing.T) {
+	var e CollectedEntity
+	e.setLanguageTaggedUIInfoField(
+		"openid_provider", "display_name", LanguageTaggedString{"": "Example OP", "fi": "Esimerkki OP"},
+	)
+	data, err := json.Marshal(e.UIInfos["openid_provider"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"display_name":"Example OP","display_name#fi":"Esimerkki OP"}`; string(data) != expected {
+		t.Errorf("unexpected ui info: %s", data)
+	}
+	var uiInfo UIInfo
+	if err = json.Unmarshal(data, &uiInfo); err != nil {
+		t.Fatal(err)
+	}
+	if name := uiInfo.PreferredDisplayName("fi-FI", "en"); name != "Esimerkki OP" {
+		t.Errorf("unexpected preferred display name: '%s'", name)
+	}
+	if name := uiInfo.PreferredDisplayName("de"); name != "Example OP" {
+		t.Errorf("unexpected display name fallback: '%s'", name)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-	"unsafe"
 
 	"github.com/pkg/errors"
 )
@@ -18,6 +17,9 @@ type Metadata struct {
 	OAuthClient              *OAuthClientMetadata              `json:"oauth_client,omitempty"`
 	OAuthProtectedResource   *OAuthProtectedResourceMetadata   `json:"oauth_resource,omitempty"`
 	FederationEntity         *FederationEntityMetadata         `json:"federation_entity,omitempty"`
+	OpenIDCredentialIssuer   *OpenIDCredentialIssuerMetadata   `json:"openid_credential_issuer,omitempty"`
+	OpenIDWalletProvider     *OpenIDWalletProviderMetadata     `json:"openid_wallet_provider,omitempty"`
+	OpenIDCredentialVerifier *OpenIDCredentialVerifierMetadata `json:"openid_credential_verifier,omitempty"`
 	// Extra contains additional metadata this entity should advertise.
 	Extra map[string]any `json:"-"`
 }
@@ -27,123 +29,206 @@ type DisplayNameGuesser interface {
 	GuessDisplayName() string
 }
 
+// metadataClaims is implemented by the metadata types of the entity types
+// modelled in Metadata; it gives access to claims without reflection
+type metadataClaims interface {
+	DisplayNameGuesser
+	LanguageTaggedDisplayNameGuesser
+	languageTaggedClaimer
+	stringClaim(claim string) (string, bool)
+	stringSliceClaim(claim string) ([]string, bool)
+}
+
+// entityTypeMetadata is the metadata of a single entity type
+type entityTypeMetadata struct {
+	entityType string
+	metadata   metadataClaims
+}
+
+// entityTypes returns the metadata of all entity types that are set, in the
+// order of the Metadata fields; entity types in Extra are not included
+func (m Metadata) entityTypes() []entityTypeMetadata {
+	var types []entityTypeMetadata
+	add := func(entityType string, set bool, metadata metadataClaims) {
+		if set {
+			types = append(types, entityTypeMetadata{entityType: entityType, metadata: metadata})
+		}
+	}
+	add("openid_provider", m.OpenIDProvider != nil, m.OpenIDProvider)
+	add("openid_relying_party", m.RelyingParty != nil, m.RelyingParty)
+	add("oauth_authorization_server", m.OAuthAuthorizationServer != nil, m.OAuthAuthorizationServer)
+	add("oauth_client", m.OAuthClient != nil, m.OAuthClient)
+	add("oauth_resource", m.OAuthProtectedResource != nil, m.OAuthProtectedResource)
+	add("federation_entity", m.FederationEntity != nil, m.FederationEntity)
+	add("openid_credential_issuer", m.OpenIDCredentialIssuer != nil, m.OpenIDCredentialIssuer)
+	add("openid_wallet_provider", m.OpenIDWalletProvider != nil, m.OpenIDWalletProvider)
+	add("openid_credential_verifier", m.OpenIDCredentialVerifier != nil, m.OpenIDCredentialVerifier)
+	return types
+}
+
 // GuessEntityTypes returns a slice of entity types for which metadata is set
 func (m Metadata) GuessEntityTypes() (entityTypes []string) {
-	value := reflect.ValueOf(m)
-	typ := value.Type()
-	for i := 0; i < value.NumField(); i++ {
-		field := value.Field(i)
-		if field.Kind() == reflect.Ptr && !field.IsNil() {
-			structField := typ.Field(i)
-			jsonTag := structField.Tag.Get("json")
-			jsonTag = strings.TrimSuffix(jsonTag, ",omitempty")
-			entityTypes = append(entityTypes, jsonTag)
-		}
+	for _, t := range m.entityTypes() {
+		entityTypes = append(entityTypes, t.entityType)
 	}
 	return
 }
 
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OpenIDProviderMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "organization_name")
+}
+
 // GuessDisplayName implements the DisplayNameGuesser interface
 func (m OpenIDProviderMetadata) GuessDisplayName() string {
-	if dn := m.DisplayName; dn != "" {
-		return dn
-	}
-	return m.OrganizationName
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OpenIDRelyingPartyMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "client_name")
 }
 
 // GuessDisplayName implements the DisplayNameGuesser interface
 func (m OpenIDRelyingPartyMetadata) GuessDisplayName() string {
-	if dn := m.DisplayName; dn != "" {
-		return dn
-	}
-	return m.ClientName
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OAuthAuthorizationServerMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "organization_name")
 }
 
 // GuessDisplayName implements the DisplayNameGuesser interface
 func (m OAuthAuthorizationServerMetadata) GuessDisplayName() string {
-	if dn := m.DisplayName; dn != "" {
-		return dn
-	}
-	return m.OrganizationName
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OAuthClientMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "client_name")
 }
 
 // GuessDisplayName implements the DisplayNameGuesser interface
 func (m OAuthClientMetadata) GuessDisplayName() string {
-	if dn := m.DisplayName; dn != "" {
-		return dn
-	}
-	return m.ClientName
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OAuthProtectedResourceMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "resource_name")
 }
 
 // GuessDisplayName implements the DisplayNameGuesser interface
 func (m OAuthProtectedResourceMetadata) GuessDisplayName() string {
-	if dn := m.DisplayName; dn != "" {
-		return dn
-	}
-	return m.ResourceName
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m FederationEntityMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "organization_name")
 }
 
 // GuessDisplayName implements the DisplayNameGuesser interface
 func (m FederationEntityMetadata) GuessDisplayName() string {
-	if dn := m.DisplayName; dn != "" {
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface; besides the display_name claim,
+// the names from the credential issuer's display claim are used
+func (m OpenIDCredentialIssuerMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	if dn := m.LanguageTaggedClaim("display_name"); len(dn) > 0 {
 		return dn
 	}
-	return m.OrganizationName
+	var names LanguageTaggedString
+	for _, d := range m.Display {
+		name, _ := d["name"].(string)
+		if name == "" {
+			continue
+		}
+		locale, _ := d["locale"].(string)
+		if names == nil {
+			names = make(LanguageTaggedString)
+		}
+		if _, ok := names[locale]; !ok {
+			names[locale] = name
+		}
+	}
+	if len(names) > 0 {
+		return names
+	}
+	return m.LanguageTaggedClaim("organization_name")
+}
+
+// GuessDisplayName implements the DisplayNameGuesser interface
+func (m OpenIDCredentialIssuerMetadata) GuessDisplayName() string {
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OpenIDWalletProviderMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "organization_name")
+}
+
+// GuessDisplayName implements the DisplayNameGuesser interface
+func (m OpenIDWalletProviderMetadata) GuessDisplayName() string {
+	return m.GuessLanguageTaggedDisplayName().Preferred()
+}
+
+// GuessLanguageTaggedDisplayName implements the
+// LanguageTaggedDisplayNameGuesser interface
+func (m OpenIDCredentialVerifierMetadata) GuessLanguageTaggedDisplayName() LanguageTaggedString {
+	return guessLanguageTaggedDisplayName(m, "display_name", "client_name", "organization_name")
+}
+
+// GuessDisplayName implements the DisplayNameGuesser interface
+func (m OpenIDCredentialVerifierMetadata) GuessDisplayName() string {
+	return m.GuessLanguageTaggedDisplayName().Preferred()
 }
 
 // GuessDisplayNames collects (guessed) display names for all present metadata types.
 func (m Metadata) GuessDisplayNames() map[string]string {
 	result := make(map[string]string)
-	value := reflect.ValueOf(m)
-	typ := value.Type()
-
-	for i := 0; i < value.NumField(); i++ {
-		field := value.Field(i)
-		if field.Kind() == reflect.Ptr && !field.IsNil() {
-			structField := typ.Field(i)
-			entityTag := structField.Tag.Get("json")
-			entityTag = strings.TrimSuffix(entityTag, ",omitempty")
-
-			elem := field.Elem().Interface()
-			displayNamer, ok := elem.(DisplayNameGuesser)
-			if ok {
-				result[entityTag] = displayNamer.GuessDisplayName()
-			}
-		}
+	for _, t := range m.entityTypes() {
+		result[t.entityType] = t.metadata.GuessDisplayName()
 	}
 	return result
 }
 
+// GuessLanguageTaggedDisplayNames collects the language variants of the
+// (guessed) display names for all present metadata types.
+func (m Metadata) GuessLanguageTaggedDisplayNames() map[string]LanguageTaggedString {
+	result := make(map[string]LanguageTaggedString)
+	for _, t := range m.entityTypes() {
+		result[t.entityType] = t.metadata.GuessLanguageTaggedDisplayName()
+	}
+	return result
+}
+
+// IterateLanguageTaggedClaim collects all language variants of a claim that
+// has a string value for all metadata types and calls the iterator on it.
+func (m Metadata) IterateLanguageTaggedClaim(tag string, iterator func(entityType string, value LanguageTaggedString)) {
+	for _, t := range m.entityTypes() {
+		if s := t.metadata.LanguageTaggedClaim(tag); len(s) > 0 {
+			iterator(t.entityType, s)
+		}
+	}
+}
+
 // IterateStringSliceClaim collects a claim that has a []string value for all
 // metadata types and calls the iterator on it.
 func (m Metadata) IterateStringSliceClaim(tag string, iterator func(entityType string, value []string)) {
-	value := reflect.ValueOf(m)
-	typ := value.Type()
-
-	for i := 0; i < value.NumField(); i++ {
-		field := value.Field(i)
-		if field.Kind() == reflect.Ptr && !field.IsNil() {
-			structField := typ.Field(i)
-			entityTag := structField.Tag.Get("json")
-			entityTag = strings.TrimSuffix(entityTag, ",omitempty")
-
-			elem := field.Elem()
-			elemType := elem.Type()
-
-			for j := 0; j < elem.NumField(); j++ {
-				subField := elem.Field(j)
-				subStructField := elemType.Field(j)
-				jsonTag := subStructField.Tag.Get("json")
-				jsonTag = strings.TrimSuffix(jsonTag, ",omitempty")
-
-				if jsonTag == tag && subField.Kind() == reflect.String {
-					slice := subField.Interface().([]string)
-					if slice != nil {
-						iterator(entityTag, slice)
-					}
-					break
-				}
-			}
+	for _, t := range m.entityTypes() {
+		if slice, ok := t.metadata.stringSliceClaim(tag); ok && slice != nil {
+			iterator(t.entityType, slice)
 		}
 	}
 }
@@ -151,33 +236,9 @@ func (m Metadata) IterateStringSliceClaim(tag string, iterator func(entityType s
 // IterateStringClaim collects a claim that has a string value for all metadata
 // types and calls the iterator on it.
 func (m Metadata) IterateStringClaim(tag string, iterator func(entityType, value string)) {
-	value := reflect.ValueOf(m)
-	typ := value.Type()
-
-	for i := 0; i < value.NumField(); i++ {
-		field := value.Field(i)
-		if field.Kind() == reflect.Ptr && !field.IsNil() {
-			structField := typ.Field(i)
-			entityTag := structField.Tag.Get("json")
-			entityTag = strings.TrimSuffix(entityTag, ",omitempty")
-
-			elem := field.Elem()
-			elemType := elem.Type()
-
-			for j := 0; j < elem.NumField(); j++ {
-				subField := elem.Field(j)
-				subStructField := elemType.Field(j)
-				jsonTag := subStructField.Tag.Get("json")
-				jsonTag = strings.TrimSuffix(jsonTag, ",omitempty")
-
-				if jsonTag == tag && subField.Kind() == reflect.String {
-					str := subField.Interface().(string)
-					if str != "" {
-						iterator(entityTag, str)
-					}
-					break
-				}
-			}
+	for _, t := range m.entityTypes() {
+		if str, ok := t.metadata.stringClaim(tag); ok && str != "" {
+			iterator(t.entityType, str)
 		}
 	}
 }
@@ -207,44 +268,66 @@ func (m *Metadata) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type policyApplicable interface {
-	ApplyPolicy(policy MetadataPolicy) (any, error)
-}
-
 // ApplyPolicy applies MetadataPolicies to Metadata and returns the final Metadata
 func (m Metadata) ApplyPolicy(p *MetadataPolicies) (*Metadata, error) {
 	if p == nil {
 		return &m, nil
 	}
-	t := reflect.TypeOf(m)
-	v := reflect.ValueOf(m)
-	out := &Metadata{}
-	for i := 0; i < t.NumField(); i++ {
-		// Ignore extra entities. We'll handle those separately without reflection.
-		if t.Field(i).Name == "Extra" {
-			continue
-		}
+	return m.applyPolicy(defaultPolicyOperatorRegistry, p, nil)
+}
 
-		policy, policyOk := reflect.ValueOf(*p).Field(i).Interface().(MetadataPolicy)
-		if !policyOk || policy == nil {
-			reflect.Indirect(reflect.ValueOf(out)).Field(i).Set(v.Field(i))
-			continue
-		}
-		var metadata policyApplicable
-		f := v.Field(i)
-		if f.IsNil() {
-			continue
-		}
-		var ok bool
-		metadata, ok = v.Field(i).Interface().(policyApplicable)
-		if !ok {
-			continue
-		}
-		applied, err := metadata.ApplyPolicy(policy)
-		if err != nil {
-			return nil, err
-		}
-		reflect.Indirect(reflect.ValueOf(out)).Field(i).Set(reflect.ValueOf(applied))
+// applyPolicy applies MetadataPolicies to Metadata using the
+// PolicyOperator of the passed policyOperatorRegistry; if explanations is not
+// nil, an EntityTypeExplanation is recorded for each entity type
+func (m Metadata) applyPolicy(
+	r *policyOperatorRegistry, p *MetadataPolicies, explanations map[string]EntityTypeExplanation,
+) (*Metadata, error) {
+	out := &Metadata{}
+	var err error
+	if out.OpenIDProvider, err = applyEntityTypePolicy(
+		r, m.OpenIDProvider, p.OpenIDProvider, "openid_provider", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.RelyingParty, err = applyEntityTypePolicy(
+		r, m.RelyingParty, p.RelyingParty, "openid_relying_party", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.OAuthAuthorizationServer, err = applyEntityTypePolicy(
+		r, m.OAuthAuthorizationServer, p.OAuthAuthorizationServer, "oauth_authorization_server", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.OAuthClient, err = applyEntityTypePolicy(
+		r, m.OAuthClient, p.OAuthClient, "oauth_client", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.OAuthProtectedResource, err = applyEntityTypePolicy(
+		r, m.OAuthProtectedResource, p.OAuthProtectedResource, "oauth_resource", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.FederationEntity, err = applyEntityTypePolicy(
+		r, m.FederationEntity, p.FederationEntity, "federation_entity", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.OpenIDCredentialIssuer, err = applyEntityTypePolicy(
+		r, m.OpenIDCredentialIssuer, p.OpenIDCredentialIssuer, "openid_credential_issuer", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.OpenIDWalletProvider, err = applyEntityTypePolicy(
+		r, m.OpenIDWalletProvider, p.OpenIDWalletProvider, "openid_wallet_provider", explanations,
+	); err != nil {
+		return nil, err
+	}
+	if out.OpenIDCredentialVerifier, err = applyEntityTypePolicy(
+		r, m.OpenIDCredentialVerifier, p.OpenIDCredentialVerifier, "openid_credential_verifier", explanations,
+	); err != nil {
+		return nil, err
 	}
 
 	// Iterate over extra metadata and associated policies
@@ -254,7 +337,12 @@ func (m Metadata) ApplyPolicy(p *MetadataPolicies) (*Metadata, error) {
 			var metadataToReturn interface{}
 			if policy, ok := p.Extra[entityType]; ok {
 				// Found a policy for the entity type, so apply it
-				applied, err := applyPolicy(metadata, policy, entityType)
+				var explanation EntityTypeExplanation
+				if explanations != nil {
+					explanation = make(EntityTypeExplanation)
+					explanations[entityType] = explanation
+				}
+				applied, err := applyPolicyWithExplanation(r, metadata, policy, entityType, explanation)
 				if err != nil {
 					return nil, err
 				}
@@ -272,45 +360,163 @@ func (m Metadata) ApplyPolicy(p *MetadataPolicies) (*Metadata, error) {
 	return out, nil
 }
 
+// policyApplier is implemented by (pointers to) the metadata types to apply
+// a MetadataPolicy without reflection
+type policyApplier interface {
+	applyPolicy(
+		r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+	) error
+}
+
+// applyEntityTypePolicy applies the MetadataPolicy to a copy of the metadata
+// of an entity type; if neither a policy nor explanations are passed, the
+// metadata is returned as is
+func applyEntityTypePolicy[T any, PT interface {
+	*T
+	policyApplier
+}](
+	r *policyOperatorRegistry, metadata *T, policy MetadataPolicy, entityType string,
+	explanations map[string]EntityTypeExplanation,
+) (*T, error) {
+	if metadata == nil || (policy == nil && explanations == nil) {
+		return metadata, nil
+	}
+	if policy == nil {
+		policy = MetadataPolicy{}
+	}
+	var explanation EntityTypeExplanation
+	if explanations != nil {
+		explanation = make(EntityTypeExplanation)
+		explanations[entityType] = explanation
+	}
+	applied := *metadata
+	if err := PT(&applied).applyPolicy(r, policy, entityType, explanation); err != nil {
+		return nil, err
+	}
+	return &applied, nil
+}
+
 func applyPolicy(metadata any, policy MetadataPolicy, ownTag string) (any, error) {
+	return applyPolicyWithExplanation(defaultPolicyOperatorRegistry, metadata, policy, ownTag, nil)
+}
+
+// applyPolicyWithExplanation applies the MetadataPolicy to the metadata using
+// the PolicyOperator of the passed policyOperatorRegistry;
+// if explanation is not nil, all claims and applied policy operators are
+// recorded in it.
+// metadata is either a pointer to one of the metadata types or, for entity
+// types not modelled in Metadata, a map of claims.
+func applyPolicyWithExplanation(
+	r *policyOperatorRegistry, metadata any, policy MetadataPolicy, ownTag string,
+	explanation EntityTypeExplanation,
+) (any, error) {
 	if policy == nil {
 		return metadata, nil
 	}
-	v := reflect.ValueOf(metadata)
-	t := v.Elem().Type()
-
-	wasSetField := v.Elem().FieldByName("wasSet")
-	wasSet := *(*map[string]bool)(unsafe.Pointer(wasSetField.UnsafeAddr())) // skipcq:  GSC-G103
-	for i := 0; i < t.NumField(); i++ {
-		j, ok := t.Field(i).Tag.Lookup("json")
-		if !ok {
-			continue
-		}
-		j = strings.TrimSuffix(j, ",omitempty")
-		p, ok := policy[j]
-		if !ok {
-			continue
-		}
-		f := reflect.Indirect(v).Field(i)
-		value, err := p.ApplyTo(f.Interface(), wasSet[t.Field(i).Name], fmt.Sprintf("%s.%s", ownTag, j))
-		if err != nil {
+	if applier, ok := metadata.(policyApplier); ok {
+		if err := applier.applyPolicy(r, policy, ownTag, explanation); err != nil {
 			return nil, err
 		}
-		rV := reflect.ValueOf(value)
-		if t.Field(i).Name == "Scope" && rV.IsValid() && rV.Kind() == reflect.Slice {
-			strSlice, ok := value.([]string)
-			if ok {
-				rV = reflect.ValueOf(strings.Join(strSlice, " "))
-			}
+		return metadata, nil
+	}
+	claims, ok := metadata.(map[string]any)
+	if !ok {
+		return nil, errors.Errorf("cannot apply metadata policy to '%s' metadata of type %T", ownTag, metadata)
+	}
+	applied := make(map[string]any, len(claims))
+	for claim, value := range claims {
+		applied[claim] = value
+	}
+	claimNames := make(map[string]struct{}, len(claims)+len(policy))
+	for claim := range claims {
+		claimNames[claim] = struct{}{}
+	}
+	for claim := range policy {
+		claimNames[claim] = struct{}{}
+	}
+	for _, claim := range sortedKeys(claimNames) {
+		value, set := applied[claim]
+		if err := applyClaimPolicy(r, policy, ownTag, claim, &value, set, set, explanation); err != nil {
+			return nil, err
 		}
-		if rV.IsValid() {
-			f.Set(rV)
+		if value == nil {
+			delete(applied, claim)
 		} else {
-			f.SetZero()
+			applied[claim] = value
 		}
 	}
+	return applied, nil
+}
 
-	return metadata, nil
+// applyClaimPolicy applies the MetadataPolicyEntry for a claim (if any) to
+// its value; this is used by the generated applyPolicy functions of the
+// metadata types.
+// wasSet indicates if the claim was set in the metadata, nonZero if the value
+// is not the zero value; both are used for explanations, wasSet is also
+// passed to the policy operators
+func applyClaimPolicy[T any](
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag, claim string, value *T, wasSet, nonZero bool,
+	explanation EntityTypeExplanation,
+) error {
+	p, ok := policy[claim]
+	var claimExplanation *ClaimExplanation
+	if explanation != nil && (ok || wasSet || nonZero) {
+		claimExplanation = explanation.addClaim(claim, *value, wasSet)
+	}
+	if !ok {
+		return nil
+	}
+	var observer policyOperatorObserver
+	if claimExplanation != nil {
+		observer = claimExplanation.addOperatorApplication
+	}
+	pathInfo := fmt.Sprintf("%s.%s", ownTag, claim)
+	var v any = *value
+	spaceSeparated := isSpaceSeparatedClaim(ownTag, claim)
+	if spaceSeparated {
+		v = splitSpaceSeparatedClaim(v)
+	}
+	v, err := r.applyEntry(p, v, wasSet, pathInfo, observer)
+	if err != nil {
+		return err
+	}
+	if spaceSeparated {
+		v, err = joinSpaceSeparatedClaim(v, reflect.TypeFor[T]())
+		if err != nil {
+			return newMetadataPolicyApplyError(pathInfo, "", *value, nil, false, err)
+		}
+	}
+	typed, err := policyValueAs[T](v)
+	if err != nil {
+		return newMetadataPolicyApplyError(pathInfo, "", *value, nil, false, err)
+	}
+	*value = typed
+	if claimExplanation != nil {
+		claimExplanation.FinalValue = typed
+	}
+	return nil
+}
+
+// policyValueAs converts a value resulting from the application of policy
+// operators to the type of the claim. Values of the claim's type are used as
+// they are, other values (e.g. float64 values for integer claims from json
+// policies) are converted through json.
+func policyValueAs[T any](value any) (T, error) {
+	var typed T
+	if value == nil {
+		return typed, nil
+	}
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return typed, errors.WithStack(err)
+	}
+	if err = json.Unmarshal(data, &typed); err != nil {
+		return typed, errors.Wrapf(err, "cannot use %v as %T", value, typed)
+	}
+	return typed, nil
 }
 
 // FindEntityMetadata finds metadata for the specified entity type in the
@@ -400,7 +606,30 @@ func (m *oAuthAuthorizationServerMetadataWithPtrs) UnmarshalJSON(data []byte) er
 
 // ApplyPolicy applies a MetadataPolicy to the OAuthAuthorizationServerMetadata
 func (m OAuthAuthorizationServerMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "oauth_authorization_server")
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "oauth_authorization_server", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *OAuthAuthorizationServerMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	return (*OpenIDProviderMetadata)(m).applyPolicy(r, policy, ownTag, explanation)
+}
+
+func (m OAuthAuthorizationServerMetadata) stringClaim(claim string) (string, bool) {
+	return OpenIDProviderMetadata(m).stringClaim(claim)
+}
+
+func (m OAuthAuthorizationServerMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	return OpenIDProviderMetadata(m).stringSliceClaim(claim)
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name; see LanguageTaggedString
+func (m OAuthAuthorizationServerMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	return OpenIDProviderMetadata(m).LanguageTaggedClaim(claim)
 }
 
 // MarshalJSON implements the json.Marshaler interface
@@ -435,5 +664,28 @@ func (m *oAuthClientMetadataWithPtrs) UnmarshalJSON(data []byte) error {
 
 // ApplyPolicy applies a MetadataPolicy to the OAuthClientMetadata
 func (m OAuthClientMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "oauth_client")
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "oauth_client", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *OAuthClientMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	return (*OpenIDRelyingPartyMetadata)(m).applyPolicy(r, policy, ownTag, explanation)
+}
+
+func (m OAuthClientMetadata) stringClaim(claim string) (string, bool) {
+	return OpenIDRelyingPartyMetadata(m).stringClaim(claim)
+}
+
+func (m OAuthClientMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	return OpenIDRelyingPartyMetadata(m).stringSliceClaim(claim)
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name; see LanguageTaggedString
+func (m OAuthClientMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	return OpenIDRelyingPartyMetadata(m).LanguageTaggedClaim(claim)
 }
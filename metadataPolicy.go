@@ -18,6 +18,9 @@ type MetadataPolicies struct {
 	OAuthClient              MetadataPolicy `json:"oauth_client,omitempty"`
 	OAuthProtectedResource   MetadataPolicy `json:"oauth_resource,omitempty"`
 	FederationEntity         MetadataPolicy `json:"federation_entity,omitempty"`
+	OpenIDCredentialIssuer   MetadataPolicy `json:"openid_credential_issuer,omitempty"`
+	OpenIDWalletProvider     MetadataPolicy `json:"openid_wallet_provider,omitempty"`
+	OpenIDCredentialVerifier MetadataPolicy `json:"openid_credential_verifier,omitempty"`
 	// Extra contains metadata policies for entity types unknown to this module.
 	Extra map[string]MetadataPolicy `json:"-"`
 }
@@ -76,24 +79,61 @@ type MetadataPolicyEntry map[PolicyOperatorName]any
 // PolicyOperatorName is the name of a PolicyOperator
 type PolicyOperatorName string
 
+// entityTypePolicies returns the MetadataPolicy for each entity type for which
+// a policy is set
+func (m MetadataPolicies) entityTypePolicies() map[string]MetadataPolicy {
+	policies := make(map[string]MetadataPolicy)
+	set := func(entityType string, p MetadataPolicy) {
+		if p != nil {
+			policies[entityType] = p
+		}
+	}
+	set("openid_provider", m.OpenIDProvider)
+	set("openid_relying_party", m.RelyingParty)
+	set("oauth_authorization_server", m.OAuthAuthorizationServer)
+	set("oauth_client", m.OAuthClient)
+	set("oauth_resource", m.OAuthProtectedResource)
+	set("federation_entity", m.FederationEntity)
+	set("openid_credential_issuer", m.OpenIDCredentialIssuer)
+	set("openid_wallet_provider", m.OpenIDWalletProvider)
+	set("openid_credential_verifier", m.OpenIDCredentialVerifier)
+	for k, v := range m.Extra {
+		set(k, v)
+	}
+	return policies
+}
+
 // Verify verifies that the MetadataPolicy is valid
 func (p MetadataPolicy) Verify(pathInfo string) error {
+	return defaultPolicyOperatorRegistry.verifyPolicy(p, pathInfo)
+}
+
+func (r *policyOperatorRegistry) verifyPolicy(p MetadataPolicy, pathInfo string) error {
 	for k, v := range p {
-		if err := v.Verify(fmt.Sprintf("%s.%s", pathInfo, k)); err != nil {
+		if err := r.verifyEntry(v, fmt.Sprintf("%s.%s", pathInfo, k)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// MergeMetadataPolicies combines multiples MetadataPolicies from a chain into a single one
+// MergeMetadataPolicies combines multiples MetadataPolicies from a chain into a single one.
+// The operator values of the merged policies are checked against the types of
+// the claims; a MetadataPolicyTypeError is returned on a mismatch.
 func MergeMetadataPolicies(policies ...*MetadataPolicies) (*MetadataPolicies, error) {
+	return defaultPolicyOperatorRegistry.mergeMetadataPolicies(policies...)
+}
+
+func (r *policyOperatorRegistry) mergeMetadataPolicies(policies ...*MetadataPolicies) (*MetadataPolicies, error) {
 	opEntries := make([]MetadataPolicy, 0)
 	rpEntries := make([]MetadataPolicy, 0)
 	asEntries := make([]MetadataPolicy, 0)
 	ocEntries := make([]MetadataPolicy, 0)
 	prEntries := make([]MetadataPolicy, 0)
 	feEntries := make([]MetadataPolicy, 0)
+	ciEntries := make([]MetadataPolicy, 0)
+	wpEntries := make([]MetadataPolicy, 0)
+	cvEntries := make([]MetadataPolicy, 0)
 	extraEntries := make(map[string][]MetadataPolicy, 0)
 	for _, p := range policies {
 		if p == nil {
@@ -105,65 +145,95 @@ func MergeMetadataPolicies(policies ...*MetadataPolicies) (*MetadataPolicies, er
 		ocEntries = append(ocEntries, p.OAuthClient)
 		prEntries = append(prEntries, p.OAuthProtectedResource)
 		feEntries = append(feEntries, p.FederationEntity)
+		ciEntries = append(ciEntries, p.OpenIDCredentialIssuer)
+		wpEntries = append(wpEntries, p.OpenIDWalletProvider)
+		cvEntries = append(cvEntries, p.OpenIDCredentialVerifier)
 		for k, v := range p.Extra {
 			extraEntries[k] = append(extraEntries[k], v)
 		}
 	}
-	op, err := CombineMetadataPolicy("openid_provider", opEntries...)
+	op, err := r.combineMetadataPolicies("openid_provider", opEntries...)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := r.combineMetadataPolicies("openid_relying_party", rpEntries...)
+	if err != nil {
+		return nil, err
+	}
+	as, err := r.combineMetadataPolicies("oauth_authorization_server", asEntries...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.combineMetadataPolicies("oauth_client", ocEntries...)
 	if err != nil {
 		return nil, err
 	}
-	rp, err := CombineMetadataPolicy("openid_relying_party", rpEntries...)
+	pr, err := r.combineMetadataPolicies("oauth_resource", prEntries...)
 	if err != nil {
 		return nil, err
 	}
-	as, err := CombineMetadataPolicy("oauth_authorization_server", asEntries...)
+	fed, err := r.combineMetadataPolicies("federation_entity", feEntries...)
 	if err != nil {
 		return nil, err
 	}
-	c, err := CombineMetadataPolicy("oauth_client", ocEntries...)
+	ci, err := r.combineMetadataPolicies("openid_credential_issuer", ciEntries...)
 	if err != nil {
 		return nil, err
 	}
-	pr, err := CombineMetadataPolicy("oauth_resource", prEntries...)
+	wp, err := r.combineMetadataPolicies("openid_wallet_provider", wpEntries...)
 	if err != nil {
 		return nil, err
 	}
-	fed, err := CombineMetadataPolicy("federation_entity", feEntries...)
+	cv, err := r.combineMetadataPolicies("openid_credential_verifier", cvEntries...)
 	if err != nil {
 		return nil, err
 	}
 	extra := make(map[string]MetadataPolicy, 0)
 	for k, v := range extraEntries {
-		extra[k], err = CombineMetadataPolicy(k, v...)
+		extra[k], err = r.combineMetadataPolicies(k, v...)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return &MetadataPolicies{
+	merged := &MetadataPolicies{
 		OpenIDProvider:           op,
 		RelyingParty:             rp,
 		OAuthAuthorizationServer: as,
 		OAuthClient:              c,
 		OAuthProtectedResource:   pr,
 		FederationEntity:         fed,
+		OpenIDCredentialIssuer:   ci,
+		OpenIDWalletProvider:     wp,
+		OpenIDCredentialVerifier: cv,
 		Extra:                    extra,
-	}, nil
+	}
+	for entityType, p := range merged.entityTypePolicies() {
+		if err = r.verifyPolicyTypes(entityType, p); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
 }
 
 // CombineMetadataPolicy combines multiples MetadataPolicy into a single MetadataPolicy,
 // at each step verifying that the result is valid
 func CombineMetadataPolicy(pathInfo string, policies ...MetadataPolicy) (MetadataPolicy, error) {
+	return defaultPolicyOperatorRegistry.combineMetadataPolicies(pathInfo, policies...)
+}
+
+func (r *policyOperatorRegistry) combineMetadataPolicies(pathInfo string, policies ...MetadataPolicy) (
+	MetadataPolicy, error,
+) {
 	if len(policies) == 0 {
 		return nil, nil
 	}
 	var err error
 	out := policies[0]
-	if err = out.Verify(pathInfo); err != nil {
+	if err = r.verifyPolicy(out, pathInfo); err != nil {
 		return nil, err
 	}
 	for i := 1; i < len(policies); i++ {
-		out, err = combineMetadataPolicy(out, policies[i], pathInfo)
+		out, err = r.combineMetadataPolicy(out, policies[i], pathInfo)
 		if err != nil {
 			return nil, err
 		}
@@ -173,6 +243,12 @@ func CombineMetadataPolicy(pathInfo string, policies ...MetadataPolicy) (Metadat
 
 // combineMetadataPolicy combines two MetadataPolicy and verifies that the resulting MetadataPolicy is valid
 func combineMetadataPolicy(parent, sub MetadataPolicy, pathInfo string) (MetadataPolicy, error) {
+	return defaultPolicyOperatorRegistry.combineMetadataPolicy(parent, sub, pathInfo)
+}
+
+func (r *policyOperatorRegistry) combineMetadataPolicy(parent, sub MetadataPolicy, pathInfo string) (
+	MetadataPolicy, error,
+) {
 	if len(sub) == 0 {
 		return parent, nil
 	}
@@ -186,7 +262,7 @@ func combineMetadataPolicy(parent, sub MetadataPolicy, pathInfo string) (Metadat
 			out[k] = pv
 			continue
 		}
-		combined, err := mergeMetadataPolicyEntries(pv, sv, fmt.Sprintf("%s.%s", pathInfo, k))
+		combined, err := r.mergeMetadataPolicyEntries(pv, sv, fmt.Sprintf("%s.%s", pathInfo, k))
 		if err != nil {
 			return nil, err
 		}
@@ -198,10 +274,12 @@ func combineMetadataPolicy(parent, sub MetadataPolicy, pathInfo string) (Metadat
 		}
 		out[k] = sv
 	}
-	return out, out.Verify(pathInfo)
+	return out, r.verifyPolicy(out, pathInfo)
 }
 
-func mergeMetadataPolicyEntries(a, b MetadataPolicyEntry, pathInfo string) (MetadataPolicyEntry, error) {
+func (r *policyOperatorRegistry) mergeMetadataPolicyEntries(a, b MetadataPolicyEntry, pathInfo string) (
+	MetadataPolicyEntry, error,
+) {
 	out := make(MetadataPolicyEntry)
 	for op, av := range a {
 		bv, bFound := b[op]
@@ -209,7 +287,7 @@ func mergeMetadataPolicyEntries(a, b MetadataPolicyEntry, pathInfo string) (Meta
 			out[op] = av
 			continue
 		}
-		operator, ok := operators[op]
+		operator, ok := r.operator(op)
 		if !ok {
 			// return nil, errors.Errorf("unknown policy operator '%s'; cannot combine these policies", op)
 			// We already checked that this is not a crit operator, so it is just ignored
@@ -217,7 +295,7 @@ func mergeMetadataPolicyEntries(a, b MetadataPolicyEntry, pathInfo string) (Meta
 		}
 		combined, err := operator.Merge(av, bv, pathInfo)
 		if err != nil {
-			return nil, err
+			return nil, newMetadataPolicyMergeError(pathInfo, op, err, av, bv)
 		}
 		out[op] = combined
 	}
@@ -232,27 +310,33 @@ func mergeMetadataPolicyEntries(a, b MetadataPolicyEntry, pathInfo string) (Meta
 
 // Verify verifies that the MetadataPolicyEntry is valid
 func (p MetadataPolicyEntry) Verify(pathInfo string) error {
+	return defaultPolicyOperatorRegistry.verifyEntry(p, pathInfo)
+}
+
+func (r *policyOperatorRegistry) verifyEntry(p MetadataPolicyEntry, pathInfo string) error {
 	activeOperators := utils.MapKeys(p)
 	for _, opN := range activeOperators {
-		op, ok := operators[opN]
+		op, ok := r.operator(opN)
 		if !ok {
 			continue
 		}
-		mayCombine := op.MayCombineWith()
+		mayCombine := r.mayCombineWith(op)
 		if mayCombine == nil {
 			continue
 		}
 		notAllowed := slices.Subtract(activeOperators, append(mayCombine, op.Name()))
 		if len(notAllowed) > 0 {
-			return errors.Errorf(
-				"policy operator '%s' in '%s' cannot be combined with these operators: %v", opN,
-				pathInfo, notAllowed,
+			return newMetadataPolicyCombinationError(
+				pathInfo, opN, notAllowed, p, errors.Errorf(
+					"policy operator '%s' in '%s' cannot be combined with these operators: %v", opN,
+					pathInfo, notAllowed,
+				),
 			)
 		}
 	}
-	for _, v := range policyVerifiers {
+	for _, v := range r.policyVerifiers() {
 		if err := v(p, pathInfo); err != nil {
-			return err
+			return newMetadataPolicyCombinationError(pathInfo, "", nil, p, err)
 		}
 	}
 	return nil
@@ -260,24 +344,43 @@ func (p MetadataPolicyEntry) Verify(pathInfo string) error {
 
 // ApplyTo applies this MetadataPolicyEntry to the passed value and returns the resulting value
 func (p MetadataPolicyEntry) ApplyTo(value any, valueSet bool, pathInfo string) (any, error) {
+	return defaultPolicyOperatorRegistry.applyEntry(p, value, valueSet, pathInfo, nil)
+}
+
+// policyOperatorObserver is called after a single PolicyOperator was applied
+// to a value
+type policyOperatorObserver func(operator PolicyOperatorName, policyValue, before, after any, err error)
+
+func (r *policyOperatorRegistry) applyEntry(
+	p MetadataPolicyEntry, value any, valueSet bool, pathInfo string, observer policyOperatorObserver,
+) (any, error) {
 	var err error
 	essentialV, ok := p[PolicyOperatorEssential]
 	essential := false
 	if ok {
 		essential, _ = essentialV.(bool)
 	}
-	for _, policyName := range OperatorOrder {
+	for _, policyName := range r.operatorOrder() {
 		policyValue, ok := p[policyName]
 		if !ok {
 			continue
 		}
-		operator, found := operators[policyName]
+		operator, found := r.operator(policyName)
 		if !found {
-			return value, errors.Errorf("unsupported policy operator '%s' in '%s'", policyName, pathInfo)
+			return value, newMetadataPolicyApplyError(
+				pathInfo, policyName, value, policyValue, false,
+				errors.Errorf("unsupported policy operator '%s' in '%s'", policyName, pathInfo),
+			)
 		}
+		before, beforeSet := value, valueSet
 		value, valueSet, err = operator.Apply(value, valueSet, policyValue, essential, pathInfo)
+		if observer != nil {
+			observer(policyName, policyValue, before, value, err)
+		}
 		if err != nil {
-			return value, err
+			return value, newMetadataPolicyApplyError(
+				pathInfo, policyName, before, policyValue, essential && (!beforeSet || before == nil), err,
+			)
 		}
 	}
 	return value, nil
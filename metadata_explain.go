@@ -0,0 +1,145 @@
+package oidfed
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// MetadataExplanation describes how the final Metadata of a TrustChain was
+// derived from the leaf's Metadata and the MetadataPolicies of its superiors
+type MetadataExplanation struct {
+	Metadata    *Metadata                        `json:"metadata,omitempty"`
+	EntityTypes map[string]EntityTypeExplanation `json:"entity_types"`
+}
+
+// EntityTypeExplanation holds a ClaimExplanation for each claim of a single
+// entity type
+type EntityTypeExplanation map[string]*ClaimExplanation
+
+// ClaimExplanation describes how the final value of a single metadata claim
+// was derived
+type ClaimExplanation struct {
+	OriginalValue any                         `json:"original_value,omitempty"`
+	OriginalSet   bool                        `json:"original_set"`
+	Operators     []PolicyOperatorApplication `json:"operators,omitempty"`
+	FinalValue    any                         `json:"final_value,omitempty"`
+}
+
+// PolicyOperatorApplication describes the application of a single
+// PolicyOperator to a claim value
+type PolicyOperatorApplication struct {
+	Operator PolicyOperatorName `json:"operator"`
+	// PolicyValue is the (merged) operator value that was applied
+	PolicyValue any `json:"policy_value"`
+	// Contributors are the issuers of the statements whose metadata policy
+	// contained this operator for this claim
+	Contributors []string `json:"contributors,omitempty"`
+	ValueBefore  any      `json:"value_before,omitempty"`
+	ValueAfter   any      `json:"value_after,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+func (e EntityTypeExplanation) addClaim(claim string, value any, valueSet bool) *ClaimExplanation {
+	c := &ClaimExplanation{
+		OriginalValue: value,
+		OriginalSet:   valueSet,
+		FinalValue:    value,
+	}
+	e[claim] = c
+	return c
+}
+
+func (c *ClaimExplanation) addOperatorApplication(
+	operator PolicyOperatorName, policyValue, before, after any, err error,
+) {
+	application := PolicyOperatorApplication{
+		Operator:    operator,
+		PolicyValue: policyValue,
+		ValueBefore: before,
+		ValueAfter:  after,
+	}
+	if err != nil {
+		application.Error = err.Error()
+	}
+	c.Operators = append(c.Operators, application)
+}
+
+// ExplainMetadata returns the final Metadata for this TrustChain (
+// as TrustChain.Metadata) together with an explanation of how the value of
+// every claim was derived, i.e. the original value, each applied policy
+// operator and the statements that contributed it, and the final value.
+// If applying the policies fails, the MetadataExplanation collected so far is
+// returned together with the error.
+// In contrast to TrustChain.Metadata the result is never cached.
+func (c TrustChain) ExplainMetadata() (*MetadataExplanation, error) {
+	return c.explainMetadata(defaultPolicyOperatorRegistry)
+}
+
+func (c TrustChain) explainMetadata(r *policyOperatorRegistry) (*MetadataExplanation, error) {
+	if len(c) == 0 {
+		return nil, errors.New("trust chain empty")
+	}
+	m := c[0].Metadata
+	if m == nil {
+		m = &Metadata{}
+	}
+	var combinedPolicy *MetadataPolicies
+	if len(c) > 1 {
+		var err error
+		combinedPolicy, err = c.combinedMetadataPolicy(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if combinedPolicy == nil {
+		combinedPolicy = &MetadataPolicies{}
+	}
+	explanations := make(map[string]EntityTypeExplanation)
+	final, err := m.applyPolicy(r, combinedPolicy, explanations)
+	if err != nil {
+		c.annotateMetadataPolicyError(err)
+	}
+	explanation := &MetadataExplanation{
+		Metadata:    final,
+		EntityTypes: explanations,
+	}
+	contributors := c.metadataPolicyContributors()
+	for entityType, claims := range explanation.EntityTypes {
+		for claim, claimExplanation := range claims {
+			for i, application := range claimExplanation.Operators {
+				claimExplanation.Operators[i].Contributors = contributors[entityType][claim][application.Operator]
+			}
+		}
+	}
+	return explanation, err
+}
+
+// metadataPolicyContributors returns for each entity type, claim,
+// and policy operator the issuers of the statements in the TrustChain that
+// contain this policy operator
+func (c TrustChain) metadataPolicyContributors() map[string]map[string]map[PolicyOperatorName][]string {
+	contributors := make(map[string]map[string]map[PolicyOperatorName][]string)
+	for _, stmt := range c {
+		if stmt == nil || stmt.MetadataPolicy == nil {
+			continue
+		}
+		for entityType, policy := range stmt.MetadataPolicy.entityTypePolicies() {
+			if contributors[entityType] == nil {
+				contributors[entityType] = make(map[string]map[PolicyOperatorName][]string)
+			}
+			for claim, entry := range policy {
+				if contributors[entityType][claim] == nil {
+					contributors[entityType][claim] = make(map[PolicyOperatorName][]string)
+				}
+				for operator := range entry {
+					issuers := contributors[entityType][claim][operator]
+					if !slices.Contains(issuers, stmt.Issuer) {
+						contributors[entityType][claim][operator] = append(issuers, stmt.Issuer)
+					}
+				}
+			}
+		}
+	}
+	return contributors
+}
@@ -0,0 +1,111 @@
+package oidfed
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTrustChain_ExplainMetadata(t *testing.T) {
+	tests := []struct {
+		name                 string
+		chain                TrustChain
+		entityType           string
+		claim                string
+		expectedOperators    []PolicyOperatorName
+		expectedContributors [][]string
+		expectedFinal        any
+		errExpected          bool
+	}{
+		{
+			name:        "empty",
+			chain:       TrustChain{},
+			errExpected: true,
+		},
+		{
+			name:              "chain rp->ia1->ta1: nil policy",
+			chain:             chainRPIA1TA1,
+			entityType:        "openid_relying_party",
+			claim:             "client_registration_types",
+			expectedOperators: nil,
+			expectedFinal:     rp1.metadata.ClientRegistrationTypes,
+		},
+		{
+			name:                 "chain rp->ia2->ta2: contacts",
+			chain:                chainRPIA2TA2,
+			entityType:           "openid_relying_party",
+			claim:                "contacts",
+			expectedOperators:    []PolicyOperatorName{PolicyOperatorAdd},
+			expectedContributors: [][]string{{ia2.EntityID, ta2.EntityID}},
+			expectedFinal:        []string{"ia@example.org", "ta@foundation.example.org"},
+		},
+		{
+			name:                 "chain rp->ia2->ta2: client_registration_types",
+			chain:                chainRPIA2TA2,
+			entityType:           "openid_relying_party",
+			claim:                "client_registration_types",
+			expectedOperators:    []PolicyOperatorName{PolicyOperatorEssential},
+			expectedContributors: [][]string{{ta2.EntityID}},
+			expectedFinal:        rp1.metadata.ClientRegistrationTypes,
+		},
+		{
+			name:        "crit chain rp->ia2->ta2WithRemoveCrit",
+			chain:       chainRPIA2TA2WithRemoveCrit,
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				explanation, err := test.chain.ExplainMetadata()
+				if err != nil {
+					if test.errExpected {
+						return
+					}
+					t.Fatal(err)
+				}
+				if test.errExpected {
+					t.Fatalf("expected error, but no error returned")
+				}
+				expectedMetadata, err := test.chain.Metadata()
+				if err != nil {
+					t.Fatal(err)
+				}
+				explainedJSON, err := json.Marshal(explanation.Metadata)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expectedJSON, err := json.Marshal(expectedMetadata)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(explainedJSON, expectedJSON) {
+					t.Errorf(
+						"explained Metadata differs from TrustChain.Metadata:\n%s\n%s",
+						explainedJSON, expectedJSON,
+					)
+				}
+				claim := explanation.EntityTypes[test.entityType][test.claim]
+				if claim == nil {
+					t.Fatalf("no explanation for '%s.%s'", test.entityType, test.claim)
+				}
+				var operators []PolicyOperatorName
+				var contributors [][]string
+				for _, o := range claim.Operators {
+					operators = append(operators, o.Operator)
+					contributors = append(contributors, o.Contributors)
+				}
+				if !reflect.DeepEqual(operators, test.expectedOperators) {
+					t.Errorf("applied operators are %v, but %v expected", operators, test.expectedOperators)
+				}
+				if !reflect.DeepEqual(contributors, test.expectedContributors) {
+					t.Errorf("contributors are %v, but %v expected", contributors, test.expectedContributors)
+				}
+				if !reflect.DeepEqual(claim.FinalValue, test.expectedFinal) {
+					t.Errorf("final value is %v, but %v expected", claim.FinalValue, test.expectedFinal)
+				}
+			},
+		)
+	}
+}
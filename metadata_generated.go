@@ -11,151 +11,6 @@ import (
 	"github.com/vmihailenco/msgpack/v5"
 )
 
-type FederationEntityMetadata struct {
-	wasSet                            map[string]bool
-	FederationFetchEndpoint           string         `json:"federation_fetch_endpoint,omitempty"`
-	FederationListEndpoint            string         `json:"federation_list_endpoint,omitempty"`
-	FederationResolveEndpoint         string         `json:"federation_resolve_endpoint,omitempty"`
-	FederationTrustMarkStatusEndpoint string         `json:"federation_trust_mark_status_endpoint,omitempty"`
-	FederationTrustMarkListEndpoint   string         `json:"federation_trust_mark_list_endpoint,omitempty"`
-	FederationTrustMarkEndpoint       string         `json:"federation_trust_mark_endpoint,omitempty"`
-	FederationHistoricalLKeysEndpoint string         `json:"federation_historical_keys_endpoint,omitempty"`
-	Extra                             map[string]any `json:"-"`
-	DisplayName                       string         `json:"display_name,omitempty"`
-	Description                       string         `json:"description,omitempty"`
-	Keywords                          []string       `json:"keywords,omitempty"`
-	Contacts                          []string       `json:"contacts,omitempty"`
-	LogoURI                           string         `json:"logo_uri,omitempty"`
-	PolicyURI                         string         `json:"policy_uri,omitempty"`
-	InformationURI                    string         `json:"information_uri,omitempty"`
-	OrganizationName                  string         `json:"organization_name,omitempty"`
-	OrganizationURI                   string         `json:"organization_uri,omitempty"`
-}
-
-type federationEntityMetadataWithPtrs struct {
-	FederationFetchEndpoint           *string        `json:"federation_fetch_endpoint,omitempty"`
-	FederationListEndpoint            *string        `json:"federation_list_endpoint,omitempty"`
-	FederationResolveEndpoint         *string        `json:"federation_resolve_endpoint,omitempty"`
-	FederationTrustMarkStatusEndpoint *string        `json:"federation_trust_mark_status_endpoint,omitempty"`
-	FederationTrustMarkListEndpoint   *string        `json:"federation_trust_mark_list_endpoint,omitempty"`
-	FederationTrustMarkEndpoint       *string        `json:"federation_trust_mark_endpoint,omitempty"`
-	FederationHistoricalLKeysEndpoint *string        `json:"federation_historical_keys_endpoint,omitempty"`
-	Extra                             map[string]any `json:"-"`
-	DisplayName                       *string        `json:"display_name,omitempty"`
-	Description                       *string        `json:"description,omitempty"`
-	Keywords                          []string       `json:"keywords,omitempty"`
-	Contacts                          []string       `json:"contacts,omitempty"`
-	LogoURI                           *string        `json:"logo_uri,omitempty"`
-	PolicyURI                         *string        `json:"policy_uri,omitempty"`
-	InformationURI                    *string        `json:"information_uri,omitempty"`
-	OrganizationName                  *string        `json:"organization_name,omitempty"`
-	OrganizationURI                   *string        `json:"organization_uri,omitempty"`
-}
-
-// MarshalJSON implements the json.Marshaler interface
-func (m FederationEntityMetadata) MarshalJSON() ([]byte, error) {
-	type Alias FederationEntityMetadata
-	explicitFields, err := json.Marshal(Alias(m))
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	return extraMarshalHelper(explicitFields, m.Extra)
-}
-
-// MarshalJSON implements the json.Marshaler interface
-func (m federationEntityMetadataWithPtrs) MarshalJSON() ([]byte, error) {
-	type Alias federationEntityMetadataWithPtrs
-	explicitFields, err := json.Marshal(Alias(m))
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	return extraMarshalHelper(explicitFields, m.Extra)
-}
-
-func (m *FederationEntityMetadata) fromPointers(withPtrs federationEntityMetadataWithPtrs) {
-
-	m.wasSet = make(map[string]bool)
-
-	valOrig := reflect.ValueOf(m).Elem()
-	valWithPtrs := reflect.ValueOf(withPtrs)
-	typeWithPtrs := valWithPtrs.Type()
-
-	for i := 0; i < typeWithPtrs.NumField(); i++ {
-		ptrField := valWithPtrs.Field(i)
-		fieldName := typeWithPtrs.Field(i).Name
-
-		origField := valOrig.FieldByName(fieldName)
-		if !origField.IsValid() || !origField.CanSet() {
-			continue
-		}
-
-		if !ptrField.IsNil() {
-			m.wasSet[fieldName] = true
-		}
-		if ptrField.Kind() == reflect.Ptr && origField.Kind() != reflect.Ptr {
-			if !ptrField.IsNil() {
-				origField.Set(ptrField.Elem())
-			}
-		} else {
-			origField.Set(ptrField)
-		}
-	}
-	for k, _ := range m.Extra {
-		m.wasSet[k] = true
-	}
-}
-
-// UnmarshalJSON implements the json.Unmarshaler interface
-func (m *FederationEntityMetadata) UnmarshalJSON(data []byte) error {
-	var withPtrs federationEntityMetadataWithPtrs
-	if err := json.Unmarshal(data, &withPtrs); err != nil {
-		return err
-	}
-	m.fromPointers(withPtrs)
-	return nil
-}
-
-// UnmarshalJSON implements the json.Unmarshaler interface
-func (m *federationEntityMetadataWithPtrs) UnmarshalJSON(data []byte) error {
-	type Alias federationEntityMetadataWithPtrs
-	mm := Alias(*m)
-
-	extra, err := unmarshalWithExtra(data, &mm)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	mm.Extra = extra
-	*m = federationEntityMetadataWithPtrs(mm)
-	return nil
-}
-
-// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
-func (m *federationEntityMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
-	type Alias federationEntityMetadataWithPtrs
-	mm := Alias(*m)
-	err := msgpack.Unmarshal(data, &mm)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	*m = federationEntityMetadataWithPtrs(mm)
-	return nil
-}
-
-// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
-func (m *FederationEntityMetadata) UnmarshalMsgpack(data []byte) error {
-	var withPtrs federationEntityMetadataWithPtrs
-	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
-		return err
-	}
-	m.fromPointers(withPtrs)
-	return nil
-}
-
-// ApplyPolicy applies a MetadataPolicy to the FederationEntityMetadata
-func (m FederationEntityMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "federation_entity")
-}
-
 type OpenIDRelyingPartyMetadata struct {
 	wasSet                                map[string]bool
 	Scope                                 string         `json:"scope,omitempty"`
@@ -402,181 +257,2622 @@ func (m *OpenIDRelyingPartyMetadata) UnmarshalMsgpack(data []byte) error {
 
 // ApplyPolicy applies a MetadataPolicy to the OpenIDRelyingPartyMetadata
 func (m OpenIDRelyingPartyMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "openid_relying_party")
-}
-
-type OpenIDProviderMetadata struct {
-	wasSet                                                    map[string]bool
-	Issuer                                                    string              `json:"issuer"`
-	AuthorizationEndpoint                                     string              `json:"authorization_endpoint"`
-	TokenEndpoint                                             string              `json:"token_endpoint"`
-	UserinfoEndpoint                                          string              `json:"userinfo_endpoint,omitempty"`
-	RegistrationEndpoint                                      string              `json:"registration_endpoint,omitempty"`
-	ScopesSupported                                           []string            `json:"scopes_supported,omitempty"`
-	ResponseTypesSupported                                    []string            `json:"response_types_supported"`
-	ResponseModesSupported                                    []string            `json:"response_modes_supported,omitempty"`
-	GrantTypesSupported                                       []string            `json:"grant_types_supported,omitempty"`
-	ACRValuesSupported                                        []string            `json:"acr_values_supported,omitempty"`
-	SubjectTypesSupported                                     []string            `json:"subject_types_supported"`
-	IDTokenSignedResponseAlgValuesSupported                   []string            `json:"id_token_signed_response_alg_values_supported,omitempty"`
-	IDTokenEncryptedResponseAlgValuesSupported                []string            `json:"id_token_encrypted_response_alg_values_supported,omitempty"`
-	IDTokenEncryptedResponseEncValuesSupported                []string            `json:"id_token_encrypted_response_enc_values_supported,omitempty"`
-	UserinfoSignedResponseAlgValuesSupported                  []string            `json:"userinfo_signed_response_alg_values_supported,omitempty"`
-	UserinfoEncryptedResponseAlgValuesSupported               []string            `json:"userinfo_encrypted_response_alg_values_supported,omitempty"`
-	UserinfoEncryptedResponseEncValuesSupported               []string            `json:"userinfo_encrypted_response_enc_values_supported,omitempty"`
-	RequestSignedResponseAlgValuesSupported                   []string            `json:"request_signed_response_alg_values_supported,omitempty"`
-	RequestEncryptedResponseAlgValuesSupported                []string            `json:"request_encrypted_response_alg_values_supported,omitempty"`
-	RequestEncryptedResponseEncValuesSupported                []string            `json:"request_encrypted_response_enc_values_supported,omitempty"`
-	TokenEndpointAuthMethodsSupported                         []string            `json:"token_endpoint_auth_methods_supported,omitempty"`
-	TokenEndpointAuthSigningAlgValuesSupported                []string            `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
-	DisplayValuesSupported                                    []string            `json:"display_values_supported,omitempty"`
-	ClaimsSupported                                           []string            `json:"claims_supported,omitempty"`
-	ServiceDocumentation                                      string              `json:"service_documentation,omitempty"`
-	ClaimsLocalesSupported                                    []string            `json:"claims_locales_supported,omitempty"`
-	UILocalesSupported                                        []string            `json:"ui_locales_supported,omitempty"`
-	ClaimsParameterSupported                                  bool                `json:"claims_parameter_supported,omitempty"`
-	RequestParameterSupported                                 bool                `json:"request_parameter_supported,omitempty"`
-	RequestURIParameterSupported                              bool                `json:"request_uri_parameter_supported,omitempty"`
-	RequireRequestURIRegistration                             bool                `json:"require_request_uri_registration,omitempty"`
-	OPPolicyURI                                               string              `json:"op_policy_uri,omitempty"`
-	OPTOSURI                                                  string              `json:"op_tos_uri,omitempty"`
-	RevocationEndpoint                                        string              `json:"revocation_endpoint,omitempty"`
-	RevocationEndpointAuthMethodsSupported                    []string            `json:"revocation_endpoint_auth_methods_supported,omitempty"`
-	RevocationEndpointAuthSigningAlgValuesSupported           []string            `json:"revocation_endpoint_auth_signing_alg_values_supported,omitempty"`
-	IntrospectionEndpoint                                     string              `json:"introspection_endpoint,omitempty"`
-	IntrospectionEndpointAuthMethodsSupported                 []string            `json:"introspection_endpoint_auth_methods_supported,omitempty"`
-	IntrospectionEndpointAuthSigningAlgValuesSupported        []string            `json:"introspection_endpoint_auth_signing_alg_values_supported,omitempty"`
-	IntrospectionSigningAlgValuesSupported                    []string            `json:"introspection_signing_alg_values_supported,omitempty"`
-	IntrospectionEncryptionAlgValuesSupported                 []string            `json:"introspection_encryption_alg_values_supported,omitempty"`
-	IntrospectionEncryptionEncValuesSupported                 []string            `json:"introspection_encryption_enc_values_supported,omitempty"`
-	CodeChallengeMethodsSupported                             []string            `json:"code_challenge_methods_supported,omitempty"`
-	SignedMetadata                                            string              `json:"signed_metadata,omitempty"`
-	DeviceAuthorizationEndpoint                               string              `json:"device_authorization_endpoint,omitempty"`
-	TLSClientCertificateBoundAccessTokens                     bool                `json:"tls_client_certificate_bound_access_tokens,omitempty"`
-	MTLSEndpointAliases                                       map[string]string   `json:"mtls_endpoint_aliases,omitempty"`
-	NFVTokenSigningAlgValuesSupported                         []string            `json:"nfv_token_signing_alg_values_supported,omitempty"`
-	NFVTokenEncryptionAlgValuesSupported                      []string            `json:"nfv_token_encryption_alg_values_supported,omitempty"`
-	NFVTokenEncryptionEncValuesSupported                      []string            `json:"nfv_token_encryption_enc_values_supported,omitempty"`
-	RequireSignedRequestObject                                bool                `json:"require_signed_request_object,omitempty"`
-	PushedAuthorizationRequestEndpoint                        string              `json:"pushed_authorization_request_endpoint,omitempty"`
-	RequirePushedAuthorizationRequests                        bool                `json:"require_pushed_authorization_requests,omitempty"`
-	AuthorizationResponseIssParameterSupported                bool                `json:"authorization_response_iss_parameter_supported,omitempty"`
-	CheckSessionIFrame                                        string              `json:"check_session_iframe,omitempty"`
-	FrontchannelLogoutSupported                               bool                `json:"frontchannel_logout_supported,omitempty"`
-	BackchannelLogoutSupported                                bool                `json:"backchannel_logout_supported,omitempty"`
-	BackchannelLogoutSessionSupported                         bool                `json:"backchannel_logout_session_supported,omitempty"`
-	EndSessionEndpoint                                        string              `json:"end_session_endpoint,omitempty"`
-	BackchannelTokenDeliveryModesSupported                    []string            `json:"backchannel_token_delivery_modes_supported,omitempty"`
-	BackchannelAuthenticationEndpoint                         string              `json:"backchannel_authentication_endpoint,omitempty"`
-	BackchannelAuthenticationRequestSigningAlgValuesSupported []string            `json:"backchannel_authentication_request_signing_alg_values_supported,omitempty"`
-	BackchannelUserCodeParameterSupported                     bool                `json:"backchannel_user_code_parameter_supported,omitempty"`
-	AuthorizationDetailsTypesSupported                        []string            `json:"authorization_details_types_supported,omitempty"`
-	ClientRegistrationTypesSupported                          []string            `json:"client_registration_types_supported"`
-	FederationRegistrationEndpoint                            string              `json:"federation_registration_endpoint,omitempty"`
-	RequestAuthenticationMethodsSupported                     map[string][]string `json:"request_authentication_methods_supported,omitempty"`
-	RequestAuthenticationSigningAlgValuesSupported            []string            `json:"request_authentication_signing_alg_values_supported,omitempty"`
-	Extra                                                     map[string]any      `json:"-"`
-	SignedJWKSURI                                             string              `json:"signed_jwks_uri,omitempty"`
-	JWKSURI                                                   string              `json:"jwks_uri,omitempty"`
-	JWKS                                                      *jwks.JWKS          `json:"jwks,omitempty"`
-	DisplayName                                               string              `json:"display_name,omitempty"`
-	Description                                               string              `json:"description,omitempty"`
-	Keywords                                                  []string            `json:"keywords,omitempty"`
-	Contacts                                                  []string            `json:"contacts,omitempty"`
-	LogoURI                                                   string              `json:"logo_uri,omitempty"`
-	PolicyURI                                                 string              `json:"policy_uri,omitempty"`
-	InformationURI                                            string              `json:"information_uri,omitempty"`
-	OrganizationName                                          string              `json:"organization_name,omitempty"`
-	OrganizationURI                                           string              `json:"organization_uri,omitempty"`
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "openid_relying_party", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
 }
 
-type openIDProviderMetadataWithPtrs struct {
-	Issuer                                                    *string             `json:"issuer"`
-	AuthorizationEndpoint                                     *string             `json:"authorization_endpoint"`
-	TokenEndpoint                                             *string             `json:"token_endpoint"`
-	UserinfoEndpoint                                          *string             `json:"userinfo_endpoint,omitempty"`
-	RegistrationEndpoint                                      *string             `json:"registration_endpoint,omitempty"`
-	ScopesSupported                                           []string            `json:"scopes_supported,omitempty"`
-	ResponseTypesSupported                                    []string            `json:"response_types_supported"`
-	ResponseModesSupported                                    []string            `json:"response_modes_supported,omitempty"`
-	GrantTypesSupported                                       []string            `json:"grant_types_supported,omitempty"`
-	ACRValuesSupported                                        []string            `json:"acr_values_supported,omitempty"`
-	SubjectTypesSupported                                     []string            `json:"subject_types_supported"`
-	IDTokenSignedResponseAlgValuesSupported                   []string            `json:"id_token_signed_response_alg_values_supported,omitempty"`
-	IDTokenEncryptedResponseAlgValuesSupported                []string            `json:"id_token_encrypted_response_alg_values_supported,omitempty"`
-	IDTokenEncryptedResponseEncValuesSupported                []string            `json:"id_token_encrypted_response_enc_values_supported,omitempty"`
-	UserinfoSignedResponseAlgValuesSupported                  []string            `json:"userinfo_signed_response_alg_values_supported,omitempty"`
-	UserinfoEncryptedResponseAlgValuesSupported               []string            `json:"userinfo_encrypted_response_alg_values_supported,omitempty"`
-	UserinfoEncryptedResponseEncValuesSupported               []string            `json:"userinfo_encrypted_response_enc_values_supported,omitempty"`
-	RequestSignedResponseAlgValuesSupported                   []string            `json:"request_signed_response_alg_values_supported,omitempty"`
-	RequestEncryptedResponseAlgValuesSupported                []string            `json:"request_encrypted_response_alg_values_supported,omitempty"`
-	RequestEncryptedResponseEncValuesSupported                []string            `json:"request_encrypted_response_enc_values_supported,omitempty"`
-	TokenEndpointAuthMethodsSupported                         []string            `json:"token_endpoint_auth_methods_supported,omitempty"`
-	TokenEndpointAuthSigningAlgValuesSupported                []string            `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
-	DisplayValuesSupported                                    []string            `json:"display_values_supported,omitempty"`
-	ClaimsSupported                                           []string            `json:"claims_supported,omitempty"`
-	ServiceDocumentation                                      *string             `json:"service_documentation,omitempty"`
+// applyPolicy applies a MetadataPolicy to the OpenIDRelyingPartyMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *OpenIDRelyingPartyMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "scope", &m.Scope,
+		m.wasSet["Scope"], m.Scope != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "redirect_uris", &m.RedirectURIS,
+		m.wasSet["RedirectURIS"], m.RedirectURIS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "response_types", &m.ResponseTypes,
+		m.wasSet["ResponseTypes"], m.ResponseTypes != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "grant_types", &m.GrantTypes,
+		m.wasSet["GrantTypes"], m.GrantTypes != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "application_type", &m.ApplicationType,
+		m.wasSet["ApplicationType"], m.ApplicationType != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_name", &m.ClientName,
+		m.wasSet["ClientName"], m.ClientName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_uri", &m.ClientURI,
+		m.wasSet["ClientURI"], m.ClientURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tos_uri", &m.TOSURI,
+		m.wasSet["TOSURI"], m.TOSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "sector_identifier_uri", &m.SectorIdentifierURI,
+		m.wasSet["SectorIdentifierURI"], m.SectorIdentifierURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "subject_type", &m.SubjectType,
+		m.wasSet["SubjectType"], m.SubjectType != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "id_token_signed_response_alg", &m.IDTokenSignedResponseAlg,
+		m.wasSet["IDTokenSignedResponseAlg"], m.IDTokenSignedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "id_token_encrypted_response_alg", &m.IDTokenEncryptedResponseAlg,
+		m.wasSet["IDTokenEncryptedResponseAlg"], m.IDTokenEncryptedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "id_token_encrypted_response_enc", &m.IDTokenEncryptedResponseEnc,
+		m.wasSet["IDTokenEncryptedResponseEnc"], m.IDTokenEncryptedResponseEnc != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_signed_response_alg", &m.UserinfoSignedResponseAlg,
+		m.wasSet["UserinfoSignedResponseAlg"], m.UserinfoSignedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_encrypted_response_alg", &m.UserinfoEncryptedResponseAlg,
+		m.wasSet["UserinfoEncryptedResponseAlg"], m.UserinfoEncryptedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_encrypted_response_enc", &m.UserinfoEncryptedResponseEnc,
+		m.wasSet["UserinfoEncryptedResponseEnc"], m.UserinfoEncryptedResponseEnc != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_signed_response_alg", &m.RequestSignedResponseAlg,
+		m.wasSet["RequestSignedResponseAlg"], m.RequestSignedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_encrypted_response_alg", &m.RequestEncryptedResponseAlg,
+		m.wasSet["RequestEncryptedResponseAlg"], m.RequestEncryptedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_encrypted_response_enc", &m.RequestEncryptedResponseEnc,
+		m.wasSet["RequestEncryptedResponseEnc"], m.RequestEncryptedResponseEnc != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint_auth_method", &m.TokenEndpointAuthMethod,
+		m.wasSet["TokenEndpointAuthMethod"], m.TokenEndpointAuthMethod != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint_auth_signing_alg", &m.TokenEndpointAuthSigningAlg,
+		m.wasSet["TokenEndpointAuthSigningAlg"], m.TokenEndpointAuthSigningAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "default_max_age", &m.DefaultMaxAge,
+		m.wasSet["DefaultMaxAge"], m.DefaultMaxAge != 0, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "require_auth_time", &m.RequireAuthTime,
+		m.wasSet["RequireAuthTime"], m.RequireAuthTime, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "default_acr_values", &m.DefaultACRValues,
+		m.wasSet["DefaultACRValues"], m.DefaultACRValues != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "initiate_login_uri", &m.InitiateLoginURI,
+		m.wasSet["InitiateLoginURI"], m.InitiateLoginURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_uris", &m.RequestURIs,
+		m.wasSet["RequestURIs"], m.RequestURIs != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "software_id", &m.SoftwareID,
+		m.wasSet["SoftwareID"], m.SoftwareID != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "software_version", &m.SoftwareVersion,
+		m.wasSet["SoftwareVersion"], m.SoftwareVersion != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_id", &m.ClientID,
+		m.wasSet["ClientID"], m.ClientID != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_secret", &m.ClientSecret,
+		m.wasSet["ClientSecret"], m.ClientSecret != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_id_issued_at", &m.ClientIDIssuedAt,
+		m.wasSet["ClientIDIssuedAt"], m.ClientIDIssuedAt != 0, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_secret_expires_at", &m.ClientSecretExpiresAt,
+		m.wasSet["ClientSecretExpiresAt"], m.ClientSecretExpiresAt != 0, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "registration_access_token", &m.RegistrationAccessToken,
+		m.wasSet["RegistrationAccessToken"], m.RegistrationAccessToken != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "registration_client_uri", &m.RegistrationClientURI,
+		m.wasSet["RegistrationClientURI"], m.RegistrationClientURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "claims_redirect_uris", &m.ClaimsRedirectURIs,
+		m.wasSet["ClaimsRedirectURIs"], m.ClaimsRedirectURIs != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nfv_token_signed_response_alg", &m.NFVTokenSignedResponseAlg,
+		m.wasSet["NFVTokenSignedResponseAlg"], m.NFVTokenSignedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nfv_token_encrypted_response_alg", &m.NFVTokenEncryptedResponseAlg,
+		m.wasSet["NFVTokenEncryptedResponseAlg"], m.NFVTokenEncryptedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nfv_token_encrypted_response_enc", &m.NFVTokenEncryptedResponseEnc,
+		m.wasSet["NFVTokenEncryptedResponseEnc"], m.NFVTokenEncryptedResponseEnc != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_certificate_bound_access_tokens", &m.TLSClientCertificateBoundAccessTokens,
+		m.wasSet["TLSClientCertificateBoundAccessTokens"], m.TLSClientCertificateBoundAccessTokens, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_auth_subject_dn", &m.TLSClientAuthSubjectDN,
+		m.wasSet["TLSClientAuthSubjectDN"], m.TLSClientAuthSubjectDN != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_auth_san_dns", &m.TLSClientAuthSANDNS,
+		m.wasSet["TLSClientAuthSANDNS"], m.TLSClientAuthSANDNS != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_auth_san_uri", &m.TLSClientAuthSANURI,
+		m.wasSet["TLSClientAuthSANURI"], m.TLSClientAuthSANURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_auth_san_ip", &m.TLSClientAuthSANIP,
+		m.wasSet["TLSClientAuthSANIP"], m.TLSClientAuthSANIP != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_auth_san_email", &m.TLSClientAuthSANEMAIL,
+		m.wasSet["TLSClientAuthSANEMAIL"], m.TLSClientAuthSANEMAIL != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "require_signed_request_object", &m.RequireSignedRequestObject,
+		m.wasSet["RequireSignedRequestObject"], m.RequireSignedRequestObject, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "require_pushed_authorization_requests", &m.RequirePushedAuthorizationRequests,
+		m.wasSet["RequirePushedAuthorizationRequests"], m.RequirePushedAuthorizationRequests, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_signed_response_alg", &m.IntrospectionSignedResponseAlg,
+		m.wasSet["IntrospectionSignedResponseAlg"], m.IntrospectionSignedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_encrypted_response_alg", &m.IntrospectionEncryptedResponseAlg,
+		m.wasSet["IntrospectionEncryptedResponseAlg"], m.IntrospectionEncryptedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_encrypted_response_enc", &m.IntrospectionEncryptedResponseEnc,
+		m.wasSet["IntrospectionEncryptedResponseEnc"], m.IntrospectionEncryptedResponseEnc != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "frontchannel_logout_uri", &m.FrontchannelLogoutURI,
+		m.wasSet["FrontchannelLogoutURI"], m.FrontchannelLogoutURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "frontchannel_logout_session_required", &m.FrontchannelLogoutSessionRequired,
+		m.wasSet["FrontchannelLogoutSessionRequired"], m.FrontchannelLogoutSessionRequired, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_logout_uri", &m.BackchannelLogoutURI,
+		m.wasSet["BackchannelLogoutURI"], m.BackchannelLogoutURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_logout_session_required", &m.BackchannelLogoutSessionRequired,
+		m.wasSet["BackchannelLogoutSessionRequired"], m.BackchannelLogoutSessionRequired, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "post_logout_redirect_uris", &m.PostLogoutRedirectURIs,
+		m.wasSet["PostLogoutRedirectURIs"], m.PostLogoutRedirectURIs != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_details_types", &m.AuthorizationDetailsTypes,
+		m.wasSet["AuthorizationDetailsTypes"], m.AuthorizationDetailsTypes != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_registration_types", &m.ClientRegistrationTypes,
+		m.wasSet["ClientRegistrationTypes"], m.ClientRegistrationTypes != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_jwks_uri", &m.SignedJWKSURI,
+		m.wasSet["SignedJWKSURI"], m.SignedJWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks_uri", &m.JWKSURI,
+		m.wasSet["JWKSURI"], m.JWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks", &m.JWKS,
+		m.wasSet["JWKS"], m.JWKS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if OpenIDRelyingPartyMetadata has such a claim
+func (m OpenIDRelyingPartyMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "scope":
+		return m.Scope, true
+	case "application_type":
+		return m.ApplicationType, true
+	case "client_name":
+		return m.ClientName, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "client_uri":
+		return m.ClientURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "tos_uri":
+		return m.TOSURI, true
+	case "sector_identifier_uri":
+		return m.SectorIdentifierURI, true
+	case "subject_type":
+		return m.SubjectType, true
+	case "id_token_signed_response_alg":
+		return m.IDTokenSignedResponseAlg, true
+	case "id_token_encrypted_response_alg":
+		return m.IDTokenEncryptedResponseAlg, true
+	case "id_token_encrypted_response_enc":
+		return m.IDTokenEncryptedResponseEnc, true
+	case "userinfo_signed_response_alg":
+		return m.UserinfoSignedResponseAlg, true
+	case "userinfo_encrypted_response_alg":
+		return m.UserinfoEncryptedResponseAlg, true
+	case "userinfo_encrypted_response_enc":
+		return m.UserinfoEncryptedResponseEnc, true
+	case "request_signed_response_alg":
+		return m.RequestSignedResponseAlg, true
+	case "request_encrypted_response_alg":
+		return m.RequestEncryptedResponseAlg, true
+	case "request_encrypted_response_enc":
+		return m.RequestEncryptedResponseEnc, true
+	case "token_endpoint_auth_method":
+		return m.TokenEndpointAuthMethod, true
+	case "token_endpoint_auth_signing_alg":
+		return m.TokenEndpointAuthSigningAlg, true
+	case "initiate_login_uri":
+		return m.InitiateLoginURI, true
+	case "software_id":
+		return m.SoftwareID, true
+	case "software_version":
+		return m.SoftwareVersion, true
+	case "client_id":
+		return m.ClientID, true
+	case "client_secret":
+		return m.ClientSecret, true
+	case "registration_access_token":
+		return m.RegistrationAccessToken, true
+	case "registration_client_uri":
+		return m.RegistrationClientURI, true
+	case "nfv_token_signed_response_alg":
+		return m.NFVTokenSignedResponseAlg, true
+	case "nfv_token_encrypted_response_alg":
+		return m.NFVTokenEncryptedResponseAlg, true
+	case "nfv_token_encrypted_response_enc":
+		return m.NFVTokenEncryptedResponseEnc, true
+	case "tls_client_auth_subject_dn":
+		return m.TLSClientAuthSubjectDN, true
+	case "tls_client_auth_san_dns":
+		return m.TLSClientAuthSANDNS, true
+	case "tls_client_auth_san_uri":
+		return m.TLSClientAuthSANURI, true
+	case "tls_client_auth_san_ip":
+		return m.TLSClientAuthSANIP, true
+	case "tls_client_auth_san_email":
+		return m.TLSClientAuthSANEMAIL, true
+	case "introspection_signed_response_alg":
+		return m.IntrospectionSignedResponseAlg, true
+	case "introspection_encrypted_response_alg":
+		return m.IntrospectionEncryptedResponseAlg, true
+	case "introspection_encrypted_response_enc":
+		return m.IntrospectionEncryptedResponseEnc, true
+	case "frontchannel_logout_uri":
+		return m.FrontchannelLogoutURI, true
+	case "backchannel_logout_uri":
+		return m.BackchannelLogoutURI, true
+	case "signed_jwks_uri":
+		return m.SignedJWKSURI, true
+	case "jwks_uri":
+		return m.JWKSURI, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if OpenIDRelyingPartyMetadata has such a claim
+func (m OpenIDRelyingPartyMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "redirect_uris":
+		return m.RedirectURIS, true
+	case "response_types":
+		return m.ResponseTypes, true
+	case "grant_types":
+		return m.GrantTypes, true
+	case "contacts":
+		return m.Contacts, true
+	case "default_acr_values":
+		return m.DefaultACRValues, true
+	case "request_uris":
+		return m.RequestURIs, true
+	case "claims_redirect_uris":
+		return m.ClaimsRedirectURIs, true
+	case "post_logout_redirect_uris":
+		return m.PostLogoutRedirectURIs, true
+	case "authorization_details_types":
+		return m.AuthorizationDetailsTypes, true
+	case "client_registration_types":
+		return m.ClientRegistrationTypes, true
+	case "keywords":
+		return m.Keywords, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m OpenIDRelyingPartyMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+
+type OpenIDProviderMetadata struct {
+	wasSet                                                    map[string]bool
+	Issuer                                                    string              `json:"issuer"`
+	AuthorizationEndpoint                                     string              `json:"authorization_endpoint"`
+	TokenEndpoint                                             string              `json:"token_endpoint"`
+	UserinfoEndpoint                                          string              `json:"userinfo_endpoint,omitempty"`
+	RegistrationEndpoint                                      string              `json:"registration_endpoint,omitempty"`
+	ScopesSupported                                           []string            `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported                                    []string            `json:"response_types_supported"`
+	ResponseModesSupported                                    []string            `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported                                       []string            `json:"grant_types_supported,omitempty"`
+	ACRValuesSupported                                        []string            `json:"acr_values_supported,omitempty"`
+	SubjectTypesSupported                                     []string            `json:"subject_types_supported"`
+	IDTokenSignedResponseAlgValuesSupported                   []string            `json:"id_token_signed_response_alg_values_supported,omitempty"`
+	IDTokenEncryptedResponseAlgValuesSupported                []string            `json:"id_token_encrypted_response_alg_values_supported,omitempty"`
+	IDTokenEncryptedResponseEncValuesSupported                []string            `json:"id_token_encrypted_response_enc_values_supported,omitempty"`
+	UserinfoSignedResponseAlgValuesSupported                  []string            `json:"userinfo_signed_response_alg_values_supported,omitempty"`
+	UserinfoEncryptedResponseAlgValuesSupported               []string            `json:"userinfo_encrypted_response_alg_values_supported,omitempty"`
+	UserinfoEncryptedResponseEncValuesSupported               []string            `json:"userinfo_encrypted_response_enc_values_supported,omitempty"`
+	RequestSignedResponseAlgValuesSupported                   []string            `json:"request_signed_response_alg_values_supported,omitempty"`
+	RequestEncryptedResponseAlgValuesSupported                []string            `json:"request_encrypted_response_alg_values_supported,omitempty"`
+	RequestEncryptedResponseEncValuesSupported                []string            `json:"request_encrypted_response_enc_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported                         []string            `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported                []string            `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	DisplayValuesSupported                                    []string            `json:"display_values_supported,omitempty"`
+	ClaimsSupported                                           []string            `json:"claims_supported,omitempty"`
+	ServiceDocumentation                                      string              `json:"service_documentation,omitempty"`
 	ClaimsLocalesSupported                                    []string            `json:"claims_locales_supported,omitempty"`
 	UILocalesSupported                                        []string            `json:"ui_locales_supported,omitempty"`
-	ClaimsParameterSupported                                  *bool               `json:"claims_parameter_supported,omitempty"`
-	RequestParameterSupported                                 *bool               `json:"request_parameter_supported,omitempty"`
-	RequestURIParameterSupported                              *bool               `json:"request_uri_parameter_supported,omitempty"`
-	RequireRequestURIRegistration                             *bool               `json:"require_request_uri_registration,omitempty"`
-	OPPolicyURI                                               *string             `json:"op_policy_uri,omitempty"`
-	OPTOSURI                                                  *string             `json:"op_tos_uri,omitempty"`
-	RevocationEndpoint                                        *string             `json:"revocation_endpoint,omitempty"`
+	ClaimsParameterSupported                                  bool                `json:"claims_parameter_supported,omitempty"`
+	RequestParameterSupported                                 bool                `json:"request_parameter_supported,omitempty"`
+	RequestURIParameterSupported                              bool                `json:"request_uri_parameter_supported,omitempty"`
+	RequireRequestURIRegistration                             bool                `json:"require_request_uri_registration,omitempty"`
+	OPPolicyURI                                               string              `json:"op_policy_uri,omitempty"`
+	OPTOSURI                                                  string              `json:"op_tos_uri,omitempty"`
+	RevocationEndpoint                                        string              `json:"revocation_endpoint,omitempty"`
 	RevocationEndpointAuthMethodsSupported                    []string            `json:"revocation_endpoint_auth_methods_supported,omitempty"`
 	RevocationEndpointAuthSigningAlgValuesSupported           []string            `json:"revocation_endpoint_auth_signing_alg_values_supported,omitempty"`
-	IntrospectionEndpoint                                     *string             `json:"introspection_endpoint,omitempty"`
+	IntrospectionEndpoint                                     string              `json:"introspection_endpoint,omitempty"`
 	IntrospectionEndpointAuthMethodsSupported                 []string            `json:"introspection_endpoint_auth_methods_supported,omitempty"`
 	IntrospectionEndpointAuthSigningAlgValuesSupported        []string            `json:"introspection_endpoint_auth_signing_alg_values_supported,omitempty"`
 	IntrospectionSigningAlgValuesSupported                    []string            `json:"introspection_signing_alg_values_supported,omitempty"`
 	IntrospectionEncryptionAlgValuesSupported                 []string            `json:"introspection_encryption_alg_values_supported,omitempty"`
 	IntrospectionEncryptionEncValuesSupported                 []string            `json:"introspection_encryption_enc_values_supported,omitempty"`
 	CodeChallengeMethodsSupported                             []string            `json:"code_challenge_methods_supported,omitempty"`
-	SignedMetadata                                            *string             `json:"signed_metadata,omitempty"`
-	DeviceAuthorizationEndpoint                               *string             `json:"device_authorization_endpoint,omitempty"`
-	TLSClientCertificateBoundAccessTokens                     *bool               `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	SignedMetadata                                            string              `json:"signed_metadata,omitempty"`
+	DeviceAuthorizationEndpoint                               string              `json:"device_authorization_endpoint,omitempty"`
+	TLSClientCertificateBoundAccessTokens                     bool                `json:"tls_client_certificate_bound_access_tokens,omitempty"`
 	MTLSEndpointAliases                                       map[string]string   `json:"mtls_endpoint_aliases,omitempty"`
 	NFVTokenSigningAlgValuesSupported                         []string            `json:"nfv_token_signing_alg_values_supported,omitempty"`
 	NFVTokenEncryptionAlgValuesSupported                      []string            `json:"nfv_token_encryption_alg_values_supported,omitempty"`
 	NFVTokenEncryptionEncValuesSupported                      []string            `json:"nfv_token_encryption_enc_values_supported,omitempty"`
-	RequireSignedRequestObject                                *bool               `json:"require_signed_request_object,omitempty"`
-	PushedAuthorizationRequestEndpoint                        *string             `json:"pushed_authorization_request_endpoint,omitempty"`
-	RequirePushedAuthorizationRequests                        *bool               `json:"require_pushed_authorization_requests,omitempty"`
-	AuthorizationResponseIssParameterSupported                *bool               `json:"authorization_response_iss_parameter_supported,omitempty"`
-	CheckSessionIFrame                                        *string             `json:"check_session_iframe,omitempty"`
-	FrontchannelLogoutSupported                               *bool               `json:"frontchannel_logout_supported,omitempty"`
-	BackchannelLogoutSupported                                *bool               `json:"backchannel_logout_supported,omitempty"`
-	BackchannelLogoutSessionSupported                         *bool               `json:"backchannel_logout_session_supported,omitempty"`
-	EndSessionEndpoint                                        *string             `json:"end_session_endpoint,omitempty"`
+	RequireSignedRequestObject                                bool                `json:"require_signed_request_object,omitempty"`
+	PushedAuthorizationRequestEndpoint                        string              `json:"pushed_authorization_request_endpoint,omitempty"`
+	RequirePushedAuthorizationRequests                        bool                `json:"require_pushed_authorization_requests,omitempty"`
+	AuthorizationResponseIssParameterSupported                bool                `json:"authorization_response_iss_parameter_supported,omitempty"`
+	CheckSessionIFrame                                        string              `json:"check_session_iframe,omitempty"`
+	FrontchannelLogoutSupported                               bool                `json:"frontchannel_logout_supported,omitempty"`
+	BackchannelLogoutSupported                                bool                `json:"backchannel_logout_supported,omitempty"`
+	BackchannelLogoutSessionSupported                         bool                `json:"backchannel_logout_session_supported,omitempty"`
+	EndSessionEndpoint                                        string              `json:"end_session_endpoint,omitempty"`
 	BackchannelTokenDeliveryModesSupported                    []string            `json:"backchannel_token_delivery_modes_supported,omitempty"`
-	BackchannelAuthenticationEndpoint                         *string             `json:"backchannel_authentication_endpoint,omitempty"`
+	BackchannelAuthenticationEndpoint                         string              `json:"backchannel_authentication_endpoint,omitempty"`
 	BackchannelAuthenticationRequestSigningAlgValuesSupported []string            `json:"backchannel_authentication_request_signing_alg_values_supported,omitempty"`
-	BackchannelUserCodeParameterSupported                     *bool               `json:"backchannel_user_code_parameter_supported,omitempty"`
+	BackchannelUserCodeParameterSupported                     bool                `json:"backchannel_user_code_parameter_supported,omitempty"`
 	AuthorizationDetailsTypesSupported                        []string            `json:"authorization_details_types_supported,omitempty"`
 	ClientRegistrationTypesSupported                          []string            `json:"client_registration_types_supported"`
-	FederationRegistrationEndpoint                            *string             `json:"federation_registration_endpoint,omitempty"`
+	FederationRegistrationEndpoint                            string              `json:"federation_registration_endpoint,omitempty"`
 	RequestAuthenticationMethodsSupported                     map[string][]string `json:"request_authentication_methods_supported,omitempty"`
 	RequestAuthenticationSigningAlgValuesSupported            []string            `json:"request_authentication_signing_alg_values_supported,omitempty"`
 	Extra                                                     map[string]any      `json:"-"`
-	SignedJWKSURI                                             *string             `json:"signed_jwks_uri,omitempty"`
-	JWKSURI                                                   *string             `json:"jwks_uri,omitempty"`
+	SignedJWKSURI                                             string              `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                                                   string              `json:"jwks_uri,omitempty"`
 	JWKS                                                      *jwks.JWKS          `json:"jwks,omitempty"`
-	DisplayName                                               *string             `json:"display_name,omitempty"`
-	Description                                               *string             `json:"description,omitempty"`
+	DisplayName                                               string              `json:"display_name,omitempty"`
+	Description                                               string              `json:"description,omitempty"`
 	Keywords                                                  []string            `json:"keywords,omitempty"`
 	Contacts                                                  []string            `json:"contacts,omitempty"`
-	LogoURI                                                   *string             `json:"logo_uri,omitempty"`
-	PolicyURI                                                 *string             `json:"policy_uri,omitempty"`
-	InformationURI                                            *string             `json:"information_uri,omitempty"`
-	OrganizationName                                          *string             `json:"organization_name,omitempty"`
-	OrganizationURI                                           *string             `json:"organization_uri,omitempty"`
+	LogoURI                                                   string              `json:"logo_uri,omitempty"`
+	PolicyURI                                                 string              `json:"policy_uri,omitempty"`
+	InformationURI                                            string              `json:"information_uri,omitempty"`
+	OrganizationName                                          string              `json:"organization_name,omitempty"`
+	OrganizationURI                                           string              `json:"organization_uri,omitempty"`
+}
+
+type openIDProviderMetadataWithPtrs struct {
+	Issuer                                                    *string             `json:"issuer"`
+	AuthorizationEndpoint                                     *string             `json:"authorization_endpoint"`
+	TokenEndpoint                                             *string             `json:"token_endpoint"`
+	UserinfoEndpoint                                          *string             `json:"userinfo_endpoint,omitempty"`
+	RegistrationEndpoint                                      *string             `json:"registration_endpoint,omitempty"`
+	ScopesSupported                                           []string            `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported                                    []string            `json:"response_types_supported"`
+	ResponseModesSupported                                    []string            `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported                                       []string            `json:"grant_types_supported,omitempty"`
+	ACRValuesSupported                                        []string            `json:"acr_values_supported,omitempty"`
+	SubjectTypesSupported                                     []string            `json:"subject_types_supported"`
+	IDTokenSignedResponseAlgValuesSupported                   []string            `json:"id_token_signed_response_alg_values_supported,omitempty"`
+	IDTokenEncryptedResponseAlgValuesSupported                []string            `json:"id_token_encrypted_response_alg_values_supported,omitempty"`
+	IDTokenEncryptedResponseEncValuesSupported                []string            `json:"id_token_encrypted_response_enc_values_supported,omitempty"`
+	UserinfoSignedResponseAlgValuesSupported                  []string            `json:"userinfo_signed_response_alg_values_supported,omitempty"`
+	UserinfoEncryptedResponseAlgValuesSupported               []string            `json:"userinfo_encrypted_response_alg_values_supported,omitempty"`
+	UserinfoEncryptedResponseEncValuesSupported               []string            `json:"userinfo_encrypted_response_enc_values_supported,omitempty"`
+	RequestSignedResponseAlgValuesSupported                   []string            `json:"request_signed_response_alg_values_supported,omitempty"`
+	RequestEncryptedResponseAlgValuesSupported                []string            `json:"request_encrypted_response_alg_values_supported,omitempty"`
+	RequestEncryptedResponseEncValuesSupported                []string            `json:"request_encrypted_response_enc_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported                         []string            `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported                []string            `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	DisplayValuesSupported                                    []string            `json:"display_values_supported,omitempty"`
+	ClaimsSupported                                           []string            `json:"claims_supported,omitempty"`
+	ServiceDocumentation                                      *string             `json:"service_documentation,omitempty"`
+	ClaimsLocalesSupported                                    []string            `json:"claims_locales_supported,omitempty"`
+	UILocalesSupported                                        []string            `json:"ui_locales_supported,omitempty"`
+	ClaimsParameterSupported                                  *bool               `json:"claims_parameter_supported,omitempty"`
+	RequestParameterSupported                                 *bool               `json:"request_parameter_supported,omitempty"`
+	RequestURIParameterSupported                              *bool               `json:"request_uri_parameter_supported,omitempty"`
+	RequireRequestURIRegistration                             *bool               `json:"require_request_uri_registration,omitempty"`
+	OPPolicyURI                                               *string             `json:"op_policy_uri,omitempty"`
+	OPTOSURI                                                  *string             `json:"op_tos_uri,omitempty"`
+	RevocationEndpoint                                        *string             `json:"revocation_endpoint,omitempty"`
+	RevocationEndpointAuthMethodsSupported                    []string            `json:"revocation_endpoint_auth_methods_supported,omitempty"`
+	RevocationEndpointAuthSigningAlgValuesSupported           []string            `json:"revocation_endpoint_auth_signing_alg_values_supported,omitempty"`
+	IntrospectionEndpoint                                     *string             `json:"introspection_endpoint,omitempty"`
+	IntrospectionEndpointAuthMethodsSupported                 []string            `json:"introspection_endpoint_auth_methods_supported,omitempty"`
+	IntrospectionEndpointAuthSigningAlgValuesSupported        []string            `json:"introspection_endpoint_auth_signing_alg_values_supported,omitempty"`
+	IntrospectionSigningAlgValuesSupported                    []string            `json:"introspection_signing_alg_values_supported,omitempty"`
+	IntrospectionEncryptionAlgValuesSupported                 []string            `json:"introspection_encryption_alg_values_supported,omitempty"`
+	IntrospectionEncryptionEncValuesSupported                 []string            `json:"introspection_encryption_enc_values_supported,omitempty"`
+	CodeChallengeMethodsSupported                             []string            `json:"code_challenge_methods_supported,omitempty"`
+	SignedMetadata                                            *string             `json:"signed_metadata,omitempty"`
+	DeviceAuthorizationEndpoint                               *string             `json:"device_authorization_endpoint,omitempty"`
+	TLSClientCertificateBoundAccessTokens                     *bool               `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	MTLSEndpointAliases                                       map[string]string   `json:"mtls_endpoint_aliases,omitempty"`
+	NFVTokenSigningAlgValuesSupported                         []string            `json:"nfv_token_signing_alg_values_supported,omitempty"`
+	NFVTokenEncryptionAlgValuesSupported                      []string            `json:"nfv_token_encryption_alg_values_supported,omitempty"`
+	NFVTokenEncryptionEncValuesSupported                      []string            `json:"nfv_token_encryption_enc_values_supported,omitempty"`
+	RequireSignedRequestObject                                *bool               `json:"require_signed_request_object,omitempty"`
+	PushedAuthorizationRequestEndpoint                        *string             `json:"pushed_authorization_request_endpoint,omitempty"`
+	RequirePushedAuthorizationRequests                        *bool               `json:"require_pushed_authorization_requests,omitempty"`
+	AuthorizationResponseIssParameterSupported                *bool               `json:"authorization_response_iss_parameter_supported,omitempty"`
+	CheckSessionIFrame                                        *string             `json:"check_session_iframe,omitempty"`
+	FrontchannelLogoutSupported                               *bool               `json:"frontchannel_logout_supported,omitempty"`
+	BackchannelLogoutSupported                                *bool               `json:"backchannel_logout_supported,omitempty"`
+	BackchannelLogoutSessionSupported                         *bool               `json:"backchannel_logout_session_supported,omitempty"`
+	EndSessionEndpoint                                        *string             `json:"end_session_endpoint,omitempty"`
+	BackchannelTokenDeliveryModesSupported                    []string            `json:"backchannel_token_delivery_modes_supported,omitempty"`
+	BackchannelAuthenticationEndpoint                         *string             `json:"backchannel_authentication_endpoint,omitempty"`
+	BackchannelAuthenticationRequestSigningAlgValuesSupported []string            `json:"backchannel_authentication_request_signing_alg_values_supported,omitempty"`
+	BackchannelUserCodeParameterSupported                     *bool               `json:"backchannel_user_code_parameter_supported,omitempty"`
+	AuthorizationDetailsTypesSupported                        []string            `json:"authorization_details_types_supported,omitempty"`
+	ClientRegistrationTypesSupported                          []string            `json:"client_registration_types_supported"`
+	FederationRegistrationEndpoint                            *string             `json:"federation_registration_endpoint,omitempty"`
+	RequestAuthenticationMethodsSupported                     map[string][]string `json:"request_authentication_methods_supported,omitempty"`
+	RequestAuthenticationSigningAlgValuesSupported            []string            `json:"request_authentication_signing_alg_values_supported,omitempty"`
+	Extra                                                     map[string]any      `json:"-"`
+	SignedJWKSURI                                             *string             `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                                                   *string             `json:"jwks_uri,omitempty"`
+	JWKS                                                      *jwks.JWKS          `json:"jwks,omitempty"`
+	DisplayName                                               *string             `json:"display_name,omitempty"`
+	Description                                               *string             `json:"description,omitempty"`
+	Keywords                                                  []string            `json:"keywords,omitempty"`
+	Contacts                                                  []string            `json:"contacts,omitempty"`
+	LogoURI                                                   *string             `json:"logo_uri,omitempty"`
+	PolicyURI                                                 *string             `json:"policy_uri,omitempty"`
+	InformationURI                                            *string             `json:"information_uri,omitempty"`
+	OrganizationName                                          *string             `json:"organization_name,omitempty"`
+	OrganizationURI                                           *string             `json:"organization_uri,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m OpenIDProviderMetadata) MarshalJSON() ([]byte, error) {
+	type Alias OpenIDProviderMetadata
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m openIDProviderMetadataWithPtrs) MarshalJSON() ([]byte, error) {
+	type Alias openIDProviderMetadataWithPtrs
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+func (m *OpenIDProviderMetadata) fromPointers(withPtrs openIDProviderMetadataWithPtrs) {
+
+	m.wasSet = make(map[string]bool)
+
+	valOrig := reflect.ValueOf(m).Elem()
+	valWithPtrs := reflect.ValueOf(withPtrs)
+	typeWithPtrs := valWithPtrs.Type()
+
+	for i := 0; i < typeWithPtrs.NumField(); i++ {
+		ptrField := valWithPtrs.Field(i)
+		fieldName := typeWithPtrs.Field(i).Name
+
+		origField := valOrig.FieldByName(fieldName)
+		if !origField.IsValid() || !origField.CanSet() {
+			continue
+		}
+
+		if !ptrField.IsNil() {
+			m.wasSet[fieldName] = true
+		}
+		if ptrField.Kind() == reflect.Ptr && origField.Kind() != reflect.Ptr {
+			if !ptrField.IsNil() {
+				origField.Set(ptrField.Elem())
+			}
+		} else {
+			origField.Set(ptrField)
+		}
+	}
+	for k, _ := range m.Extra {
+		m.wasSet[k] = true
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *OpenIDProviderMetadata) UnmarshalJSON(data []byte) error {
+	var withPtrs openIDProviderMetadataWithPtrs
+	if err := json.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *openIDProviderMetadataWithPtrs) UnmarshalJSON(data []byte) error {
+	type Alias openIDProviderMetadataWithPtrs
+	mm := Alias(*m)
+
+	extra, err := unmarshalWithExtra(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	mm.Extra = extra
+	*m = openIDProviderMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *openIDProviderMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
+	type Alias openIDProviderMetadataWithPtrs
+	mm := Alias(*m)
+	err := msgpack.Unmarshal(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*m = openIDProviderMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *OpenIDProviderMetadata) UnmarshalMsgpack(data []byte) error {
+	var withPtrs openIDProviderMetadataWithPtrs
+	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// ApplyPolicy applies a MetadataPolicy to the OpenIDProviderMetadata
+func (m OpenIDProviderMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "openid_provider", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// applyPolicy applies a MetadataPolicy to the OpenIDProviderMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *OpenIDProviderMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "issuer", &m.Issuer,
+		m.wasSet["Issuer"], m.Issuer != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_endpoint", &m.AuthorizationEndpoint,
+		m.wasSet["AuthorizationEndpoint"], m.AuthorizationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint", &m.TokenEndpoint,
+		m.wasSet["TokenEndpoint"], m.TokenEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_endpoint", &m.UserinfoEndpoint,
+		m.wasSet["UserinfoEndpoint"], m.UserinfoEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "registration_endpoint", &m.RegistrationEndpoint,
+		m.wasSet["RegistrationEndpoint"], m.RegistrationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "scopes_supported", &m.ScopesSupported,
+		m.wasSet["ScopesSupported"], m.ScopesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "response_types_supported", &m.ResponseTypesSupported,
+		m.wasSet["ResponseTypesSupported"], m.ResponseTypesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "response_modes_supported", &m.ResponseModesSupported,
+		m.wasSet["ResponseModesSupported"], m.ResponseModesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "grant_types_supported", &m.GrantTypesSupported,
+		m.wasSet["GrantTypesSupported"], m.GrantTypesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "acr_values_supported", &m.ACRValuesSupported,
+		m.wasSet["ACRValuesSupported"], m.ACRValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "subject_types_supported", &m.SubjectTypesSupported,
+		m.wasSet["SubjectTypesSupported"], m.SubjectTypesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "id_token_signed_response_alg_values_supported", &m.IDTokenSignedResponseAlgValuesSupported,
+		m.wasSet["IDTokenSignedResponseAlgValuesSupported"], m.IDTokenSignedResponseAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "id_token_encrypted_response_alg_values_supported", &m.IDTokenEncryptedResponseAlgValuesSupported,
+		m.wasSet["IDTokenEncryptedResponseAlgValuesSupported"], m.IDTokenEncryptedResponseAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "id_token_encrypted_response_enc_values_supported", &m.IDTokenEncryptedResponseEncValuesSupported,
+		m.wasSet["IDTokenEncryptedResponseEncValuesSupported"], m.IDTokenEncryptedResponseEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_signed_response_alg_values_supported", &m.UserinfoSignedResponseAlgValuesSupported,
+		m.wasSet["UserinfoSignedResponseAlgValuesSupported"], m.UserinfoSignedResponseAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_encrypted_response_alg_values_supported", &m.UserinfoEncryptedResponseAlgValuesSupported,
+		m.wasSet["UserinfoEncryptedResponseAlgValuesSupported"], m.UserinfoEncryptedResponseAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "userinfo_encrypted_response_enc_values_supported", &m.UserinfoEncryptedResponseEncValuesSupported,
+		m.wasSet["UserinfoEncryptedResponseEncValuesSupported"], m.UserinfoEncryptedResponseEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_signed_response_alg_values_supported", &m.RequestSignedResponseAlgValuesSupported,
+		m.wasSet["RequestSignedResponseAlgValuesSupported"], m.RequestSignedResponseAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_encrypted_response_alg_values_supported", &m.RequestEncryptedResponseAlgValuesSupported,
+		m.wasSet["RequestEncryptedResponseAlgValuesSupported"], m.RequestEncryptedResponseAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_encrypted_response_enc_values_supported", &m.RequestEncryptedResponseEncValuesSupported,
+		m.wasSet["RequestEncryptedResponseEncValuesSupported"], m.RequestEncryptedResponseEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint_auth_methods_supported", &m.TokenEndpointAuthMethodsSupported,
+		m.wasSet["TokenEndpointAuthMethodsSupported"], m.TokenEndpointAuthMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint_auth_signing_alg_values_supported", &m.TokenEndpointAuthSigningAlgValuesSupported,
+		m.wasSet["TokenEndpointAuthSigningAlgValuesSupported"], m.TokenEndpointAuthSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_values_supported", &m.DisplayValuesSupported,
+		m.wasSet["DisplayValuesSupported"], m.DisplayValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "claims_supported", &m.ClaimsSupported,
+		m.wasSet["ClaimsSupported"], m.ClaimsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "service_documentation", &m.ServiceDocumentation,
+		m.wasSet["ServiceDocumentation"], m.ServiceDocumentation != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "claims_locales_supported", &m.ClaimsLocalesSupported,
+		m.wasSet["ClaimsLocalesSupported"], m.ClaimsLocalesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "ui_locales_supported", &m.UILocalesSupported,
+		m.wasSet["UILocalesSupported"], m.UILocalesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "claims_parameter_supported", &m.ClaimsParameterSupported,
+		m.wasSet["ClaimsParameterSupported"], m.ClaimsParameterSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_parameter_supported", &m.RequestParameterSupported,
+		m.wasSet["RequestParameterSupported"], m.RequestParameterSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_uri_parameter_supported", &m.RequestURIParameterSupported,
+		m.wasSet["RequestURIParameterSupported"], m.RequestURIParameterSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "require_request_uri_registration", &m.RequireRequestURIRegistration,
+		m.wasSet["RequireRequestURIRegistration"], m.RequireRequestURIRegistration, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "op_policy_uri", &m.OPPolicyURI,
+		m.wasSet["OPPolicyURI"], m.OPPolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "op_tos_uri", &m.OPTOSURI,
+		m.wasSet["OPTOSURI"], m.OPTOSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "revocation_endpoint", &m.RevocationEndpoint,
+		m.wasSet["RevocationEndpoint"], m.RevocationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "revocation_endpoint_auth_methods_supported", &m.RevocationEndpointAuthMethodsSupported,
+		m.wasSet["RevocationEndpointAuthMethodsSupported"], m.RevocationEndpointAuthMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "revocation_endpoint_auth_signing_alg_values_supported", &m.RevocationEndpointAuthSigningAlgValuesSupported,
+		m.wasSet["RevocationEndpointAuthSigningAlgValuesSupported"], m.RevocationEndpointAuthSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_endpoint", &m.IntrospectionEndpoint,
+		m.wasSet["IntrospectionEndpoint"], m.IntrospectionEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_endpoint_auth_methods_supported", &m.IntrospectionEndpointAuthMethodsSupported,
+		m.wasSet["IntrospectionEndpointAuthMethodsSupported"], m.IntrospectionEndpointAuthMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_endpoint_auth_signing_alg_values_supported", &m.IntrospectionEndpointAuthSigningAlgValuesSupported,
+		m.wasSet["IntrospectionEndpointAuthSigningAlgValuesSupported"], m.IntrospectionEndpointAuthSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_signing_alg_values_supported", &m.IntrospectionSigningAlgValuesSupported,
+		m.wasSet["IntrospectionSigningAlgValuesSupported"], m.IntrospectionSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_encryption_alg_values_supported", &m.IntrospectionEncryptionAlgValuesSupported,
+		m.wasSet["IntrospectionEncryptionAlgValuesSupported"], m.IntrospectionEncryptionAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "introspection_encryption_enc_values_supported", &m.IntrospectionEncryptionEncValuesSupported,
+		m.wasSet["IntrospectionEncryptionEncValuesSupported"], m.IntrospectionEncryptionEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "code_challenge_methods_supported", &m.CodeChallengeMethodsSupported,
+		m.wasSet["CodeChallengeMethodsSupported"], m.CodeChallengeMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_metadata", &m.SignedMetadata,
+		m.wasSet["SignedMetadata"], m.SignedMetadata != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "device_authorization_endpoint", &m.DeviceAuthorizationEndpoint,
+		m.wasSet["DeviceAuthorizationEndpoint"], m.DeviceAuthorizationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tls_client_certificate_bound_access_tokens", &m.TLSClientCertificateBoundAccessTokens,
+		m.wasSet["TLSClientCertificateBoundAccessTokens"], m.TLSClientCertificateBoundAccessTokens, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "mtls_endpoint_aliases", &m.MTLSEndpointAliases,
+		m.wasSet["MTLSEndpointAliases"], m.MTLSEndpointAliases != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nfv_token_signing_alg_values_supported", &m.NFVTokenSigningAlgValuesSupported,
+		m.wasSet["NFVTokenSigningAlgValuesSupported"], m.NFVTokenSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nfv_token_encryption_alg_values_supported", &m.NFVTokenEncryptionAlgValuesSupported,
+		m.wasSet["NFVTokenEncryptionAlgValuesSupported"], m.NFVTokenEncryptionAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nfv_token_encryption_enc_values_supported", &m.NFVTokenEncryptionEncValuesSupported,
+		m.wasSet["NFVTokenEncryptionEncValuesSupported"], m.NFVTokenEncryptionEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "require_signed_request_object", &m.RequireSignedRequestObject,
+		m.wasSet["RequireSignedRequestObject"], m.RequireSignedRequestObject, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "pushed_authorization_request_endpoint", &m.PushedAuthorizationRequestEndpoint,
+		m.wasSet["PushedAuthorizationRequestEndpoint"], m.PushedAuthorizationRequestEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "require_pushed_authorization_requests", &m.RequirePushedAuthorizationRequests,
+		m.wasSet["RequirePushedAuthorizationRequests"], m.RequirePushedAuthorizationRequests, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_response_iss_parameter_supported", &m.AuthorizationResponseIssParameterSupported,
+		m.wasSet["AuthorizationResponseIssParameterSupported"], m.AuthorizationResponseIssParameterSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "check_session_iframe", &m.CheckSessionIFrame,
+		m.wasSet["CheckSessionIFrame"], m.CheckSessionIFrame != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "frontchannel_logout_supported", &m.FrontchannelLogoutSupported,
+		m.wasSet["FrontchannelLogoutSupported"], m.FrontchannelLogoutSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_logout_supported", &m.BackchannelLogoutSupported,
+		m.wasSet["BackchannelLogoutSupported"], m.BackchannelLogoutSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_logout_session_supported", &m.BackchannelLogoutSessionSupported,
+		m.wasSet["BackchannelLogoutSessionSupported"], m.BackchannelLogoutSessionSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "end_session_endpoint", &m.EndSessionEndpoint,
+		m.wasSet["EndSessionEndpoint"], m.EndSessionEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_token_delivery_modes_supported", &m.BackchannelTokenDeliveryModesSupported,
+		m.wasSet["BackchannelTokenDeliveryModesSupported"], m.BackchannelTokenDeliveryModesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_authentication_endpoint", &m.BackchannelAuthenticationEndpoint,
+		m.wasSet["BackchannelAuthenticationEndpoint"], m.BackchannelAuthenticationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_authentication_request_signing_alg_values_supported", &m.BackchannelAuthenticationRequestSigningAlgValuesSupported,
+		m.wasSet["BackchannelAuthenticationRequestSigningAlgValuesSupported"], m.BackchannelAuthenticationRequestSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "backchannel_user_code_parameter_supported", &m.BackchannelUserCodeParameterSupported,
+		m.wasSet["BackchannelUserCodeParameterSupported"], m.BackchannelUserCodeParameterSupported, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_details_types_supported", &m.AuthorizationDetailsTypesSupported,
+		m.wasSet["AuthorizationDetailsTypesSupported"], m.AuthorizationDetailsTypesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_registration_types_supported", &m.ClientRegistrationTypesSupported,
+		m.wasSet["ClientRegistrationTypesSupported"], m.ClientRegistrationTypesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_registration_endpoint", &m.FederationRegistrationEndpoint,
+		m.wasSet["FederationRegistrationEndpoint"], m.FederationRegistrationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_authentication_methods_supported", &m.RequestAuthenticationMethodsSupported,
+		m.wasSet["RequestAuthenticationMethodsSupported"], m.RequestAuthenticationMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_authentication_signing_alg_values_supported", &m.RequestAuthenticationSigningAlgValuesSupported,
+		m.wasSet["RequestAuthenticationSigningAlgValuesSupported"], m.RequestAuthenticationSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_jwks_uri", &m.SignedJWKSURI,
+		m.wasSet["SignedJWKSURI"], m.SignedJWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks_uri", &m.JWKSURI,
+		m.wasSet["JWKSURI"], m.JWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks", &m.JWKS,
+		m.wasSet["JWKS"], m.JWKS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if OpenIDProviderMetadata has such a claim
+func (m OpenIDProviderMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "issuer":
+		return m.Issuer, true
+	case "authorization_endpoint":
+		return m.AuthorizationEndpoint, true
+	case "token_endpoint":
+		return m.TokenEndpoint, true
+	case "userinfo_endpoint":
+		return m.UserinfoEndpoint, true
+	case "registration_endpoint":
+		return m.RegistrationEndpoint, true
+	case "service_documentation":
+		return m.ServiceDocumentation, true
+	case "op_policy_uri":
+		return m.OPPolicyURI, true
+	case "op_tos_uri":
+		return m.OPTOSURI, true
+	case "revocation_endpoint":
+		return m.RevocationEndpoint, true
+	case "introspection_endpoint":
+		return m.IntrospectionEndpoint, true
+	case "signed_metadata":
+		return m.SignedMetadata, true
+	case "device_authorization_endpoint":
+		return m.DeviceAuthorizationEndpoint, true
+	case "pushed_authorization_request_endpoint":
+		return m.PushedAuthorizationRequestEndpoint, true
+	case "check_session_iframe":
+		return m.CheckSessionIFrame, true
+	case "end_session_endpoint":
+		return m.EndSessionEndpoint, true
+	case "backchannel_authentication_endpoint":
+		return m.BackchannelAuthenticationEndpoint, true
+	case "federation_registration_endpoint":
+		return m.FederationRegistrationEndpoint, true
+	case "signed_jwks_uri":
+		return m.SignedJWKSURI, true
+	case "jwks_uri":
+		return m.JWKSURI, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if OpenIDProviderMetadata has such a claim
+func (m OpenIDProviderMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "scopes_supported":
+		return m.ScopesSupported, true
+	case "response_types_supported":
+		return m.ResponseTypesSupported, true
+	case "response_modes_supported":
+		return m.ResponseModesSupported, true
+	case "grant_types_supported":
+		return m.GrantTypesSupported, true
+	case "acr_values_supported":
+		return m.ACRValuesSupported, true
+	case "subject_types_supported":
+		return m.SubjectTypesSupported, true
+	case "id_token_signed_response_alg_values_supported":
+		return m.IDTokenSignedResponseAlgValuesSupported, true
+	case "id_token_encrypted_response_alg_values_supported":
+		return m.IDTokenEncryptedResponseAlgValuesSupported, true
+	case "id_token_encrypted_response_enc_values_supported":
+		return m.IDTokenEncryptedResponseEncValuesSupported, true
+	case "userinfo_signed_response_alg_values_supported":
+		return m.UserinfoSignedResponseAlgValuesSupported, true
+	case "userinfo_encrypted_response_alg_values_supported":
+		return m.UserinfoEncryptedResponseAlgValuesSupported, true
+	case "userinfo_encrypted_response_enc_values_supported":
+		return m.UserinfoEncryptedResponseEncValuesSupported, true
+	case "request_signed_response_alg_values_supported":
+		return m.RequestSignedResponseAlgValuesSupported, true
+	case "request_encrypted_response_alg_values_supported":
+		return m.RequestEncryptedResponseAlgValuesSupported, true
+	case "request_encrypted_response_enc_values_supported":
+		return m.RequestEncryptedResponseEncValuesSupported, true
+	case "token_endpoint_auth_methods_supported":
+		return m.TokenEndpointAuthMethodsSupported, true
+	case "token_endpoint_auth_signing_alg_values_supported":
+		return m.TokenEndpointAuthSigningAlgValuesSupported, true
+	case "display_values_supported":
+		return m.DisplayValuesSupported, true
+	case "claims_supported":
+		return m.ClaimsSupported, true
+	case "claims_locales_supported":
+		return m.ClaimsLocalesSupported, true
+	case "ui_locales_supported":
+		return m.UILocalesSupported, true
+	case "revocation_endpoint_auth_methods_supported":
+		return m.RevocationEndpointAuthMethodsSupported, true
+	case "revocation_endpoint_auth_signing_alg_values_supported":
+		return m.RevocationEndpointAuthSigningAlgValuesSupported, true
+	case "introspection_endpoint_auth_methods_supported":
+		return m.IntrospectionEndpointAuthMethodsSupported, true
+	case "introspection_endpoint_auth_signing_alg_values_supported":
+		return m.IntrospectionEndpointAuthSigningAlgValuesSupported, true
+	case "introspection_signing_alg_values_supported":
+		return m.IntrospectionSigningAlgValuesSupported, true
+	case "introspection_encryption_alg_values_supported":
+		return m.IntrospectionEncryptionAlgValuesSupported, true
+	case "introspection_encryption_enc_values_supported":
+		return m.IntrospectionEncryptionEncValuesSupported, true
+	case "code_challenge_methods_supported":
+		return m.CodeChallengeMethodsSupported, true
+	case "nfv_token_signing_alg_values_supported":
+		return m.NFVTokenSigningAlgValuesSupported, true
+	case "nfv_token_encryption_alg_values_supported":
+		return m.NFVTokenEncryptionAlgValuesSupported, true
+	case "nfv_token_encryption_enc_values_supported":
+		return m.NFVTokenEncryptionEncValuesSupported, true
+	case "backchannel_token_delivery_modes_supported":
+		return m.BackchannelTokenDeliveryModesSupported, true
+	case "backchannel_authentication_request_signing_alg_values_supported":
+		return m.BackchannelAuthenticationRequestSigningAlgValuesSupported, true
+	case "authorization_details_types_supported":
+		return m.AuthorizationDetailsTypesSupported, true
+	case "client_registration_types_supported":
+		return m.ClientRegistrationTypesSupported, true
+	case "request_authentication_signing_alg_values_supported":
+		return m.RequestAuthenticationSigningAlgValuesSupported, true
+	case "keywords":
+		return m.Keywords, true
+	case "contacts":
+		return m.Contacts, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m OpenIDProviderMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+
+type OAuthProtectedResourceMetadata struct {
+	wasSet                               map[string]bool
+	Resource                             string         `json:"resource,omitempty"`
+	AuthorizationServers                 []string       `json:"authorization_servers,omitempty"`
+	ScopesSupported                      []string       `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported               []string       `json:"bearer_methods_supported,omitempty"`
+	ResourceSigningAlgValuesSupported    []string       `json:"resource_signing_alg_values_supported,omitempty"`
+	ResourceEncryptionAlgValuesSupported []string       `json:"resource_encryption_alg_values_supported"`
+	ResourceEncryptionEncValuesSupported []string       `json:"resource_encryption_enc_values_supported"`
+	ResourceName                         string         `json:"resource_name,omitempty"`
+	ResourceDocumentation                string         `json:"resource_documentation,omitempty"`
+	ResourcePolicyURI                    string         `json:"resource_policy_uri,omitempty"`
+	ResourceTOSURI                       string         `json:"resource_tos_uri,omitempty"`
+	Extra                                map[string]any `json:"-"`
+	SignedJWKSURI                        string         `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                              string         `json:"jwks_uri,omitempty"`
+	JWKS                                 *jwks.JWKS     `json:"jwks,omitempty"`
+	DisplayName                          string         `json:"display_name,omitempty"`
+	Description                          string         `json:"description,omitempty"`
+	Keywords                             []string       `json:"keywords,omitempty"`
+	Contacts                             []string       `json:"contacts,omitempty"`
+	LogoURI                              string         `json:"logo_uri,omitempty"`
+	PolicyURI                            string         `json:"policy_uri,omitempty"`
+	InformationURI                       string         `json:"information_uri,omitempty"`
+	OrganizationName                     string         `json:"organization_name,omitempty"`
+	OrganizationURI                      string         `json:"organization_uri,omitempty"`
+}
+
+type oAuthProtectedResourceMetadataWithPtrs struct {
+	Resource                             *string        `json:"resource,omitempty"`
+	AuthorizationServers                 []string       `json:"authorization_servers,omitempty"`
+	ScopesSupported                      []string       `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported               []string       `json:"bearer_methods_supported,omitempty"`
+	ResourceSigningAlgValuesSupported    []string       `json:"resource_signing_alg_values_supported,omitempty"`
+	ResourceEncryptionAlgValuesSupported []string       `json:"resource_encryption_alg_values_supported"`
+	ResourceEncryptionEncValuesSupported []string       `json:"resource_encryption_enc_values_supported"`
+	ResourceName                         *string        `json:"resource_name,omitempty"`
+	ResourceDocumentation                *string        `json:"resource_documentation,omitempty"`
+	ResourcePolicyURI                    *string        `json:"resource_policy_uri,omitempty"`
+	ResourceTOSURI                       *string        `json:"resource_tos_uri,omitempty"`
+	Extra                                map[string]any `json:"-"`
+	SignedJWKSURI                        *string        `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                              *string        `json:"jwks_uri,omitempty"`
+	JWKS                                 *jwks.JWKS     `json:"jwks,omitempty"`
+	DisplayName                          *string        `json:"display_name,omitempty"`
+	Description                          *string        `json:"description,omitempty"`
+	Keywords                             []string       `json:"keywords,omitempty"`
+	Contacts                             []string       `json:"contacts,omitempty"`
+	LogoURI                              *string        `json:"logo_uri,omitempty"`
+	PolicyURI                            *string        `json:"policy_uri,omitempty"`
+	InformationURI                       *string        `json:"information_uri,omitempty"`
+	OrganizationName                     *string        `json:"organization_name,omitempty"`
+	OrganizationURI                      *string        `json:"organization_uri,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m OAuthProtectedResourceMetadata) MarshalJSON() ([]byte, error) {
+	type Alias OAuthProtectedResourceMetadata
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m oAuthProtectedResourceMetadataWithPtrs) MarshalJSON() ([]byte, error) {
+	type Alias oAuthProtectedResourceMetadataWithPtrs
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+func (m *OAuthProtectedResourceMetadata) fromPointers(withPtrs oAuthProtectedResourceMetadataWithPtrs) {
+
+	m.wasSet = make(map[string]bool)
+
+	valOrig := reflect.ValueOf(m).Elem()
+	valWithPtrs := reflect.ValueOf(withPtrs)
+	typeWithPtrs := valWithPtrs.Type()
+
+	for i := 0; i < typeWithPtrs.NumField(); i++ {
+		ptrField := valWithPtrs.Field(i)
+		fieldName := typeWithPtrs.Field(i).Name
+
+		origField := valOrig.FieldByName(fieldName)
+		if !origField.IsValid() || !origField.CanSet() {
+			continue
+		}
+
+		if !ptrField.IsNil() {
+			m.wasSet[fieldName] = true
+		}
+		if ptrField.Kind() == reflect.Ptr && origField.Kind() != reflect.Ptr {
+			if !ptrField.IsNil() {
+				origField.Set(ptrField.Elem())
+			}
+		} else {
+			origField.Set(ptrField)
+		}
+	}
+	for k, _ := range m.Extra {
+		m.wasSet[k] = true
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *OAuthProtectedResourceMetadata) UnmarshalJSON(data []byte) error {
+	var withPtrs oAuthProtectedResourceMetadataWithPtrs
+	if err := json.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *oAuthProtectedResourceMetadataWithPtrs) UnmarshalJSON(data []byte) error {
+	type Alias oAuthProtectedResourceMetadataWithPtrs
+	mm := Alias(*m)
+
+	extra, err := unmarshalWithExtra(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	mm.Extra = extra
+	*m = oAuthProtectedResourceMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *oAuthProtectedResourceMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
+	type Alias oAuthProtectedResourceMetadataWithPtrs
+	mm := Alias(*m)
+	err := msgpack.Unmarshal(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*m = oAuthProtectedResourceMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *OAuthProtectedResourceMetadata) UnmarshalMsgpack(data []byte) error {
+	var withPtrs oAuthProtectedResourceMetadataWithPtrs
+	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// ApplyPolicy applies a MetadataPolicy to the OAuthProtectedResourceMetadata
+func (m OAuthProtectedResourceMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "oauth_resource", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// applyPolicy applies a MetadataPolicy to the OAuthProtectedResourceMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *OAuthProtectedResourceMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource", &m.Resource,
+		m.wasSet["Resource"], m.Resource != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_servers", &m.AuthorizationServers,
+		m.wasSet["AuthorizationServers"], m.AuthorizationServers != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "scopes_supported", &m.ScopesSupported,
+		m.wasSet["ScopesSupported"], m.ScopesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "bearer_methods_supported", &m.BearerMethodsSupported,
+		m.wasSet["BearerMethodsSupported"], m.BearerMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_signing_alg_values_supported", &m.ResourceSigningAlgValuesSupported,
+		m.wasSet["ResourceSigningAlgValuesSupported"], m.ResourceSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_encryption_alg_values_supported", &m.ResourceEncryptionAlgValuesSupported,
+		m.wasSet["ResourceEncryptionAlgValuesSupported"], m.ResourceEncryptionAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_encryption_enc_values_supported", &m.ResourceEncryptionEncValuesSupported,
+		m.wasSet["ResourceEncryptionEncValuesSupported"], m.ResourceEncryptionEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_name", &m.ResourceName,
+		m.wasSet["ResourceName"], m.ResourceName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_documentation", &m.ResourceDocumentation,
+		m.wasSet["ResourceDocumentation"], m.ResourceDocumentation != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_policy_uri", &m.ResourcePolicyURI,
+		m.wasSet["ResourcePolicyURI"], m.ResourcePolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "resource_tos_uri", &m.ResourceTOSURI,
+		m.wasSet["ResourceTOSURI"], m.ResourceTOSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_jwks_uri", &m.SignedJWKSURI,
+		m.wasSet["SignedJWKSURI"], m.SignedJWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks_uri", &m.JWKSURI,
+		m.wasSet["JWKSURI"], m.JWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks", &m.JWKS,
+		m.wasSet["JWKS"], m.JWKS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if OAuthProtectedResourceMetadata has such a claim
+func (m OAuthProtectedResourceMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "resource":
+		return m.Resource, true
+	case "resource_name":
+		return m.ResourceName, true
+	case "resource_documentation":
+		return m.ResourceDocumentation, true
+	case "resource_policy_uri":
+		return m.ResourcePolicyURI, true
+	case "resource_tos_uri":
+		return m.ResourceTOSURI, true
+	case "signed_jwks_uri":
+		return m.SignedJWKSURI, true
+	case "jwks_uri":
+		return m.JWKSURI, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if OAuthProtectedResourceMetadata has such a claim
+func (m OAuthProtectedResourceMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "authorization_servers":
+		return m.AuthorizationServers, true
+	case "scopes_supported":
+		return m.ScopesSupported, true
+	case "bearer_methods_supported":
+		return m.BearerMethodsSupported, true
+	case "resource_signing_alg_values_supported":
+		return m.ResourceSigningAlgValuesSupported, true
+	case "resource_encryption_alg_values_supported":
+		return m.ResourceEncryptionAlgValuesSupported, true
+	case "resource_encryption_enc_values_supported":
+		return m.ResourceEncryptionEncValuesSupported, true
+	case "keywords":
+		return m.Keywords, true
+	case "contacts":
+		return m.Contacts, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m OAuthProtectedResourceMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+
+type FederationEntityMetadata struct {
+	wasSet                            map[string]bool
+	FederationFetchEndpoint           string         `json:"federation_fetch_endpoint,omitempty"`
+	FederationListEndpoint            string         `json:"federation_list_endpoint,omitempty"`
+	FederationResolveEndpoint         string         `json:"federation_resolve_endpoint,omitempty"`
+	FederationTrustMarkStatusEndpoint string         `json:"federation_trust_mark_status_endpoint,omitempty"`
+	FederationTrustMarkListEndpoint   string         `json:"federation_trust_mark_list_endpoint,omitempty"`
+	FederationTrustMarkEndpoint       string         `json:"federation_trust_mark_endpoint,omitempty"`
+	FederationHistoricalLKeysEndpoint string         `json:"federation_historical_keys_endpoint,omitempty"`
+	Extra                             map[string]any `json:"-"`
+	DisplayName                       string         `json:"display_name,omitempty"`
+	Description                       string         `json:"description,omitempty"`
+	Keywords                          []string       `json:"keywords,omitempty"`
+	Contacts                          []string       `json:"contacts,omitempty"`
+	LogoURI                           string         `json:"logo_uri,omitempty"`
+	PolicyURI                         string         `json:"policy_uri,omitempty"`
+	InformationURI                    string         `json:"information_uri,omitempty"`
+	OrganizationName                  string         `json:"organization_name,omitempty"`
+	OrganizationURI                   string         `json:"organization_uri,omitempty"`
+}
+
+type federationEntityMetadataWithPtrs struct {
+	FederationFetchEndpoint           *string        `json:"federation_fetch_endpoint,omitempty"`
+	FederationListEndpoint            *string        `json:"federation_list_endpoint,omitempty"`
+	FederationResolveEndpoint         *string        `json:"federation_resolve_endpoint,omitempty"`
+	FederationTrustMarkStatusEndpoint *string        `json:"federation_trust_mark_status_endpoint,omitempty"`
+	FederationTrustMarkListEndpoint   *string        `json:"federation_trust_mark_list_endpoint,omitempty"`
+	FederationTrustMarkEndpoint       *string        `json:"federation_trust_mark_endpoint,omitempty"`
+	FederationHistoricalLKeysEndpoint *string        `json:"federation_historical_keys_endpoint,omitempty"`
+	Extra                             map[string]any `json:"-"`
+	DisplayName                       *string        `json:"display_name,omitempty"`
+	Description                       *string        `json:"description,omitempty"`
+	Keywords                          []string       `json:"keywords,omitempty"`
+	Contacts                          []string       `json:"contacts,omitempty"`
+	LogoURI                           *string        `json:"logo_uri,omitempty"`
+	PolicyURI                         *string        `json:"policy_uri,omitempty"`
+	InformationURI                    *string        `json:"information_uri,omitempty"`
+	OrganizationName                  *string        `json:"organization_name,omitempty"`
+	OrganizationURI                   *string        `json:"organization_uri,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m FederationEntityMetadata) MarshalJSON() ([]byte, error) {
+	type Alias FederationEntityMetadata
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m federationEntityMetadataWithPtrs) MarshalJSON() ([]byte, error) {
+	type Alias federationEntityMetadataWithPtrs
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+func (m *FederationEntityMetadata) fromPointers(withPtrs federationEntityMetadataWithPtrs) {
+
+	m.wasSet = make(map[string]bool)
+
+	valOrig := reflect.ValueOf(m).Elem()
+	valWithPtrs := reflect.ValueOf(withPtrs)
+	typeWithPtrs := valWithPtrs.Type()
+
+	for i := 0; i < typeWithPtrs.NumField(); i++ {
+		ptrField := valWithPtrs.Field(i)
+		fieldName := typeWithPtrs.Field(i).Name
+
+		origField := valOrig.FieldByName(fieldName)
+		if !origField.IsValid() || !origField.CanSet() {
+			continue
+		}
+
+		if !ptrField.IsNil() {
+			m.wasSet[fieldName] = true
+		}
+		if ptrField.Kind() == reflect.Ptr && origField.Kind() != reflect.Ptr {
+			if !ptrField.IsNil() {
+				origField.Set(ptrField.Elem())
+			}
+		} else {
+			origField.Set(ptrField)
+		}
+	}
+	for k, _ := range m.Extra {
+		m.wasSet[k] = true
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *FederationEntityMetadata) UnmarshalJSON(data []byte) error {
+	var withPtrs federationEntityMetadataWithPtrs
+	if err := json.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *federationEntityMetadataWithPtrs) UnmarshalJSON(data []byte) error {
+	type Alias federationEntityMetadataWithPtrs
+	mm := Alias(*m)
+
+	extra, err := unmarshalWithExtra(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	mm.Extra = extra
+	*m = federationEntityMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *federationEntityMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
+	type Alias federationEntityMetadataWithPtrs
+	mm := Alias(*m)
+	err := msgpack.Unmarshal(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*m = federationEntityMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *FederationEntityMetadata) UnmarshalMsgpack(data []byte) error {
+	var withPtrs federationEntityMetadataWithPtrs
+	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// ApplyPolicy applies a MetadataPolicy to the FederationEntityMetadata
+func (m FederationEntityMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "federation_entity", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// applyPolicy applies a MetadataPolicy to the FederationEntityMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *FederationEntityMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_fetch_endpoint", &m.FederationFetchEndpoint,
+		m.wasSet["FederationFetchEndpoint"], m.FederationFetchEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_list_endpoint", &m.FederationListEndpoint,
+		m.wasSet["FederationListEndpoint"], m.FederationListEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_resolve_endpoint", &m.FederationResolveEndpoint,
+		m.wasSet["FederationResolveEndpoint"], m.FederationResolveEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_trust_mark_status_endpoint", &m.FederationTrustMarkStatusEndpoint,
+		m.wasSet["FederationTrustMarkStatusEndpoint"], m.FederationTrustMarkStatusEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_trust_mark_list_endpoint", &m.FederationTrustMarkListEndpoint,
+		m.wasSet["FederationTrustMarkListEndpoint"], m.FederationTrustMarkListEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_trust_mark_endpoint", &m.FederationTrustMarkEndpoint,
+		m.wasSet["FederationTrustMarkEndpoint"], m.FederationTrustMarkEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "federation_historical_keys_endpoint", &m.FederationHistoricalLKeysEndpoint,
+		m.wasSet["FederationHistoricalLKeysEndpoint"], m.FederationHistoricalLKeysEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if FederationEntityMetadata has such a claim
+func (m FederationEntityMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "federation_fetch_endpoint":
+		return m.FederationFetchEndpoint, true
+	case "federation_list_endpoint":
+		return m.FederationListEndpoint, true
+	case "federation_resolve_endpoint":
+		return m.FederationResolveEndpoint, true
+	case "federation_trust_mark_status_endpoint":
+		return m.FederationTrustMarkStatusEndpoint, true
+	case "federation_trust_mark_list_endpoint":
+		return m.FederationTrustMarkListEndpoint, true
+	case "federation_trust_mark_endpoint":
+		return m.FederationTrustMarkEndpoint, true
+	case "federation_historical_keys_endpoint":
+		return m.FederationHistoricalLKeysEndpoint, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if FederationEntityMetadata has such a claim
+func (m FederationEntityMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "keywords":
+		return m.Keywords, true
+	case "contacts":
+		return m.Contacts, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m FederationEntityMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+
+type OpenIDCredentialIssuerMetadata struct {
+	wasSet                            map[string]bool
+	CredentialIssuer                  string           `json:"credential_issuer"`
+	AuthorizationServers              []string         `json:"authorization_servers,omitempty"`
+	CredentialEndpoint                string           `json:"credential_endpoint"`
+	NonceEndpoint                     string           `json:"nonce_endpoint,omitempty"`
+	DeferredCredentialEndpoint        string           `json:"deferred_credential_endpoint,omitempty"`
+	NotificationEndpoint              string           `json:"notification_endpoint,omitempty"`
+	CredentialRequestEncryption       map[string]any   `json:"credential_request_encryption,omitempty"`
+	CredentialResponseEncryption      map[string]any   `json:"credential_response_encryption,omitempty"`
+	BatchCredentialIssuance           map[string]any   `json:"batch_credential_issuance,omitempty"`
+	SignedMetadata                    string           `json:"signed_metadata,omitempty"`
+	Display                           []map[string]any `json:"display,omitempty"`
+	CredentialConfigurationsSupported map[string]any   `json:"credential_configurations_supported"`
+	Extra                             map[string]any   `json:"-"`
+	SignedJWKSURI                     string           `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                           string           `json:"jwks_uri,omitempty"`
+	JWKS                              *jwks.JWKS       `json:"jwks,omitempty"`
+	DisplayName                       string           `json:"display_name,omitempty"`
+	Description                       string           `json:"description,omitempty"`
+	Keywords                          []string         `json:"keywords,omitempty"`
+	Contacts                          []string         `json:"contacts,omitempty"`
+	LogoURI                           string           `json:"logo_uri,omitempty"`
+	PolicyURI                         string           `json:"policy_uri,omitempty"`
+	InformationURI                    string           `json:"information_uri,omitempty"`
+	OrganizationName                  string           `json:"organization_name,omitempty"`
+	OrganizationURI                   string           `json:"organization_uri,omitempty"`
+}
+
+type openIDCredentialIssuerMetadataWithPtrs struct {
+	CredentialIssuer                  *string          `json:"credential_issuer"`
+	AuthorizationServers              []string         `json:"authorization_servers,omitempty"`
+	CredentialEndpoint                *string          `json:"credential_endpoint"`
+	NonceEndpoint                     *string          `json:"nonce_endpoint,omitempty"`
+	DeferredCredentialEndpoint        *string          `json:"deferred_credential_endpoint,omitempty"`
+	NotificationEndpoint              *string          `json:"notification_endpoint,omitempty"`
+	CredentialRequestEncryption       map[string]any   `json:"credential_request_encryption,omitempty"`
+	CredentialResponseEncryption      map[string]any   `json:"credential_response_encryption,omitempty"`
+	BatchCredentialIssuance           map[string]any   `json:"batch_credential_issuance,omitempty"`
+	SignedMetadata                    *string          `json:"signed_metadata,omitempty"`
+	Display                           []map[string]any `json:"display,omitempty"`
+	CredentialConfigurationsSupported map[string]any   `json:"credential_configurations_supported"`
+	Extra                             map[string]any   `json:"-"`
+	SignedJWKSURI                     *string          `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                           *string          `json:"jwks_uri,omitempty"`
+	JWKS                              *jwks.JWKS       `json:"jwks,omitempty"`
+	DisplayName                       *string          `json:"display_name,omitempty"`
+	Description                       *string          `json:"description,omitempty"`
+	Keywords                          []string         `json:"keywords,omitempty"`
+	Contacts                          []string         `json:"contacts,omitempty"`
+	LogoURI                           *string          `json:"logo_uri,omitempty"`
+	PolicyURI                         *string          `json:"policy_uri,omitempty"`
+	InformationURI                    *string          `json:"information_uri,omitempty"`
+	OrganizationName                  *string          `json:"organization_name,omitempty"`
+	OrganizationURI                   *string          `json:"organization_uri,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m OpenIDCredentialIssuerMetadata) MarshalJSON() ([]byte, error) {
+	type Alias OpenIDCredentialIssuerMetadata
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m openIDCredentialIssuerMetadataWithPtrs) MarshalJSON() ([]byte, error) {
+	type Alias openIDCredentialIssuerMetadataWithPtrs
+	explicitFields, err := json.Marshal(Alias(m))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return extraMarshalHelper(explicitFields, m.Extra)
+}
+
+func (m *OpenIDCredentialIssuerMetadata) fromPointers(withPtrs openIDCredentialIssuerMetadataWithPtrs) {
+
+	m.wasSet = make(map[string]bool)
+
+	valOrig := reflect.ValueOf(m).Elem()
+	valWithPtrs := reflect.ValueOf(withPtrs)
+	typeWithPtrs := valWithPtrs.Type()
+
+	for i := 0; i < typeWithPtrs.NumField(); i++ {
+		ptrField := valWithPtrs.Field(i)
+		fieldName := typeWithPtrs.Field(i).Name
+
+		origField := valOrig.FieldByName(fieldName)
+		if !origField.IsValid() || !origField.CanSet() {
+			continue
+		}
+
+		if !ptrField.IsNil() {
+			m.wasSet[fieldName] = true
+		}
+		if ptrField.Kind() == reflect.Ptr && origField.Kind() != reflect.Ptr {
+			if !ptrField.IsNil() {
+				origField.Set(ptrField.Elem())
+			}
+		} else {
+			origField.Set(ptrField)
+		}
+	}
+	for k, _ := range m.Extra {
+		m.wasSet[k] = true
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *OpenIDCredentialIssuerMetadata) UnmarshalJSON(data []byte) error {
+	var withPtrs openIDCredentialIssuerMetadataWithPtrs
+	if err := json.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *openIDCredentialIssuerMetadataWithPtrs) UnmarshalJSON(data []byte) error {
+	type Alias openIDCredentialIssuerMetadataWithPtrs
+	mm := Alias(*m)
+
+	extra, err := unmarshalWithExtra(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	mm.Extra = extra
+	*m = openIDCredentialIssuerMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *openIDCredentialIssuerMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
+	type Alias openIDCredentialIssuerMetadataWithPtrs
+	mm := Alias(*m)
+	err := msgpack.Unmarshal(data, &mm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*m = openIDCredentialIssuerMetadataWithPtrs(mm)
+	return nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface
+func (m *OpenIDCredentialIssuerMetadata) UnmarshalMsgpack(data []byte) error {
+	var withPtrs openIDCredentialIssuerMetadataWithPtrs
+	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
+		return err
+	}
+	m.fromPointers(withPtrs)
+	return nil
+}
+
+// ApplyPolicy applies a MetadataPolicy to the OpenIDCredentialIssuerMetadata
+func (m OpenIDCredentialIssuerMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "openid_credential_issuer", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// applyPolicy applies a MetadataPolicy to the OpenIDCredentialIssuerMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *OpenIDCredentialIssuerMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "credential_issuer", &m.CredentialIssuer,
+		m.wasSet["CredentialIssuer"], m.CredentialIssuer != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_servers", &m.AuthorizationServers,
+		m.wasSet["AuthorizationServers"], m.AuthorizationServers != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "credential_endpoint", &m.CredentialEndpoint,
+		m.wasSet["CredentialEndpoint"], m.CredentialEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "nonce_endpoint", &m.NonceEndpoint,
+		m.wasSet["NonceEndpoint"], m.NonceEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "deferred_credential_endpoint", &m.DeferredCredentialEndpoint,
+		m.wasSet["DeferredCredentialEndpoint"], m.DeferredCredentialEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "notification_endpoint", &m.NotificationEndpoint,
+		m.wasSet["NotificationEndpoint"], m.NotificationEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "credential_request_encryption", &m.CredentialRequestEncryption,
+		m.wasSet["CredentialRequestEncryption"], m.CredentialRequestEncryption != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "credential_response_encryption", &m.CredentialResponseEncryption,
+		m.wasSet["CredentialResponseEncryption"], m.CredentialResponseEncryption != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "batch_credential_issuance", &m.BatchCredentialIssuance,
+		m.wasSet["BatchCredentialIssuance"], m.BatchCredentialIssuance != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_metadata", &m.SignedMetadata,
+		m.wasSet["SignedMetadata"], m.SignedMetadata != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display", &m.Display,
+		m.wasSet["Display"], m.Display != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "credential_configurations_supported", &m.CredentialConfigurationsSupported,
+		m.wasSet["CredentialConfigurationsSupported"], m.CredentialConfigurationsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_jwks_uri", &m.SignedJWKSURI,
+		m.wasSet["SignedJWKSURI"], m.SignedJWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks_uri", &m.JWKSURI,
+		m.wasSet["JWKSURI"], m.JWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks", &m.JWKS,
+		m.wasSet["JWKS"], m.JWKS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if OpenIDCredentialIssuerMetadata has such a claim
+func (m OpenIDCredentialIssuerMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "credential_issuer":
+		return m.CredentialIssuer, true
+	case "credential_endpoint":
+		return m.CredentialEndpoint, true
+	case "nonce_endpoint":
+		return m.NonceEndpoint, true
+	case "deferred_credential_endpoint":
+		return m.DeferredCredentialEndpoint, true
+	case "notification_endpoint":
+		return m.NotificationEndpoint, true
+	case "signed_metadata":
+		return m.SignedMetadata, true
+	case "signed_jwks_uri":
+		return m.SignedJWKSURI, true
+	case "jwks_uri":
+		return m.JWKSURI, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if OpenIDCredentialIssuerMetadata has such a claim
+func (m OpenIDCredentialIssuerMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "authorization_servers":
+		return m.AuthorizationServers, true
+	case "keywords":
+		return m.Keywords, true
+	case "contacts":
+		return m.Contacts, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m OpenIDCredentialIssuerMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+
+type OpenIDWalletProviderMetadata struct {
+	wasSet                                     map[string]bool
+	TokenEndpoint                              string         `json:"token_endpoint,omitempty"`
+	GrantTypesSupported                        []string       `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported          []string       `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported []string       `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	AALValuesSupported                         []string       `json:"aal_values_supported,omitempty"`
+	Extra                                      map[string]any `json:"-"`
+	SignedJWKSURI                              string         `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                                    string         `json:"jwks_uri,omitempty"`
+	JWKS                                       *jwks.JWKS     `json:"jwks,omitempty"`
+	DisplayName                                string         `json:"display_name,omitempty"`
+	Description                                string         `json:"description,omitempty"`
+	Keywords                                   []string       `json:"keywords,omitempty"`
+	Contacts                                   []string       `json:"contacts,omitempty"`
+	LogoURI                                    string         `json:"logo_uri,omitempty"`
+	PolicyURI                                  string         `json:"policy_uri,omitempty"`
+	InformationURI                             string         `json:"information_uri,omitempty"`
+	OrganizationName                           string         `json:"organization_name,omitempty"`
+	OrganizationURI                            string         `json:"organization_uri,omitempty"`
+}
+
+type openIDWalletProviderMetadataWithPtrs struct {
+	TokenEndpoint                              *string        `json:"token_endpoint,omitempty"`
+	GrantTypesSupported                        []string       `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported          []string       `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported []string       `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	AALValuesSupported                         []string       `json:"aal_values_supported,omitempty"`
+	Extra                                      map[string]any `json:"-"`
+	SignedJWKSURI                              *string        `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                                    *string        `json:"jwks_uri,omitempty"`
+	JWKS                                       *jwks.JWKS     `json:"jwks,omitempty"`
+	DisplayName                                *string        `json:"display_name,omitempty"`
+	Description                                *string        `json:"description,omitempty"`
+	Keywords                                   []string       `json:"keywords,omitempty"`
+	Contacts                                   []string       `json:"contacts,omitempty"`
+	LogoURI                                    *string        `json:"logo_uri,omitempty"`
+	PolicyURI                                  *string        `json:"policy_uri,omitempty"`
+	InformationURI                             *string        `json:"information_uri,omitempty"`
+	OrganizationName                           *string        `json:"organization_name,omitempty"`
+	OrganizationURI                            *string        `json:"organization_uri,omitempty"`
 }
 
 // MarshalJSON implements the json.Marshaler interface
-func (m OpenIDProviderMetadata) MarshalJSON() ([]byte, error) {
-	type Alias OpenIDProviderMetadata
+func (m OpenIDWalletProviderMetadata) MarshalJSON() ([]byte, error) {
+	type Alias OpenIDWalletProviderMetadata
 	explicitFields, err := json.Marshal(Alias(m))
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -585,8 +2881,8 @@ func (m OpenIDProviderMetadata) MarshalJSON() ([]byte, error) {
 }
 
 // MarshalJSON implements the json.Marshaler interface
-func (m openIDProviderMetadataWithPtrs) MarshalJSON() ([]byte, error) {
-	type Alias openIDProviderMetadataWithPtrs
+func (m openIDWalletProviderMetadataWithPtrs) MarshalJSON() ([]byte, error) {
+	type Alias openIDWalletProviderMetadataWithPtrs
 	explicitFields, err := json.Marshal(Alias(m))
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -594,7 +2890,7 @@ func (m openIDProviderMetadataWithPtrs) MarshalJSON() ([]byte, error) {
 	return extraMarshalHelper(explicitFields, m.Extra)
 }
 
-func (m *OpenIDProviderMetadata) fromPointers(withPtrs openIDProviderMetadataWithPtrs) {
+func (m *OpenIDWalletProviderMetadata) fromPointers(withPtrs openIDWalletProviderMetadataWithPtrs) {
 
 	m.wasSet = make(map[string]bool)
 
@@ -628,8 +2924,8 @@ func (m *OpenIDProviderMetadata) fromPointers(withPtrs openIDProviderMetadataWit
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface
-func (m *OpenIDProviderMetadata) UnmarshalJSON(data []byte) error {
-	var withPtrs openIDProviderMetadataWithPtrs
+func (m *OpenIDWalletProviderMetadata) UnmarshalJSON(data []byte) error {
+	var withPtrs openIDWalletProviderMetadataWithPtrs
 	if err := json.Unmarshal(data, &withPtrs); err != nil {
 		return err
 	}
@@ -638,8 +2934,8 @@ func (m *OpenIDProviderMetadata) UnmarshalJSON(data []byte) error {
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface
-func (m *openIDProviderMetadataWithPtrs) UnmarshalJSON(data []byte) error {
-	type Alias openIDProviderMetadataWithPtrs
+func (m *openIDWalletProviderMetadataWithPtrs) UnmarshalJSON(data []byte) error {
+	type Alias openIDWalletProviderMetadataWithPtrs
 	mm := Alias(*m)
 
 	extra, err := unmarshalWithExtra(data, &mm)
@@ -647,25 +2943,25 @@ func (m *openIDProviderMetadataWithPtrs) UnmarshalJSON(data []byte) error {
 		return errors.WithStack(err)
 	}
 	mm.Extra = extra
-	*m = openIDProviderMetadataWithPtrs(mm)
+	*m = openIDWalletProviderMetadataWithPtrs(mm)
 	return nil
 }
 
 // UnmarshalMsgpack implements the msgpack.Unmarshaler interface
-func (m *openIDProviderMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
-	type Alias openIDProviderMetadataWithPtrs
+func (m *openIDWalletProviderMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
+	type Alias openIDWalletProviderMetadataWithPtrs
 	mm := Alias(*m)
 	err := msgpack.Unmarshal(data, &mm)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	*m = openIDProviderMetadataWithPtrs(mm)
+	*m = openIDWalletProviderMetadataWithPtrs(mm)
 	return nil
 }
 
 // UnmarshalMsgpack implements the msgpack.Unmarshaler interface
-func (m *OpenIDProviderMetadata) UnmarshalMsgpack(data []byte) error {
-	var withPtrs openIDProviderMetadataWithPtrs
+func (m *OpenIDWalletProviderMetadata) UnmarshalMsgpack(data []byte) error {
+	var withPtrs openIDWalletProviderMetadataWithPtrs
 	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
 		return err
 	}
@@ -673,69 +2969,244 @@ func (m *OpenIDProviderMetadata) UnmarshalMsgpack(data []byte) error {
 	return nil
 }
 
-// ApplyPolicy applies a MetadataPolicy to the OpenIDProviderMetadata
-func (m OpenIDProviderMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "openid_provider")
+// ApplyPolicy applies a MetadataPolicy to the OpenIDWalletProviderMetadata
+func (m OpenIDWalletProviderMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "openid_wallet_provider", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
 }
 
-type OAuthProtectedResourceMetadata struct {
-	wasSet                               map[string]bool
-	Resource                             string         `json:"resource,omitempty"`
-	AuthorizationServers                 []string       `json:"authorization_servers,omitempty"`
-	ScopesSupported                      []string       `json:"scopes_supported,omitempty"`
-	BearerMethodsSupported               []string       `json:"bearer_methods_supported,omitempty"`
-	ResourceSigningAlgValuesSupported    []string       `json:"resource_signing_alg_values_supported,omitempty"`
-	ResourceEncryptionAlgValuesSupported []string       `json:"resource_encryption_alg_values_supported"`
-	ResourceEncryptionEncValuesSupported []string       `json:"resource_encryption_enc_values_supported"`
-	ResourceName                         string         `json:"resource_name,omitempty"`
-	ResourceDocumentation                string         `json:"resource_documentation,omitempty"`
-	ResourcePolicyURI                    string         `json:"resource_policy_uri,omitempty"`
-	ResourceTOSURI                       string         `json:"resource_tos_uri,omitempty"`
-	Extra                                map[string]any `json:"-"`
-	SignedJWKSURI                        string         `json:"signed_jwks_uri,omitempty"`
-	JWKSURI                              string         `json:"jwks_uri,omitempty"`
-	JWKS                                 *jwks.JWKS     `json:"jwks,omitempty"`
-	DisplayName                          string         `json:"display_name,omitempty"`
-	Description                          string         `json:"description,omitempty"`
-	Keywords                             []string       `json:"keywords,omitempty"`
-	Contacts                             []string       `json:"contacts,omitempty"`
-	LogoURI                              string         `json:"logo_uri,omitempty"`
-	PolicyURI                            string         `json:"policy_uri,omitempty"`
-	InformationURI                       string         `json:"information_uri,omitempty"`
-	OrganizationName                     string         `json:"organization_name,omitempty"`
-	OrganizationURI                      string         `json:"organization_uri,omitempty"`
+// applyPolicy applies a MetadataPolicy to the OpenIDWalletProviderMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *OpenIDWalletProviderMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint", &m.TokenEndpoint,
+		m.wasSet["TokenEndpoint"], m.TokenEndpoint != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "grant_types_supported", &m.GrantTypesSupported,
+		m.wasSet["GrantTypesSupported"], m.GrantTypesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint_auth_methods_supported", &m.TokenEndpointAuthMethodsSupported,
+		m.wasSet["TokenEndpointAuthMethodsSupported"], m.TokenEndpointAuthMethodsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "token_endpoint_auth_signing_alg_values_supported", &m.TokenEndpointAuthSigningAlgValuesSupported,
+		m.wasSet["TokenEndpointAuthSigningAlgValuesSupported"], m.TokenEndpointAuthSigningAlgValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "aal_values_supported", &m.AALValuesSupported,
+		m.wasSet["AALValuesSupported"], m.AALValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_jwks_uri", &m.SignedJWKSURI,
+		m.wasSet["SignedJWKSURI"], m.SignedJWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks_uri", &m.JWKSURI,
+		m.wasSet["JWKSURI"], m.JWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks", &m.JWKS,
+		m.wasSet["JWKS"], m.JWKS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
 }
 
-type oAuthProtectedResourceMetadataWithPtrs struct {
-	Resource                             *string        `json:"resource,omitempty"`
-	AuthorizationServers                 []string       `json:"authorization_servers,omitempty"`
-	ScopesSupported                      []string       `json:"scopes_supported,omitempty"`
-	BearerMethodsSupported               []string       `json:"bearer_methods_supported,omitempty"`
-	ResourceSigningAlgValuesSupported    []string       `json:"resource_signing_alg_values_supported,omitempty"`
-	ResourceEncryptionAlgValuesSupported []string       `json:"resource_encryption_alg_values_supported"`
-	ResourceEncryptionEncValuesSupported []string       `json:"resource_encryption_enc_values_supported"`
-	ResourceName                         *string        `json:"resource_name,omitempty"`
-	ResourceDocumentation                *string        `json:"resource_documentation,omitempty"`
-	ResourcePolicyURI                    *string        `json:"resource_policy_uri,omitempty"`
-	ResourceTOSURI                       *string        `json:"resource_tos_uri,omitempty"`
-	Extra                                map[string]any `json:"-"`
-	SignedJWKSURI                        *string        `json:"signed_jwks_uri,omitempty"`
-	JWKSURI                              *string        `json:"jwks_uri,omitempty"`
-	JWKS                                 *jwks.JWKS     `json:"jwks,omitempty"`
-	DisplayName                          *string        `json:"display_name,omitempty"`
-	Description                          *string        `json:"description,omitempty"`
-	Keywords                             []string       `json:"keywords,omitempty"`
-	Contacts                             []string       `json:"contacts,omitempty"`
-	LogoURI                              *string        `json:"logo_uri,omitempty"`
-	PolicyURI                            *string        `json:"policy_uri,omitempty"`
-	InformationURI                       *string        `json:"information_uri,omitempty"`
-	OrganizationName                     *string        `json:"organization_name,omitempty"`
-	OrganizationURI                      *string        `json:"organization_uri,omitempty"`
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if OpenIDWalletProviderMetadata has such a claim
+func (m OpenIDWalletProviderMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "token_endpoint":
+		return m.TokenEndpoint, true
+	case "signed_jwks_uri":
+		return m.SignedJWKSURI, true
+	case "jwks_uri":
+		return m.JWKSURI, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if OpenIDWalletProviderMetadata has such a claim
+func (m OpenIDWalletProviderMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "grant_types_supported":
+		return m.GrantTypesSupported, true
+	case "token_endpoint_auth_methods_supported":
+		return m.TokenEndpointAuthMethodsSupported, true
+	case "token_endpoint_auth_signing_alg_values_supported":
+		return m.TokenEndpointAuthSigningAlgValuesSupported, true
+	case "aal_values_supported":
+		return m.AALValuesSupported, true
+	case "keywords":
+		return m.Keywords, true
+	case "contacts":
+		return m.Contacts, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m OpenIDWalletProviderMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
+}
+
+type OpenIDCredentialVerifierMetadata struct {
+	wasSet                              map[string]bool
+	ClientName                          string         `json:"client_name,omitempty"`
+	RedirectURIS                        []string       `json:"redirect_uris,omitempty"`
+	ResponseTypes                       []string       `json:"response_types,omitempty"`
+	RequestURIs                         []string       `json:"request_uris,omitempty"`
+	TOSURI                              string         `json:"tos_uri,omitempty"`
+	VPFormatsSupported                  map[string]any `json:"vp_formats_supported,omitempty"`
+	EncryptedResponseEncValuesSupported []string       `json:"encrypted_response_enc_values_supported,omitempty"`
+	AuthorizationSignedResponseAlg      string         `json:"authorization_signed_response_alg,omitempty"`
+	AuthorizationEncryptedResponseAlg   string         `json:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc   string         `json:"authorization_encrypted_response_enc,omitempty"`
+	ClientRegistrationTypes             []string       `json:"client_registration_types,omitempty"`
+	Extra                               map[string]any `json:"-"`
+	SignedJWKSURI                       string         `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                             string         `json:"jwks_uri,omitempty"`
+	JWKS                                *jwks.JWKS     `json:"jwks,omitempty"`
+	DisplayName                         string         `json:"display_name,omitempty"`
+	Description                         string         `json:"description,omitempty"`
+	Keywords                            []string       `json:"keywords,omitempty"`
+	Contacts                            []string       `json:"contacts,omitempty"`
+	LogoURI                             string         `json:"logo_uri,omitempty"`
+	PolicyURI                           string         `json:"policy_uri,omitempty"`
+	InformationURI                      string         `json:"information_uri,omitempty"`
+	OrganizationName                    string         `json:"organization_name,omitempty"`
+	OrganizationURI                     string         `json:"organization_uri,omitempty"`
+}
+
+type openIDCredentialVerifierMetadataWithPtrs struct {
+	ClientName                          *string        `json:"client_name,omitempty"`
+	RedirectURIS                        []string       `json:"redirect_uris,omitempty"`
+	ResponseTypes                       []string       `json:"response_types,omitempty"`
+	RequestURIs                         []string       `json:"request_uris,omitempty"`
+	TOSURI                              *string        `json:"tos_uri,omitempty"`
+	VPFormatsSupported                  map[string]any `json:"vp_formats_supported,omitempty"`
+	EncryptedResponseEncValuesSupported []string       `json:"encrypted_response_enc_values_supported,omitempty"`
+	AuthorizationSignedResponseAlg      *string        `json:"authorization_signed_response_alg,omitempty"`
+	AuthorizationEncryptedResponseAlg   *string        `json:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc   *string        `json:"authorization_encrypted_response_enc,omitempty"`
+	ClientRegistrationTypes             []string       `json:"client_registration_types,omitempty"`
+	Extra                               map[string]any `json:"-"`
+	SignedJWKSURI                       *string        `json:"signed_jwks_uri,omitempty"`
+	JWKSURI                             *string        `json:"jwks_uri,omitempty"`
+	JWKS                                *jwks.JWKS     `json:"jwks,omitempty"`
+	DisplayName                         *string        `json:"display_name,omitempty"`
+	Description                         *string        `json:"description,omitempty"`
+	Keywords                            []string       `json:"keywords,omitempty"`
+	Contacts                            []string       `json:"contacts,omitempty"`
+	LogoURI                             *string        `json:"logo_uri,omitempty"`
+	PolicyURI                           *string        `json:"policy_uri,omitempty"`
+	InformationURI                      *string        `json:"information_uri,omitempty"`
+	OrganizationName                    *string        `json:"organization_name,omitempty"`
+	OrganizationURI                     *string        `json:"organization_uri,omitempty"`
 }
 
 // MarshalJSON implements the json.Marshaler interface
-func (m OAuthProtectedResourceMetadata) MarshalJSON() ([]byte, error) {
-	type Alias OAuthProtectedResourceMetadata
+func (m OpenIDCredentialVerifierMetadata) MarshalJSON() ([]byte, error) {
+	type Alias OpenIDCredentialVerifierMetadata
 	explicitFields, err := json.Marshal(Alias(m))
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -744,8 +3215,8 @@ func (m OAuthProtectedResourceMetadata) MarshalJSON() ([]byte, error) {
 }
 
 // MarshalJSON implements the json.Marshaler interface
-func (m oAuthProtectedResourceMetadataWithPtrs) MarshalJSON() ([]byte, error) {
-	type Alias oAuthProtectedResourceMetadataWithPtrs
+func (m openIDCredentialVerifierMetadataWithPtrs) MarshalJSON() ([]byte, error) {
+	type Alias openIDCredentialVerifierMetadataWithPtrs
 	explicitFields, err := json.Marshal(Alias(m))
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -753,7 +3224,7 @@ func (m oAuthProtectedResourceMetadataWithPtrs) MarshalJSON() ([]byte, error) {
 	return extraMarshalHelper(explicitFields, m.Extra)
 }
 
-func (m *OAuthProtectedResourceMetadata) fromPointers(withPtrs oAuthProtectedResourceMetadataWithPtrs) {
+func (m *OpenIDCredentialVerifierMetadata) fromPointers(withPtrs openIDCredentialVerifierMetadataWithPtrs) {
 
 	m.wasSet = make(map[string]bool)
 
@@ -787,8 +3258,8 @@ func (m *OAuthProtectedResourceMetadata) fromPointers(withPtrs oAuthProtectedRes
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface
-func (m *OAuthProtectedResourceMetadata) UnmarshalJSON(data []byte) error {
-	var withPtrs oAuthProtectedResourceMetadataWithPtrs
+func (m *OpenIDCredentialVerifierMetadata) UnmarshalJSON(data []byte) error {
+	var withPtrs openIDCredentialVerifierMetadataWithPtrs
 	if err := json.Unmarshal(data, &withPtrs); err != nil {
 		return err
 	}
@@ -797,8 +3268,8 @@ func (m *OAuthProtectedResourceMetadata) UnmarshalJSON(data []byte) error {
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface
-func (m *oAuthProtectedResourceMetadataWithPtrs) UnmarshalJSON(data []byte) error {
-	type Alias oAuthProtectedResourceMetadataWithPtrs
+func (m *openIDCredentialVerifierMetadataWithPtrs) UnmarshalJSON(data []byte) error {
+	type Alias openIDCredentialVerifierMetadataWithPtrs
 	mm := Alias(*m)
 
 	extra, err := unmarshalWithExtra(data, &mm)
@@ -806,25 +3277,25 @@ func (m *oAuthProtectedResourceMetadataWithPtrs) UnmarshalJSON(data []byte) erro
 		return errors.WithStack(err)
 	}
 	mm.Extra = extra
-	*m = oAuthProtectedResourceMetadataWithPtrs(mm)
+	*m = openIDCredentialVerifierMetadataWithPtrs(mm)
 	return nil
 }
 
 // UnmarshalMsgpack implements the msgpack.Unmarshaler interface
-func (m *oAuthProtectedResourceMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
-	type Alias oAuthProtectedResourceMetadataWithPtrs
+func (m *openIDCredentialVerifierMetadataWithPtrs) UnmarshalMsgpack(data []byte) error {
+	type Alias openIDCredentialVerifierMetadataWithPtrs
 	mm := Alias(*m)
 	err := msgpack.Unmarshal(data, &mm)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	*m = oAuthProtectedResourceMetadataWithPtrs(mm)
+	*m = openIDCredentialVerifierMetadataWithPtrs(mm)
 	return nil
 }
 
 // UnmarshalMsgpack implements the msgpack.Unmarshaler interface
-func (m *OAuthProtectedResourceMetadata) UnmarshalMsgpack(data []byte) error {
-	var withPtrs oAuthProtectedResourceMetadataWithPtrs
+func (m *OpenIDCredentialVerifierMetadata) UnmarshalMsgpack(data []byte) error {
+	var withPtrs openIDCredentialVerifierMetadataWithPtrs
 	if err := msgpack.Unmarshal(data, &withPtrs); err != nil {
 		return err
 	}
@@ -832,7 +3303,228 @@ func (m *OAuthProtectedResourceMetadata) UnmarshalMsgpack(data []byte) error {
 	return nil
 }
 
-// ApplyPolicy applies a MetadataPolicy to the OAuthProtectedResourceMetadata
-func (m OAuthProtectedResourceMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
-	return applyPolicy(&m, policy, "oauth_resource")
+// ApplyPolicy applies a MetadataPolicy to the OpenIDCredentialVerifierMetadata
+func (m OpenIDCredentialVerifierMetadata) ApplyPolicy(policy MetadataPolicy) (any, error) {
+	if err := m.applyPolicy(defaultPolicyOperatorRegistry, policy, "openid_credential_verifier", nil); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// applyPolicy applies a MetadataPolicy to the OpenIDCredentialVerifierMetadata using the PolicyOperator
+// of the passed policyOperatorRegistry; if explanation is not nil, all claims
+// and applied policy operators are recorded in it
+func (m *OpenIDCredentialVerifierMetadata) applyPolicy(
+	r *policyOperatorRegistry, policy MetadataPolicy, ownTag string, explanation EntityTypeExplanation,
+) error {
+	if policy == nil {
+		return nil
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_name", &m.ClientName,
+		m.wasSet["ClientName"], m.ClientName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "redirect_uris", &m.RedirectURIS,
+		m.wasSet["RedirectURIS"], m.RedirectURIS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "response_types", &m.ResponseTypes,
+		m.wasSet["ResponseTypes"], m.ResponseTypes != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "request_uris", &m.RequestURIs,
+		m.wasSet["RequestURIs"], m.RequestURIs != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "tos_uri", &m.TOSURI,
+		m.wasSet["TOSURI"], m.TOSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "vp_formats_supported", &m.VPFormatsSupported,
+		m.wasSet["VPFormatsSupported"], m.VPFormatsSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "encrypted_response_enc_values_supported", &m.EncryptedResponseEncValuesSupported,
+		m.wasSet["EncryptedResponseEncValuesSupported"], m.EncryptedResponseEncValuesSupported != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_signed_response_alg", &m.AuthorizationSignedResponseAlg,
+		m.wasSet["AuthorizationSignedResponseAlg"], m.AuthorizationSignedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_encrypted_response_alg", &m.AuthorizationEncryptedResponseAlg,
+		m.wasSet["AuthorizationEncryptedResponseAlg"], m.AuthorizationEncryptedResponseAlg != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "authorization_encrypted_response_enc", &m.AuthorizationEncryptedResponseEnc,
+		m.wasSet["AuthorizationEncryptedResponseEnc"], m.AuthorizationEncryptedResponseEnc != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "client_registration_types", &m.ClientRegistrationTypes,
+		m.wasSet["ClientRegistrationTypes"], m.ClientRegistrationTypes != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "signed_jwks_uri", &m.SignedJWKSURI,
+		m.wasSet["SignedJWKSURI"], m.SignedJWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks_uri", &m.JWKSURI,
+		m.wasSet["JWKSURI"], m.JWKSURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "jwks", &m.JWKS,
+		m.wasSet["JWKS"], m.JWKS != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "display_name", &m.DisplayName,
+		m.wasSet["DisplayName"], m.DisplayName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "description", &m.Description,
+		m.wasSet["Description"], m.Description != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "keywords", &m.Keywords,
+		m.wasSet["Keywords"], m.Keywords != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "contacts", &m.Contacts,
+		m.wasSet["Contacts"], m.Contacts != nil, explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "logo_uri", &m.LogoURI,
+		m.wasSet["LogoURI"], m.LogoURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "policy_uri", &m.PolicyURI,
+		m.wasSet["PolicyURI"], m.PolicyURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "information_uri", &m.InformationURI,
+		m.wasSet["InformationURI"], m.InformationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_name", &m.OrganizationName,
+		m.wasSet["OrganizationName"], m.OrganizationName != "", explanation,
+	); err != nil {
+		return err
+	}
+	if err := applyClaimPolicy(
+		r, policy, ownTag, "organization_uri", &m.OrganizationURI,
+		m.wasSet["OrganizationURI"], m.OrganizationURI != "", explanation,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringClaim returns the value of the string claim with the passed name; the
+// second return value indicates if OpenIDCredentialVerifierMetadata has such a claim
+func (m OpenIDCredentialVerifierMetadata) stringClaim(claim string) (string, bool) {
+	switch claim {
+	case "client_name":
+		return m.ClientName, true
+	case "tos_uri":
+		return m.TOSURI, true
+	case "authorization_signed_response_alg":
+		return m.AuthorizationSignedResponseAlg, true
+	case "authorization_encrypted_response_alg":
+		return m.AuthorizationEncryptedResponseAlg, true
+	case "authorization_encrypted_response_enc":
+		return m.AuthorizationEncryptedResponseEnc, true
+	case "signed_jwks_uri":
+		return m.SignedJWKSURI, true
+	case "jwks_uri":
+		return m.JWKSURI, true
+	case "display_name":
+		return m.DisplayName, true
+	case "description":
+		return m.Description, true
+	case "logo_uri":
+		return m.LogoURI, true
+	case "policy_uri":
+		return m.PolicyURI, true
+	case "information_uri":
+		return m.InformationURI, true
+	case "organization_name":
+		return m.OrganizationName, true
+	case "organization_uri":
+		return m.OrganizationURI, true
+	}
+	var zero string
+	return zero, false
+}
+
+// stringSliceClaim returns the value of the []string claim with the passed name; the
+// second return value indicates if OpenIDCredentialVerifierMetadata has such a claim
+func (m OpenIDCredentialVerifierMetadata) stringSliceClaim(claim string) ([]string, bool) {
+	switch claim {
+	case "redirect_uris":
+		return m.RedirectURIS, true
+	case "response_types":
+		return m.ResponseTypes, true
+	case "request_uris":
+		return m.RequestURIs, true
+	case "encrypted_response_enc_values_supported":
+		return m.EncryptedResponseEncValuesSupported, true
+	case "client_registration_types":
+		return m.ClientRegistrationTypes, true
+	case "keywords":
+		return m.Keywords, true
+	case "contacts":
+		return m.Contacts, true
+	}
+	var zero []string
+	return zero, false
+}
+
+// LanguageTaggedClaim returns all language variants of the string claim with
+// the passed name, i.e. the untagged claim and the language-tagged members
+// in Extra; see LanguageTaggedString
+func (m OpenIDCredentialVerifierMetadata) LanguageTaggedClaim(claim string) LanguageTaggedString {
+	untagged, _ := m.stringClaim(claim)
+	return languageTaggedClaim(claim, untagged, m.Extra)
 }
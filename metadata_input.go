@@ -5,6 +5,7 @@ import (
 )
 
 //go:generate go run internal/generators/metadata.go
+//go:generate go run ./internal/generators/jsonschema -out jsonschema/schemas
 
 type commonMetadata struct {
 	SignedJWKSURI string     `json:"signed_jwks_uri,omitempty"`
@@ -187,3 +188,46 @@ type federationEntityMetadata struct {
 
 	Extra map[string]any `json:"-"`
 }
+
+type openIDCredentialIssuerMetadata struct {
+	CredentialIssuer                  string           `json:"credential_issuer"`
+	AuthorizationServers              []string         `json:"authorization_servers,omitempty"`
+	CredentialEndpoint                string           `json:"credential_endpoint"`
+	NonceEndpoint                     string           `json:"nonce_endpoint,omitempty"`
+	DeferredCredentialEndpoint        string           `json:"deferred_credential_endpoint,omitempty"`
+	NotificationEndpoint              string           `json:"notification_endpoint,omitempty"`
+	CredentialRequestEncryption       map[string]any   `json:"credential_request_encryption,omitempty"`
+	CredentialResponseEncryption      map[string]any   `json:"credential_response_encryption,omitempty"`
+	BatchCredentialIssuance           map[string]any   `json:"batch_credential_issuance,omitempty"`
+	SignedMetadata                    string           `json:"signed_metadata,omitempty"`
+	Display                           []map[string]any `json:"display,omitempty"`
+	CredentialConfigurationsSupported map[string]any   `json:"credential_configurations_supported"`
+
+	Extra map[string]any `json:"-"`
+}
+
+type openIDWalletProviderMetadata struct {
+	TokenEndpoint                              string   `json:"token_endpoint,omitempty"`
+	GrantTypesSupported                        []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	AALValuesSupported                         []string `json:"aal_values_supported,omitempty"`
+
+	Extra map[string]any `json:"-"`
+}
+
+type openIDCredentialVerifierMetadata struct {
+	ClientName                          string         `json:"client_name,omitempty"`
+	RedirectURIS                        []string       `json:"redirect_uris,omitempty"`
+	ResponseTypes                       []string       `json:"response_types,omitempty"`
+	RequestURIs                         []string       `json:"request_uris,omitempty"`
+	TOSURI                              string         `json:"tos_uri,omitempty"`
+	VPFormatsSupported                  map[string]any `json:"vp_formats_supported,omitempty"`
+	EncryptedResponseEncValuesSupported []string       `json:"encrypted_response_enc_values_supported,omitempty"`
+	AuthorizationSignedResponseAlg      string         `json:"authorization_signed_response_alg,omitempty"`
+	AuthorizationEncryptedResponseAlg   string         `json:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc   string         `json:"authorization_encrypted_response_enc,omitempty"`
+	ClientRegistrationTypes             []string       `json:"client_registration_types,omitempty"`
+
+	Extra map[string]any `json:"-"`
+}
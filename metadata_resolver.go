@@ -28,7 +28,14 @@ var DefaultMetadataResolver MetadataResolver = LocalMetadataResolver{}
 // LocalMetadataResolver is a MetadataResolver that resolves trust chains and
 // evaluates metadata policies to obtain the final Metadata; it does not use
 // a resolve endpoint
-type LocalMetadataResolver struct{}
+type LocalMetadataResolver struct {
+	// PolicyEngine is used to apply the metadata policies;
+	// if not set, the DefaultPolicyEngine is used
+	PolicyEngine *PolicyEngine
+	// ValidateMetadata indicates that the resolved Metadata must pass
+	// Metadata.Validate
+	ValidateMetadata bool
+}
 
 // Resolve implements the MetadataResolver interface
 func (r LocalMetadataResolver) Resolve(req apimodel.ResolveRequest) (*Metadata, error) {
@@ -39,15 +46,17 @@ func (r LocalMetadataResolver) Resolve(req apimodel.ResolveRequest) (*Metadata,
 	return res.Metadata, nil
 }
 
-func (LocalMetadataResolver) resolveResponsePayloadWithoutTrustMarks(
+func (r LocalMetadataResolver) resolveResponsePayloadWithoutTrustMarks(
 	req apimodel.ResolveRequest,
 ) (
 	res ResolveResponsePayload, chain TrustChain, err error,
 ) {
 	tr := TrustResolver{
-		TrustAnchors:   NewTrustAnchorsFromEntityIDs(req.TrustAnchor...),
-		StartingEntity: req.Subject,
-		Types:          req.EntityTypes,
+		TrustAnchors:     NewTrustAnchorsFromEntityIDs(req.TrustAnchor...),
+		StartingEntity:   req.Subject,
+		Types:            req.EntityTypes,
+		PolicyEngine:     r.PolicyEngine,
+		ValidateMetadata: r.ValidateMetadata,
 	}
 	chains := tr.ResolveToValidChains()
 	if len(chains) == 0 {
@@ -56,7 +65,10 @@ func (LocalMetadataResolver) resolveResponsePayloadWithoutTrustMarks(
 	}
 	chains = chains.SortAsc(TrustChainScoringPathLen)
 	for _, chain = range chains {
-		m, err := chain.Metadata()
+		m, err := r.PolicyEngine.TrustChainMetadata(chain)
+		if err == nil && r.ValidateMetadata {
+			err = m.Validate()
+		}
 		if err == nil {
 			res.TrustChain = chain.Messages()
 			res.Metadata = m
@@ -81,11 +93,13 @@ func (r LocalMetadataResolver) ResolveResponsePayload(req apimodel.ResolveReques
 }
 
 // ResolvePossible implements the MetadataResolver interface
-func (LocalMetadataResolver) ResolvePossible(req apimodel.ResolveRequest) (bool, bool) {
+func (r LocalMetadataResolver) ResolvePossible(req apimodel.ResolveRequest) (bool, bool) {
 	tr := TrustResolver{
-		TrustAnchors:   NewTrustAnchorsFromEntityIDs(req.TrustAnchor...),
-		StartingEntity: req.Subject,
-		Types:          req.EntityTypes,
+		TrustAnchors:     NewTrustAnchorsFromEntityIDs(req.TrustAnchor...),
+		StartingEntity:   req.Subject,
+		Types:            req.EntityTypes,
+		PolicyEngine:     r.PolicyEngine,
+		ValidateMetadata: r.ValidateMetadata,
 	}
 	chains := tr.ResolveToValidChains()
 	valid := len(chains) > 0
@@ -380,3 +380,263 @@ func TestMetadata_FindEntityMetadata(t *testing.T) {
 		)
 	}
 }
+
+func TestMetadata_OpenID4VC(t *testing.T) {
+	data := []byte(`{
+		"openid_credential_issuer": {
+			"credential_issuer": "https://issuer.example.org",
+			"credential_endpoint": "https://issuer.example.org/credential",
+			"display": [{"name": "Example Issuer", "locale": "en-US"}],
+			"credential_configurations_supported": {
+				"UniversityDegree": {"format": "dc+sd-jwt"},
+				"Diploma": {"format": "dc+sd-jwt"}
+			}
+		},
+		"openid_wallet_provider": {
+			"organization_name": "Example Wallet Inc.",
+			"token_endpoint": "https://wallet.example.org/token"
+		},
+		"openid_credential_verifier": {
+			"client_name": "Example Verifier",
+			"vp_formats_supported": {"dc+sd-jwt": {}}
+		}
+	}`)
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.OpenIDCredentialIssuer == nil || metadata.OpenIDWalletProvider == nil ||
+		metadata.OpenIDCredentialVerifier == nil {
+		t.Fatalf("OpenID4VC metadata not unmarshalled: %+v", metadata)
+	}
+	if metadata.Extra != nil {
+		t.Errorf("OpenID4VC metadata must not end up in extra: %v", metadata.Extra)
+	}
+
+	expectedTypes := []string{"openid_credential_issuer", "openid_wallet_provider", "openid_credential_verifier"}
+	if types := metadata.GuessEntityTypes(); !reflect.DeepEqual(types, expectedTypes) {
+		t.Errorf("unexpected entity types: %v", types)
+	}
+	expectedNames := map[string]string{
+		"openid_credential_issuer":   "Example Issuer",
+		"openid_wallet_provider":     "Example Wallet Inc.",
+		"openid_credential_verifier": "Example Verifier",
+	}
+	if names := metadata.GuessDisplayNames(); !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("unexpected display names: %v", names)
+	}
+	if err := metadata.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	policies := &MetadataPolicies{
+		OpenIDCredentialIssuer: MetadataPolicy{
+			"credential_configurations_supported": MetadataPolicyEntry{
+				"default": map[string]any{"UniversityDegree": map[string]any{"format": "dc+sd-jwt"}},
+			},
+			"authorization_servers": MetadataPolicyEntry{
+				"value": []string{"https://as.example.org"},
+			},
+		},
+		OpenIDCredentialVerifier: MetadataPolicy{
+			"tos_uri": MetadataPolicyEntry{"default": "https://federation.example.org/tos"},
+		},
+	}
+	merged, err := MergeMetadataPolicies(policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := metadata.ApplyPolicy(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if as := applied.OpenIDCredentialIssuer.AuthorizationServers; !reflect.DeepEqual(as, []string{"https://as.example.org"}) {
+		t.Errorf("unexpected authorization_servers: %v", as)
+	}
+	if n := len(applied.OpenIDCredentialIssuer.CredentialConfigurationsSupported); n != 2 {
+		t.Errorf("default must not replace the set credential configurations, but got %d", n)
+	}
+	if tos := applied.OpenIDCredentialVerifier.TOSURI; tos != "https://federation.example.org/tos" {
+		t.Errorf("unexpected tos_uri: %s", tos)
+	}
+
+	if _, err = MergeMetadataPolicies(
+		&MetadataPolicies{
+			OpenIDWalletProvider: MetadataPolicy{
+				"token_endpoint": MetadataPolicyEntry{"subset_of": []string{"https://wallet.example.org/token"}},
+			},
+		},
+	); err == nil {
+		t.Errorf("expected type error for subset_of on a string claim")
+	}
+}
+
+func newBenchmarkMetadata(b *testing.B) Metadata {
+	b.Helper()
+	data := []byte(`{
+		"openid_provider": {
+			"issuer": "https://op.example.org",
+			"authorization_endpoint": "https://op.example.org/authorize",
+			"token_endpoint": "https://op.example.org/token",
+			"display_name": "Example OP",
+			"description": "An example OP",
+			"logo_uri": "https://op.example.org/logo.png",
+			"keywords": ["example", "op"],
+			"scopes_supported": ["openid", "profile", "email", "address"],
+			"response_types_supported": ["code"],
+			"grant_types_supported": ["authorization_code", "refresh_token"],
+			"subject_types_supported": ["public", "pairwise"],
+			"client_registration_types_supported": ["automatic", "explicit"]
+		},
+		"openid_relying_party": {
+			"client_name": "Example RP",
+			"scope": "openid profile email",
+			"redirect_uris": ["https://rp.example.org/callback"],
+			"response_types": ["code"],
+			"grant_types": ["authorization_code"],
+			"client_registration_types": ["automatic"]
+		},
+		"federation_entity": {
+			"organization_name": "Example Organization",
+			"policy_uri": "https://example.org/policy",
+			"federation_fetch_endpoint": "https://example.org/fetch"
+		}
+	}`)
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		b.Fatal(err)
+	}
+	return m
+}
+
+func BenchmarkMetadata_ApplyPolicy(b *testing.B) {
+	m := newBenchmarkMetadata(b)
+	policies := &MetadataPolicies{
+		OpenIDProvider: MetadataPolicy{
+			"scopes_supported":        {"subset_of": []string{"openid", "profile", "email"}},
+			"subject_types_supported": {"value": []string{"public"}},
+			"contacts":                {"add": []string{"ops@example.org"}},
+		},
+		RelyingParty: MetadataPolicy{
+			"scope":       {"subset_of": []string{"openid", "email"}},
+			"grant_types": {"subset_of": []string{"authorization_code", "refresh_token"}},
+		},
+		FederationEntity: MetadataPolicy{
+			"organization_uri": {"default": "https://example.org"},
+		},
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.ApplyPolicy(policies); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMetadata_Discovery simulates the metadata handling of an entity
+// collection, i.e. the claims that are collected for each entity
+func BenchmarkMetadata_Discovery(b *testing.B) {
+	const entities = 1000
+	m := newBenchmarkMetadata(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for e := 0; e < entities; e++ {
+			_ = m.GuessEntityTypes()
+			_ = m.GuessDisplayNames()
+			for _, claim := range []string{"description", "logo_uri", "policy_uri", "information_uri"} {
+				m.IterateStringClaim(claim, func(_, _ string) {})
+			}
+			m.IterateStringSliceClaim("keywords", func(_ string, _ []string) {})
+		}
+	}
+}
+
+func TestMetadata_IterateClaims(t *testing.T) {
+	m := Metadata{
+		OpenIDProvider: &OpenIDProviderMetadata{
+			Issuer:      "https://op.example.org",
+			Description: "An example OP",
+			Keywords:    []string{"example", "op"},
+		},
+		OAuthClient: &OAuthClientMetadata{
+			ClientName: "Example Client",
+			Keywords:   []string{"client"},
+		},
+		FederationEntity: &FederationEntityMetadata{
+			OrganizationName: "Example Organization",
+		},
+	}
+
+	stringClaims := make(map[string]string)
+	m.IterateStringClaim(
+		"description", func(entityType, value string) {
+			stringClaims[entityType] = value
+		},
+	)
+	if !reflect.DeepEqual(stringClaims, map[string]string{"openid_provider": "An example OP"}) {
+		t.Errorf("unexpected description claims: %v", stringClaims)
+	}
+
+	sliceClaims := make(map[string][]string)
+	m.IterateStringSliceClaim(
+		"keywords", func(entityType string, value []string) {
+			sliceClaims[entityType] = value
+		},
+	)
+	expectedSlices := map[string][]string{
+		"openid_provider": {"example", "op"},
+		"oauth_client":    {"client"},
+	}
+	if !reflect.DeepEqual(sliceClaims, expectedSlices) {
+		t.Errorf("unexpected keywords claims: %v", sliceClaims)
+	}
+
+	m.IterateStringClaim(
+		"keywords", func(entityType, _ string) {
+			t.Errorf("keywords is not a string claim, but got it for '%s'", entityType)
+		},
+	)
+
+	expectedNames := map[string]string{
+		"openid_provider":   "",
+		"oauth_client":      "Example Client",
+		"federation_entity": "Example Organization",
+	}
+	if names := m.GuessDisplayNames(); !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("unexpected display names: %v", names)
+	}
+}
+
+func TestMetadata_ApplyPolicy_Extra(t *testing.T) {
+	m := Metadata{
+		Extra: map[string]any{
+			"custom_entity": map[string]any{
+				"scope":  "openid profile email",
+				"remove": "me",
+			},
+		},
+	}
+	applied, err := m.ApplyPolicy(
+		&MetadataPolicies{
+			Extra: map[string]MetadataPolicy{
+				"custom_entity": {
+					"remove":  {"value": nil},
+					"contact": {"default": "ops@example.org"},
+				},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"scope":   "openid profile email",
+		"contact": "ops@example.org",
+	}
+	if !reflect.DeepEqual(applied.Extra["custom_entity"], expected) {
+		t.Errorf("unexpected custom metadata: %v", applied.Extra["custom_entity"])
+	}
+	if _, ok := m.Extra["custom_entity"].(map[string]any)["remove"]; !ok {
+		t.Errorf("the original metadata must not be modified")
+	}
+}
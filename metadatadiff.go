@@ -0,0 +1,280 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DiffChangeType describes how a value changed between two versions
+type DiffChangeType string
+
+// Constants for DiffChangeType
+const (
+	DiffChangeAdded   DiffChangeType = "added"
+	DiffChangeRemoved DiffChangeType = "removed"
+	DiffChangeChanged DiffChangeType = "changed"
+)
+
+// MetadataPolicyChange is a change of a single policy operator of a claim
+// between two MetadataPolicies
+type MetadataPolicyChange struct {
+	Type       DiffChangeType     `json:"type"`
+	EntityType string             `json:"entity_type"`
+	Claim      string             `json:"claim"`
+	Operator   PolicyOperatorName `json:"operator"`
+	Old        any                `json:"old,omitempty"`
+	New        any                `json:"new,omitempty"`
+	// AddedValues and RemovedValues are only set for changed operators whose
+	// old and updated values are both arrays, e.g. 'subset_of'
+	AddedValues   []any `json:"added_values,omitempty"`
+	RemovedValues []any `json:"removed_values,omitempty"`
+}
+
+// String implements the fmt.Stringer interface
+func (c MetadataPolicyChange) String() string {
+	return formatDiffChange(
+		c.Type, fmt.Sprintf("%s.%s.%s", c.EntityType, c.Claim, c.Operator), c.Old, c.New, c.AddedValues,
+		c.RemovedValues,
+	)
+}
+
+// MetadataPolicyDiff is the list of changes between two MetadataPolicies,
+// ordered by entity type, claim, and operator
+type MetadataPolicyDiff []MetadataPolicyChange
+
+// String implements the fmt.Stringer interface; each change is on its own
+// line
+func (d MetadataPolicyDiff) String() string {
+	return joinStringers(d)
+}
+
+// MetadataChange is a change of a single claim between two Metadata
+type MetadataChange struct {
+	Type       DiffChangeType `json:"type"`
+	EntityType string         `json:"entity_type"`
+	Claim      string         `json:"claim"`
+	Old        any            `json:"old,omitempty"`
+	New        any            `json:"new,omitempty"`
+	// AddedValues and RemovedValues are only set for changed claims whose
+	// old and updated values are both arrays, e.g. 'grant_types'
+	AddedValues   []any `json:"added_values,omitempty"`
+	RemovedValues []any `json:"removed_values,omitempty"`
+}
+
+// String implements the fmt.Stringer interface
+func (c MetadataChange) String() string {
+	return formatDiffChange(
+		c.Type, fmt.Sprintf("%s.%s", c.EntityType, c.Claim), c.Old, c.New, c.AddedValues, c.RemovedValues,
+	)
+}
+
+// MetadataDiff is the list of changes between two Metadata, ordered by
+// entity type and claim
+type MetadataDiff []MetadataChange
+
+// String implements the fmt.Stringer interface; each change is on its own
+// line
+func (d MetadataDiff) String() string {
+	return joinStringers(d)
+}
+
+// DiffMetadataPolicies compares two MetadataPolicies and returns the
+// operator-level changes for each claim. Values are compared by their json
+// representation. Either of the MetadataPolicies might be nil.
+func DiffMetadataPolicies(old, updated *MetadataPolicies) (MetadataPolicyDiff, error) {
+	oldV, err := diffJSONObject(old)
+	if err != nil {
+		return nil, err
+	}
+	newV, err := diffJSONObject(updated)
+	if err != nil {
+		return nil, err
+	}
+	diff := MetadataPolicyDiff{}
+	for _, entityType := range sortedKeys(unionKeys(oldV, newV)) {
+		oldPolicy, _ := oldV[entityType].(map[string]any)
+		newPolicy, _ := newV[entityType].(map[string]any)
+		for _, claim := range sortedKeys(unionKeys(oldPolicy, newPolicy)) {
+			oldEntry, _ := oldPolicy[claim].(map[string]any)
+			newEntry, _ := newPolicy[claim].(map[string]any)
+			for _, op := range sortedKeys(unionKeys(oldEntry, newEntry)) {
+				t, added, removed, changed := diffValues(oldEntry, newEntry, op)
+				if !changed {
+					continue
+				}
+				diff = append(
+					diff, MetadataPolicyChange{
+						Type:          t,
+						EntityType:    entityType,
+						Claim:         claim,
+						Operator:      PolicyOperatorName(op),
+						Old:           oldEntry[op],
+						New:           newEntry[op],
+						AddedValues:   added,
+						RemovedValues: removed,
+					},
+				)
+			}
+		}
+	}
+	return diff, nil
+}
+
+// DiffMetadata compares two Metadata and returns the claim-level changes for
+// each entity type. Values are compared by their json representation.
+// Either of the Metadata might be nil.
+func DiffMetadata(old, updated *Metadata) (MetadataDiff, error) {
+	oldV, err := diffJSONObject(old)
+	if err != nil {
+		return nil, err
+	}
+	newV, err := diffJSONObject(updated)
+	if err != nil {
+		return nil, err
+	}
+	diff := MetadataDiff{}
+	for _, entityType := range sortedKeys(unionKeys(oldV, newV)) {
+		oldMetadata := withoutNullValues(oldV[entityType])
+		newMetadata := withoutNullValues(newV[entityType])
+		for _, claim := range sortedKeys(unionKeys(oldMetadata, newMetadata)) {
+			t, added, removed, changed := diffValues(oldMetadata, newMetadata, claim)
+			if !changed {
+				continue
+			}
+			diff = append(
+				diff, MetadataChange{
+					Type:          t,
+					EntityType:    entityType,
+					Claim:         claim,
+					Old:           oldMetadata[claim],
+					New:           newMetadata[claim],
+					AddedValues:   added,
+					RemovedValues: removed,
+				},
+			)
+		}
+	}
+	return diff, nil
+}
+
+// diffValues compares the value for key in the old and updated map; arrays
+// are compared as sets, i.e. a different order is not a change
+func diffValues(old, updated map[string]any, key string) (
+	t DiffChangeType, added, removed []any, changed bool,
+) {
+	oldValue, inOld := old[key]
+	newValue, inNew := updated[key]
+	switch {
+	case !inOld:
+		return DiffChangeAdded, nil, nil, true
+	case !inNew:
+		return DiffChangeRemoved, nil, nil, true
+	case reflect.DeepEqual(oldValue, newValue):
+		return "", nil, nil, false
+	}
+	oldSlice, oldIsSlice := oldValue.([]any)
+	newSlice, newIsSlice := newValue.([]any)
+	if oldIsSlice && newIsSlice {
+		added = subtractValues(newSlice, oldSlice)
+		removed = subtractValues(oldSlice, newSlice)
+		if len(added) == 0 && len(removed) == 0 {
+			return "", nil, nil, false
+		}
+	}
+	return DiffChangeChanged, added, removed, true
+}
+
+// subtractValues returns the values of a that are not in b
+func subtractValues(a, b []any) (out []any) {
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if reflect.DeepEqual(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, v)
+		}
+	}
+	return
+}
+
+// diffJSONObject returns the json representation of v as a map
+func diffJSONObject(v any) (map[string]any, error) {
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var out map[string]any
+	if err = json.Unmarshal(data, &out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
+
+// withoutNullValues returns the json object v without null values, since in
+// metadata a null claim is the same as an unset claim
+func withoutNullValues(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	for k, vv := range m {
+		if vv == nil {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+func unionKeys(a, b map[string]any) map[string]any {
+	out := make(map[string]any, len(a)+len(b))
+	for k := range a {
+		out[k] = nil
+	}
+	for k := range b {
+		out[k] = nil
+	}
+	return out
+}
+
+func formatDiffChange(t DiffChangeType, path string, old, updated any, added, removed []any) string {
+	var s string
+	switch t {
+	case DiffChangeAdded:
+		s = fmt.Sprintf("%s %s: %s", t, path, formatDiffValue(updated))
+	case DiffChangeRemoved:
+		s = fmt.Sprintf("%s %s: %s", t, path, formatDiffValue(old))
+	default:
+		s = fmt.Sprintf("%s %s: %s -> %s", t, path, formatDiffValue(old), formatDiffValue(updated))
+	}
+	if len(added) > 0 {
+		s += fmt.Sprintf(" (added: %s)", formatDiffValue(added))
+	}
+	if len(removed) > 0 {
+		s += fmt.Sprintf(" (removed: %s)", formatDiffValue(removed))
+	}
+	return s
+}
+
+func formatDiffValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func joinStringers[S fmt.Stringer](s []S) string {
+	lines := make([]string, len(s))
+	for i, ss := range s {
+		lines[i] = ss.String()
+	}
+	return strings.Join(lines, "\n")
+}
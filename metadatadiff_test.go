@@ -0,0 +1,170 @@
+package oidfed
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffMetadataPolicies(t *testing.T) {
+	old := &MetadataPolicies{
+		RelyingParty: MetadataPolicy{
+			"grant_types": {
+				PolicyOperatorSubsetOf: []string{"authorization_code", "refresh_token", "implicit"},
+			},
+			"client_name": {PolicyOperatorEssential: true},
+			"logo_uri":    {PolicyOperatorDefault: "https://ta.example.org/logo.png"},
+		},
+		FederationEntity: MetadataPolicy{
+			"contacts": {PolicyOperatorAdd: []string{"ta@example.org"}},
+			"policy_uri": {
+				PolicyOperatorOneOf: []string{"https://a.example.org/policy", "https://b.example.org/policy"},
+			},
+		},
+	}
+	updated := &MetadataPolicies{
+		RelyingParty: MetadataPolicy{
+			"grant_types": {
+				PolicyOperatorSubsetOf:  []string{"authorization_code", "refresh_token"},
+				PolicyOperatorEssential: true,
+			},
+			"client_name": {PolicyOperatorEssential: true},
+		},
+		OpenIDProvider: MetadataPolicy{
+			"id_token_signing_alg_values_supported": {PolicyOperatorSubsetOf: []string{"ES256"}},
+		},
+		FederationEntity: MetadataPolicy{
+			"contacts": {PolicyOperatorAdd: []any{"ta@example.org"}},
+			// only the order differs
+			"policy_uri": {
+				PolicyOperatorOneOf: []string{"https://b.example.org/policy", "https://a.example.org/policy"},
+			},
+		},
+	}
+
+	diff, err := DiffMetadataPolicies(old, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := MetadataPolicyDiff{
+		{
+			Type:       DiffChangeAdded,
+			EntityType: "openid_provider",
+			Claim:      "id_token_signing_alg_values_supported",
+			Operator:   PolicyOperatorSubsetOf,
+			New:        []any{"ES256"},
+		},
+		{
+			Type:       DiffChangeAdded,
+			EntityType: "openid_relying_party",
+			Claim:      "grant_types",
+			Operator:   PolicyOperatorEssential,
+			New:        true,
+		},
+		{
+			Type:          DiffChangeChanged,
+			EntityType:    "openid_relying_party",
+			Claim:         "grant_types",
+			Operator:      PolicyOperatorSubsetOf,
+			Old:           []any{"authorization_code", "refresh_token", "implicit"},
+			New:           []any{"authorization_code", "refresh_token"},
+			RemovedValues: []any{"implicit"},
+		},
+		{
+			Type:       DiffChangeRemoved,
+			EntityType: "openid_relying_party",
+			Claim:      "logo_uri",
+			Operator:   PolicyOperatorDefault,
+			Old:        "https://ta.example.org/logo.png",
+		},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+	expectedString := `changed openid_relying_party.grant_types.subset_of: ` +
+		`["authorization_code","refresh_token","implicit"] -> ["authorization_code","refresh_token"] ` +
+		`(removed: ["implicit"])`
+	if s := diff[2].String(); s != expectedString {
+		t.Errorf("unexpected string '%s'", s)
+	}
+
+	diff, err = DiffMetadataPolicies(old, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected no changes, but got:\n%s", diff)
+	}
+	diff, err = DiffMetadataPolicies(nil, &MetadataPolicies{FederationEntity: old.FederationEntity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 2 || diff[0].Type != DiffChangeAdded {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestDiffMetadata(t *testing.T) {
+	old := &Metadata{
+		RelyingParty: &OpenIDRelyingPartyMetadata{
+			ClientName:    "rp",
+			GrantTypes:    []string{"authorization_code", "refresh_token"},
+			ResponseTypes: []string{"code", "id_token"},
+		},
+		Extra: map[string]any{"custom_entity": map[string]any{"claim": "value"}},
+	}
+	updated := &Metadata{
+		RelyingParty: &OpenIDRelyingPartyMetadata{
+			ClientName: "rp",
+			GrantTypes: []string{"authorization_code", "urn:ietf:params:oauth:grant-type:device_code"},
+			LogoURI:    "https://rp.example.org/logo.png",
+			// only the order differs
+			ResponseTypes: []string{"id_token", "code"},
+		},
+		FederationEntity: &FederationEntityMetadata{OrganizationName: "Org"},
+	}
+
+	diff, err := DiffMetadata(old, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := MetadataDiff{
+		{
+			Type:       DiffChangeRemoved,
+			EntityType: "custom_entity",
+			Claim:      "claim",
+			Old:        "value",
+		},
+		{
+			Type:       DiffChangeAdded,
+			EntityType: "federation_entity",
+			Claim:      "organization_name",
+			New:        "Org",
+		},
+		{
+			Type:          DiffChangeChanged,
+			EntityType:    "openid_relying_party",
+			Claim:         "grant_types",
+			Old:           []any{"authorization_code", "refresh_token"},
+			New:           []any{"authorization_code", "urn:ietf:params:oauth:grant-type:device_code"},
+			AddedValues:   []any{"urn:ietf:params:oauth:grant-type:device_code"},
+			RemovedValues: []any{"refresh_token"},
+		},
+		{
+			Type:       DiffChangeAdded,
+			EntityType: "openid_relying_party",
+			Claim:      "logo_uri",
+			New:        "https://rp.example.org/logo.png",
+		},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+
+	diff, err = DiffMetadata(old, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 4 {
+		t.Errorf("expected 4 removed claims, but got:\n%s", diff)
+	}
+}
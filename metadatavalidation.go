@@ -0,0 +1,441 @@
+package oidfed
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/lionick/oidfed-lib/internal/utils"
+	"github.com/lionick/oidfed-lib/jwks"
+)
+
+// MetadataValidationError is a single problem found when validating Metadata
+type MetadataValidationError struct {
+	EntityType string
+	Claim      string
+	Message    string
+}
+
+// Error implements the error interface
+func (e MetadataValidationError) Error() string {
+	if e.Claim == "" {
+		return fmt.Sprintf("%s: %s", e.EntityType, e.Message)
+	}
+	return fmt.Sprintf("%s.%s: %s", e.EntityType, e.Claim, e.Message)
+}
+
+// MetadataValidationErrors holds all problems found when validating Metadata
+type MetadataValidationErrors []MetadataValidationError
+
+// Error implements the error interface
+func (e MetadataValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, ee := range e {
+		msgs[i] = ee.Error()
+	}
+	return fmt.Sprintf("invalid metadata: %s", strings.Join(msgs, "; "))
+}
+
+// MetadataValidator validates the metadata of a single entity type.
+// metadata is the pointer to the entity type's metadata struct, e.g.
+// *OpenIDProviderMetadata, or for entity types in Metadata.Extra the value
+// from Extra.
+// The EntityType of the returned MetadataValidationError can be left empty,
+// it is set by Metadata.Validate.
+type MetadataValidator func(metadata any) []MetadataValidationError
+
+var metadataValidators = struct {
+	sync.RWMutex
+	validators map[string][]MetadataValidator
+}{
+	validators: map[string][]MetadataValidator{
+		"openid_provider":            {validateOpenIDProviderMetadata},
+		"openid_relying_party":       {validateOpenIDRelyingPartyMetadata},
+		"oauth_authorization_server": {validateOAuthAuthorizationServerMetadata},
+		"oauth_client":               {validateOAuthClientMetadata},
+		"oauth_resource":             {validateOAuthProtectedResourceMetadata},
+		"federation_entity":          {validateFederationEntityMetadata},
+		"openid_credential_issuer":   {validateOpenIDCredentialIssuerMetadata},
+		"openid_wallet_provider":     {validateOpenIDWalletProviderMetadata},
+		"openid_credential_verifier": {validateOpenIDCredentialVerifierMetadata},
+	},
+}
+
+// RegisterMetadataValidator registers an additional MetadataValidator for an
+// entity type; it can be used to add rules for the entity types defined in
+// this package as well as for custom entity types in Metadata.Extra
+func RegisterMetadataValidator(entityType string, validator MetadataValidator) {
+	metadataValidators.Lock()
+	defer metadataValidators.Unlock()
+	metadataValidators.validators[entityType] = append(metadataValidators.validators[entityType], validator)
+}
+
+// Validate validates the Metadata for each entity type against the rules of
+// OpenID Connect Discovery, RFC 8414, RFC 7591, OpenID Federation, and
+// OpenID for Verifiable Credentials, and all validators registered with RegisterMetadataValidator.
+// If problems are found, a MetadataValidationErrors is returned.
+func (m Metadata) Validate() error {
+	var errs MetadataValidationErrors
+	validate := func(entityType string, metadata any) {
+		metadataValidators.RLock()
+		validators := metadataValidators.validators[entityType]
+		metadataValidators.RUnlock()
+		for _, v := range validators {
+			for _, e := range v(metadata) {
+				if e.EntityType == "" {
+					e.EntityType = entityType
+				}
+				errs = append(errs, e)
+			}
+		}
+	}
+	if m.OpenIDProvider != nil {
+		validate("openid_provider", m.OpenIDProvider)
+	}
+	if m.RelyingParty != nil {
+		validate("openid_relying_party", m.RelyingParty)
+	}
+	if m.OAuthAuthorizationServer != nil {
+		validate("oauth_authorization_server", m.OAuthAuthorizationServer)
+	}
+	if m.OAuthClient != nil {
+		validate("oauth_client", m.OAuthClient)
+	}
+	if m.OAuthProtectedResource != nil {
+		validate("oauth_resource", m.OAuthProtectedResource)
+	}
+	if m.FederationEntity != nil {
+		validate("federation_entity", m.FederationEntity)
+	}
+	if m.OpenIDCredentialIssuer != nil {
+		validate("openid_credential_issuer", m.OpenIDCredentialIssuer)
+	}
+	if m.OpenIDWalletProvider != nil {
+		validate("openid_wallet_provider", m.OpenIDWalletProvider)
+	}
+	if m.OpenIDCredentialVerifier != nil {
+		validate("openid_credential_verifier", m.OpenIDCredentialVerifier)
+	}
+	for _, entityType := range sortedKeys(m.Extra) {
+		validate(entityType, m.Extra[entityType])
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// metadataValidation collects MetadataValidationError
+type metadataValidation []MetadataValidationError
+
+func (v *metadataValidation) add(claim, format string, args ...any) {
+	*v = append(*v, MetadataValidationError{Claim: claim, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *metadataValidation) required(claim string, set bool) {
+	if !set {
+		v.add(claim, "claim is required")
+	}
+}
+
+// url checks that a set value is an absolute url; if httpsOnly is set the
+// https scheme is required, fragments are never allowed
+func (v *metadataValidation) url(claim, value string, httpsOnly bool) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		v.add(claim, "'%s' is not an absolute url", value)
+		return
+	}
+	if httpsOnly && u.Scheme != "https" {
+		v.add(claim, "'%s' must use the https scheme", value)
+	}
+	if u.Fragment != "" {
+		v.add(claim, "'%s' must not contain a fragment", value)
+	}
+}
+
+// endpoints checks that all set endpoints are https urls
+func (v *metadataValidation) endpoints(endpoints map[string]string) {
+	for _, claim := range sortedKeys(endpoints) {
+		v.url(claim, endpoints[claim], true)
+	}
+}
+
+// issuer checks an issuer identifier, i.e. an https url without query and
+// fragment
+func (v *metadataValidation) issuer(claim, value string) {
+	if value == "" {
+		return
+	}
+	v.url(claim, value, true)
+	if u, err := url.Parse(value); err == nil && u.RawQuery != "" {
+		v.add(claim, "'%s' must not contain a query", value)
+	}
+}
+
+// keys checks the claims used to publish keys; jwks, jwks_uri, and
+// signed_jwks_uri must not be used together
+func (v *metadataValidation) keys(jwksURI, signedJWKSURI string, keys *jwks.JWKS) {
+	v.url("jwks_uri", jwksURI, true)
+	v.url("signed_jwks_uri", signedJWKSURI, true)
+	var used []string
+	if keys != nil && keys.Set != nil {
+		used = append(used, "jwks")
+	}
+	if jwksURI != "" {
+		used = append(used, "jwks_uri")
+	}
+	if signedJWKSURI != "" {
+		used = append(used, "signed_jwks_uri")
+	}
+	if len(used) > 1 {
+		v.add("", "%s must not be used together", strings.Join(used, ", "))
+	}
+}
+
+func (v *metadataValidation) informational(logoURI, policyURI, informationURI, organizationURI string) {
+	v.url("logo_uri", logoURI, false)
+	v.url("policy_uri", policyURI, false)
+	v.url("information_uri", informationURI, false)
+	v.url("organization_uri", organizationURI, false)
+}
+
+// onlyImplicitResponseTypes checks if all response types only use the
+// implicit flow, i.e. if no token endpoint is needed
+func onlyImplicitResponseTypes(responseTypes []string) bool {
+	if len(responseTypes) == 0 {
+		return false
+	}
+	for _, rt := range responseTypes {
+		if utils.SliceContains("code", strings.Fields(rt)) {
+			return false
+		}
+	}
+	return true
+}
+
+// grantTypesMatchResponseTypes checks that the grant types needed for the
+// response types are registered, as described in RFC 7591 section 2.1
+func (v *metadataValidation) grantTypesMatchResponseTypes(grantTypes, responseTypes []string) {
+	if len(grantTypes) == 0 || len(responseTypes) == 0 {
+		return
+	}
+	for _, rt := range responseTypes {
+		parts := strings.Fields(rt)
+		if utils.SliceContains("code", parts) && !utils.SliceContains("authorization_code", grantTypes) {
+			v.add(
+				"grant_types", "response type '%s' requires the 'authorization_code' grant type", rt,
+			)
+		}
+		if (utils.SliceContains("token", parts) || utils.SliceContains("id_token", parts)) &&
+			!utils.SliceContains("implicit", grantTypes) {
+			v.add("grant_types", "response type '%s' requires the 'implicit' grant type", rt)
+		}
+	}
+}
+
+func validateOpenIDProviderMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OpenIDProviderMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.required("issuer", m.Issuer != "")
+	v.issuer("issuer", m.Issuer)
+	v.required("authorization_endpoint", m.AuthorizationEndpoint != "")
+	if !onlyImplicitResponseTypes(m.ResponseTypesSupported) {
+		v.required("token_endpoint", m.TokenEndpoint != "")
+	}
+	v.required("response_types_supported", len(m.ResponseTypesSupported) > 0)
+	v.required("subject_types_supported", len(m.SubjectTypesSupported) > 0)
+	v.required("client_registration_types_supported", len(m.ClientRegistrationTypesSupported) > 0)
+	v.required("jwks_uri", m.JWKSURI != "" || m.SignedJWKSURI != "" || (m.JWKS != nil && m.JWKS.Set != nil))
+	v.endpoints(
+		map[string]string{
+			"authorization_endpoint":                m.AuthorizationEndpoint,
+			"token_endpoint":                        m.TokenEndpoint,
+			"userinfo_endpoint":                     m.UserinfoEndpoint,
+			"registration_endpoint":                 m.RegistrationEndpoint,
+			"federation_registration_endpoint":      m.FederationRegistrationEndpoint,
+			"pushed_authorization_request_endpoint": m.PushedAuthorizationRequestEndpoint,
+			"end_session_endpoint":                  m.EndSessionEndpoint,
+			"revocation_endpoint":                   m.RevocationEndpoint,
+			"introspection_endpoint":                m.IntrospectionEndpoint,
+		},
+	)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOAuthAuthorizationServerMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OAuthAuthorizationServerMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.required("issuer", m.Issuer != "")
+	v.issuer("issuer", m.Issuer)
+	// RFC 8414: the authorization endpoint is required unless no grant types
+	// are supported that use it, the token endpoint unless only the implicit
+	// grant type is supported
+	if len(m.GrantTypesSupported) == 0 ||
+		utils.SliceContains("authorization_code", m.GrantTypesSupported) ||
+		utils.SliceContains("implicit", m.GrantTypesSupported) {
+		v.required("authorization_endpoint", m.AuthorizationEndpoint != "")
+	}
+	if len(m.GrantTypesSupported) != 1 || m.GrantTypesSupported[0] != "implicit" {
+		v.required("token_endpoint", m.TokenEndpoint != "")
+	}
+	v.required("response_types_supported", len(m.ResponseTypesSupported) > 0)
+	v.endpoints(
+		map[string]string{
+			"authorization_endpoint":                m.AuthorizationEndpoint,
+			"token_endpoint":                        m.TokenEndpoint,
+			"registration_endpoint":                 m.RegistrationEndpoint,
+			"pushed_authorization_request_endpoint": m.PushedAuthorizationRequestEndpoint,
+			"revocation_endpoint":                   m.RevocationEndpoint,
+			"introspection_endpoint":                m.IntrospectionEndpoint,
+		},
+	)
+	v.url("service_documentation", m.ServiceDocumentation, false)
+	v.url("op_policy_uri", m.OPPolicyURI, false)
+	v.url("op_tos_uri", m.OPTOSURI, false)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOpenIDRelyingPartyMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OpenIDRelyingPartyMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.required("redirect_uris", len(m.RedirectURIS) > 0)
+	v.required("client_registration_types", len(m.ClientRegistrationTypes) > 0)
+	for _, uri := range m.RedirectURIS {
+		v.url("redirect_uris", uri, false)
+	}
+	v.grantTypesMatchResponseTypes(m.GrantTypes, m.ResponseTypes)
+	v.url("initiate_login_uri", m.InitiateLoginURI, true)
+	v.url("sector_identifier_uri", m.SectorIdentifierURI, true)
+	v.url("client_uri", m.ClientURI, false)
+	v.url("tos_uri", m.TOSURI, false)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOAuthClientMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OAuthClientMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	for _, uri := range m.RedirectURIS {
+		v.url("redirect_uris", uri, false)
+	}
+	v.grantTypesMatchResponseTypes(m.GrantTypes, m.ResponseTypes)
+	v.url("client_uri", m.ClientURI, false)
+	v.url("tos_uri", m.TOSURI, false)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOAuthProtectedResourceMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OAuthProtectedResourceMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.url("resource", m.Resource, true)
+	for _, as := range m.AuthorizationServers {
+		v.issuer("authorization_servers", as)
+	}
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateFederationEntityMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*FederationEntityMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.endpoints(
+		map[string]string{
+			"federation_fetch_endpoint":             m.FederationFetchEndpoint,
+			"federation_list_endpoint":              m.FederationListEndpoint,
+			"federation_resolve_endpoint":           m.FederationResolveEndpoint,
+			"federation_trust_mark_status_endpoint": m.FederationTrustMarkStatusEndpoint,
+			"federation_trust_mark_list_endpoint":   m.FederationTrustMarkListEndpoint,
+			"federation_trust_mark_endpoint":        m.FederationTrustMarkEndpoint,
+			"federation_historical_keys_endpoint":   m.FederationHistoricalLKeysEndpoint,
+		},
+	)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOpenIDCredentialIssuerMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OpenIDCredentialIssuerMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.required("credential_issuer", m.CredentialIssuer != "")
+	v.issuer("credential_issuer", m.CredentialIssuer)
+	v.required("credential_endpoint", m.CredentialEndpoint != "")
+	v.required("credential_configurations_supported", len(m.CredentialConfigurationsSupported) > 0)
+	for _, as := range m.AuthorizationServers {
+		v.issuer("authorization_servers", as)
+	}
+	v.endpoints(
+		map[string]string{
+			"credential_endpoint":          m.CredentialEndpoint,
+			"nonce_endpoint":               m.NonceEndpoint,
+			"deferred_credential_endpoint": m.DeferredCredentialEndpoint,
+			"notification_endpoint":        m.NotificationEndpoint,
+		},
+	)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOpenIDWalletProviderMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OpenIDWalletProviderMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	v.url("token_endpoint", m.TokenEndpoint, true)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
+
+func validateOpenIDCredentialVerifierMetadata(metadata any) []MetadataValidationError {
+	m, ok := metadata.(*OpenIDCredentialVerifierMetadata)
+	if !ok {
+		return nil
+	}
+	var v metadataValidation
+	for _, uri := range m.RedirectURIS {
+		v.url("redirect_uris", uri, false)
+	}
+	for _, uri := range m.RequestURIs {
+		v.url("request_uris", uri, true)
+	}
+	v.url("tos_uri", m.TOSURI, false)
+	v.keys(m.JWKSURI, m.SignedJWKSURI, m.JWKS)
+	v.informational(m.LogoURI, m.PolicyURI, m.InformationURI, m.OrganizationURI)
+	return v
+}
@@ -0,0 +1,236 @@
+package oidfed
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/jwks"
+)
+
+func TestMetadata_Validate(t *testing.T) {
+	validOP := func() *OpenIDProviderMetadata {
+		return &OpenIDProviderMetadata{
+			Issuer:                           "https://op.example.org",
+			AuthorizationEndpoint:            "https://op.example.org/authorize",
+			TokenEndpoint:                    "https://op.example.org/token",
+			ResponseTypesSupported:           []string{"code"},
+			SubjectTypesSupported:            []string{"public"},
+			ClientRegistrationTypesSupported: []string{"automatic"},
+			JWKSURI:                          "https://op.example.org/jwks",
+		}
+	}
+	validRP := func() *OpenIDRelyingPartyMetadata {
+		return &OpenIDRelyingPartyMetadata{
+			RedirectURIS:            []string{"https://rp.example.org/callback"},
+			ClientRegistrationTypes: []string{"automatic"},
+			ResponseTypes:           []string{"code"},
+			GrantTypes:              []string{"authorization_code", "refresh_token"},
+		}
+	}
+	keys := jwks.NewJWKS()
+
+	type expectedError struct {
+		entityType string
+		claim      string
+	}
+	tests := []struct {
+		name     string
+		metadata func() Metadata
+		expected []expectedError
+	}{
+		{
+			name: "valid",
+			metadata: func() Metadata {
+				return Metadata{
+					OpenIDProvider: validOP(),
+					RelyingParty:   validRP(),
+					FederationEntity: &FederationEntityMetadata{
+						FederationFetchEndpoint: "https://ta.example.org/fetch?x=y",
+						OrganizationURI:         "http://example.org",
+					},
+					OAuthAuthorizationServer: &OAuthAuthorizationServerMetadata{
+						Issuer:                 "https://as.example.org",
+						TokenEndpoint:          "https://as.example.org/token",
+						GrantTypesSupported:    []string{"client_credentials"},
+						ResponseTypesSupported: []string{"code"},
+					},
+				}
+			},
+		},
+		{
+			name: "op without issuer",
+			metadata: func() Metadata {
+				op := validOP()
+				op.Issuer = ""
+				return Metadata{OpenIDProvider: op}
+			},
+			expected: []expectedError{{"openid_provider", "issuer"}},
+		},
+		{
+			name: "op issuer with query and http endpoint",
+			metadata: func() Metadata {
+				op := validOP()
+				op.Issuer = "https://op.example.org?tenant=1"
+				op.UserinfoEndpoint = "http://op.example.org/userinfo"
+				return Metadata{OpenIDProvider: op}
+			},
+			expected: []expectedError{
+				{"openid_provider", "issuer"},
+				{"openid_provider", "userinfo_endpoint"},
+			},
+		},
+		{
+			name: "implicit op without token endpoint",
+			metadata: func() Metadata {
+				op := validOP()
+				op.TokenEndpoint = ""
+				op.ResponseTypesSupported = []string{"id_token", "id_token token"}
+				return Metadata{OpenIDProvider: op}
+			},
+		},
+		{
+			name: "rp with jwks and jwks_uri",
+			metadata: func() Metadata {
+				rp := validRP()
+				rp.JWKS = &keys
+				rp.JWKSURI = "https://rp.example.org/jwks"
+				return Metadata{RelyingParty: rp}
+			},
+			expected: []expectedError{{"openid_relying_party", ""}},
+		},
+		{
+			name: "rp grant types do not match response types",
+			metadata: func() Metadata {
+				rp := validRP()
+				rp.ResponseTypes = []string{"code id_token"}
+				return Metadata{RelyingParty: rp}
+			},
+			expected: []expectedError{{"openid_relying_party", "grant_types"}},
+		},
+		{
+			name: "rp without redirect uris",
+			metadata: func() Metadata {
+				rp := validRP()
+				rp.RedirectURIS = nil
+				return Metadata{RelyingParty: rp}
+			},
+			expected: []expectedError{{"openid_relying_party", "redirect_uris"}},
+		},
+		{
+			name: "federation entity with non-https endpoints",
+			metadata: func() Metadata {
+				return Metadata{
+					FederationEntity: &FederationEntityMetadata{
+						FederationFetchEndpoint:   "http://ta.example.org/fetch",
+						FederationResolveEndpoint: "https://ta.example.org/resolve#fragment",
+						LogoURI:                   "logo.png",
+					},
+				}
+			},
+			expected: []expectedError{
+				{"federation_entity", "federation_fetch_endpoint"},
+				{"federation_entity", "federation_resolve_endpoint"},
+				{"federation_entity", "logo_uri"},
+			},
+		},
+		{
+			name: "as without required endpoints",
+			metadata: func() Metadata {
+				return Metadata{
+					OAuthAuthorizationServer: &OAuthAuthorizationServerMetadata{
+						Issuer:                 "https://as.example.org",
+						ResponseTypesSupported: []string{"code"},
+					},
+				}
+			},
+			expected: []expectedError{
+				{"oauth_authorization_server", "authorization_endpoint"},
+				{"oauth_authorization_server", "token_endpoint"},
+			},
+		},
+		{
+			name: "protected resource with invalid authorization server",
+			metadata: func() Metadata {
+				return Metadata{
+					OAuthProtectedResource: &OAuthProtectedResourceMetadata{
+						Resource:             "https://rs.example.org",
+						AuthorizationServers: []string{"https://as.example.org/?x=y"},
+					},
+				}
+			},
+			expected: []expectedError{{"oauth_resource", "authorization_servers"}},
+		},
+		{
+			name: "credential issuer without required claims",
+			metadata: func() Metadata {
+				return Metadata{
+					OpenIDCredentialIssuer: &OpenIDCredentialIssuerMetadata{
+						CredentialIssuer:     "https://issuer.example.org",
+						NonceEndpoint:        "http://issuer.example.org/nonce",
+						AuthorizationServers: []string{"https://as.example.org"},
+					},
+				}
+			},
+			expected: []expectedError{
+				{"openid_credential_issuer", "credential_endpoint"},
+				{"openid_credential_issuer", "credential_configurations_supported"},
+				{"openid_credential_issuer", "nonce_endpoint"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := test.metadata().Validate()
+				if len(test.expected) == 0 {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				var errs MetadataValidationErrors
+				if !errors.As(err, &errs) {
+					t.Fatalf("expected MetadataValidationErrors, but got: %v", err)
+				}
+				if len(errs) != len(test.expected) {
+					t.Fatalf("expected %d errors, but got: %v", len(test.expected), errs)
+				}
+				for i, e := range test.expected {
+					if errs[i].EntityType != e.entityType || errs[i].Claim != e.claim {
+						t.Errorf("error %d is '%s', but expected %+v", i, errs[i], e)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestRegisterMetadataValidator(t *testing.T) {
+	const entityType = "test_validated_entity"
+	RegisterMetadataValidator(
+		entityType, func(metadata any) []MetadataValidationError {
+			m, _ := metadata.(map[string]any)
+			if _, ok := m["required_claim"]; !ok {
+				return []MetadataValidationError{{Claim: "required_claim", Message: "claim is required"}}
+			}
+			return nil
+		},
+	)
+	defer func() {
+		metadataValidators.Lock()
+		delete(metadataValidators.validators, entityType)
+		metadataValidators.Unlock()
+	}()
+
+	m := Metadata{Extra: map[string]any{entityType: map[string]any{"required_claim": true}}}
+	if err := m.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	m.Extra[entityType] = map[string]any{}
+	err := m.Validate()
+	var errs MetadataValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].EntityType != entityType {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
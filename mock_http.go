@@ -2,6 +2,8 @@ package oidfed
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 
 	"github.com/jarcoal/httpmock"
@@ -15,6 +17,12 @@ type mockedEntityConfigurationSigner interface {
 type mockedFetchResponder interface {
 	FetchResponse(sub string) ([]byte, error)
 }
+type mockedTrustMarkStatusResponder interface {
+	TrustMarkStatusResponse(trustMark []byte) ([]byte, error)
+}
+type mockedTrustMarkedEntitiesLister interface {
+	TrustMarkedEntities(trustMarkType string) ([]string, error)
+}
 type mockedSubordinateLister interface {
 	Subordinates(entityType string) ([]string, error)
 }
@@ -57,3 +65,46 @@ func mockListEndpoint(listEndpoint string, mocker mockedSubordinateLister) {
 		},
 	)
 }
+
+func mockTrustMarkStatusEndpoint(statusEndpoint string, mocker mockedTrustMarkStatusResponder) {
+	httpmock.RegisterResponder(
+		"POST", statusEndpoint, func(request *http.Request) (*http.Response, error) {
+			if err := request.ParseForm(); err != nil {
+				return nil, err
+			}
+			res, err := mocker.TrustMarkStatusResponse([]byte(request.PostForm.Get("trust_mark")))
+			if err != nil {
+				return nil, err
+			}
+			return httpmock.NewBytesResponse(200, res), nil
+		},
+	)
+}
+
+func mockTrustMarkListEndpoint(listEndpoint string, mocker mockedTrustMarkedEntitiesLister) {
+	httpmock.RegisterResponder(
+		"GET", listEndpoint, func(request *http.Request) (*http.Response, error) {
+			entities, err := mocker.TrustMarkedEntities(request.URL.Query().Get("trust_mark_type"))
+			if err != nil {
+				return nil, err
+			}
+			if sub := request.URL.Query().Get("sub"); sub != "" {
+				entities = slices.DeleteFunc(entities, func(e string) bool { return e != sub })
+			}
+			if entities == nil {
+				entities = []string{}
+			}
+			return httpmock.NewJsonResponse(200, entities)
+		},
+	)
+}
+
+func mockHandler(method, uri string, handler http.Handler) {
+	httpmock.RegisterResponder(
+		method, uri, func(request *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, request)
+			return rec.Result(), nil
+		},
+	)
+}
@@ -19,7 +19,8 @@ type mockOP struct {
 	authorities []string
 	jwks        jwks.JWKS
 	*EntityStatementSigner
-	metadata *OpenIDProviderMetadata
+	metadata   *OpenIDProviderMetadata
+	trustMarks TrustMarkInfos
 }
 
 func (op mockOP) EntityConfigurationJWT() ([]byte, error) {
@@ -53,6 +54,7 @@ func (op mockOP) EntityStatementPayload() EntityStatementPayload {
 		JWKS:           op.jwks,
 		Audience:       "",
 		AuthorityHints: op.authorities,
+		TrustMarks:     op.trustMarks,
 		Metadata: &Metadata{
 			FederationEntity: &FederationEntityMetadata{
 				OrganizationName: fmt.Sprintf("Organization: %s", orgID[:8]),
@@ -36,7 +36,8 @@ func (tmi mockTMI) EntityStatementPayload() EntityStatementPayload {
 		JWKS:           tmi.jwks,
 		Metadata: &Metadata{
 			FederationEntity: &FederationEntityMetadata{
-				FederationTrustMarkStatusEndpoint: "TODO", //TODO
+				FederationTrustMarkStatusEndpoint: tmi.statusEndpoint(),
+				FederationTrustMarkListEndpoint:   tmi.listEndpoint(),
 				OrganizationName:                  fmt.Sprintf("Organization: %s", orgID[:8]),
 			},
 		},
@@ -44,6 +45,14 @@ func (tmi mockTMI) EntityStatementPayload() EntityStatementPayload {
 	return payload
 }
 
+func (tmi mockTMI) statusEndpoint() string {
+	return tmi.EntityID + "/status"
+}
+
+func (tmi mockTMI) listEndpoint() string {
+	return tmi.EntityID + "/list"
+}
+
 func (tmi *mockTMI) AddAuthority(authorityID string) {
 	tmi.authorities = append(tmi.authorities, authorityID)
 }
@@ -66,7 +75,10 @@ func newMockTrustMarkIssuer(entityID string, trustMarkSpecs []TrustMarkSpec) *mo
 		TrustMarkIssuer: *tmi,
 		jwks:            jwks.KeyToJWKS(tmi.key.Public(), tmi.alg),
 	}
+	mock.IssuanceStore = NewInMemoryTrustMarkIssuanceStore()
 	mockEntityConfiguration(mock.EntityID, mock)
+	mockTrustMarkStatusEndpoint(mock.statusEndpoint(), mock)
+	mockTrustMarkListEndpoint(mock.listEndpoint(), mock)
 	return mock
 }
 
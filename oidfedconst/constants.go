@@ -12,21 +12,36 @@ const (
 	ContentTypeTrustMarkDelegation          = "application/trust-mark-delegation+jwt"
 	ContentTypeJWKS                         = "application/jwk-set+jwt"
 	ContentTypeExplicitRegistrationResponse = "application/explicit-registration-response+jwt"
+	ContentTypeTrustMarkStatusResponse      = "application/trust-mark-status-response+jwt"
+	ContentTypeDelegationStatusResponse     = "application/trust-mark-delegation-status-response+jwt"
 	JWTTypeEntityStatement                  = "entity-statement+jwt"
 	JWTTypeTrustMarkDelegation              = "trust-mark-delegation+jwt"
 	JWTTypeTrustMark                        = "trust-mark+jwt"
 	JWTTypeResolveResponse                  = "resolve-response+jwt"
 	JWTTypeJWKS                             = "jwk-set+jwt"
 	JWTTypeExplicitRegistrationResponse     = "explicit-registration-response+jwt"
+	JWTTypeTrustMarkStatusResponse          = "trust-mark-status-response+jwt"
+	JWTTypeDelegationStatusResponse         = "trust-mark-delegation-status-response+jwt"
 )
 
 // Constants for entity types
 const (
-	EntityTypeFederationEntity       = "federation_entity"
-	EntityTypeOpenIDRelyingParty     = "openid_relying_party"
-	EntityTypeOpenIDProvider         = "openid_provider"
-	EntityTypeOAuthClient            = "oauth_client"
-	EntityTypeOAuthProtectedResource = "oauth_resource"
+	EntityTypeFederationEntity         = "federation_entity"
+	EntityTypeOpenIDRelyingParty       = "openid_relying_party"
+	EntityTypeOpenIDProvider           = "openid_provider"
+	EntityTypeOAuthClient              = "oauth_client"
+	EntityTypeOAuthProtectedResource   = "oauth_resource"
+	EntityTypeOpenIDCredentialIssuer   = "openid_credential_issuer"
+	EntityTypeOpenIDWalletProvider     = "openid_wallet_provider"
+	EntityTypeOpenIDCredentialVerifier = "openid_credential_verifier"
+)
+
+// Constants for trust mark status values
+const (
+	TrustMarkStatusActive  = "active"
+	TrustMarkStatusExpired = "expired"
+	TrustMarkStatusRevoked = "revoked"
+	TrustMarkStatusInvalid = "invalid"
 )
 
 // Constants for registration types
@@ -0,0 +1,240 @@
+package oidfed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Error codes used in the metadata policy test vectors
+const (
+	policyConformanceErrorInvalidPolicy   = "invalid_policy"
+	policyConformanceErrorInvalidMetadata = "invalid_metadata"
+)
+
+// PolicyConformanceStage describes the stage of the metadata policy
+// processing in which an error occurred
+type PolicyConformanceStage string
+
+// Constants for PolicyConformanceStage
+const (
+	PolicyConformanceStageMerge PolicyConformanceStage = "merge"
+	PolicyConformanceStageApply PolicyConformanceStage = "apply"
+)
+
+// PolicyConformanceVector is a single metadata policy test vector as
+// published together with the specification
+type PolicyConformanceVector struct {
+	Number           int64                      `json:"n"`
+	Combination      []PolicyOperatorName       `json:"combination,omitempty"`
+	TAPolicy         MetadataPolicy             `json:"TA"`
+	INTPolicy        MetadataPolicy             `json:"INT"`
+	MergedPolicy     MetadataPolicy             `json:"merged,omitempty"`
+	LeafMetadata     OpenIDRelyingPartyMetadata `json:"metadata"`
+	ResolvedMetadata *json.RawMessage           `json:"resolved,omitempty"`
+	Error            string                     `json:"error,omitempty"`
+	ErrorDescription string                     `json:"error_description,omitempty"`
+}
+
+// LoadPolicyConformanceVectors loads metadata policy test vectors from a
+// json file
+func LoadPolicyConformanceVectors(path string) ([]PolicyConformanceVector, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var vectors []PolicyConformanceVector
+	if err = json.Unmarshal(content, &vectors); err != nil {
+		return nil, errors.Wrap(err, "could not parse metadata policy test vectors")
+	}
+	return vectors, nil
+}
+
+// PolicyConformanceResult is the result of running a single
+// PolicyConformanceVector
+type PolicyConformanceResult struct {
+	Number      int64                `json:"n"`
+	Combination []PolicyOperatorName `json:"combination,omitempty"`
+	Passed      bool                 `json:"passed"`
+	// ExpectedError is the error code expected by the test vector
+	ExpectedError string `json:"expected_error,omitempty"`
+	// Error is the error returned by the library
+	Error      string                 `json:"error,omitempty"`
+	ErrorStage PolicyConformanceStage `json:"error_stage,omitempty"`
+	// Failure describes why the vector did not pass
+	Failure string `json:"failure,omitempty"`
+	// Deviations describe differences to the test vector that do not
+	// make the vector fail, e.g. a differently merged policy that still
+	// results in the expected metadata
+	Deviations []string `json:"deviations,omitempty"`
+}
+
+// PolicyConformanceReport is the machine-readable result of running a set of
+// metadata policy test vectors
+type PolicyConformanceReport struct {
+	Total          int                       `json:"total"`
+	Passed         int                       `json:"passed"`
+	Failed         int                       `json:"failed"`
+	WithDeviations int                       `json:"with_deviations"`
+	Results        []PolicyConformanceResult `json:"results"`
+}
+
+// RunPolicyConformance runs the passed metadata policy test vectors through
+// MergeMetadataPolicies and Metadata.ApplyPolicy and returns a
+// PolicyConformanceReport.
+// The currently registered policy operators are used,
+// i.e. custom operators registered with RegisterPolicyOperatorWithOrdering are also
+// exercised by vectors that use them.
+func RunPolicyConformance(vectors []PolicyConformanceVector) PolicyConformanceReport {
+	report := PolicyConformanceReport{
+		Total:   len(vectors),
+		Results: make([]PolicyConformanceResult, len(vectors)),
+	}
+	for i, v := range vectors {
+		result := v.run()
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		if len(result.Deviations) > 0 {
+			report.WithDeviations++
+		}
+		report.Results[i] = result
+	}
+	return report
+}
+
+func (v PolicyConformanceVector) run() (result PolicyConformanceResult) {
+	result = PolicyConformanceResult{
+		Number:        v.Number,
+		Combination:   v.Combination,
+		ExpectedError: v.Error,
+	}
+	resolved, stage, err := v.process()
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorStage = stage
+		switch v.Error {
+		case "":
+			result.Failure = fmt.Sprintf("unexpected error in %s stage: %s", stage, err.Error())
+			return
+		case policyConformanceErrorInvalidPolicy:
+			// an invalid policy must be detected when the policies are
+			// merged; the error in the apply stage might have another cause
+			if stage != PolicyConformanceStageMerge {
+				result.Failure = fmt.Sprintf(
+					"expected '%s' in merge stage, but error occurred in %s stage: %s", v.Error, stage,
+					err.Error(),
+				)
+				return
+			}
+		case policyConformanceErrorInvalidMetadata:
+			if stage != PolicyConformanceStageApply {
+				result.Deviations = append(
+					result.Deviations,
+					fmt.Sprintf("expected '%s' in apply stage, but error occurred in %s stage", v.Error, stage),
+				)
+			}
+		}
+		result.Passed = true
+		return
+	}
+	if v.Error != "" {
+		result.Failure = fmt.Sprintf("expected error '%s', but no error occurred", v.Error)
+		return
+	}
+	if deviation := v.compareMergedPolicy(); deviation != "" {
+		result.Deviations = append(result.Deviations, deviation)
+	}
+	if failure := v.compareResolvedMetadata(resolved); failure != "" {
+		result.Failure = failure
+		return
+	}
+	result.Passed = true
+	return
+}
+
+// process merges and applies the policies of the PolicyConformanceVector
+func (v PolicyConformanceVector) process() (*OpenIDRelyingPartyMetadata, PolicyConformanceStage, error) {
+	merged, err := MergeMetadataPolicies(
+		&MetadataPolicies{RelyingParty: v.TAPolicy},
+		&MetadataPolicies{RelyingParty: v.INTPolicy},
+	)
+	if err != nil {
+		return nil, PolicyConformanceStageMerge, err
+	}
+	leaf := v.LeafMetadata
+	applied, err := (&Metadata{RelyingParty: &leaf}).ApplyPolicy(merged)
+	if err != nil {
+		return nil, PolicyConformanceStageApply, err
+	}
+	return applied.RelyingParty, "", nil
+}
+
+func (v PolicyConformanceVector) compareMergedPolicy() string {
+	if v.MergedPolicy == nil {
+		return ""
+	}
+	merged, err := CombineMetadataPolicy("openid_relying_party", v.TAPolicy, v.INTPolicy)
+	if err != nil {
+		return ""
+	}
+	equal, err := jsonEqual(merged, v.MergedPolicy)
+	if err != nil {
+		return fmt.Sprintf("could not compare merged policy: %s", err.Error())
+	}
+	if !equal {
+		mergedJSON, _ := json.Marshal(merged)
+		expectedJSON, _ := json.Marshal(v.MergedPolicy)
+		return fmt.Sprintf("merged policy differs: expected %s, got %s", expectedJSON, mergedJSON)
+	}
+	return ""
+}
+
+func (v PolicyConformanceVector) compareResolvedMetadata(resolved *OpenIDRelyingPartyMetadata) string {
+	if v.ResolvedMetadata == nil {
+		return ""
+	}
+	var expected OpenIDRelyingPartyMetadata
+	if err := json.Unmarshal(*v.ResolvedMetadata, &expected); err != nil {
+		return fmt.Sprintf("could not parse expected resolved metadata: %s", err.Error())
+	}
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		return fmt.Sprintf("could not marshal expected resolved metadata: %s", err.Error())
+	}
+	resolvedJSON, err := json.Marshal(resolved)
+	if err != nil {
+		return fmt.Sprintf("could not marshal resolved metadata: %s", err.Error())
+	}
+	if !bytes.Equal(expectedJSON, resolvedJSON) {
+		return fmt.Sprintf("resolved metadata differs: expected %s, got %s", expectedJSON, resolvedJSON)
+	}
+	return ""
+}
+
+// jsonEqual checks if two values have the same json representation,
+// independent of the order of object members
+func jsonEqual(a, b any) (bool, error) {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	var aV, bV any
+	if err = json.Unmarshal(aJSON, &aV); err != nil {
+		return false, errors.WithStack(err)
+	}
+	if err = json.Unmarshal(bJSON, &bV); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return reflect.DeepEqual(aV, bV), nil
+}
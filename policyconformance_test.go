@@ -0,0 +1,131 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRunPolicyConformance(t *testing.T) {
+	resolved := json.RawMessage(`{"client_name":"b"}`)
+	tests := []struct {
+		name              string
+		vector            PolicyConformanceVector
+		expectedPassed    bool
+		expectedStage     PolicyConformanceStage
+		expectedDeviation bool
+	}{
+		{
+			name: "resolved",
+			vector: PolicyConformanceVector{
+				TAPolicy:         MetadataPolicy{"client_name": {PolicyOperatorValue: "b"}},
+				LeafMetadata:     OpenIDRelyingPartyMetadata{ClientName: "a"},
+				ResolvedMetadata: &resolved,
+			},
+			expectedPassed: true,
+		},
+		{
+			name: "resolved differs",
+			vector: PolicyConformanceVector{
+				TAPolicy:         MetadataPolicy{"client_name": {PolicyOperatorValue: "c"}},
+				LeafMetadata:     OpenIDRelyingPartyMetadata{ClientName: "a"},
+				ResolvedMetadata: &resolved,
+			},
+			expectedPassed: false,
+		},
+		{
+			name: "merge error",
+			vector: PolicyConformanceVector{
+				TAPolicy:  MetadataPolicy{"client_name": {PolicyOperatorValue: "b"}},
+				INTPolicy: MetadataPolicy{"client_name": {PolicyOperatorValue: "c"}},
+				Error:     policyConformanceErrorInvalidPolicy,
+			},
+			expectedPassed: true,
+			expectedStage:  PolicyConformanceStageMerge,
+		},
+		{
+			name: "error in other stage",
+			vector: PolicyConformanceVector{
+				TAPolicy: MetadataPolicy{"client_name": {PolicyOperatorValue: "b"}},
+				INTPolicy: MetadataPolicy{
+					"client_name": {PolicyOperatorValue: "c"},
+				},
+				Error: policyConformanceErrorInvalidMetadata,
+			},
+			expectedPassed:    true,
+			expectedStage:     PolicyConformanceStageMerge,
+			expectedDeviation: true,
+		},
+		{
+			name: "apply error",
+			vector: PolicyConformanceVector{
+				TAPolicy: MetadataPolicy{
+					"client_name": {
+						PolicyOperatorEssential: true,
+						PolicyOperatorOneOf:     []any{"b"},
+					},
+				},
+				Error: policyConformanceErrorInvalidMetadata,
+			},
+			expectedPassed: true,
+			expectedStage:  PolicyConformanceStageApply,
+		},
+		{
+			name: "invalid policy only detected when applied",
+			vector: PolicyConformanceVector{
+				TAPolicy: MetadataPolicy{
+					"client_name": {
+						PolicyOperatorEssential: true,
+						PolicyOperatorOneOf:     []any{"b"},
+					},
+				},
+				Error: policyConformanceErrorInvalidPolicy,
+			},
+			expectedPassed: false,
+			expectedStage:  PolicyConformanceStageApply,
+		},
+		{
+			name: "missing error",
+			vector: PolicyConformanceVector{
+				TAPolicy: MetadataPolicy{"client_name": {PolicyOperatorValue: "b"}},
+				Error:    policyConformanceErrorInvalidPolicy,
+			},
+			expectedPassed: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				report := RunPolicyConformance([]PolicyConformanceVector{test.vector})
+				if report.Total != 1 || len(report.Results) != 1 {
+					t.Fatalf("unexpected report: %+v", report)
+				}
+				result := report.Results[0]
+				if result.Passed != test.expectedPassed {
+					t.Errorf("passed is %v, but %v expected: %s", result.Passed, test.expectedPassed, result.Failure)
+				}
+				if result.ErrorStage != test.expectedStage {
+					t.Errorf("error stage is '%s', but '%s' expected", result.ErrorStage, test.expectedStage)
+				}
+				if (len(result.Deviations) > 0) != test.expectedDeviation {
+					t.Errorf("unexpected deviations: %v", result.Deviations)
+				}
+			},
+		)
+	}
+}
+
+func TestRunPolicyConformance_TestVectors(t *testing.T) {
+	vectors, err := LoadPolicyConformanceVectors("metadata-policy-test-vectors-2025-02-13.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := RunPolicyConformance(vectors)
+	if report.Total != len(vectors) || report.Passed+report.Failed != report.Total {
+		t.Fatalf("inconsistent report totals: %+v", report)
+	}
+	for _, r := range report.Results {
+		if !r.Passed && r.ExpectedError == "" {
+			t.Errorf("test vector #%d failed: %s", r.Number, r.Failure)
+		}
+	}
+}
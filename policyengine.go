@@ -0,0 +1,149 @@
+package oidfed
+
+import (
+	"github.com/google/uuid"
+)
+
+// PolicyEngine holds a set of PolicyOperator, PolicyVerifier,
+// and the order in which the operators are applied.
+// It can be used to merge and apply metadata policies with a different set of
+// operators than the process-global one, e.g. when multiple federations with
+// different custom operators are handled in one process.
+// A PolicyEngine is safe for concurrent use.
+type PolicyEngine struct {
+	registry *policyOperatorRegistry
+	// id is used to separate cached metadata between PolicyEngine; it is
+	// empty for the DefaultPolicyEngine
+	id string
+}
+
+// DefaultPolicyEngine is the PolicyEngine used by the package-level
+// functions, e.g. RegisterPolicyOperatorWithOrdering, MergeMetadataPolicies,
+// and TrustChain.Metadata
+var DefaultPolicyEngine = &PolicyEngine{registry: defaultPolicyOperatorRegistry}
+
+// NewPolicyEngine returns a new PolicyEngine with only the built-in
+// PolicyOperator and PolicyVerifier registered
+func NewPolicyEngine() *PolicyEngine {
+	return &PolicyEngine{
+		registry: newBuiltinPolicyOperatorRegistry(),
+		id:       uuid.NewString(),
+	}
+}
+
+// policyOperatorRegistry returns the registry of the PolicyEngine; a nil
+// PolicyEngine uses the DefaultPolicyEngine
+func (e *PolicyEngine) policyOperatorRegistry() *policyOperatorRegistry {
+	if e == nil {
+		return defaultPolicyOperatorRegistry
+	}
+	return e.registry
+}
+
+func (e *PolicyEngine) cacheID() string {
+	if e == nil {
+		return ""
+	}
+	return e.id
+}
+
+// RegisterPolicyOperator registers a PolicyOperator with this PolicyEngine;
+// see the package-level RegisterPolicyOperatorWithOrdering for details
+func (e *PolicyEngine) RegisterPolicyOperator(operator PolicyOperator, ordering ...PolicyOperatorOrdering) error {
+	var o *PolicyOperatorOrdering
+	if len(ordering) > 0 {
+		o = &PolicyOperatorOrdering{}
+		for _, oo := range ordering {
+			o.After = append(o.After, oo.After...)
+			o.Before = append(o.Before, oo.Before...)
+		}
+	}
+	return e.policyOperatorRegistry().register(operator, o, false)
+}
+
+// ReplaceBuiltinPolicyOperator replaces the implementation of a built-in
+// PolicyOperator in this PolicyEngine
+func (e *PolicyEngine) ReplaceBuiltinPolicyOperator(operator PolicyOperator) error {
+	return e.policyOperatorRegistry().register(operator, nil, true)
+}
+
+// RegisterPolicyVerifier registers a PolicyVerifier with this PolicyEngine
+func (e *PolicyEngine) RegisterPolicyVerifier(v PolicyVerifier) {
+	e.policyOperatorRegistry().registerVerifier(v)
+}
+
+// PolicyOperatorOrder returns the order in which the PolicyOperator of this
+// PolicyEngine are applied
+func (e *PolicyEngine) PolicyOperatorOrder() []PolicyOperatorName {
+	return e.policyOperatorRegistry().operatorOrder()
+}
+
+// MergeMetadataPolicies combines multiples MetadataPolicies from a chain into
+// a single one using the PolicyOperator of this PolicyEngine
+func (e *PolicyEngine) MergeMetadataPolicies(policies ...*MetadataPolicies) (*MetadataPolicies, error) {
+	return e.policyOperatorRegistry().mergeMetadataPolicies(policies...)
+}
+
+// CombineMetadataPolicy combines multiples MetadataPolicy into a single
+// MetadataPolicy using the PolicyOperator of this PolicyEngine
+func (e *PolicyEngine) CombineMetadataPolicy(pathInfo string, policies ...MetadataPolicy) (
+	MetadataPolicy, error,
+) {
+	return e.policyOperatorRegistry().combineMetadataPolicies(pathInfo, policies...)
+}
+
+// ApplyPolicy applies MetadataPolicies to Metadata using the PolicyOperator
+// of this PolicyEngine and returns the final Metadata
+func (e *PolicyEngine) ApplyPolicy(m Metadata, p *MetadataPolicies) (*Metadata, error) {
+	if p == nil {
+		return &m, nil
+	}
+	return m.applyPolicy(e.policyOperatorRegistry(), p, nil)
+}
+
+// TrustChainMetadata returns the final Metadata for a TrustChain like
+// TrustChain.Metadata, but using the PolicyOperator of this PolicyEngine
+func (e *PolicyEngine) TrustChainMetadata(c TrustChain) (*Metadata, error) {
+	return c.metadata(e)
+}
+
+// ExplainTrustChainMetadata explains the final Metadata for a TrustChain like
+// TrustChain.ExplainMetadata, but using the PolicyOperator of this
+// PolicyEngine
+func (e *PolicyEngine) ExplainTrustChainMetadata(c TrustChain) (*MetadataExplanation, error) {
+	return c.explainMetadata(e.policyOperatorRegistry())
+}
+
+// SimulateMetadata resolves the final Metadata of a leaf entity with the
+// passed superiors like the package-level SimulateMetadata, but using the
+// PolicyOperator of this PolicyEngine
+func (e *PolicyEngine) SimulateMetadata(
+	leaf PolicySimulationLeaf, superiors ...PolicySimulationSuperior,
+) PolicySimulationResult {
+	return simulateMetadata(e, leaf, superiors)
+}
+
+// RunPolicySimulation runs a PolicySimulation like PolicySimulation.Run, but
+// using the PolicyOperator of this PolicyEngine
+func (e *PolicyEngine) RunPolicySimulation(s PolicySimulation) PolicySimulationReport {
+	return s.run(e)
+}
+
+// LintMetadataPolicies checks MetadataPolicies like the package-level
+// LintMetadataPolicies, but using the PolicyOperator of this PolicyEngine
+func (e *PolicyEngine) LintMetadataPolicies(
+	policies *MetadataPolicies, superiors ...*MetadataPolicies,
+) PolicyLintFindings {
+	return e.policyOperatorRegistry().lintMetadataPolicies(policies, superiors)
+}
+
+// TrustChainsFilterValidMetadata returns a TrustChainsFilter that filters
+// the TrustChains to the ones with valid Metadata using this PolicyEngine
+func (e *PolicyEngine) TrustChainsFilterValidMetadata() TrustChainsFilter {
+	return NewTrustChainsFilterFromCheckerFnc(
+		func(chain TrustChain) bool {
+			_, err := chain.metadata(e)
+			return err == nil
+		},
+	)
+}
@@ -0,0 +1,81 @@
+package oidfed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var policyOperatorUpper = NewPolicyOperator(
+	"upper",
+	func(a, _ any, _ string) (any, error) { return a, nil },
+	func(value any, valueSet bool, policyValue any, _ bool, _ string) (any, bool, error) {
+		s, ok := value.(string)
+		if upper, _ := policyValue.(bool); !ok || !upper {
+			return value, valueSet, nil
+		}
+		return strings.ToUpper(s), valueSet, nil
+	},
+	nil,
+)
+
+func TestPolicyEngine(t *testing.T) {
+	engine := NewPolicyEngine()
+	if err := engine.RegisterPolicyOperator(policyOperatorUpper); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.RegisterPolicyOperator(newTestPolicyOperator(PolicyOperatorValue)); err == nil {
+		t.Errorf("expected error when overriding built-in operator")
+	}
+	for _, o := range PolicyOperatorOrder() {
+		if o == "upper" {
+			t.Fatalf("operator registered with PolicyEngine is also registered globally")
+		}
+	}
+
+	chain := simulatedTrustChain(
+		PolicySimulationLeaf{
+			EntityID: "https://rp.example.org",
+			Metadata: &Metadata{RelyingParty: &OpenIDRelyingPartyMetadata{ClientName: "rp"}},
+		},
+		[]PolicySimulationSuperior{
+			{
+				EntityID: "https://ta.example.org",
+				MetadataPolicy: &MetadataPolicies{
+					RelyingParty: MetadataPolicy{"client_name": {"upper": true}},
+				},
+				MetadataPolicyCrit: []PolicyOperatorName{"upper"},
+			},
+		},
+	)
+
+	m, err := engine.TrustChainMetadata(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.RelyingParty.ClientName != "RP" {
+		t.Errorf("client_name is '%s', but 'RP' expected", m.RelyingParty.ClientName)
+	}
+	// cached result must not be used for the default engine
+	_, err = chain.Metadata()
+	var critErr *MetadataPolicyCritError
+	if !errors.As(err, &critErr) {
+		t.Fatalf("expected MetadataPolicyCritError for the default engine, got: %v", err)
+	}
+
+	merged, err := engine.MergeMetadataPolicies(chain[1].MetadataPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := engine.ApplyPolicy(*chain[0].Metadata, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.RelyingParty.ClientName != "RP" {
+		t.Errorf("client_name is '%s', but 'RP' expected", applied.RelyingParty.ClientName)
+	}
+	if chain[0].Metadata.RelyingParty.ClientName != "rp" {
+		t.Errorf("applying the policy modified the original metadata")
+	}
+}
@@ -0,0 +1,287 @@
+package oidfed
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal/utils"
+)
+
+// MetadataPolicyError is implemented by all errors that result from merging,
+// verifying, or applying metadata policies
+type MetadataPolicyError interface {
+	error
+	// ErrorCode returns the spec error code that should be used when
+	// reporting this error, e.g. in a resolve response
+	ErrorCode() string
+}
+
+// splitPolicyPath splits a policy path info of the form 'entity_type.claim'
+// into the entity type and the claim name
+func splitPolicyPath(pathInfo string) (entityType, claim string) {
+	entityType, claim, _ = strings.Cut(pathInfo, ".")
+	return
+}
+
+// MetadataPolicyMergeError is returned if the values of a policy operator
+// from different metadata policies in a trust chain cannot be merged,
+// e.g. because a TA and an IA set conflicting 'value' operators
+type MetadataPolicyMergeError struct {
+	EntityType string
+	Claim      string
+	Operator   PolicyOperatorName
+	// Values are the conflicting operator values
+	Values []any
+	// Issuers are the entities whose metadata policies contain the
+	// conflicting operator; only set if the error results from evaluating a
+	// TrustChain
+	Issuers []string
+	Err     error
+}
+
+func newMetadataPolicyMergeError(pathInfo string, operator PolicyOperatorName, err error, values ...any) error {
+	entityType, claim := splitPolicyPath(pathInfo)
+	return &MetadataPolicyMergeError{
+		EntityType: entityType,
+		Claim:      claim,
+		Operator:   operator,
+		Values:     values,
+		Err:        err,
+	}
+}
+
+// Error implements the error interface
+func (e *MetadataPolicyMergeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *MetadataPolicyMergeError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode implements the MetadataPolicyError interface
+func (*MetadataPolicyMergeError) ErrorCode() string {
+	return InvalidTrustChain
+}
+
+// MetadataPolicyCombinationError is returned if the policy operators of a
+// (merged) MetadataPolicyEntry cannot be combined, either because of the
+// PolicyOperator.MayCombineWith rules or because a PolicyVerifier failed
+type MetadataPolicyCombinationError struct {
+	EntityType string
+	Claim      string
+	// Operator is the PolicyOperatorName that cannot be combined with the
+	// ConflictingOperators; it is empty if the error was raised by a
+	// PolicyVerifier
+	Operator             PolicyOperatorName
+	ConflictingOperators []PolicyOperatorName
+	// Entry is the offending MetadataPolicyEntry
+	Entry MetadataPolicyEntry
+	// Issuers are the entities whose metadata policies contain operators
+	// for this claim; only set if the error results from evaluating a
+	// TrustChain
+	Issuers []string
+	Err     error
+}
+
+func newMetadataPolicyCombinationError(
+	pathInfo string, operator PolicyOperatorName, conflicting []PolicyOperatorName, entry MetadataPolicyEntry,
+	err error,
+) error {
+	entityType, claim := splitPolicyPath(pathInfo)
+	return &MetadataPolicyCombinationError{
+		EntityType:           entityType,
+		Claim:                claim,
+		Operator:             operator,
+		ConflictingOperators: conflicting,
+		Entry:                entry,
+		Err:                  err,
+	}
+}
+
+// Error implements the error interface
+func (e *MetadataPolicyCombinationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *MetadataPolicyCombinationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode implements the MetadataPolicyError interface
+func (*MetadataPolicyCombinationError) ErrorCode() string {
+	return InvalidTrustChain
+}
+
+// MetadataPolicyTypeError is returned if the value of a policy operator is not
+// compatible with the type of the claim it is used for, e.g. a 'subset_of'
+// operator on a string claim or a 'value' of the wrong type
+type MetadataPolicyTypeError struct {
+	EntityType string
+	Claim      string
+	Operator   PolicyOperatorName
+	// ClaimType is the type of the claim as seen by metadata policies
+	ClaimType reflect.Type
+	// PolicyValue is the offending operator value
+	PolicyValue any
+	// Issuers are the entities whose metadata policies contain the operator;
+	// only set if the error results from evaluating a TrustChain
+	Issuers []string
+	Err     error
+}
+
+func newMetadataPolicyTypeError(
+	entityType, claim string, operator PolicyOperatorName, claimType reflect.Type, policyValue any, err error,
+) error {
+	return &MetadataPolicyTypeError{
+		EntityType:  entityType,
+		Claim:       claim,
+		Operator:    operator,
+		ClaimType:   claimType,
+		PolicyValue: policyValue,
+		Err: errors.Wrapf(
+			err, "invalid metadata policy operator '%s' for '%s.%s'", operator, entityType, claim,
+		),
+	}
+}
+
+// Error implements the error interface
+func (e *MetadataPolicyTypeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *MetadataPolicyTypeError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode implements the MetadataPolicyError interface
+func (*MetadataPolicyTypeError) ErrorCode() string {
+	return InvalidTrustChain
+}
+
+// MetadataPolicyApplyError is returned if a (merged) metadata policy
+// cannot be applied to an entity's metadata, e.g. because an essential claim
+// is missing or a value is not allowed by a 'one_of' operator
+type MetadataPolicyApplyError struct {
+	EntityType string
+	Claim      string
+	Operator   PolicyOperatorName
+	// Value is the claim value the operator was applied to
+	Value any
+	// PolicyValue is the operator value
+	PolicyValue any
+	// EssentialValueMissing indicates that the claim is essential but not
+	// set in the metadata
+	EssentialValueMissing bool
+	// Subject is the entity the metadata belongs to; only set if the error
+	// results from evaluating a TrustChain
+	Subject string
+	// Issuers are the entities whose metadata policies contain the failing
+	// operator; only set if the error results from evaluating a TrustChain
+	Issuers []string
+	Err     error
+}
+
+func newMetadataPolicyApplyError(
+	pathInfo string, operator PolicyOperatorName, value, policyValue any, essentialMissing bool, err error,
+) error {
+	entityType, claim := splitPolicyPath(pathInfo)
+	return &MetadataPolicyApplyError{
+		EntityType:            entityType,
+		Claim:                 claim,
+		Operator:              operator,
+		Value:                 value,
+		PolicyValue:           policyValue,
+		EssentialValueMissing: essentialMissing,
+		Err:                   err,
+	}
+}
+
+// Error implements the error interface
+func (e *MetadataPolicyApplyError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *MetadataPolicyApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode implements the MetadataPolicyError interface
+func (*MetadataPolicyApplyError) ErrorCode() string {
+	return InvalidMetadata
+}
+
+// MetadataPolicyCritError is returned if a trust chain marks metadata policy
+// operators as critical that are not supported
+type MetadataPolicyCritError struct {
+	Operators []PolicyOperatorName
+	// Issuers are the entities whose statements list the unsupported
+	// operators in 'metadata_policy_crit'
+	Issuers []string
+}
+
+// Error implements the error interface
+func (e *MetadataPolicyCritError) Error() string {
+	return errors.Errorf(
+		"the following metadata policy operators are critical but not understood: %v", e.Operators,
+	).Error()
+}
+
+// ErrorCode implements the MetadataPolicyError interface
+func (*MetadataPolicyCritError) ErrorCode() string {
+	return InvalidTrustChain
+}
+
+// annotateMetadataPolicyError adds information about the statements in the
+// TrustChain to a MetadataPolicyError
+func (c TrustChain) annotateMetadataPolicyError(err error) {
+	contributors := c.metadataPolicyContributors()
+	var mergeErr *MetadataPolicyMergeError
+	if errors.As(err, &mergeErr) {
+		mergeErr.Issuers = contributors[mergeErr.EntityType][mergeErr.Claim][mergeErr.Operator]
+	}
+	var combinationErr *MetadataPolicyCombinationError
+	if errors.As(err, &combinationErr) {
+		combinationErr.Issuers = nil
+		for _, issuers := range contributors[combinationErr.EntityType][combinationErr.Claim] {
+			for _, iss := range issuers {
+				if !utils.SliceContains(iss, combinationErr.Issuers) {
+					combinationErr.Issuers = append(combinationErr.Issuers, iss)
+				}
+			}
+		}
+		slices.Sort(combinationErr.Issuers)
+	}
+	var typeErr *MetadataPolicyTypeError
+	if errors.As(err, &typeErr) {
+		typeErr.Issuers = contributors[typeErr.EntityType][typeErr.Claim][typeErr.Operator]
+	}
+	var applyErr *MetadataPolicyApplyError
+	if errors.As(err, &applyErr) {
+		applyErr.Issuers = contributors[applyErr.EntityType][applyErr.Claim][applyErr.Operator]
+		if len(c) > 0 {
+			applyErr.Subject = c[0].Subject
+		}
+	}
+}
+
+// ErrorFromMetadataPolicyError returns an Error for a MetadataPolicyError
+// contained in err, using its ErrorCode; if err does not contain a
+// MetadataPolicyError false is returned
+func ErrorFromMetadataPolicyError(err error) (Error, bool) {
+	var policyErr MetadataPolicyError
+	if !errors.As(err, &policyErr) {
+		return Error{}, false
+	}
+	return Error{
+		Error:            policyErr.ErrorCode(),
+		ErrorDescription: err.Error(),
+	}, true
+}
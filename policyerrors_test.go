@@ -0,0 +1,95 @@
+package oidfed
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestMetadataPolicyErrors(t *testing.T) {
+	t.Run(
+		"merge", func(t *testing.T) {
+			_, err := combineMetadataPolicy(
+				MetadataPolicy{"client_name": {PolicyOperatorValue: "a"}},
+				MetadataPolicy{"client_name": {PolicyOperatorValue: "b"}},
+				"openid_relying_party",
+			)
+			var mergeErr *MetadataPolicyMergeError
+			if !errors.As(err, &mergeErr) {
+				t.Fatalf("expected MetadataPolicyMergeError, got: %v", err)
+			}
+			if mergeErr.EntityType != "openid_relying_party" || mergeErr.Claim != "client_name" {
+				t.Errorf("unexpected path '%s.%s'", mergeErr.EntityType, mergeErr.Claim)
+			}
+			if mergeErr.Operator != PolicyOperatorValue {
+				t.Errorf("operator is '%s', but '%s' expected", mergeErr.Operator, PolicyOperatorValue)
+			}
+			if !reflect.DeepEqual(mergeErr.Values, []any{"a", "b"}) {
+				t.Errorf("unexpected conflicting values: %v", mergeErr.Values)
+			}
+			if mergeErr.ErrorCode() != InvalidTrustChain {
+				t.Errorf("error code is '%s', but '%s' expected", mergeErr.ErrorCode(), InvalidTrustChain)
+			}
+		},
+	)
+	t.Run(
+		"combination", func(t *testing.T) {
+			err := MetadataPolicyEntry{
+				PolicyOperatorAdd:   []any{"a"},
+				PolicyOperatorOneOf: []any{"b"},
+			}.Verify("openid_relying_party.contacts")
+			var combinationErr *MetadataPolicyCombinationError
+			if !errors.As(err, &combinationErr) {
+				t.Fatalf("expected MetadataPolicyCombinationError, got: %v", err)
+			}
+			if combinationErr.Claim != "contacts" {
+				t.Errorf("claim is '%s', but 'contacts' expected", combinationErr.Claim)
+			}
+			if len(combinationErr.ConflictingOperators) == 0 {
+				t.Errorf("no conflicting operators set")
+			}
+		},
+	)
+	t.Run(
+		"apply essential missing", func(t *testing.T) {
+			_, err := MetadataPolicyEntry{
+				PolicyOperatorEssential: true,
+				PolicyOperatorOneOf:     []any{"a", "b"},
+			}.ApplyTo(nil, false, "openid_relying_party.client_name")
+			var applyErr *MetadataPolicyApplyError
+			if !errors.As(err, &applyErr) {
+				t.Fatalf("expected MetadataPolicyApplyError, got: %v", err)
+			}
+			if !applyErr.EssentialValueMissing {
+				t.Errorf("EssentialValueMissing not set")
+			}
+			e, ok := ErrorFromMetadataPolicyError(err)
+			if !ok {
+				t.Fatalf("ErrorFromMetadataPolicyError did not find policy error")
+			}
+			if e.Error != InvalidMetadata {
+				t.Errorf("error code is '%s', but '%s' expected", e.Error, InvalidMetadata)
+			}
+		},
+	)
+	t.Run(
+		"crit", func(t *testing.T) {
+			_, err := chainRPIA2TA2WithRemoveCrit.combinedMetadataPolicy(defaultPolicyOperatorRegistry)
+			var critErr *MetadataPolicyCritError
+			if !errors.As(err, &critErr) {
+				t.Fatalf("expected MetadataPolicyCritError, got: %v", err)
+			}
+			if !reflect.DeepEqual(critErr.Issuers, []string{ta2WithRemoveCrit.EntityID}) {
+				t.Errorf("issuers are %v, but %v expected", critErr.Issuers, []string{ta2WithRemoveCrit.EntityID})
+			}
+		},
+	)
+	t.Run(
+		"no policy error", func(t *testing.T) {
+			if _, ok := ErrorFromMetadataPolicyError(errors.New("other")); ok {
+				t.Errorf("unexpected policy error")
+			}
+		},
+	)
+}
@@ -0,0 +1,228 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal/utils"
+)
+
+// PolicyLintSeverity is the severity of a PolicyLintFinding
+type PolicyLintSeverity string
+
+// Constants for PolicyLintSeverity
+const (
+	// PolicyLintSeverityError is used for findings that result in an error
+	// when the metadata policy is used in a trust chain
+	PolicyLintSeverityError PolicyLintSeverity = "error"
+	// PolicyLintSeverityWarning is used for findings that are likely a
+	// mistake, but do not result in an error
+	PolicyLintSeverityWarning PolicyLintSeverity = "warning"
+)
+
+// PolicyLintFinding is a single problem found by LintMetadataPolicies
+type PolicyLintFinding struct {
+	Severity   PolicyLintSeverity `json:"severity"`
+	EntityType string             `json:"entity_type"`
+	Claim      string             `json:"claim,omitempty"`
+	Operator   PolicyOperatorName `json:"operator,omitempty"`
+	Message    string             `json:"message"`
+}
+
+// String implements the fmt.Stringer interface
+func (f PolicyLintFinding) String() string {
+	path := f.EntityType
+	if f.Claim != "" {
+		path += "." + f.Claim
+	}
+	if f.Operator != "" {
+		path += "." + string(f.Operator)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Severity, path, f.Message)
+}
+
+// PolicyLintFindings is a slice of PolicyLintFinding
+type PolicyLintFindings []PolicyLintFinding
+
+// HasErrors checks if the PolicyLintFindings contain a finding with
+// PolicyLintSeverityError
+func (fs PolicyLintFindings) HasErrors() bool {
+	for _, f := range fs {
+		if f.Severity == PolicyLintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// LintMetadataPolicies checks MetadataPolicies before they are published.
+// superiors are the MetadataPolicies of the superiors of the publishing
+// entity, starting with the immediate superior and ending with the trust
+// anchor; they are optional.
+//
+// The following checks are done:
+//   - each MetadataPolicyEntry is verified, i.e. the operator combinations
+//     and all registered PolicyVerifier are checked
+//   - each MetadataPolicyEntry is merged with the superiors' entries
+//   - unknown policy operators
+//   - dead operators, i.e. operators that can never have an effect
+//   - unknown claims for the entity type
+//   - operator values whose type does not match the type of the claim; such
+//     policies are rejected when metadata policies are merged
+//
+// The PolicyOperator of the DefaultPolicyEngine are used, see
+// PolicyEngine.LintMetadataPolicies to use a different PolicyEngine.
+func LintMetadataPolicies(policies *MetadataPolicies, superiors ...*MetadataPolicies) PolicyLintFindings {
+	return DefaultPolicyEngine.policyOperatorRegistry().lintMetadataPolicies(policies, superiors)
+}
+
+func (r *policyOperatorRegistry) lintMetadataPolicies(
+	policies *MetadataPolicies, superiors []*MetadataPolicies,
+) PolicyLintFindings {
+	findings := PolicyLintFindings{}
+	if policies == nil {
+		return findings
+	}
+	superiorPolicies := make([]map[string]MetadataPolicy, 0, len(superiors))
+	for i := len(superiors) - 1; i >= 0; i-- {
+		if superiors[i] != nil {
+			superiorPolicies = append(superiorPolicies, superiors[i].entityTypePolicies())
+		}
+	}
+	entityTypes := policies.entityTypePolicies()
+	for _, entityType := range sortedKeys(entityTypes) {
+		policy := entityTypes[entityType]
+		claimTypes, knownEntityType := metadataClaimTypes(entityType)
+		for _, claim := range sortedKeys(policy) {
+			entry := policy[claim]
+			add := func(severity PolicyLintSeverity, operator PolicyOperatorName, format string, args ...any) {
+				findings = append(
+					findings, PolicyLintFinding{
+						Severity:   severity,
+						EntityType: entityType,
+						Claim:      claim,
+						Operator:   operator,
+						Message:    fmt.Sprintf(format, args...),
+					},
+				)
+			}
+			pathInfo := fmt.Sprintf("%s.%s", entityType, claim)
+
+			for _, op := range sortedKeys(entry) {
+				if _, ok := r.operator(op); !ok {
+					add(PolicyLintSeverityWarning, op, "unknown policy operator")
+				}
+			}
+			if err := r.verifyEntry(entry, pathInfo); err != nil {
+				add(PolicyLintSeverityError, "", "%s", err.Error())
+			}
+
+			effective := entry
+			superiorEntries := make([]MetadataPolicy, 0, len(superiorPolicies)+1)
+			for _, sp := range superiorPolicies {
+				if e, ok := sp[entityType][claim]; ok {
+					superiorEntries = append(superiorEntries, MetadataPolicy{claim: e})
+				}
+			}
+			if len(superiorEntries) > 0 {
+				merged, err := r.combineMetadataPolicies(
+					entityType, append(superiorEntries, MetadataPolicy{claim: entry})...,
+				)
+				if err != nil {
+					var operator PolicyOperatorName
+					var mergeErr *MetadataPolicyMergeError
+					if errors.As(err, &mergeErr) {
+						operator = mergeErr.Operator
+					}
+					add(PolicyLintSeverityError, operator, "conflicts with superior policies: %s", err.Error())
+					effective = nil
+				} else {
+					effective = merged[claim]
+				}
+			}
+			for _, op := range deadPolicyOperators(effective) {
+				if _, own := entry[op]; own {
+					add(PolicyLintSeverityWarning, op, "operator has no effect")
+				}
+			}
+
+			if !knownEntityType {
+				continue
+			}
+			claimType, knownClaim := claimTypes[claim]
+			if !knownClaim {
+				add(PolicyLintSeverityWarning, "", "unknown claim for entity type '%s'", entityType)
+				continue
+			}
+			for _, op := range sortedKeys(entry) {
+				if err := r.checkPolicyOperatorValueType(
+					op, entry[op], claimType,
+				); err != nil {
+					add(PolicyLintSeverityError, op, "%s", err.Error())
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// deadPolicyOperators returns the operators of a (merged) MetadataPolicyEntry
+// that can never have an effect
+func deadPolicyOperators(entry MetadataPolicyEntry) (dead []PolicyOperatorName) {
+	if entry == nil {
+		return nil
+	}
+	if _, valueSet := entry[PolicyOperatorValue]; valueSet {
+		// 'value' always sets the claim, the checks are already done by the
+		// policy verifiers and a default is never used
+		for _, op := range []PolicyOperatorName{
+			PolicyOperatorDefault,
+			PolicyOperatorAdd,
+			PolicyOperatorOneOf,
+			PolicyOperatorSubsetOf,
+			PolicyOperatorSupersetOf,
+		} {
+			if _, ok := entry[op]; ok {
+				dead = append(dead, op)
+			}
+		}
+	}
+	if essential, ok := entry[PolicyOperatorEssential]; ok {
+		if b, isBool := essential.(bool); isBool && !b {
+			dead = append(dead, PolicyOperatorEssential)
+		}
+	}
+	for _, op := range []PolicyOperatorName{PolicyOperatorAdd, PolicyOperatorSupersetOf} {
+		if v, ok := entry[op]; ok && !utils.SliceContains(op, dead) && isEmptySlice(v) {
+			dead = append(dead, op)
+		}
+	}
+	return
+}
+
+func isEmptySlice(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Slice && rv.Len() == 0
+}
+
+// decodesAs checks if a value can be json decoded into the passed type
+func decodesAs(value any, t reflect.Type) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(json.Unmarshal(data, reflect.New(t).Interface()))
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := utils.MapKeys(m)
+	slices.Sort(keys)
+	return keys
+}
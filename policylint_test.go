@@ -0,0 +1,160 @@
+package oidfed
+
+import (
+	"testing"
+)
+
+func TestLintMetadataPolicies(t *testing.T) {
+	type expectedFinding struct {
+		severity PolicyLintSeverity
+		claim    string
+		operator PolicyOperatorName
+	}
+	tests := []struct {
+		name      string
+		policies  *MetadataPolicies
+		superiors []*MetadataPolicies
+		expected  []expectedFinding
+	}{
+		{
+			name:     "nil",
+			policies: nil,
+			expected: nil,
+		},
+		{
+			name: "valid",
+			policies: &MetadataPolicies{
+				RelyingParty: MetadataPolicy{
+					"contacts":    {PolicyOperatorAdd: []any{"ops@example.org"}},
+					"scope":       {PolicyOperatorSubsetOf: []any{"openid", "profile"}},
+					"client_name": {PolicyOperatorEssential: true},
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "invalid combination",
+			policies: &MetadataPolicies{
+				RelyingParty: MetadataPolicy{
+					"grant_types": {
+						PolicyOperatorOneOf:    []any{"authorization_code"},
+						PolicyOperatorSubsetOf: []any{"authorization_code"},
+					},
+				},
+			},
+			expected: []expectedFinding{
+				{PolicyLintSeverityError, "grant_types", ""},
+				{PolicyLintSeverityError, "grant_types", PolicyOperatorOneOf},
+			},
+		},
+		{
+			name: "conflict with superior",
+			policies: &MetadataPolicies{
+				RelyingParty: MetadataPolicy{
+					"client_name": {PolicyOperatorValue: "a"},
+				},
+			},
+			superiors: []*MetadataPolicies{
+				nil,
+				{
+					RelyingParty: MetadataPolicy{
+						"client_name": {PolicyOperatorValue: "b"},
+					},
+				},
+			},
+			expected: []expectedFinding{
+				{PolicyLintSeverityError, "client_name", PolicyOperatorValue},
+			},
+		},
+		{
+			name: "dead operators",
+			policies: &MetadataPolicies{
+				RelyingParty: MetadataPolicy{
+					"client_name": {PolicyOperatorDefault: "a"},
+					"logo_uri":    {PolicyOperatorEssential: false},
+				},
+			},
+			superiors: []*MetadataPolicies{
+				{
+					RelyingParty: MetadataPolicy{
+						"client_name": {PolicyOperatorValue: "a"},
+					},
+				},
+			},
+			expected: []expectedFinding{
+				{PolicyLintSeverityWarning, "client_name", PolicyOperatorDefault},
+				{PolicyLintSeverityWarning, "logo_uri", PolicyOperatorEssential},
+			},
+		},
+		{
+			name: "unknown claim and operator",
+			policies: &MetadataPolicies{
+				OpenIDProvider: MetadataPolicy{
+					"not_a_claim": {"not_an_operator": "x"},
+				},
+				Extra: map[string]MetadataPolicy{
+					"custom_entity": {"custom_claim": {PolicyOperatorValue: 1}},
+				},
+			},
+			expected: []expectedFinding{
+				{PolicyLintSeverityWarning, "not_a_claim", "not_an_operator"},
+				{PolicyLintSeverityWarning, "not_a_claim", ""},
+			},
+		},
+		{
+			name: "type mismatches",
+			policies: &MetadataPolicies{
+				RelyingParty: MetadataPolicy{
+					"client_name":                  {PolicyOperatorValue: 5},
+					"contacts":                     {PolicyOperatorOneOf: []any{"a"}},
+					"logo_uri":                     {PolicyOperatorSubsetOf: []any{"a"}},
+					"response_types":               {PolicyOperatorSupersetOf: []any{1}},
+					"client_uri":                   {PolicyOperatorEssential: "yes"},
+					"redirect_uris":                {PolicyOperatorAdd: "https://rp.example.org/redirect"},
+					"id_token_signed_response_alg": {PolicyOperatorOneOf: []any{"ES256", "RS256"}},
+				},
+			},
+			expected: []expectedFinding{
+				{PolicyLintSeverityError, "client_name", PolicyOperatorValue},
+				{PolicyLintSeverityError, "client_uri", PolicyOperatorEssential},
+				{PolicyLintSeverityError, "contacts", PolicyOperatorOneOf},
+				{PolicyLintSeverityError, "logo_uri", PolicyOperatorSubsetOf},
+				{PolicyLintSeverityError, "response_types", PolicyOperatorSupersetOf},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				findings := LintMetadataPolicies(test.policies, test.superiors...)
+				if len(findings) != len(test.expected) {
+					t.Fatalf("expected %d findings, but got: %v", len(test.expected), findings)
+				}
+				for i, e := range test.expected {
+					f := findings[i]
+					if f.Severity != e.severity || f.Claim != e.claim || f.Operator != e.operator {
+						t.Errorf("finding %d is '%s', but expected %+v", i, f, e)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestPolicyEngine_LintMetadataPolicies(t *testing.T) {
+	engine := NewPolicyEngine()
+	if err := engine.RegisterPolicyOperator(policyOperatorUpper); err != nil {
+		t.Fatal(err)
+	}
+	policies := &MetadataPolicies{
+		RelyingParty: MetadataPolicy{"client_name": {"upper": true}},
+	}
+	if findings := engine.LintMetadataPolicies(policies); len(findings) != 0 {
+		t.Errorf("expected no findings for the PolicyEngine, but got: %v", findings)
+	}
+	// the operator is not registered with the DefaultPolicyEngine
+	findings := LintMetadataPolicies(policies)
+	if len(findings) != 1 || findings[0].Operator != "upper" || findings[0].Severity != PolicyLintSeverityWarning {
+		t.Errorf("expected unknown operator warning for the DefaultPolicyEngine, but got: %v", findings)
+	}
+}
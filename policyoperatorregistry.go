@@ -0,0 +1,362 @@
+package oidfed
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal"
+)
+
+// PolicyOperatorOrdering declares where a PolicyOperator is applied relative
+// to other PolicyOperator.
+// If neither After nor Before is set, the operator is applied after all
+// standard operators except 'essential' and before 'essential'.
+// Referenced operators that are not registered are ignored.
+type PolicyOperatorOrdering struct {
+	// After lists the operators that must be applied before this operator
+	After []PolicyOperatorName
+	// Before lists the operators that must be applied after this operator
+	Before []PolicyOperatorName
+}
+
+// defaultPolicyOperatorOrdering is used for operators registered without an
+// explicit PolicyOperatorOrdering
+var defaultPolicyOperatorOrdering = PolicyOperatorOrdering{
+	After: []PolicyOperatorName{
+		PolicyOperatorValue,
+		PolicyOperatorAdd,
+		PolicyOperatorDefault,
+		PolicyOperatorOneOf,
+		PolicyOperatorSubsetOf,
+		PolicyOperatorSupersetOf,
+	},
+	Before: []PolicyOperatorName{PolicyOperatorEssential},
+}
+
+// policyOperatorRegistry holds registered PolicyOperator and PolicyVerifier
+// and the order in which the operators are applied; it is safe for concurrent
+// use
+type policyOperatorRegistry struct {
+	mutex     sync.RWMutex
+	operators map[PolicyOperatorName]PolicyOperator
+	orderings map[PolicyOperatorName]PolicyOperatorOrdering
+	builtin   map[PolicyOperatorName]bool
+	// registered holds the operator names in registration order; it is used
+	// as a tiebreaker when computing the order
+	registered []PolicyOperatorName
+	order      []PolicyOperatorName
+	verifiers  []PolicyVerifier
+}
+
+func newPolicyOperatorRegistry() *policyOperatorRegistry {
+	return &policyOperatorRegistry{
+		operators: make(map[PolicyOperatorName]PolicyOperator),
+		orderings: make(map[PolicyOperatorName]PolicyOperatorOrdering),
+		builtin:   make(map[PolicyOperatorName]bool),
+	}
+}
+
+// newBuiltinPolicyOperatorRegistry returns a new policyOperatorRegistry with
+// the built-in PolicyOperator and PolicyVerifier registered
+func newBuiltinPolicyOperatorRegistry() *policyOperatorRegistry {
+	r := newPolicyOperatorRegistry()
+	r.registerBuiltin(
+		policyOperatorValue,
+		policyOperatorAdd,
+		policyOperatorDefault,
+		policyOperatorOneOf,
+		policyOperatorSubsetOf,
+		policyOperatorSupersetOf,
+		policyOperatorEssential,
+	)
+	for _, v := range builtinPolicyVerifiers {
+		r.registerVerifier(v)
+	}
+	return r
+}
+
+var defaultPolicyOperatorRegistry = newBuiltinPolicyOperatorRegistry()
+
+// initialOperatorOrder is the value OperatorOrder is initialized with; if
+// OperatorOrder differs from it, it was modified
+var initialOperatorOrder []PolicyOperatorName
+
+// operatorOrderModifiedWarning is used to log only once that the deprecated
+// OperatorOrder was modified
+var operatorOrderModifiedWarning sync.Once
+
+func init() {
+	OperatorOrder = defaultPolicyOperatorRegistry.operatorOrder()
+	initialOperatorOrder = slices.Clone(OperatorOrder)
+}
+
+// register registers a PolicyOperator; built-in operators cannot be replaced
+// unless replaceBuiltin is set
+func (r *policyOperatorRegistry) register(
+	operator PolicyOperator, ordering *PolicyOperatorOrdering, replaceBuiltin bool,
+) error {
+	if operator == nil {
+		return errors.New("cannot register nil policy operator")
+	}
+	name := operator.Name()
+	if name == "" {
+		return errors.New("cannot register policy operator without a name")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, exists := r.operators[name]
+	if r.builtin[name] {
+		if !replaceBuiltin {
+			return errors.Errorf("cannot override built-in policy operator '%s'", name)
+		}
+		r.operators[name] = operator
+		return nil
+	}
+	if replaceBuiltin {
+		return errors.Errorf("'%s' is not a built-in policy operator", name)
+	}
+	o := defaultPolicyOperatorOrdering
+	if ordering != nil {
+		o = *ordering
+	}
+	if slices.Contains(o.After, name) || slices.Contains(o.Before, name) {
+		return errors.Errorf("policy operator '%s' cannot be ordered relative to itself", name)
+	}
+	oldOrdering, hadOrdering := r.orderings[name]
+	r.orderings[name] = o
+	registered := r.registered
+	if !exists {
+		registered = append(slices.Clone(r.registered), name)
+	}
+	order, err := computePolicyOperatorOrder(registered, r.orderings)
+	if err != nil {
+		if hadOrdering {
+			r.orderings[name] = oldOrdering
+		} else {
+			delete(r.orderings, name)
+		}
+		return err
+	}
+	r.operators[name] = operator
+	r.registered = registered
+	r.order = order
+	return nil
+}
+
+// registerBuiltin registers the built-in operators in the passed order
+func (r *policyOperatorRegistry) registerBuiltin(ops ...PolicyOperator) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, op := range ops {
+		name := op.Name()
+		var ordering PolicyOperatorOrdering
+		if i > 0 {
+			ordering.After = []PolicyOperatorName{ops[i-1].Name()}
+		}
+		r.operators[name] = op
+		r.orderings[name] = ordering
+		r.builtin[name] = true
+		r.registered = append(r.registered, name)
+	}
+	order, err := computePolicyOperatorOrder(r.registered, r.orderings)
+	if err != nil {
+		panic(err)
+	}
+	r.order = order
+}
+
+func (r *policyOperatorRegistry) registerVerifier(v PolicyVerifier) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.verifiers = append(r.verifiers, v)
+}
+
+func (r *policyOperatorRegistry) operator(name PolicyOperatorName) (PolicyOperator, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	op, ok := r.operators[name]
+	return op, ok
+}
+
+// mayCombineWith returns the PolicyOperatorName with which the passed
+// PolicyOperator may be combined: the ones from its MayCombineWith and the
+// custom operators that list it in their MayCombineWith. This allows custom
+// operators to declare that they can be combined with built-in operators.
+// A nil result means that the operator can be combined with all operators.
+func (r *policyOperatorRegistry) mayCombineWith(op PolicyOperator) []PolicyOperatorName {
+	mayCombine := op.MayCombineWith()
+	if mayCombine == nil {
+		return nil
+	}
+	mayCombine = slices.Clone(mayCombine)
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, name := range r.registered {
+		if r.builtin[name] || name == op.Name() || slices.Contains(mayCombine, name) {
+			continue
+		}
+		if slices.Contains(r.operators[name].MayCombineWith(), op.Name()) {
+			mayCombine = append(mayCombine, name)
+		}
+	}
+	return mayCombine
+}
+
+// operatorOrder returns the order in which the operators are applied; for
+// the defaultPolicyOperatorRegistry a modified OperatorOrder is honoured, see
+// mergeOperatorOrder
+func (r *policyOperatorRegistry) operatorOrder() []PolicyOperatorName {
+	r.mutex.RLock()
+	order := slices.Clone(r.order)
+	r.mutex.RUnlock()
+	if r == defaultPolicyOperatorRegistry && !slices.Equal(OperatorOrder, initialOperatorOrder) {
+		operatorOrderModifiedWarning.Do(
+			func() {
+				internal.Log(
+					"OperatorOrder was modified; modifying it is deprecated, " +
+						"use RegisterPolicyOperatorWithOrdering to position custom policy operators",
+				)
+			},
+		)
+		order = mergeOperatorOrder(order, OperatorOrder)
+	}
+	return order
+}
+
+// mergeOperatorOrder rearranges the computed order so that the operators
+// listed in the legacy order are applied in that order; operators that are
+// not listed are placed directly after the operator that precedes them in the
+// computed order. Listed operators that are not registered are ignored.
+func mergeOperatorOrder(computed, legacy []PolicyOperatorName) []PolicyOperatorName {
+	order := make([]PolicyOperatorName, 0, len(computed))
+	for _, name := range legacy {
+		if slices.Contains(computed, name) && !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+	for i, name := range computed {
+		if slices.Contains(order, name) {
+			continue
+		}
+		pos := 0
+		if i > 0 {
+			pos = slices.Index(order, computed[i-1]) + 1
+		}
+		order = slices.Insert(order, pos, name)
+	}
+	return order
+}
+
+func (r *policyOperatorRegistry) policyVerifiers() []PolicyVerifier {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return slices.Clone(r.verifiers)
+}
+
+// computePolicyOperatorOrder computes an order of the registered operators
+// that satisfies all orderings; if multiple operators could be placed next,
+// the one registered first is used
+func computePolicyOperatorOrder(
+	registered []PolicyOperatorName, orderings map[PolicyOperatorName]PolicyOperatorOrdering,
+) ([]PolicyOperatorName, error) {
+	isRegistered := make(map[PolicyOperatorName]bool, len(registered))
+	for _, name := range registered {
+		isRegistered[name] = true
+	}
+	// predecessors[x] holds the operators that must be applied before x
+	predecessors := make(map[PolicyOperatorName]map[PolicyOperatorName]struct{}, len(registered))
+	for _, name := range registered {
+		predecessors[name] = make(map[PolicyOperatorName]struct{})
+	}
+	for _, name := range registered {
+		o := orderings[name]
+		for _, a := range o.After {
+			if isRegistered[a] {
+				predecessors[name][a] = struct{}{}
+			}
+		}
+		for _, b := range o.Before {
+			if isRegistered[b] {
+				predecessors[b][name] = struct{}{}
+			}
+		}
+	}
+	order := make([]PolicyOperatorName, 0, len(registered))
+	placed := make(map[PolicyOperatorName]bool, len(registered))
+	for len(order) < len(registered) {
+		progress := false
+		for _, name := range registered {
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for p := range predecessors[name] {
+				if !placed[p] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order = append(order, name)
+				placed[name] = true
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			var cyclic []string
+			for _, name := range registered {
+				if !placed[name] {
+					cyclic = append(cyclic, string(name))
+				}
+			}
+			return nil, errors.Errorf(
+				"policy operator ordering contains a cycle between: %s", strings.Join(cyclic, ", "),
+			)
+		}
+	}
+	return order, nil
+}
+
+// PolicyOperatorOrder returns the order in which the registered
+// PolicyOperator are applied
+func PolicyOperatorOrder() []PolicyOperatorName {
+	return defaultPolicyOperatorRegistry.operatorOrder()
+}
+
+// RegisterPolicyOperator registers a new PolicyOperator and therefore makes
+// it available to be used; the operator is applied after all standard
+// operators except 'essential'.
+// Errors, e.g. when trying to register an operator with the name of a
+// built-in operator, are only logged; use RegisterPolicyOperatorWithOrdering
+// to obtain them.
+func RegisterPolicyOperator(operator PolicyOperator) {
+	if err := RegisterPolicyOperatorWithOrdering(operator); err != nil {
+		internal.Log(err)
+	}
+}
+
+// RegisterPolicyOperatorWithOrdering registers a new PolicyOperator and
+// therefore makes it available to be used.
+// An optional PolicyOperatorOrdering declares where the operator is applied;
+// the resulting order is computed from all registered operators and
+// validated. Registering an operator with the name of a built-in operator
+// returns an error, use ReplaceBuiltinPolicyOperator to deliberately replace
+// a built-in operator. Registering an operator with the name of an already
+// registered custom operator replaces it.
+// The PolicyOperatorName listed in the operator's MayCombineWith may also be
+// combined with the operator, even if they are built-in operators that do not
+// list it themselves.
+// RegisterPolicyOperatorWithOrdering is safe for concurrent use.
+func RegisterPolicyOperatorWithOrdering(operator PolicyOperator, ordering ...PolicyOperatorOrdering) error {
+	return DefaultPolicyEngine.RegisterPolicyOperator(operator, ordering...)
+}
+
+// ReplaceBuiltinPolicyOperator replaces the implementation of a built-in
+// PolicyOperator; the operator keeps its position in the order
+func ReplaceBuiltinPolicyOperator(operator PolicyOperator) error {
+	return DefaultPolicyEngine.ReplaceBuiltinPolicyOperator(operator)
+}
@@ -0,0 +1,300 @@
+package oidfed
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func newTestPolicyOperator(name PolicyOperatorName) PolicyOperator {
+	return NewPolicyOperator(
+		name,
+		func(a, _ any, _ string) (any, error) { return a, nil },
+		func(value any, valueSet bool, _ any, _ bool, _ string) (any, bool, error) {
+			return value, valueSet, nil
+		},
+		nil,
+	)
+}
+
+func TestPolicyOperatorRegistry_Order(t *testing.T) {
+	type registration struct {
+		name     PolicyOperatorName
+		ordering *PolicyOperatorOrdering
+	}
+	tests := []struct {
+		name          string
+		registrations []registration
+		expectedOrder []PolicyOperatorName
+		errExpected   bool
+	}{
+		{
+			name: "builtin",
+			expectedOrder: []PolicyOperatorName{
+				PolicyOperatorValue, PolicyOperatorAdd, PolicyOperatorDefault, PolicyOperatorOneOf,
+				PolicyOperatorSubsetOf, PolicyOperatorSupersetOf, PolicyOperatorEssential,
+			},
+		},
+		{
+			name:          "default ordering",
+			registrations: []registration{{name: "custom"}},
+			expectedOrder: []PolicyOperatorName{
+				PolicyOperatorValue, PolicyOperatorAdd, PolicyOperatorDefault, PolicyOperatorOneOf,
+				PolicyOperatorSubsetOf, PolicyOperatorSupersetOf, "custom", PolicyOperatorEssential,
+			},
+		},
+		{
+			name: "after value before one_of",
+			registrations: []registration{
+				{
+					name: "custom",
+					ordering: &PolicyOperatorOrdering{
+						After:  []PolicyOperatorName{PolicyOperatorValue},
+						Before: []PolicyOperatorName{PolicyOperatorAdd},
+					},
+				},
+			},
+			expectedOrder: []PolicyOperatorName{
+				PolicyOperatorValue, "custom", PolicyOperatorAdd, PolicyOperatorDefault, PolicyOperatorOneOf,
+				PolicyOperatorSubsetOf, PolicyOperatorSupersetOf, PolicyOperatorEssential,
+			},
+		},
+		{
+			name: "depending on custom operator",
+			registrations: []registration{
+				{name: "first"},
+				{
+					name: "second",
+					ordering: &PolicyOperatorOrdering{
+						After:  []PolicyOperatorName{PolicyOperatorSupersetOf},
+						Before: []PolicyOperatorName{"first"},
+					},
+				},
+			},
+			expectedOrder: []PolicyOperatorName{
+				PolicyOperatorValue, PolicyOperatorAdd, PolicyOperatorDefault, PolicyOperatorOneOf,
+				PolicyOperatorSubsetOf, PolicyOperatorSupersetOf, "second", "first", PolicyOperatorEssential,
+			},
+		},
+		{
+			name: "cycle",
+			registrations: []registration{
+				{
+					name: "custom",
+					ordering: &PolicyOperatorOrdering{
+						After:  []PolicyOperatorName{PolicyOperatorOneOf},
+						Before: []PolicyOperatorName{PolicyOperatorAdd},
+					},
+				},
+			},
+			errExpected: true,
+		},
+		{
+			name: "self reference",
+			registrations: []registration{
+				{
+					name:     "custom",
+					ordering: &PolicyOperatorOrdering{After: []PolicyOperatorName{"custom"}},
+				},
+			},
+			errExpected: true,
+		},
+		{
+			name:          "override builtin",
+			registrations: []registration{{name: PolicyOperatorValue}},
+			errExpected:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				r := newBuiltinPolicyOperatorRegistry()
+				before := r.operatorOrder()
+				var err error
+				for _, reg := range test.registrations {
+					if err = r.register(newTestPolicyOperator(reg.name), reg.ordering, false); err != nil {
+						break
+					}
+				}
+				if err != nil {
+					if !test.errExpected {
+						t.Fatal(err)
+					}
+					if order := r.operatorOrder(); !reflect.DeepEqual(order, before) {
+						t.Errorf("failed registration changed order to %v", order)
+					}
+					return
+				}
+				if test.errExpected {
+					t.Fatalf("expected error, but no error returned")
+				}
+				if order := r.operatorOrder(); !reflect.DeepEqual(order, test.expectedOrder) {
+					t.Errorf("order is %v, but %v expected", order, test.expectedOrder)
+				}
+			},
+		)
+	}
+}
+
+func TestPolicyOperatorRegistry_ReplaceBuiltin(t *testing.T) {
+	r := newBuiltinPolicyOperatorRegistry()
+	type replacementOperator struct {
+		PolicyOperator
+	}
+	if err := r.register(replacementOperator{policyOperatorValue}, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if op, _ := r.operator(PolicyOperatorValue); !isType[replacementOperator](op) {
+		t.Errorf("built-in operator was not replaced")
+	}
+	if err := r.register(newTestPolicyOperator("custom"), nil, true); err == nil {
+		t.Errorf("expected error when replacing a non built-in operator")
+	}
+}
+
+func TestRegisterPolicyOperatorWithOrdering_Builtin(t *testing.T) {
+	if err := RegisterPolicyOperatorWithOrdering(newTestPolicyOperator(PolicyOperatorEssential)); err == nil {
+		t.Errorf("expected error when overriding built-in operator")
+	}
+}
+
+func TestRegisterPolicyOperator(t *testing.T) {
+	const name PolicyOperatorName = "legacy-registered"
+	builtinOrder := slices.Clone(OperatorOrder)
+	RegisterPolicyOperator(newTestPolicyOperator(name))
+	if !slices.Contains(PolicyOperatorOrder(), name) {
+		t.Errorf("operator '%s' not registered", name)
+	}
+	if !slices.Equal(OperatorOrder, builtinOrder) {
+		t.Errorf("OperatorOrder must not be updated after init: %v", OperatorOrder)
+	}
+}
+
+func TestOperatorOrder_Modified(t *testing.T) {
+	const name PolicyOperatorName = "legacy-ordered"
+	// the operator sets the claim to its policy value, so the applied value
+	// shows whether it was applied before or after 'value'
+	RegisterPolicyOperator(
+		NewPolicyOperator(
+			name,
+			func(a, _ any, _ string) (any, error) { return a, nil },
+			func(_ any, _ bool, policyValue any, _ bool, _ string) (any, bool, error) {
+				return policyValue, true, nil
+			},
+			nil,
+		),
+	)
+	builtinOrder := slices.Clone(OperatorOrder)
+	t.Cleanup(
+		func() {
+			OperatorOrder = builtinOrder
+		},
+	)
+	apply := func() string {
+		t.Helper()
+		m, err := DefaultPolicyEngine.ApplyPolicy(
+			Metadata{RelyingParty: &OpenIDRelyingPartyMetadata{ClientName: "rp"}},
+			&MetadataPolicies{
+				RelyingParty: MetadataPolicy{
+					"client_name": {
+						name:                "legacy",
+						PolicyOperatorValue: "value",
+					},
+				},
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m.RelyingParty.ClientName
+	}
+
+	if clientName := apply(); clientName != "legacy" {
+		t.Errorf("expected '%s' to be applied after 'value', but client_name is '%s'", name, clientName)
+	}
+	OperatorOrder = append([]PolicyOperatorName{name}, builtinOrder...)
+	if order := PolicyOperatorOrder(); order[0] != name {
+		t.Errorf("operator placed by modifying OperatorOrder must be applied first: %v", order)
+	}
+	if clientName := apply(); clientName != "value" {
+		t.Errorf("expected '%s' to be applied before 'value', but client_name is '%s'", name, clientName)
+	}
+}
+
+func TestMergeOperatorOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		computed []PolicyOperatorName
+		legacy   []PolicyOperatorName
+		expected []PolicyOperatorName
+	}{
+		{
+			name:     "reordered",
+			computed: []PolicyOperatorName{"a", "b", "c"},
+			legacy:   []PolicyOperatorName{"c", "a", "b"},
+			expected: []PolicyOperatorName{"c", "a", "b"},
+		},
+		{
+			name:     "not listed operator keeps its predecessor",
+			computed: []PolicyOperatorName{"a", "x", "b", "c"},
+			legacy:   []PolicyOperatorName{"b", "a", "c"},
+			expected: []PolicyOperatorName{"b", "a", "x", "c"},
+		},
+		{
+			name:     "not listed first operator",
+			computed: []PolicyOperatorName{"x", "a", "b"},
+			legacy:   []PolicyOperatorName{"b", "a"},
+			expected: []PolicyOperatorName{"x", "b", "a"},
+		},
+		{
+			name:     "unregistered and duplicate operators are ignored",
+			computed: []PolicyOperatorName{"a", "b"},
+			legacy:   []PolicyOperatorName{"b", "unknown", "b", "a"},
+			expected: []PolicyOperatorName{"b", "a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				if order := mergeOperatorOrder(test.computed, test.legacy); !slices.Equal(order, test.expected) {
+					t.Errorf("expected %v, but got %v", test.expected, order)
+				}
+			},
+		)
+	}
+}
+
+func TestPolicyOperatorRegistry_Concurrent(t *testing.T) {
+	r := newBuiltinPolicyOperatorRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := r.register(
+				newTestPolicyOperator(PolicyOperatorName(fmt.Sprintf("custom-%d", i))), nil, false,
+			); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for _, name := range r.operatorOrder() {
+				if _, ok := r.operator(name); !ok {
+					t.Errorf("operator '%s' in order but not registered", name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if l := len(r.operatorOrder()); l != 27 {
+		t.Errorf("expected 27 operators, got %d", l)
+	}
+}
+
+func isType[T any](v any) bool {
+	_, ok := v.(T)
+	return ok
+}
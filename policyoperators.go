@@ -31,24 +31,17 @@ const (
 	PolicyOperatorEssential  PolicyOperatorName = "essential"
 )
 
-// OperatorOrder defines the order in which the PolicyOperator are applied.
-// If custom PolicyOperator are implemented they must be added to this slice at the correct position
-var OperatorOrder = []PolicyOperatorName{
-	PolicyOperatorValue,
-	PolicyOperatorAdd,
-	PolicyOperatorDefault,
-	PolicyOperatorOneOf,
-	PolicyOperatorSubsetOf,
-	PolicyOperatorSupersetOf,
-	PolicyOperatorEssential,
-}
-
-var operators map[PolicyOperatorName]PolicyOperator
-
-// RegisterPolicyOperator registers a new PolicyOperator and therefore makes it available to be used
-func RegisterPolicyOperator(operator PolicyOperator) {
-	operators[operator.Name()] = operator
-}
+// OperatorOrder holds the order in which the built-in PolicyOperator are
+// applied.
+// For compatibility, a modified OperatorOrder is still honoured by the
+// DefaultPolicyEngine: the listed operators are applied in this order and
+// registered operators that are not listed keep their position relative to
+// the other operators; a warning is logged.
+//
+// Deprecated: OperatorOrder is not updated when operators are registered. Use
+// PolicyOperatorOrder to obtain the order and pass a PolicyOperatorOrdering to
+// RegisterPolicyOperatorWithOrdering to position custom operators.
+var OperatorOrder []PolicyOperatorName
 
 type policyOperator struct {
 	name        PolicyOperatorName
@@ -358,14 +351,3 @@ var policyOperatorEssential = NewPolicyOperator(
 	},
 	nil,
 )
-
-func init() {
-	operators = make(map[PolicyOperatorName]PolicyOperator)
-	RegisterPolicyOperator(policyOperatorSubsetOf)
-	RegisterPolicyOperator(policyOperatorOneOf)
-	RegisterPolicyOperator(policyOperatorSupersetOf)
-	RegisterPolicyOperator(policyOperatorAdd)
-	RegisterPolicyOperator(policyOperatorValue)
-	RegisterPolicyOperator(policyOperatorDefault)
-	RegisterPolicyOperator(policyOperatorEssential)
-}
@@ -0,0 +1,202 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicySimulationLeaf is a leaf entity used in a PolicySimulation
+type PolicySimulationLeaf struct {
+	EntityID string    `json:"entity_id,omitempty"`
+	Metadata *Metadata `json:"metadata"`
+}
+
+// PolicySimulationSuperior is a superior entity used in a PolicySimulation,
+// i.e. the issuer of a subordinate statement in the simulated TrustChain
+type PolicySimulationSuperior struct {
+	EntityID           string               `json:"entity_id,omitempty"`
+	MetadataPolicy     *MetadataPolicies    `json:"metadata_policy,omitempty"`
+	MetadataPolicyCrit []PolicyOperatorName `json:"metadata_policy_crit,omitempty"`
+}
+
+// PolicySimulation describes a hypothetical federation in which the
+// MetadataPolicies of the Superiors are applied to the Metadata of each of the
+// Leaves.
+// Superiors are ordered from the immediate superior of the leaves to the
+// trust anchor, i.e. in the same order as in a TrustChain.
+type PolicySimulation struct {
+	Leaves    []PolicySimulationLeaf     `json:"leaves"`
+	Superiors []PolicySimulationSuperior `json:"superiors"`
+}
+
+// PolicySimulationResult is the result of simulating the metadata
+// resolution for a single leaf
+type PolicySimulationResult struct {
+	EntityID string    `json:"entity_id,omitempty"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// ErrorCode is the spec error code for Err, if Err is a
+	// MetadataPolicyError
+	ErrorCode string `json:"error,omitempty"`
+	// ErrorDescription is the error message of Err, if Err is set
+	ErrorDescription string `json:"error_description,omitempty"`
+	// Err is the error returned from the metadata resolution; it usually is
+	// a MetadataPolicyError
+	Err error `json:"-"`
+}
+
+// Broken indicates that the metadata resolution failed for this leaf
+func (r PolicySimulationResult) Broken() bool {
+	return r.Err != nil
+}
+
+// PolicySimulationReport is the result of running a PolicySimulation
+type PolicySimulationReport struct {
+	Total   int                      `json:"total"`
+	Broken  int                      `json:"broken"`
+	Results []PolicySimulationResult `json:"results"`
+}
+
+// BrokenEntities returns the entity ids of the leaves for which the metadata
+// resolution failed
+func (r PolicySimulationReport) BrokenEntities() []string {
+	var broken []string
+	for _, res := range r.Results {
+		if res.Broken() {
+			broken = append(broken, res.EntityID)
+		}
+	}
+	return broken
+}
+
+// SimulateMetadata resolves the final Metadata of a leaf entity with the
+// passed superiors, using the same pipeline as TrustChain.Metadata,
+// but without fetching, verifying, or caching anything.
+// The DefaultPolicyEngine is used, see PolicyEngine.SimulateMetadata to use a
+// different one.
+func SimulateMetadata(leaf PolicySimulationLeaf, superiors ...PolicySimulationSuperior) PolicySimulationResult {
+	return simulateMetadata(DefaultPolicyEngine, leaf, superiors)
+}
+
+func simulateMetadata(
+	e *PolicyEngine, leaf PolicySimulationLeaf, superiors []PolicySimulationSuperior,
+) PolicySimulationResult {
+	result := PolicySimulationResult{EntityID: leaf.EntityID}
+	final, err := simulatedTrustChain(leaf, superiors).resolveMetadata(e.policyOperatorRegistry())
+	if err != nil {
+		result.Err = err
+		result.ErrorDescription = err.Error()
+		if e, ok := ErrorFromMetadataPolicyError(err); ok {
+			result.ErrorCode = e.Error
+		}
+		return result
+	}
+	result.Metadata = final
+	return result
+}
+
+// Run runs the PolicySimulation for all leaves using the
+// DefaultPolicyEngine, see PolicyEngine.RunPolicySimulation to use a different
+// one
+func (s PolicySimulation) Run() PolicySimulationReport {
+	return s.run(DefaultPolicyEngine)
+}
+
+func (s PolicySimulation) run(e *PolicyEngine) PolicySimulationReport {
+	report := PolicySimulationReport{
+		Total:   len(s.Leaves),
+		Results: make([]PolicySimulationResult, len(s.Leaves)),
+	}
+	for i, leaf := range s.Leaves {
+		result := simulateMetadata(e, leaf, s.Superiors)
+		if result.Broken() {
+			report.Broken++
+		}
+		report.Results[i] = result
+	}
+	return report
+}
+
+func simulatedTrustChain(leaf PolicySimulationLeaf, superiors []PolicySimulationSuperior) TrustChain {
+	chain := TrustChain{
+		{
+			EntityStatementPayload: EntityStatementPayload{
+				Issuer:   leaf.EntityID,
+				Subject:  leaf.EntityID,
+				Metadata: leaf.Metadata,
+			},
+		},
+	}
+	subject := leaf.EntityID
+	for _, s := range superiors {
+		chain = append(
+			chain, &EntityStatement{
+				EntityStatementPayload: EntityStatementPayload{
+					Issuer:             s.EntityID,
+					Subject:            subject,
+					MetadataPolicy:     s.MetadataPolicy,
+					MetadataPolicyCrit: s.MetadataPolicyCrit,
+				},
+			},
+		)
+		subject = s.EntityID
+	}
+	return chain
+}
+
+// LoadPolicySimulation loads a PolicySimulation from a json or yaml file
+func LoadPolicySimulation(path string) (*PolicySimulation, error) {
+	var s PolicySimulation
+	if err := loadJSONOrYAMLFile(path, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// LoadPolicySimulationLeaf loads a PolicySimulationLeaf from a json or yaml
+// file
+func LoadPolicySimulationLeaf(path string) (*PolicySimulationLeaf, error) {
+	var l PolicySimulationLeaf
+	if err := loadJSONOrYAMLFile(path, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// LoadPolicySimulationSuperior loads a PolicySimulationSuperior from a json
+// or yaml file
+func LoadPolicySimulationSuperior(path string) (*PolicySimulationSuperior, error) {
+	var s PolicySimulationSuperior
+	if err := loadJSONOrYAMLFile(path, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// loadJSONOrYAMLFile decodes a json or yaml file (depending on the file
+// extension) into target. yaml is converted to json first, so that the json
+// unmarshalling of the library types is used in both cases.
+func loadJSONOrYAMLFile(path string, target any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var v any
+		if err = yaml.Unmarshal(data, &v); err != nil {
+			return errors.Wrapf(err, "could not parse '%s'", path)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return errors.Wrapf(err, "could not convert '%s' to json", path)
+		}
+	}
+	if err = json.Unmarshal(data, target); err != nil {
+		return errors.Wrapf(err, "could not parse '%s'", path)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package oidfed
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const policySimulationYAML = `
+leaves:
+  - entity_id: https://rp1.example.org
+    metadata:
+      openid_relying_party:
+        client_name: RP 1
+        contacts:
+          - rp1@example.org
+  - entity_id: https://rp2.example.org
+    metadata:
+      openid_relying_party:
+        contacts:
+          - rp2@example.org
+superiors:
+  - entity_id: https://ia.example.org
+    metadata_policy:
+      openid_relying_party:
+        contacts:
+          add:
+            - ia@example.org
+  - entity_id: https://ta.example.org
+    metadata_policy:
+      openid_relying_party:
+        client_name:
+          essential: true
+`
+
+func TestLoadPolicySimulation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "simulation.yaml")
+	if err := os.WriteFile(path, []byte(policySimulationYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadPolicySimulation(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := s.Run()
+	if report.Total != 2 || report.Broken != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if broken := report.BrokenEntities(); !reflect.DeepEqual(broken, []string{"https://rp2.example.org"}) {
+		t.Errorf("broken entities are %v", broken)
+	}
+	rp1Result := report.Results[0]
+	if rp1Result.Metadata == nil || rp1Result.Metadata.RelyingParty == nil {
+		t.Fatalf("no metadata resolved for rp1")
+	}
+	expectedContacts := []string{"rp1@example.org", "ia@example.org"}
+	if !reflect.DeepEqual(rp1Result.Metadata.RelyingParty.Contacts, expectedContacts) {
+		t.Errorf(
+			"resolved contacts are %v, but %v expected", rp1Result.Metadata.RelyingParty.Contacts,
+			expectedContacts,
+		)
+	}
+	rp2Result := report.Results[1]
+	if rp2Result.ErrorCode != InvalidMetadata {
+		t.Errorf("error code is '%s', but '%s' expected", rp2Result.ErrorCode, InvalidMetadata)
+	}
+	var applyErr *MetadataPolicyApplyError
+	if !errors.As(rp2Result.Err, &applyErr) {
+		t.Fatalf("expected MetadataPolicyApplyError, got: %v", rp2Result.Err)
+	}
+	if !reflect.DeepEqual(applyErr.Issuers, []string{"https://ta.example.org"}) {
+		t.Errorf("issuers are %v", applyErr.Issuers)
+	}
+}
+
+func TestSimulateMetadata(t *testing.T) {
+	leaf := PolicySimulationLeaf{
+		EntityID: rp1.EntityID,
+		Metadata: rp1.EntityStatementPayload().Metadata,
+	}
+	tests := []struct {
+		name          string
+		chain         TrustChain
+		superiors     []PolicySimulationSuperior
+		expectedError string
+	}{
+		{
+			name:  "rp->ia2->ta2",
+			chain: chainRPIA2TA2,
+			superiors: []PolicySimulationSuperior{
+				{
+					EntityID:       ia2.EntityID,
+					MetadataPolicy: ia2.SubordinateEntityStatementPayload(rp1.EntityID).MetadataPolicy,
+				},
+				{
+					EntityID:       ta2.EntityID,
+					MetadataPolicy: ta2.SubordinateEntityStatementPayload(ia2.EntityID).MetadataPolicy,
+				},
+			},
+		},
+		{
+			name:      "no superiors",
+			superiors: nil,
+		},
+		{
+			name: "crit",
+			superiors: []PolicySimulationSuperior{
+				{
+					EntityID:           ta2.EntityID,
+					MetadataPolicyCrit: []PolicyOperatorName{"not-supported"},
+				},
+			},
+			expectedError: InvalidTrustChain,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				result := SimulateMetadata(leaf, test.superiors...)
+				if result.ErrorCode != test.expectedError {
+					t.Fatalf("error code is '%s', but '%s' expected: %v", result.ErrorCode, test.expectedError, result.Err)
+				}
+				if test.expectedError != "" {
+					return
+				}
+				var expected *Metadata
+				if test.chain != nil {
+					var err error
+					expected, err = test.chain.Metadata()
+					if err != nil {
+						t.Fatal(err)
+					}
+				} else {
+					expected = leaf.Metadata
+				}
+				equal, err := jsonEqual(result.Metadata, expected)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !equal {
+					t.Errorf("simulated metadata differs from TrustChain.Metadata")
+				}
+			},
+		)
+	}
+}
+
+func TestPolicyEngine_RunPolicySimulation(t *testing.T) {
+	engine := NewPolicyEngine()
+	if err := engine.RegisterPolicyOperator(policyOperatorUpper); err != nil {
+		t.Fatal(err)
+	}
+	simulation := PolicySimulation{
+		Leaves: []PolicySimulationLeaf{
+			{
+				EntityID: "https://rp.example.org",
+				Metadata: &Metadata{RelyingParty: &OpenIDRelyingPartyMetadata{ClientName: "rp"}},
+			},
+		},
+		Superiors: []PolicySimulationSuperior{
+			{
+				EntityID: "https://ta.example.org",
+				MetadataPolicy: &MetadataPolicies{
+					RelyingParty: MetadataPolicy{"client_name": {"upper": true}},
+				},
+				MetadataPolicyCrit: []PolicyOperatorName{"upper"},
+			},
+		},
+	}
+
+	report := engine.RunPolicySimulation(simulation)
+	if report.Broken != 0 {
+		t.Fatalf("simulation with the PolicyEngine failed: %v", report.Results[0].Err)
+	}
+	if name := report.Results[0].Metadata.RelyingParty.ClientName; name != "RP" {
+		t.Errorf("client_name is '%s', but 'RP' expected", name)
+	}
+	// the operator is not registered with the DefaultPolicyEngine
+	if report = simulation.Run(); report.Broken != 1 {
+		t.Errorf("expected simulation with the DefaultPolicyEngine to fail")
+	}
+	if result := engine.SimulateMetadata(simulation.Leaves[0], simulation.Superiors...); result.Broken() {
+		t.Errorf("simulation with the PolicyEngine failed: %v", result.Err)
+	}
+}
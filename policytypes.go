@@ -0,0 +1,210 @@
+package oidfed
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal/utils"
+)
+
+// PolicyOperatorTypeChecker can optionally be implemented by a PolicyOperator
+// to check that an operator value is compatible with the type of the claim
+// the operator is used for.
+// The claimType is the type of the claim as seen by metadata policies, i.e.
+// space separated claims like 'scope' have the type []string.
+// For the built-in operators the types are checked by this package.
+type PolicyOperatorTypeChecker interface {
+	CheckValueType(value any, claimType reflect.Type) error
+}
+
+// spaceSeparatedClaims holds the claims per entity type that are encoded as a
+// single space separated string in metadata, but are handled as an array of
+// strings by metadata policies
+var spaceSeparatedClaims = struct {
+	sync.RWMutex
+	claims map[string]map[string]bool
+}{
+	claims: map[string]map[string]bool{
+		"openid_relying_party": {"scope": true},
+		"oauth_client":         {"scope": true},
+	},
+}
+
+// RegisterSpaceSeparatedClaim registers a claim of an entity type that is
+// encoded as a space separated string in metadata, like 'scope'.
+// Metadata policies handle such claims as an array of strings, i.e. the claim
+// value is split before the policy is applied and joined afterwards.
+func RegisterSpaceSeparatedClaim(entityType, claim string) {
+	spaceSeparatedClaims.Lock()
+	defer spaceSeparatedClaims.Unlock()
+	if spaceSeparatedClaims.claims[entityType] == nil {
+		spaceSeparatedClaims.claims[entityType] = make(map[string]bool)
+	}
+	spaceSeparatedClaims.claims[entityType][claim] = true
+}
+
+// isSpaceSeparatedClaim checks if a claim of an entity type is registered as
+// a space separated claim
+func isSpaceSeparatedClaim(entityType, claim string) bool {
+	spaceSeparatedClaims.RLock()
+	defer spaceSeparatedClaims.RUnlock()
+	return spaceSeparatedClaims.claims[entityType][claim]
+}
+
+// splitSpaceSeparatedClaim converts the metadata value of a space separated
+// claim into the []string used by metadata policies; an empty value results
+// in a nil []string, like an unset array claim
+func splitSpaceSeparatedClaim(value any) any {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return []string(nil)
+		}
+		return strings.Fields(v)
+	case *string:
+		if v == nil || *v == "" {
+			return []string(nil)
+		}
+		return strings.Fields(*v)
+	default:
+		return value
+	}
+}
+
+// joinSpaceSeparatedClaim converts the value resulting from applying a
+// metadata policy to a space separated claim back into a value of the passed
+// type, i.e. string or *string
+func joinSpaceSeparatedClaim(value any, t reflect.Type) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	var joined string
+	switch v := value.(type) {
+	case string:
+		joined = v
+	case []string:
+		joined = strings.Join(v, " ")
+	case []any:
+		values := make([]string, len(v))
+		for i, vv := range v {
+			s, ok := vv.(string)
+			if !ok {
+				return nil, errors.Errorf("space separated claim contains non-string value '%v'", vv)
+			}
+			values[i] = s
+		}
+		joined = strings.Join(values, " ")
+	default:
+		return nil, errors.Errorf("unexpected type '%T' for space separated claim", value)
+	}
+	if t.Kind() == reflect.Ptr {
+		return &joined, nil
+	}
+	return joined, nil
+}
+
+// metadataClaimTypes returns the types of the claims of the metadata struct
+// for an entity type as seen by metadata policies; false is returned for
+// entity types that are not explicitly modelled in Metadata
+func metadataClaimTypes(entityType string) (map[string]reflect.Type, bool) {
+	t := reflect.TypeOf(Metadata{})
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("json")
+		if !ok || tag == "-" || strings.TrimSuffix(tag, ",omitempty") != entityType {
+			continue
+		}
+		st := t.Field(i).Type.Elem()
+		claims := make(map[string]reflect.Type)
+		for j := 0; j < st.NumField(); j++ {
+			f := st.Field(j)
+			claim, ok := f.Tag.Lookup("json")
+			if !ok || claim == "-" || !f.IsExported() {
+				continue
+			}
+			claim, _, _ = strings.Cut(claim, ",")
+			claims[claim] = f.Type
+			if isSpaceSeparatedClaim(entityType, claim) {
+				claims[claim] = reflect.TypeOf([]string{})
+			}
+		}
+		return claims, true
+	}
+	return nil, false
+}
+
+// verifyPolicyTypes checks that the operator values of a MetadataPolicy for
+// an entity type are compatible with the types of the claims; claims and
+// entity types that are not modelled in Metadata are not checked
+func (r *policyOperatorRegistry) verifyPolicyTypes(entityType string, p MetadataPolicy) error {
+	claimTypes, ok := metadataClaimTypes(entityType)
+	if !ok {
+		return nil
+	}
+	for _, claim := range sortedKeys(p) {
+		claimType, ok := claimTypes[claim]
+		if !ok {
+			continue
+		}
+		entry := p[claim]
+		for _, op := range sortedKeys(entry) {
+			if err := r.checkPolicyOperatorValueType(op, entry[op], claimType); err != nil {
+				return newMetadataPolicyTypeError(entityType, claim, op, claimType, entry[op], err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPolicyOperatorValueType checks if the value of a policy operator is
+// compatible with the type of the claim; operators that are neither built-in
+// nor implement PolicyOperatorTypeChecker are not checked
+func (r *policyOperatorRegistry) checkPolicyOperatorValueType(
+	op PolicyOperatorName, value any, claimType reflect.Type,
+) error {
+	if operator, ok := r.operator(op); ok {
+		if checker, ok := operator.(PolicyOperatorTypeChecker); ok {
+			return checker.CheckValueType(value, claimType)
+		}
+	}
+	return checkBuiltinPolicyOperatorValueType(op, value, claimType)
+}
+
+// checkBuiltinPolicyOperatorValueType checks if the value of a built-in
+// policy operator is compatible with the type of the claim
+func checkBuiltinPolicyOperatorValueType(op PolicyOperatorName, value any, claimType reflect.Type) error {
+	if value == nil {
+		return nil
+	}
+	isSlice := claimType.Kind() == reflect.Slice && claimType.Elem().Kind() != reflect.Uint8
+	switch op {
+	case PolicyOperatorValue, PolicyOperatorDefault:
+		if err := decodesAs(value, claimType); err != nil {
+			return errors.Errorf("operator value does not match claim type '%s'", claimType)
+		}
+	case PolicyOperatorEssential:
+		if _, ok := value.(bool); !ok {
+			return errors.New("operator value must be a boolean")
+		}
+	case PolicyOperatorOneOf:
+		if isSlice {
+			return errors.Errorf("operator can not be used with array claim of type '%s'", claimType)
+		}
+		rv := reflect.ValueOf(utils.Slicify(value))
+		for i := 0; i < rv.Len(); i++ {
+			if err := decodesAs(rv.Index(i).Interface(), claimType); err != nil {
+				return errors.Errorf("operator value '%v' does not match claim type '%s'", rv.Index(i), claimType)
+			}
+		}
+	case PolicyOperatorAdd, PolicyOperatorSubsetOf, PolicyOperatorSupersetOf:
+		if !isSlice {
+			return errors.Errorf("operator can only be used with array claims, but claim is '%s'", claimType)
+		}
+		if err := decodesAs(utils.Slicify(value), claimType); err != nil {
+			return errors.Errorf("operator value does not match claim type '%s'", claimType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package oidfed
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestMergeMetadataPolicies_Types(t *testing.T) {
+	tests := []struct {
+		name        string
+		policies    []*MetadataPolicies
+		errClaim    string
+		errOperator PolicyOperatorName
+	}{
+		{
+			name: "valid",
+			policies: []*MetadataPolicies{
+				{
+					RelyingParty: MetadataPolicy{
+						"client_name":    {PolicyOperatorValue: "rp"},
+						"response_types": {PolicyOperatorSubsetOf: []any{"code"}},
+						"scope":          {PolicyOperatorSubsetOf: []any{"openid", "profile"}},
+						"default_max_age": {
+							PolicyOperatorDefault: 3600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "subset_of on string claim",
+			policies: []*MetadataPolicies{
+				{RelyingParty: MetadataPolicy{"client_name": {PolicyOperatorSubsetOf: []any{"rp"}}}},
+			},
+			errClaim:    "client_name",
+			errOperator: PolicyOperatorSubsetOf,
+		},
+		{
+			name: "value of wrong type",
+			policies: []*MetadataPolicies{
+				{RelyingParty: MetadataPolicy{"default_max_age": {PolicyOperatorValue: "1h"}}},
+			},
+			errClaim:    "default_max_age",
+			errOperator: PolicyOperatorValue,
+		},
+		{
+			name: "one_of on space separated claim",
+			policies: []*MetadataPolicies{
+				{OAuthClient: MetadataPolicy{"scope": {PolicyOperatorOneOf: []any{"openid"}}}},
+			},
+			errClaim:    "scope",
+			errOperator: PolicyOperatorOneOf,
+		},
+		{
+			name: "mismatch in superior",
+			policies: []*MetadataPolicies{
+				{RelyingParty: MetadataPolicy{"client_name": {PolicyOperatorEssential: true}}},
+				{RelyingParty: MetadataPolicy{"client_name": {PolicyOperatorDefault: []any{"rp"}}}},
+			},
+			errClaim:    "client_name",
+			errOperator: PolicyOperatorDefault,
+		},
+		{
+			name: "unknown claims and entity types are not checked",
+			policies: []*MetadataPolicies{
+				{
+					RelyingParty: MetadataPolicy{"custom_claim": {PolicyOperatorSubsetOf: []any{1}}},
+					Extra: map[string]MetadataPolicy{
+						"custom_entity": {"client_name": {PolicyOperatorSubsetOf: []any{1}}},
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				_, err := MergeMetadataPolicies(test.policies...)
+				if test.errClaim == "" {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				var typeErr *MetadataPolicyTypeError
+				if !errors.As(err, &typeErr) {
+					t.Fatalf("expected MetadataPolicyTypeError, but got: %v", err)
+				}
+				if typeErr.Claim != test.errClaim || typeErr.Operator != test.errOperator {
+					t.Errorf(
+						"error is for '%s.%s', but expected '%s.%s'", typeErr.Claim, typeErr.Operator,
+						test.errClaim, test.errOperator,
+					)
+				}
+				if typeErr.ErrorCode() != InvalidTrustChain {
+					t.Errorf("unexpected error code '%s'", typeErr.ErrorCode())
+				}
+			},
+		)
+	}
+}
+
+func TestMetadata_ApplyPolicy_SpaceSeparatedClaim(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    string
+		policy   MetadataPolicyEntry
+		expected string
+		errorExp bool
+	}{
+		{
+			name:     "subset_of",
+			scope:    "openid profile email",
+			policy:   MetadataPolicyEntry{PolicyOperatorSubsetOf: []any{"openid", "email", "address"}},
+			expected: "openid email",
+		},
+		{
+			name:     "add",
+			scope:    "openid",
+			policy:   MetadataPolicyEntry{PolicyOperatorAdd: []any{"profile"}},
+			expected: "openid profile",
+		},
+		{
+			name:     "superset_of",
+			scope:    "openid profile",
+			policy:   MetadataPolicyEntry{PolicyOperatorSupersetOf: []any{"openid"}},
+			expected: "openid profile",
+		},
+		{
+			name:     "superset_of not fulfilled",
+			scope:    "profile",
+			policy:   MetadataPolicyEntry{PolicyOperatorSupersetOf: []any{"openid"}},
+			errorExp: true,
+		},
+		{
+			name:     "value",
+			scope:    "profile",
+			policy:   MetadataPolicyEntry{PolicyOperatorValue: []any{"openid", "profile"}},
+			expected: "openid profile",
+		},
+		{
+			name:     "default",
+			policy:   MetadataPolicyEntry{PolicyOperatorDefault: []any{"openid"}},
+			expected: "openid",
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				var rp OpenIDRelyingPartyMetadata
+				if test.scope != "" {
+					if err := rp.UnmarshalJSON([]byte(`{"scope":"` + test.scope + `"}`)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				m := Metadata{RelyingParty: &rp}
+				final, err := m.ApplyPolicy(&MetadataPolicies{RelyingParty: MetadataPolicy{"scope": test.policy}})
+				if test.errorExp {
+					if err == nil {
+						t.Fatalf("expected error, but got scope '%s'", final.RelyingParty.Scope)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if final.RelyingParty.Scope != test.expected {
+					t.Errorf("scope is '%s', but expected '%s'", final.RelyingParty.Scope, test.expected)
+				}
+			},
+		)
+	}
+}
+
+type typeCheckedPolicyOperator struct {
+	PolicyOperator
+}
+
+func (typeCheckedPolicyOperator) CheckValueType(_ any, claimType reflect.Type) error {
+	if claimType.Kind() != reflect.String {
+		return errors.New("only string claims are supported")
+	}
+	return nil
+}
+
+func TestPolicyOperatorTypeChecker(t *testing.T) {
+	engine := NewPolicyEngine()
+	if err := engine.RegisterPolicyOperator(typeCheckedPolicyOperator{policyOperatorUpper}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.MergeMetadataPolicies(
+		&MetadataPolicies{RelyingParty: MetadataPolicy{"client_name": {"upper": true}}},
+	); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err := engine.MergeMetadataPolicies(
+		&MetadataPolicies{RelyingParty: MetadataPolicy{"contacts": {"upper": true}}},
+	)
+	var typeErr *MetadataPolicyTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected MetadataPolicyTypeError, but got: %v", err)
+	}
+	if typeErr.ClaimType != reflect.TypeOf([]string{}) {
+		t.Errorf("unexpected claim type '%s'", typeErr.ClaimType)
+	}
+}
+
+func TestRegisterSpaceSeparatedClaim(t *testing.T) {
+	claimTypes, _ := metadataClaimTypes("openid_provider")
+	if claimTypes["service_documentation"].Kind() != reflect.String {
+		t.Fatalf("unexpected claim type '%s'", claimTypes["service_documentation"])
+	}
+	RegisterSpaceSeparatedClaim("openid_provider", "service_documentation")
+	defer func() {
+		spaceSeparatedClaims.Lock()
+		delete(spaceSeparatedClaims.claims["openid_provider"], "service_documentation")
+		spaceSeparatedClaims.Unlock()
+	}()
+	claimTypes, _ = metadataClaimTypes("openid_provider")
+	if claimTypes["service_documentation"] != reflect.TypeOf([]string{}) {
+		t.Errorf("unexpected claim type '%s'", claimTypes["service_documentation"])
+	}
+}
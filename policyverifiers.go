@@ -11,11 +11,10 @@ import (
 // PolicyVerifier is a function that verifies a MetadataPolicyEntry
 type PolicyVerifier func(p MetadataPolicyEntry, pathInfo string) error
 
-var policyVerifiers []PolicyVerifier
-
-// RegisterPolicyVerifier registers a PolicyVerifier
+// RegisterPolicyVerifier registers a PolicyVerifier; it is safe for
+// concurrent use
 func RegisterPolicyVerifier(v PolicyVerifier) {
-	policyVerifiers = append(policyVerifiers, v)
+	defaultPolicyOperatorRegistry.registerVerifier(v)
 }
 
 func policyVerifierSubsetSupersetOneOf(p MetadataPolicyEntry, pathInfo string) error {
@@ -234,17 +233,18 @@ func policyVerifyOneOfStillHasValues(p MetadataPolicyEntry, pathInfo string) err
 	return nil
 }
 
-func init() {
-	RegisterPolicyVerifier(policyVerifierSubsetSupersetOneOf)
-	RegisterPolicyVerifier(policyVerifierSubsetSupersetOf)
-	RegisterPolicyVerifier(policyVerifyAddInSubset)
-	RegisterPolicyVerifier(policyVerifyAddInOneOf)
-	RegisterPolicyVerifier(policyVerifyAddInValue)
-	// RegisterPolicyVerifier(policyVerifyDefaultInOneOf)
-	// RegisterPolicyVerifier(policyVerifyDefaultInSubset)
-	// RegisterPolicyVerifier(policyVerifyDefaultSuperset)
-	// RegisterPolicyVerifier(policyVerifySubsetOfStillHasValues)
-	RegisterPolicyVerifier(policyVerifyOneOfStillHasValues)
-	RegisterPolicyVerifier(policyVerifySubsetOfAndValue)
-	RegisterPolicyVerifier(policyVerifyDefaultAndValue)
+// builtinPolicyVerifiers are the PolicyVerifier registered by default
+var builtinPolicyVerifiers = []PolicyVerifier{
+	policyVerifierSubsetSupersetOneOf,
+	policyVerifierSubsetSupersetOf,
+	policyVerifyAddInSubset,
+	policyVerifyAddInOneOf,
+	policyVerifyAddInValue,
+	// policyVerifyDefaultInOneOf,
+	// policyVerifyDefaultInSubset,
+	// policyVerifyDefaultSuperset,
+	// policyVerifySubsetOfStillHasValues,
+	policyVerifyOneOfStillHasValues,
+	policyVerifySubsetOfAndValue,
+	policyVerifyDefaultAndValue,
 }
@@ -0,0 +1,278 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/cache"
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/internal/http"
+	"github.com/lionick/oidfed-lib/internal/jwx"
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// DefaultJWKSCacheDuration is the duration for which keys obtained from a
+// jwks_uri, or from a signed_jwks_uri without an expiration, are cached
+var DefaultJWKSCacheDuration = time.Hour
+
+// SignedJWKSPayload is the payload of a signed JWKS as published at a
+// signed_jwks_uri
+type SignedJWKSPayload struct {
+	Issuer    string             `json:"iss"`
+	Subject   string             `json:"sub"`
+	IssuedAt  *unixtime.Unixtime `json:"iat,omitempty"`
+	ExpiresAt *unixtime.Unixtime `json:"exp,omitempty"`
+	Keys      jwks.JWKS          `json:"-"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (p SignedJWKSPayload) MarshalJSON() ([]byte, error) {
+	type Alias SignedJWKSPayload
+	claims, err := json.Marshal(Alias(p))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var out map[string]any
+	if err = json.Unmarshal(claims, &out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out["keys"] = []any{}
+	if p.Keys.Set != nil {
+		keys, err := json.Marshal(p.Keys)
+		if err != nil {
+			return nil, err
+		}
+		var set struct {
+			Keys []any `json:"keys"`
+		}
+		if err = json.Unmarshal(keys, &set); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		out["keys"] = set.Keys
+	}
+	data, err := json.Marshal(out)
+	return data, errors.WithStack(err)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (p *SignedJWKSPayload) UnmarshalJSON(data []byte) error {
+	type Alias SignedJWKSPayload
+	a := Alias(*p)
+	if err := json.Unmarshal(data, &a); err != nil {
+		return errors.WithStack(err)
+	}
+	var keys struct {
+		Keys json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(keys.Keys) > 0 {
+		keysData, err := json.Marshal(keys)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err = a.Keys.UnmarshalJSON(keysData); err != nil {
+			return err
+		}
+	}
+	*p = SignedJWKSPayload(a)
+	return nil
+}
+
+// ParseSignedJWKS parses a signed JWKS jwt and verifies it for the passed
+// entity, i.e. the jwt type, the signature with the entity's federation keys,
+// the 'iss' and 'sub' claims, and the expiration
+func ParseSignedJWKS(data []byte, entityID string, federationKeys jwks.JWKS) (*SignedJWKSPayload, error) {
+	m, err := jwx.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse signed jwks")
+	}
+	if !m.VerifyType(oidfedconst.JWTTypeJWKS) {
+		return nil, errors.Errorf("signed jwks does not have '%s' JWT type", oidfedconst.JWTTypeJWKS)
+	}
+	payload, err := m.VerifyWithSet(federationKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not verify signed jwks")
+	}
+	var p SignedJWKSPayload
+	if err = json.Unmarshal(payload, &p); err != nil {
+		return nil, errors.Wrap(err, "could not parse signed jwks")
+	}
+	if p.Issuer != entityID || p.Subject != entityID {
+		return nil, errors.Errorf("signed jwks was not issued by and about '%s'", entityID)
+	}
+	if err = unixtime.VerifyTime(p.IssuedAt, p.ExpiresAt); err != nil {
+		return nil, errors.Wrap(err, "signed jwks is not valid")
+	}
+	if p.Keys.Set == nil || p.Keys.Len() == 0 {
+		return nil, errors.New("signed jwks does not contain keys")
+	}
+	return &p, nil
+}
+
+// FetchJWKS fetches the JWKS published at a jwks_uri; the result is cached for
+// DefaultJWKSCacheDuration
+func FetchJWKS(jwksURI string) (jwks.JWKS, error) {
+	cacheKey := cache.Key(cache.KeyJWKS, jwksURI)
+	var keys jwks.JWKS
+	set, err := cache.Get(cacheKey, &keys)
+	if err != nil {
+		internal.Log(err)
+	} else if set {
+		internal.Log("Obtained jwks from cache")
+		return keys, nil
+	}
+	res, errRes, err := http.Get(jwksURI, nil, nil)
+	if err != nil {
+		return keys, err
+	}
+	if errRes != nil {
+		return keys, errRes.Err()
+	}
+	if err = json.Unmarshal(res.Body(), &keys); err != nil {
+		return keys, errors.Wrapf(err, "could not parse jwks from '%s'", jwksURI)
+	}
+	if keys.Set == nil || keys.Len() == 0 {
+		return keys, errors.Errorf("jwks from '%s' does not contain keys", jwksURI)
+	}
+	if err = cache.Set(cacheKey, keys, DefaultJWKSCacheDuration); err != nil {
+		internal.Log(err)
+	}
+	return keys, nil
+}
+
+// FetchSignedJWKS fetches the signed JWKS published at a signed_jwks_uri and
+// verifies it with the federation keys of the entity (see ParseSignedJWKS).
+// The keys are cached until the signed JWKS expires, but at most for
+// DefaultJWKSCacheDuration if it does not expire.
+func FetchSignedJWKS(signedJWKSURI, entityID string, federationKeys jwks.JWKS) (jwks.JWKS, error) {
+	cacheKey := cache.Key(cache.KeySignedJWKS, entityID+":"+signedJWKSURI)
+	var keys jwks.JWKS
+	set, err := cache.Get(cacheKey, &keys)
+	if err != nil {
+		internal.Log(err)
+	} else if set {
+		internal.Log("Obtained signed jwks from cache")
+		return keys, nil
+	}
+	res, errRes, err := http.Get(signedJWKSURI, nil, nil)
+	if err != nil {
+		return keys, err
+	}
+	if errRes != nil {
+		return keys, errRes.Err()
+	}
+	p, err := ParseSignedJWKS(res.Body(), entityID, federationKeys)
+	if err != nil {
+		return keys, err
+	}
+	cacheDuration := DefaultJWKSCacheDuration
+	if p.ExpiresAt != nil && !p.ExpiresAt.IsZero() {
+		cacheDuration = time.Until(p.ExpiresAt.Time)
+	}
+	if err = cache.Set(cacheKey, p.Keys, cacheDuration); err != nil {
+		internal.Log(err)
+	}
+	return p.Keys, nil
+}
+
+// entityKeyMetadata holds the claims used to publish protocol keys
+type entityKeyMetadata struct {
+	JWKS          *jwks.JWKS `json:"jwks,omitempty"`
+	JWKSURI       string     `json:"jwks_uri,omitempty"`
+	SignedJWKSURI string     `json:"signed_jwks_uri,omitempty"`
+}
+
+// ProtocolKeys returns the keys of an entity used for the protocol of an
+// entity type, e.g. the keys of an OP used for signing ID tokens.
+// The keys are taken from the 'signed_jwks_uri', 'jwks_uri', or 'jwks' claim
+// (in that order) of the entity type's metadata, which is resolved from the
+// TrustChain with the passed PolicyEngine (nil for the DefaultPolicyEngine);
+// a signed JWKS is verified with the federation keys of the entity as
+// published by its immediate superior in the TrustChain.
+func ProtocolKeys(chain TrustChain, entityType string, engine *PolicyEngine) (jwks.JWKS, error) {
+	if len(chain) == 0 {
+		return jwks.JWKS{}, errors.New("empty trust chain")
+	}
+	m, err := engine.TrustChainMetadata(chain)
+	if err != nil {
+		return jwks.JWKS{}, err
+	}
+	entityID := chain[0].Subject
+	data, err := json.Marshal(m)
+	if err != nil {
+		return jwks.JWKS{}, errors.WithStack(err)
+	}
+	var metadata map[string]json.RawMessage
+	if err = json.Unmarshal(data, &metadata); err != nil {
+		return jwks.JWKS{}, errors.WithStack(err)
+	}
+	entityTypeMetadata, ok := metadata[entityType]
+	if !ok {
+		return jwks.JWKS{}, errors.Errorf("no '%s' metadata for '%s'", entityType, entityID)
+	}
+	var km entityKeyMetadata
+	if err = json.Unmarshal(entityTypeMetadata, &km); err != nil {
+		return jwks.JWKS{}, errors.WithStack(err)
+	}
+	switch {
+	case km.SignedJWKSURI != "":
+		return FetchSignedJWKS(km.SignedJWKSURI, entityID, chain.subjectFederationKeys())
+	case km.JWKSURI != "":
+		return FetchJWKS(km.JWKSURI)
+	case km.JWKS != nil && km.JWKS.Set != nil:
+		return *km.JWKS, nil
+	default:
+		return jwks.JWKS{}, errors.Errorf("no keys published in '%s' metadata for '%s'", entityType, entityID)
+	}
+}
+
+// subjectFederationKeys returns the federation keys of the subject of the
+// TrustChain; they are taken from the subordinate statement of the immediate
+// superior, or from the entity configuration if the subject is the trust
+// anchor
+func (c TrustChain) subjectFederationKeys() jwks.JWKS {
+	if len(c) > 1 {
+		return c[1].JWKS
+	}
+	return c[0].JWKS
+}
+
+// ProtocolKeyResolver resolves the protocol keys of entities, i.e. the keys
+// published in their metadata for a certain entity type, using the
+// TrustChain to a trust anchor
+type ProtocolKeyResolver struct {
+	TrustAnchors TrustAnchors
+	// PolicyEngine is used to apply the metadata policies;
+	// if not set, the DefaultPolicyEngine is used
+	PolicyEngine *PolicyEngine
+}
+
+// ResolveKeys resolves a TrustChain for the entity and returns its protocol
+// keys for the passed entity type; see ProtocolKeys
+func (r ProtocolKeyResolver) ResolveKeys(entityID, entityType string) (jwks.JWKS, error) {
+	tr := TrustResolver{
+		TrustAnchors:   r.TrustAnchors,
+		StartingEntity: entityID,
+		Types:          []string{entityType},
+		PolicyEngine:   r.PolicyEngine,
+	}
+	chains := tr.ResolveToValidChains()
+	if len(chains) == 0 {
+		return jwks.JWKS{}, errors.Errorf("no trust chain found for '%s'", entityID)
+	}
+	var err error
+	for _, chain := range chains.SortAsc(TrustChainScoringPathLen) {
+		var keys jwks.JWKS
+		keys, err = ProtocolKeys(chain, entityType, r.PolicyEngine)
+		if err == nil {
+			return keys, nil
+		}
+	}
+	return jwks.JWKS{}, err
+}
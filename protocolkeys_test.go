@@ -0,0 +1,243 @@
+package oidfed
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+func newTestJWTSigner(t *testing.T) *GeneralJWTSigner {
+	t.Helper()
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGeneralJWTSigner(sk, jwa.ES256())
+}
+
+func TestParseSignedJWKS(t *testing.T) {
+	const entityID = "https://op.example.org"
+	federationSigner := newTestJWTSigner(t)
+	otherSigner := newTestJWTSigner(t)
+	protocolKeys := newTestJWTSigner(t).JWKS()
+	exp := unixtime.Unixtime{Time: time.Now().Add(time.Hour)}
+	expired := unixtime.Unixtime{Time: time.Now().Add(-time.Hour)}
+
+	tests := []struct {
+		name     string
+		signer   *GeneralJWTSigner
+		typ      string
+		payload  SignedJWKSPayload
+		errorExp bool
+	}{
+		{
+			name:    "valid",
+			signer:  federationSigner,
+			typ:     oidfedconst.JWTTypeJWKS,
+			payload: SignedJWKSPayload{Issuer: entityID, Subject: entityID, ExpiresAt: &exp, Keys: protocolKeys},
+		},
+		{
+			name:     "wrong type",
+			signer:   federationSigner,
+			typ:      oidfedconst.JWTTypeEntityStatement,
+			payload:  SignedJWKSPayload{Issuer: entityID, Subject: entityID, Keys: protocolKeys},
+			errorExp: true,
+		},
+		{
+			name:     "wrong key",
+			signer:   otherSigner,
+			typ:      oidfedconst.JWTTypeJWKS,
+			payload:  SignedJWKSPayload{Issuer: entityID, Subject: entityID, Keys: protocolKeys},
+			errorExp: true,
+		},
+		{
+			name:     "wrong issuer",
+			signer:   federationSigner,
+			typ:      oidfedconst.JWTTypeJWKS,
+			payload:  SignedJWKSPayload{Issuer: "https://other.example.org", Subject: entityID, Keys: protocolKeys},
+			errorExp: true,
+		},
+		{
+			name:   "expired",
+			signer: federationSigner,
+			typ:    oidfedconst.JWTTypeJWKS,
+			payload: SignedJWKSPayload{
+				Issuer: entityID, Subject: entityID, ExpiresAt: &expired, Keys: protocolKeys,
+			},
+			errorExp: true,
+		},
+		{
+			name:     "no keys",
+			signer:   federationSigner,
+			typ:      oidfedconst.JWTTypeJWKS,
+			payload:  SignedJWKSPayload{Issuer: entityID, Subject: entityID},
+			errorExp: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				jwt, err := test.signer.JWT(test.payload, test.typ)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p, err := ParseSignedJWKS(jwt, entityID, federationSigner.JWKS())
+				if test.errorExp {
+					if err == nil {
+						t.Fatal("expected error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p.Keys.Len() != protocolKeys.Len() {
+					t.Errorf("expected %d keys, but got %d", protocolKeys.Len(), p.Keys.Len())
+				}
+			},
+		)
+	}
+}
+
+func TestSignedJWKSPayload_JSON(t *testing.T) {
+	keys := newTestJWTSigner(t).JWKS()
+	p := SignedJWKSPayload{Issuer: "https://op.example.org", Subject: "https://op.example.org", Keys: keys}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]any
+	if err = json.Unmarshal(data, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := claims["keys"].([]any); !ok || len(k) != 1 {
+		t.Errorf("unexpected keys claim: %v", claims["keys"])
+	}
+	var parsed SignedJWKSPayload
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Issuer != p.Issuer || parsed.Subject != p.Subject || parsed.Keys.Len() != 1 {
+		t.Errorf("unexpected payload after round trip: %+v", parsed)
+	}
+}
+
+func TestProtocolKeys(t *testing.T) {
+	const entityID = "https://keys-op.example.org"
+	federationSigner := newTestJWTSigner(t)
+	inlineKeys := newTestJWTSigner(t).JWKS()
+	uriKeys := newTestJWTSigner(t).JWKS()
+	signedKeys := newTestJWTSigner(t).JWKS()
+
+	jwksURI := entityID + "/jwks"
+	signedJWKSURI := entityID + "/signed-jwks"
+	var jwksRequests int
+	httpmock.RegisterResponder(
+		"GET", jwksURI, func(_ *http.Request) (*http.Response, error) {
+			jwksRequests++
+			return httpmock.NewJsonResponse(200, uriKeys)
+		},
+	)
+	httpmock.RegisterResponder(
+		"GET", signedJWKSURI, func(_ *http.Request) (*http.Response, error) {
+			jwt, err := federationSigner.JWT(
+				SignedJWKSPayload{Issuer: entityID, Subject: entityID, Keys: signedKeys},
+				oidfedconst.JWTTypeJWKS,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return httpmock.NewBytesResponse(200, jwt), nil
+		},
+	)
+
+	chain := func(op *OpenIDProviderMetadata) TrustChain {
+		return TrustChain{
+			{
+				EntityStatementPayload: EntityStatementPayload{
+					Issuer:   entityID,
+					Subject:  entityID,
+					Metadata: &Metadata{OpenIDProvider: op},
+				},
+			},
+			{
+				EntityStatementPayload: EntityStatementPayload{
+					Issuer:  "https://ta.example.org",
+					Subject: entityID,
+					JWKS:    federationSigner.JWKS(),
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		op       *OpenIDProviderMetadata
+		expected jwks.JWKS
+		errorExp bool
+	}{
+		{
+			name:     "inline jwks",
+			op:       &OpenIDProviderMetadata{Issuer: entityID, JWKS: &inlineKeys},
+			expected: inlineKeys,
+		},
+		{
+			name:     "jwks_uri",
+			op:       &OpenIDProviderMetadata{Issuer: entityID, JWKSURI: jwksURI},
+			expected: uriKeys,
+		},
+		{
+			name:     "signed_jwks_uri",
+			op:       &OpenIDProviderMetadata{Issuer: entityID, SignedJWKSURI: signedJWKSURI},
+			expected: signedKeys,
+		},
+		{
+			name:     "no keys",
+			op:       &OpenIDProviderMetadata{Issuer: entityID},
+			errorExp: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				keys, err := ProtocolKeys(chain(test.op), "openid_provider", nil)
+				if test.errorExp {
+					if err == nil {
+						t.Fatal("expected error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				expectedKey, _ := test.expected.Key(0)
+				key, _ := keys.Key(0)
+				expectedKID, _ := expectedKey.KeyID()
+				kid, _ := key.KeyID()
+				if keys.Len() != 1 || kid != expectedKID {
+					t.Errorf("got unexpected keys")
+				}
+			},
+		)
+	}
+
+	if _, err := ProtocolKeys(chain(&OpenIDProviderMetadata{JWKSURI: jwksURI}), "openid_provider", nil); err != nil {
+		t.Fatal(err)
+	}
+	if jwksRequests != 1 {
+		t.Errorf("expected jwks to be fetched once and then cached, but got %d requests", jwksRequests)
+	}
+	if _, err := ProtocolKeys(chain(&OpenIDProviderMetadata{}), "openid_relying_party", nil); err == nil {
+		t.Errorf("expected error for missing entity type")
+	}
+}
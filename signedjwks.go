@@ -0,0 +1,104 @@
+package oidfed
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// DefaultSignedJWKSLifetime is the lifetime of a signed JWKS created by a
+// SignedJWKSProducer without an explicit lifetime
+var DefaultSignedJWKSLifetime = 24 * time.Hour
+
+// SignedJWKSProducer produces signed JWKS, i.e. the protocol keys of an
+// entity as a jwt signed with one of its federation keys, so they can be
+// published at a signed_jwks_uri.
+// A SignedJWKSProducer is a http.Handler serving the signed JWKS;
+// it is safe for concurrent use.
+type SignedJWKSProducer struct {
+	entityID string
+	signer   *JWKSSigner
+	lifetime time.Duration
+	mutex    sync.RWMutex
+	keys     jwks.JWKS
+}
+
+// NewSignedJWKSProducer creates a new SignedJWKSProducer that publishes the
+// passed keys for the entity; the jwt is signed with the passed JWKSSigner,
+// which must use a federation key of the entity.
+// If lifetime is not positive, DefaultSignedJWKSLifetime is used.
+func NewSignedJWKSProducer(
+	entityID string, keys jwks.JWKS, signer *JWKSSigner, lifetime time.Duration,
+) *SignedJWKSProducer {
+	if lifetime <= 0 {
+		lifetime = DefaultSignedJWKSLifetime
+	}
+	return &SignedJWKSProducer{
+		entityID: entityID,
+		signer:   signer,
+		lifetime: lifetime,
+		keys:     keys,
+	}
+}
+
+// SignedJWKSProducer returns a SignedJWKSProducer for the FederationEntity,
+// which signs the passed protocol keys with the entity's federation key
+func (f FederationEntity) SignedJWKSProducer(keys jwks.JWKS, lifetime time.Duration) *SignedJWKSProducer {
+	var signer *JWKSSigner
+	if f.EntityStatementSigner != nil {
+		signer = f.EntityStatementSigner.JWKSSigner()
+	}
+	return NewSignedJWKSProducer(f.EntityID, keys, signer, lifetime)
+}
+
+// SetKeys replaces the published keys, e.g. after a key rollover
+func (p *SignedJWKSProducer) SetKeys(keys jwks.JWKS) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.keys = keys
+}
+
+// Payload returns the SignedJWKSPayload for the current keys
+func (p *SignedJWKSProducer) Payload() SignedJWKSPayload {
+	p.mutex.RLock()
+	keys := p.keys
+	p.mutex.RUnlock()
+	now := time.Now()
+	return SignedJWKSPayload{
+		Issuer:    p.entityID,
+		Subject:   p.entityID,
+		IssuedAt:  &unixtime.Unixtime{Time: now},
+		ExpiresAt: &unixtime.Unixtime{Time: now.Add(p.lifetime)},
+		Keys:      keys,
+	}
+}
+
+// JWT returns the signed JWKS jwt for the current keys
+func (p *SignedJWKSProducer) JWT() ([]byte, error) {
+	if p.signer == nil {
+		return nil, errors.New("no signer set for signed jwks")
+	}
+	return p.signer.JWT(p.Payload())
+}
+
+// ServeHTTP implements the http.Handler interface; it serves the signed JWKS
+// with the oidfedconst.ContentTypeJWKS content type
+func (p *SignedJWKSProducer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	jwt, err := p.JWT()
+	if err != nil {
+		internal.Log(err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrorServerError("could not create signed jwks"))
+		return
+	}
+	writeJWTResponse(w, oidfedconst.ContentTypeJWKS, jwt)
+}
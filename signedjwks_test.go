@@ -0,0 +1,77 @@
+package oidfed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lionick/oidfed-lib/oidfedconst"
+)
+
+func TestSignedJWKSProducer(t *testing.T) {
+	const entityID = "https://rp.example.org"
+	federationSigner := newTestJWTSigner(t)
+	fed, err := NewFederationEntity(
+		entityID, nil, nil, federationSigner.EntityStatementSigner(), 0, nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocolKeys := newTestJWTSigner(t).JWKS()
+	producer := fed.SignedJWKSProducer(protocolKeys, time.Minute)
+
+	rec := httptest.NewRecorder()
+	producer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/signed-jwks", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != oidfedconst.ContentTypeJWKS {
+		t.Errorf("unexpected content type '%s'", ct)
+	}
+	p, err := ParseSignedJWKS(rec.Body.Bytes(), entityID, fed.EntityStatementSigner.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IssuedAt == nil || p.ExpiresAt == nil {
+		t.Fatalf("iat and exp must be set")
+	}
+	if lifetime := p.ExpiresAt.Sub(p.IssuedAt.Time); lifetime != time.Minute {
+		t.Errorf("unexpected lifetime %s", lifetime)
+	}
+	if p.Keys.Len() != 1 {
+		t.Errorf("expected 1 key, but got %d", p.Keys.Len())
+	}
+
+	rotated := newTestJWTSigner(t).JWKS()
+	producer.SetKeys(rotated)
+	jwt, err := producer.JWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = ParseSignedJWKS(jwt, entityID, fed.EntityStatementSigner.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := p.Keys.Key(0)
+	rotatedKey, _ := rotated.Key(0)
+	kid, _ := key.KeyID()
+	rotatedKID, _ := rotatedKey.KeyID()
+	if kid != rotatedKID {
+		t.Errorf("rotated keys are not published")
+	}
+
+	rec = httptest.NewRecorder()
+	producer.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/signed-jwks", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status %d for POST", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	NewSignedJWKSProducer(entityID, protocolKeys, nil, 0).ServeHTTP(
+		rec, httptest.NewRequest(http.MethodGet, "/signed-jwks", nil),
+	)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status %d without signer", rec.Code)
+	}
+}
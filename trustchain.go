@@ -58,17 +58,56 @@ func (c TrustChain) ExpiresAt() unixtime.Unixtime {
 // Metadata returns the final Metadata for this TrustChain,
 // i.e. the Metadata of the leaf entity with MetadataPolicies of authorities applied to it.
 func (c TrustChain) Metadata() (*Metadata, error) {
-	if m, set, err := c.cacheGetMetadata(); err != nil {
+	return c.metadata(DefaultPolicyEngine)
+}
+
+func (c TrustChain) metadata(e *PolicyEngine) (*Metadata, error) {
+	if m, set, err := c.cacheGetMetadata(e); err != nil {
 		internal.Log(err.Error())
 	} else if set {
 		return m, nil
 	}
+	final, err := c.resolveMetadata(e.policyOperatorRegistry())
+	if err != nil {
+		return nil, err
+	}
+	if len(c) == 1 {
+		return final, nil
+	}
+	if err = c.cacheSetMetadata(e, final); err != nil {
+		internal.Log(err.Error())
+	}
+	return final, nil
+}
+
+// resolveMetadata applies the MetadataPolicies of the TrustChain to the
+// leaf's Metadata without using the cache
+func (c TrustChain) resolveMetadata(r *policyOperatorRegistry) (*Metadata, error) {
 	if len(c) == 0 {
 		return nil, errors.New("trust chain empty")
 	}
 	if len(c) == 1 {
 		return c[0].Metadata, nil
 	}
+	combinedPolicy, err := c.combinedMetadataPolicy(r)
+	if err != nil {
+		return nil, err
+	}
+	m := c[0].Metadata
+	if m == nil {
+		m = &Metadata{}
+	}
+	final, err := m.applyPolicy(r, combinedPolicy, nil)
+	if err != nil {
+		c.annotateMetadataPolicyError(err)
+		return nil, err
+	}
+	return final, nil
+}
+
+// combinedMetadataPolicy checks the metadata_policy_crit of all statements
+// in the TrustChain and merges their MetadataPolicies into a single one
+func (c TrustChain) combinedMetadataPolicy(r *policyOperatorRegistry) (*MetadataPolicies, error) {
 	metadataPolicies := make([]*MetadataPolicies, len(c))
 	critPolicies := make(map[PolicyOperatorName]struct{})
 	for i, stmt := range c {
@@ -77,30 +116,25 @@ func (c TrustChain) Metadata() (*Metadata, error) {
 			critPolicies[mpoc] = struct{}{}
 		}
 	}
-	unsupportedCritPolicies := slices.Subtract(utils.MapKeys(critPolicies), OperatorOrder)
+	unsupportedCritPolicies := slices.Subtract(utils.MapKeys(critPolicies), r.operatorOrder())
 	if len(unsupportedCritPolicies) > 0 {
-		return nil, errors.Errorf(
-			"the following metadata policy operators are critical but not understood: %v",
-			unsupportedCritPolicies,
-		)
-	}
-
-	combinedPolicy, err := MergeMetadataPolicies(metadataPolicies...)
-	if err != nil {
-		return nil, err
-	}
-	m := c[0].Metadata
-	if m == nil {
-		m = &Metadata{}
+		critErr := &MetadataPolicyCritError{Operators: unsupportedCritPolicies}
+		for _, stmt := range c {
+			for _, mpoc := range stmt.MetadataPolicyCrit {
+				if utils.SliceContains(mpoc, unsupportedCritPolicies) &&
+					!utils.SliceContains(stmt.Issuer, critErr.Issuers) {
+					critErr.Issuers = append(critErr.Issuers, stmt.Issuer)
+				}
+			}
+		}
+		return nil, critErr
 	}
-	final, err := m.ApplyPolicy(combinedPolicy)
+	combined, err := r.mergeMetadataPolicies(metadataPolicies...)
 	if err != nil {
+		c.annotateMetadataPolicyError(err)
 		return nil, err
 	}
-	if err = c.cacheSetMetadata(final); err != nil {
-		internal.Log(err.Error())
-	}
-	return final, nil
+	return combined, nil
 }
 
 // Messages returns the jwts of the TrustChain
@@ -111,27 +145,30 @@ func (c TrustChain) Messages() (msgs JWSMessages) {
 	return
 }
 
-func (c TrustChain) cacheGetMetadata() (
+func (c TrustChain) metadataCacheKey(e *PolicyEngine) (string, error) {
+	hash, err := c.hash()
+	if err != nil {
+		return "", err
+	}
+	return cache.Key(cache.KeyTrustChainResolvedMetadata, e.cacheID()+string(hash)), nil
+}
+
+func (c TrustChain) cacheGetMetadata(e *PolicyEngine) (
 	metadata *Metadata, set bool, err error,
 ) {
-	hash, err := c.hash()
+	key, err := c.metadataCacheKey(e)
 	if err != nil {
 		return nil, false, err
 	}
 	metadata = &Metadata{}
-	set, err = cache.Get(
-		cache.Key(cache.KeyTrustChainResolvedMetadata, string(hash)), metadata,
-	)
+	set, err = cache.Get(key, metadata)
 	return
 }
 
-func (c TrustChain) cacheSetMetadata(metadata *Metadata) error {
-	hash, err := c.hash()
+func (c TrustChain) cacheSetMetadata(e *PolicyEngine, metadata *Metadata) error {
+	key, err := c.metadataCacheKey(e)
 	if err != nil {
 		return err
 	}
-	return cache.Set(
-		cache.Key(cache.KeyTrustChainResolvedMetadata, string(hash)), metadata,
-		unixtime.Until(c.ExpiresAt()),
-	)
+	return cache.Set(key, metadata, unixtime.Until(c.ExpiresAt()))
 }
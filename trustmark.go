@@ -5,6 +5,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
@@ -156,6 +157,7 @@ type TrustMark struct {
 	ExpiresAt     *unixtime.Unixtime     `json:"exp,omitempty"`
 	Ref           string                 `json:"ref,omitempty"`
 	DelegationJWT string                 `json:"delegation,omitempty"`
+	JWTID         string                 `json:"jti,omitempty"`
 	Extra         map[string]interface{} `json:"-"`
 	jwtMsg        *jwx.ParsedJWT
 	delegation    *DelegationJWT
@@ -259,6 +261,12 @@ func getTrustMarkIssuerJWKS(
 
 // VerifyFederation verifies the TrustMark by using the passed trust anchor
 func (tm *TrustMark) VerifyFederation(ta *EntityStatementPayload) error {
+	return tm.verifyFederation(ta, TrustMarkStatusVerifier{})
+}
+
+// verifyFederation verifies the TrustMark by using the passed trust anchor;
+// the passed TrustMarkStatusVerifier is used to verify the delegation status
+func (tm *TrustMark) verifyFederation(ta *EntityStatementPayload, statusVerifier TrustMarkStatusVerifier) error {
 	if ta.TrustMarkIssuers != nil {
 		if tmis, found := ta.TrustMarkIssuers[tm.TrustMarkType]; found {
 			if !slices.Contains(tmis, tm.Issuer) {
@@ -273,14 +281,21 @@ func (tm *TrustMark) VerifyFederation(ta *EntityStatementPayload) error {
 	tmo, tmoFound := ta.TrustMarkOwners[tm.TrustMarkType]
 	if !tmoFound {
 		// no delegation
-		return tm.VerifyExternal(jwks)
+		return tm.verifyExternal(jwks, statusVerifier)
 	}
-	return tm.VerifyExternal(jwks, tmo)
+	return tm.verifyExternal(jwks, statusVerifier, tmo)
 }
 
 // VerifyExternal verifies the TrustMark by using the passed trust mark issuer jwks and optionally the passed
-// trust mark owner jwks
+// trust mark owner jwks; if the TrustMarkOwnerSpec has a DelegationStatusEndpoint, the status of the delegation
+// is also verified, see DelegationJWT.VerifyStatus
 func (tm *TrustMark) VerifyExternal(jwks jwks.JWKS, tmo ...TrustMarkOwnerSpec) error {
+	return tm.verifyExternal(jwks, TrustMarkStatusVerifier{}, tmo...)
+}
+
+func (tm *TrustMark) verifyExternal(
+	jwks jwks.JWKS, statusVerifier TrustMarkStatusVerifier, tmo ...TrustMarkOwnerSpec,
+) error {
 	if err := unixtime.VerifyTime(&tm.IssuedAt, tm.ExpiresAt); err != nil {
 		return err
 	}
@@ -308,7 +323,13 @@ func (tm *TrustMark) VerifyExternal(jwks jwks.JWKS, tmo ...TrustMarkOwnerSpec) e
 	if delegation.Issuer != tmo[0].ID {
 		return errors.New("verify trustmark: delegation jwt not issued by trust mark owner")
 	}
-	return delegation.VerifyExternal(tmo[0].JWKS)
+	if err = delegation.VerifyExternal(tmo[0].JWKS); err != nil {
+		return err
+	}
+	if tmo[0].DelegationStatusEndpoint == "" {
+		return nil
+	}
+	return errors.Wrap(statusVerifier.VerifyDelegationStatus(*delegation, tmo[0]), "verify trustmark")
 }
 
 // DelegationJWT is a type for holding information about a delegation jwt
@@ -319,6 +340,7 @@ type DelegationJWT struct {
 	IssuedAt      unixtime.Unixtime      `json:"iat"`
 	ExpiresAt     *unixtime.Unixtime     `json:"exp,omitempty"`
 	Ref           string                 `json:"ref,omitempty"`
+	JWTID         string                 `json:"jti,omitempty"`
 	Extra         map[string]interface{} `json:"-"`
 	jwtMsg        *jwx.ParsedJWT
 }
@@ -374,7 +396,15 @@ func (djwt DelegationJWT) VerifyExternal(jwks jwks.JWKS) error {
 type TrustMarkIssuer struct {
 	EntityID string
 	*TrustMarkSigner
-	trustMarks map[string]TrustMarkSpec
+	// IssuanceStore records the issued trust marks; it is required for
+	// revoking trust marks and listing the trust marked entities
+	IssuanceStore TrustMarkIssuanceStore
+	// EligibilityCheckers are checked for all trust marks before issuing
+	// them, in addition to the EligibilityCheckers of the TrustMarkSpec
+	EligibilityCheckers []TrustMarkEligibilityChecker
+	trustMarks          map[string]TrustMarkSpec
+	delegations         *trustMarkDelegations
+	current             *currentJWTs
 }
 
 // TrustMarkSpec describes a TrustMark for a TrustMarkIssuer
@@ -386,6 +416,19 @@ type TrustMarkSpec struct {
 	Extra                    map[string]any             `json:"-" yaml:"-"`
 	IncludeExtraClaimsInInfo bool                       `json:"include_extra_claims_in_info" yaml:"include_extra_claims_in_info"`
 	DelegationJWT            string                     `json:"delegation_jwt" yaml:"delegation_jwt"`
+	// DelegationEndpoint is the trust mark owner's delegation endpoint; if
+	// set, the delegation jwt is obtained from there and renewed
+	// automatically instead of using DelegationJWT
+	DelegationEndpoint string `json:"delegation_endpoint,omitempty" yaml:"delegation_endpoint"`
+	// DelegationRenewalPeriod is the remaining lifetime of the delegation
+	// jwt at which it is renewed; defaults to a quarter of its lifetime
+	DelegationRenewalPeriod unixtime.DurationInSeconds `json:"delegation_renewal_period,omitempty" yaml:"delegation_renewal_period"`
+	// DelegationTrustAnchor is the trust anchor whose 'trust_mark_owners'
+	// are used to verify the delegation jwts obtained from the
+	// DelegationEndpoint; it is required if DelegationEndpoint is set
+	DelegationTrustAnchor string `json:"delegation_trust_anchor,omitempty" yaml:"delegation_trust_anchor"`
+	// EligibilityCheckers are checked before issuing this trust mark
+	EligibilityCheckers []TrustMarkEligibilityChecker `json:"-" yaml:"-"`
 }
 
 // MarshalJSON implements the json.Marshaler interface
@@ -448,12 +491,16 @@ func NewTrustMarkIssuer(
 		EntityID:        entityID,
 		TrustMarkSigner: signer,
 		trustMarks:      trustMarks,
+		delegations:     newTrustMarkDelegations(),
+		current:         newCurrentJWTs(),
 	}
 }
 
 // AddTrustMark adds a TrustMarkSpec to the TrustMarkIssuer enabling it to issue the TrustMarkInfo
 func (tmi *TrustMarkIssuer) AddTrustMark(spec TrustMarkSpec) {
 	tmi.trustMarks[spec.TrustMarkType] = spec
+	tmi.delegations.reset(spec.TrustMarkType)
+	tmi.current.reset(spec.TrustMarkType)
 }
 
 // TrustMarkTypes returns a slice of the trust mark ids for which this TrustMarKIssuer can issue TrustMarks
@@ -466,7 +513,10 @@ func (tmi *TrustMarkIssuer) TrustMarkTypes() []string {
 }
 
 // IssueTrustMark issues a TrustMarkInfo for the passed trust mark id and subject; optionally  a custom lifetime can
-// be passed
+// be passed.
+// If the subject is not eligible for the trust mark or its trust marks of this type were revoked and it was not
+// reinstated, a *TrustMarkEligibilityError is returned.
+// For delegated trust marks a valid delegation jwt is embedded and the trust mark does not outlive it.
 func (tmi TrustMarkIssuer) IssueTrustMark(trustMarkType, sub string, lifetime ...time.Duration) (
 	*TrustMarkInfo, error,
 ) {
@@ -474,6 +524,24 @@ func (tmi TrustMarkIssuer) IssueTrustMark(trustMarkType, sub string, lifetime ..
 	if !ok {
 		return nil, errors.Errorf("unknown trustmark '%s'", trustMarkType)
 	}
+	if tmi.IssuanceStore != nil {
+		revoked, err := tmi.IssuanceStore.Revoked(trustMarkType, sub)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not check if trust mark was revoked")
+		}
+		if revoked {
+			return nil, NewTrustMarkEligibilityError(
+				TrustMarkEligibilityReasonRevoked, "trust mark was revoked for '%s'", sub,
+			)
+		}
+	}
+	if err := tmi.CheckEligibility(trustMarkType, sub); err != nil {
+		return nil, err
+	}
+	delegation, err := tmi.delegation(spec)
+	if err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	tm := &TrustMark{
 		Issuer:        tmi.EntityID,
@@ -482,7 +550,6 @@ func (tmi TrustMarkIssuer) IssueTrustMark(trustMarkType, sub string, lifetime ..
 		IssuedAt:      unixtime.Unixtime{Time: now},
 		LogoURI:       spec.LogoURI,
 		Ref:           spec.Ref,
-		DelegationJWT: spec.DelegationJWT,
 		Extra:         spec.Extra,
 	}
 	lf := spec.Lifetime.Duration
@@ -492,10 +559,38 @@ func (tmi TrustMarkIssuer) IssueTrustMark(trustMarkType, sub string, lifetime ..
 	if lf != 0 {
 		tm.ExpiresAt = &unixtime.Unixtime{Time: now.Add(lf)}
 	}
+	if delegation != nil {
+		tm.DelegationJWT = string(delegation.jwtMsg.RawJWT)
+		tm.delegation = delegation
+		if exp := delegation.ExpiresAt; exp != nil && !exp.IsZero() &&
+			(tm.ExpiresAt == nil || tm.ExpiresAt.After(exp.Time)) {
+			tm.ExpiresAt = exp
+		}
+	}
+	if tmi.IssuanceStore != nil {
+		jti, err := uuid.NewRandom()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		tm.JWTID = jti.String()
+	}
 	jwt, err := tmi.TrustMarkSigner.JWT(tm)
 	if err != nil {
 		return nil, err
 	}
+	if tmi.IssuanceStore != nil {
+		if err = tmi.IssuanceStore.Add(
+			IssuedTrustMark{
+				JWTID:         tm.JWTID,
+				TrustMarkType: tm.TrustMarkType,
+				Subject:       tm.Subject,
+				IssuedAt:      tm.IssuedAt,
+				ExpiresAt:     tm.ExpiresAt,
+			},
+		); err != nil {
+			return nil, errors.Wrap(err, "could not record issued trust mark")
+		}
+	}
 	var extra map[string]any
 	if spec.IncludeExtraClaimsInInfo {
 		extra = spec.Extra
@@ -512,7 +607,13 @@ func (tmi TrustMarkIssuer) IssueTrustMark(trustMarkType, sub string, lifetime ..
 type TrustMarkOwner struct {
 	EntityID string
 	*TrustMarkDelegationSigner
+	// DelegationStore records the issued delegations; the Subject of a record
+	// is the delegated trust mark issuer. It is required for revoking
+	// delegations, listing the delegated issuers, and the delegation status
+	// endpoint.
+	DelegationStore TrustMarkIssuanceStore
 	ownedTrustMarks map[string]OwnedTrustMark
+	current         *currentJWTs
 }
 
 // OwnedTrustMark is a type describing the trust marks owned by a TrustMarkOwner
@@ -521,6 +622,9 @@ type OwnedTrustMark struct {
 	DelegationLifetime time.Duration
 	Ref                string
 	Extra              map[string]any
+	// DelegatedIssuers are the trust mark issuers that can obtain a
+	// DelegationJWT from the TrustMarkOwner.DelegationHandler
+	DelegatedIssuers []string
 }
 
 // NewTrustMarkOwner creates a new TrustMarkOwner
@@ -535,20 +639,29 @@ func NewTrustMarkOwner(
 		EntityID:                  entityID,
 		TrustMarkDelegationSigner: signer,
 		ownedTrustMarks:           trustMarks,
+		current:                   newCurrentJWTs(),
 	}
 }
 
 // AddTrustMark adds a new OwnedTrustMark to the TrustMarkOwner
 func (tmo *TrustMarkOwner) AddTrustMark(spec OwnedTrustMark) {
 	tmo.ownedTrustMarks[spec.ID] = spec
+	tmo.current.reset(spec.ID)
 }
 
 // DelegationJWT issues a DelegationJWT (as []byte) for the passed trust mark id and subject; optionally a custom
 // lifetime can be passed
 func (tmo TrustMarkOwner) DelegationJWT(trustMarkType, sub string, lifetime ...time.Duration) ([]byte, error) {
+	_, jwt, err := tmo.issueDelegation(trustMarkType, sub, lifetime...)
+	return jwt, err
+}
+
+func (tmo TrustMarkOwner) issueDelegation(trustMarkType, sub string, lifetime ...time.Duration) (
+	*DelegationJWT, []byte, error,
+) {
 	spec, ok := tmo.ownedTrustMarks[trustMarkType]
 	if !ok {
-		return nil, errors.Errorf("unknown trustmark '%s'", trustMarkType)
+		return nil, nil, errors.Errorf("unknown trustmark '%s'", trustMarkType)
 	}
 	now := time.Now()
 	delegation := &DelegationJWT{
@@ -566,5 +679,29 @@ func (tmo TrustMarkOwner) DelegationJWT(trustMarkType, sub string, lifetime ...t
 	if spec.DelegationLifetime != 0 {
 		delegation.ExpiresAt = &unixtime.Unixtime{Time: now.Add(lf)}
 	}
-	return tmo.TrustMarkDelegationSigner.JWT(delegation)
+	if tmo.DelegationStore != nil {
+		jti, err := uuid.NewRandom()
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		delegation.JWTID = jti.String()
+	}
+	jwt, err := tmo.TrustMarkDelegationSigner.JWT(delegation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tmo.DelegationStore != nil {
+		if err = tmo.DelegationStore.Add(
+			IssuedTrustMark{
+				JWTID:         delegation.JWTID,
+				TrustMarkType: delegation.TrustMarkType,
+				Subject:       delegation.Subject,
+				IssuedAt:      delegation.IssuedAt,
+				ExpiresAt:     delegation.ExpiresAt,
+			},
+		); err != nil {
+			return nil, nil, errors.Wrap(err, "could not record issued delegation")
+		}
+	}
+	return delegation, jwt, nil
 }
@@ -0,0 +1,192 @@
+package oidfed
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// Default values for the TrustMarkManager
+const (
+	DefaultTrustMarkManagerMinBackoff = 30 * time.Second
+	DefaultTrustMarkManagerMaxBackoff = time.Hour
+	DefaultTrustMarkManagerJitter     = 0.1
+)
+
+// TrustMarkManager refreshes the trust marks of an entity configuration in
+// the background.
+// A trust mark is refreshed when its remaining lifetime falls below its
+// RefreshGracePeriod; the refresh time is randomly advanced by up to Jitter
+// times the RefreshGracePeriod, so that refreshes of many entities are not
+// synchronized. Failed refreshes are retried with an exponential backoff
+// between MinBackoff and MaxBackoff.
+// While the TrustMarkManager is running, EntityConfigurationTrustMarkConfig.
+// TrustMarkJWT does not refresh trust marks itself.
+type TrustMarkManager struct {
+	// MinBackoff is the delay before the first retry of a failed refresh
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between retries of a failed refresh
+	MaxBackoff time.Duration
+	// Jitter is the fraction (between 0 and 1) by which refresh and retry
+	// delays are randomly shortened
+	Jitter float64
+
+	trustMarks []*EntityConfigurationTrustMarkConfig
+	mutex      sync.Mutex
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewTrustMarkManager creates a new TrustMarkManager for the passed trust
+// marks, e.g. the FederationEntity.TrustMarks; the trust marks must have
+// been verified with EntityConfigurationTrustMarkConfig.Verify
+func NewTrustMarkManager(trustMarks []*EntityConfigurationTrustMarkConfig) *TrustMarkManager {
+	return &TrustMarkManager{
+		MinBackoff: DefaultTrustMarkManagerMinBackoff,
+		MaxBackoff: DefaultTrustMarkManagerMaxBackoff,
+		Jitter:     DefaultTrustMarkManagerJitter,
+		trustMarks: trustMarks,
+	}
+}
+
+// Start starts refreshing the trust marks in the background; trust marks
+// that are not available are obtained immediately. Calling Start on a
+// running TrustMarkManager has no effect.
+func (m *TrustMarkManager) Start() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	for _, c := range m.trustMarks {
+		if !c.Refresh {
+			continue
+		}
+		c.mutex.Lock()
+		c.managed = true
+		c.mutex.Unlock()
+		m.wg.Add(1)
+		go m.run(c, m.stop)
+	}
+}
+
+// Stop stops refreshing the trust marks and waits until running refreshes
+// are finished
+func (m *TrustMarkManager) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	m.wg.Wait()
+	m.stop = nil
+	for _, c := range m.trustMarks {
+		c.mutex.Lock()
+		c.managed = false
+		c.health.NextRefresh = nil
+		c.mutex.Unlock()
+	}
+}
+
+// Health returns the TrustMarkHealth of all managed trust marks
+func (m *TrustMarkManager) Health() []TrustMarkHealth {
+	health := make([]TrustMarkHealth, len(m.trustMarks))
+	for i, c := range m.trustMarks {
+		health[i] = c.Health()
+	}
+	return health
+}
+
+func (m *TrustMarkManager) run(c *EntityConfigurationTrustMarkConfig, stop chan struct{}) {
+	defer m.wg.Done()
+	failures := 0
+	for {
+		var delay time.Duration
+		if failures > 0 {
+			delay = m.backoff(failures)
+		} else {
+			var ok bool
+			delay, ok = m.refreshDelay(c)
+			if !ok {
+				// trust mark does not expire
+				<-stop
+				return
+			}
+		}
+		next := unixtime.Unixtime{Time: time.Now().Add(delay)}
+		c.mutex.Lock()
+		c.health.NextRefresh = &next
+		c.mutex.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := c.refreshTrustMark(0); err != nil {
+			internal.Logf("Could not refresh trust mark '%s': %s", c.TrustMarkType, err.Error())
+			failures++
+		} else {
+			failures = 0
+		}
+	}
+}
+
+// refreshDelay returns the duration until the trust mark should be
+// refreshed; the second return value is false if the trust mark does not
+// need to be refreshed
+func (m *TrustMarkManager) refreshDelay(c *EntityConfigurationTrustMarkConfig) (time.Duration, bool) {
+	c.mutex.RLock()
+	jwt, expiration := c.JWT, c.expiration
+	c.mutex.RUnlock()
+	if jwt == "" {
+		return 0, true
+	}
+	if expiration.IsZero() {
+		return 0, false
+	}
+	grace := c.RefreshGracePeriod.Duration
+	delay := unixtime.Until(expiration) - grace - time.Duration(m.jitter()*float64(grace))
+	// trust marks with a lifetime shorter than the grace period or
+	// MinLifetime are not refreshed more often than MinBackoff
+	return max(delay, m.minBackoff()), true
+}
+
+// backoff returns the delay before the next retry after the passed number of
+// consecutive failures
+func (m *TrustMarkManager) backoff(failures int) time.Duration {
+	delay, maxBackoff := m.minBackoff(), m.maxBackoff()
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+	return delay - time.Duration(m.jitter()*float64(delay))
+}
+
+func (m *TrustMarkManager) minBackoff() time.Duration {
+	if m.MinBackoff <= 0 {
+		return DefaultTrustMarkManagerMinBackoff
+	}
+	return m.MinBackoff
+}
+
+func (m *TrustMarkManager) maxBackoff() time.Duration {
+	if m.MaxBackoff <= 0 {
+		return max(DefaultTrustMarkManagerMaxBackoff, m.minBackoff())
+	}
+	return max(m.MaxBackoff, m.minBackoff())
+}
+
+func (m *TrustMarkManager) jitter() float64 {
+	if m.Jitter <= 0 {
+		return 0
+	}
+	return rand.Float64() * min(m.Jitter, 1)
+}
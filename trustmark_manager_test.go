@@ -0,0 +1,227 @@
+package oidfed
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+func newTestManagedTrustMarkConfig(
+	t *testing.T, c *EntityConfigurationTrustMarkConfig,
+) *EntityConfigurationTrustMarkConfig {
+	t.Helper()
+	if err := c.Verify("https://managed.example.org", "", newTestJWTSigner(t).TrustMarkSigner()); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTrustMarkManager_Refresh(t *testing.T) {
+	c := newTestManagedTrustMarkConfig(
+		t, &EntityConfigurationTrustMarkConfig{
+			TrustMarkType: "https://trustmarks.org/managed",
+			SelfIssued:    true,
+			SelfIssuanceSpec: TrustMarkSpec{
+				Lifetime: unixtime.DurationInSeconds{Duration: 3 * time.Second},
+			},
+			RefreshGracePeriod: unixtime.DurationInSeconds{Duration: 2 * time.Second},
+			MinLifetime:        unixtime.DurationInSeconds{Duration: time.Second},
+		},
+	)
+	m := NewTrustMarkManager([]*EntityConfigurationTrustMarkConfig{c})
+	m.MinBackoff = 100 * time.Millisecond
+	m.Jitter = 0
+	m.Start()
+	defer m.Stop()
+
+	// concurrent readers, e.g. entity configuration requests
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_, _ = c.TrustMarkJWT()
+					_ = m.Health()
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	waitFor(t, 2*time.Second, func() bool { return m.Health()[0].Available })
+	first, err := c.TrustMarkJWT()
+	if err != nil || first == "" {
+		t.Fatalf("expected trust mark, got error: %v", err)
+	}
+	health := m.Health()[0]
+	if health.LastSuccess == nil || health.ExpiresAt == nil || health.NextRefresh == nil {
+		t.Errorf("incomplete health: %+v", health)
+	}
+	if health.TrustMarkType != "https://trustmarks.org/managed" {
+		t.Errorf("unexpected trust mark type '%s'", health.TrustMarkType)
+	}
+	waitFor(
+		t, 3*time.Second, func() bool {
+			jwt, _ := c.TrustMarkJWT()
+			return jwt != first
+		},
+	)
+}
+
+func TestTrustMarkManager_Backoff(t *testing.T) {
+	c := newTestManagedTrustMarkConfig(
+		t, &EntityConfigurationTrustMarkConfig{
+			TrustMarkType:   "https://trustmarks.org/managed",
+			TrustMarkIssuer: "https://unknown-tmi.manager.example.org",
+		},
+	)
+	m := NewTrustMarkManager([]*EntityConfigurationTrustMarkConfig{c})
+	m.MinBackoff = 20 * time.Millisecond
+	m.MaxBackoff = 50 * time.Millisecond
+	m.Start()
+
+	waitFor(t, 2*time.Second, func() bool { return m.Health()[0].ConsecutiveFailures >= 3 })
+	health := m.Health()[0]
+	if health.Available || health.LastError == "" || health.LastSuccess != nil {
+		t.Errorf("unexpected health: %+v", health)
+	}
+	start := time.Now()
+	if _, err := c.TrustMarkJWT(); err == nil {
+		t.Errorf("expected error for unavailable trust mark")
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Errorf("TrustMarkJWT must not block while managed")
+	}
+	m.Stop()
+	if health = c.Health(); health.NextRefresh != nil {
+		t.Errorf("next refresh must be cleared after stop")
+	}
+}
+
+func TestTrustMarkManager_backoff(t *testing.T) {
+	m := &TrustMarkManager{
+		MinBackoff: time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+	for failures, expected := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  5 * time.Second,
+		10: 5 * time.Second,
+	} {
+		if delay := m.backoff(failures); delay != expected {
+			t.Errorf("expected backoff %s after %d failures, but got %s", expected, failures, delay)
+		}
+	}
+	m.Jitter = 0.5
+	for range 100 {
+		if delay := m.backoff(2); delay > 2*time.Second || delay < time.Second {
+			t.Fatalf("jittered backoff out of range: %s", delay)
+		}
+	}
+}
+
+func TestTrustMarkManager_refreshDelay(t *testing.T) {
+	m := &TrustMarkManager{MinBackoff: time.Second}
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		min      time.Duration
+		max      time.Duration
+	}{
+		{
+			name:     "refresh before grace period",
+			lifetime: time.Hour,
+			min:      58 * time.Minute,
+			max:      time.Hour - time.Minute,
+		},
+		{
+			name:     "lifetime shorter than grace period",
+			lifetime: 30 * time.Second,
+			min:      time.Second,
+			max:      time.Second,
+		},
+		{
+			name:     "lifetime shorter than min lifetime",
+			lifetime: 5 * time.Second,
+			min:      time.Second,
+			max:      time.Second,
+		},
+		{
+			name:     "expired",
+			lifetime: -time.Second,
+			min:      time.Second,
+			max:      time.Second,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				c := &EntityConfigurationTrustMarkConfig{
+					JWT:                "jwt",
+					RefreshGracePeriod: unixtime.DurationInSeconds{Duration: time.Minute},
+					MinLifetime:        unixtime.DurationInSeconds{Duration: 10 * time.Second},
+					expiration:         unixtime.Unixtime{Time: time.Now().Add(test.lifetime)},
+				}
+				delay, ok := m.refreshDelay(c)
+				if !ok {
+					t.Fatalf("trust mark must be refreshed")
+				}
+				if delay < test.min || delay > test.max {
+					t.Errorf("refresh delay %s not in [%s, %s]", delay, test.min, test.max)
+				}
+			},
+		)
+	}
+}
+
+func TestEntityConfigurationTrustMarkConfig_TrustMarkJWT_Concurrent(t *testing.T) {
+	c := newTestManagedTrustMarkConfig(
+		t, &EntityConfigurationTrustMarkConfig{
+			TrustMarkType: "https://trustmarks.org/unmanaged",
+			SelfIssued:    true,
+			SelfIssuanceSpec: TrustMarkSpec{
+				Lifetime: unixtime.DurationInSeconds{Duration: time.Minute},
+			},
+			RefreshGracePeriod: unixtime.DurationInSeconds{Duration: 2 * time.Minute},
+		},
+	)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				_, _ = c.TrustMarkJWT()
+				_ = c.Health()
+			}
+		}()
+	}
+	wg.Wait()
+	if jwt, err := c.TrustMarkJWT(); err != nil || jwt == "" {
+		t.Errorf("expected trust mark, got error: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package oidfed
 
 import (
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/lestrrat-go/jwx/v3/jwt"
@@ -24,10 +25,13 @@ type EntityConfigurationTrustMarkConfig struct {
 	MinLifetime          unixtime.DurationInSeconds `yaml:"min_lifetime"`
 	RefreshGracePeriod   unixtime.DurationInSeconds `yaml:"refresh_grace_period"`
 	expiration           unixtime.Unixtime
-	lastTried            unixtime.Unixtime
 	sub                  string
 	ownTrustMarkEndpoint string
 	ownTrustMarkIssuer   *TrustMarkIssuer
+	mutex                sync.RWMutex
+	health               TrustMarkHealth
+	refreshing           bool
+	managed              bool
 }
 
 // Verify verifies that the EntityConfigurationTrustMarkConfig is correct and also extracts trust mark id and issuer
@@ -78,42 +82,127 @@ func (c *EntityConfigurationTrustMarkConfig) Verify(
 	return nil
 }
 
+// TrustMarkHealth describes the state of a trust mark that is included in an
+// entity configuration and refreshed from its trust mark issuer
+type TrustMarkHealth struct {
+	TrustMarkType       string             `json:"trust_mark_type"`
+	TrustMarkIssuer     string             `json:"trust_mark_issuer"`
+	Available           bool               `json:"available"`
+	ExpiresAt           *unixtime.Unixtime `json:"expires_at,omitempty"`
+	LastSuccess         *unixtime.Unixtime `json:"last_success,omitempty"`
+	LastAttempt         *unixtime.Unixtime `json:"last_attempt,omitempty"`
+	LastError           string             `json:"last_error,omitempty"`
+	ConsecutiveFailures int                `json:"consecutive_failures"`
+	NextRefresh         *unixtime.Unixtime `json:"next_refresh,omitempty"`
+}
+
+// Health returns the TrustMarkHealth of the trust mark
+func (c *EntityConfigurationTrustMarkConfig) Health() TrustMarkHealth {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	h := c.health
+	h.TrustMarkType = c.TrustMarkType
+	h.TrustMarkIssuer = c.TrustMarkIssuer
+	if c.JWT != "" {
+		h.Available = c.expiration.IsZero() || c.expiration.After(time.Now())
+		if !c.expiration.IsZero() {
+			exp := c.expiration
+			h.ExpiresAt = &exp
+		}
+	}
+	return h
+}
+
 // TrustMarkJWT returns a trust mark jwt for the linked trust mark,
-// if needed the trust mark is refreshed using the trust mark issuer's trust mark endpoint
+// if needed the trust mark is refreshed using the trust mark issuer's trust mark endpoint.
+// If the trust mark is managed by a running TrustMarkManager, it is only
+// refreshed by the manager and TrustMarkJWT does not block.
 func (c *EntityConfigurationTrustMarkConfig) TrustMarkJWT() (string, error) {
+	c.mutex.RLock()
+	jwt, expiration, managed := c.JWT, c.expiration, c.managed
+	c.mutex.RUnlock()
 	if !c.Refresh {
-		return c.JWT, nil
+		return jwt, nil
+	}
+	valid := jwt != "" && (expiration.IsZero() || unixtime.Until(expiration) > c.MinLifetime.Duration)
+	if managed {
+		if !valid {
+			return "", errors.Errorf("trust mark '%s' is not available", c.TrustMarkType)
+		}
+		return jwt, nil
 	}
-	if c.JWT != "" && unixtime.Until(c.expiration) > c.MinLifetime.Duration {
-		if unixtime.Until(c.expiration) < c.RefreshGracePeriod.Duration {
-			go c.refresh()
+	if valid {
+		if !expiration.IsZero() && unixtime.Until(expiration) < c.RefreshGracePeriod.Duration {
+			go func() {
+				if err := c.refresh(); err != nil {
+					internal.Log(err)
+				}
+			}()
 		}
-		return c.JWT, nil
+		return jwt, nil
 	}
 	err := c.refresh()
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.JWT, err
 }
 
-// refresh refreshes the trust mark at the trust mark issuer's trust mark endpoint
+// refresh refreshes the trust mark at the trust mark issuer's trust mark
+// endpoint, but only once a minute
 func (c *EntityConfigurationTrustMarkConfig) refresh() error {
-	if time.Since(c.lastTried.Time) < time.Minute {
-		// Only try once a minute to obtain a new trust mark
-		return errors.New("only trying to refresh trust mark once a minute")
+	return c.refreshTrustMark(time.Minute)
+}
+
+// refreshTrustMark obtains a new trust mark and updates the health; it does
+// nothing if a refresh is already in progress or if the last attempt was
+// less than minInterval ago
+func (c *EntityConfigurationTrustMarkConfig) refreshTrustMark(minInterval time.Duration) error {
+	c.mutex.Lock()
+	if c.refreshing {
+		c.mutex.Unlock()
+		return errors.New("trust mark refresh already in progress")
+	}
+	if c.health.LastAttempt != nil && time.Since(c.health.LastAttempt.Time) < minInterval {
+		c.mutex.Unlock()
+		return errors.Errorf("only trying to refresh trust mark once every %s", minInterval)
+	}
+	c.refreshing = true
+	now := unixtime.Now()
+	c.health.LastAttempt = &now
+	c.mutex.Unlock()
+
+	jwt, expiration, err := c.obtainTrustMark()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.refreshing = false
+	if err != nil {
+		c.health.LastError = err.Error()
+		c.health.ConsecutiveFailures++
+		return err
 	}
-	c.lastTried = unixtime.Now()
+	c.JWT = jwt
+	if expiration != nil {
+		c.expiration = *expiration
+	} else {
+		c.expiration = unixtime.Unixtime{}
+	}
+	success := unixtime.Now()
+	c.health.LastSuccess = &success
+	c.health.LastError = ""
+	c.health.ConsecutiveFailures = 0
+	return nil
+}
+
+// obtainTrustMark obtains a new trust mark jwt and its expiration, either by
+// issuing it or from the trust mark issuer's trust mark endpoint
+func (c *EntityConfigurationTrustMarkConfig) obtainTrustMark() (string, *unixtime.Unixtime, error) {
 	if c.SelfIssued {
-		tmi, err := c.ownTrustMarkIssuer.IssueTrustMark(c.TrustMarkType, c.sub)
+		info, err := c.ownTrustMarkIssuer.IssueTrustMark(c.TrustMarkType, c.sub)
 		if err != nil {
-			return err
-		}
-		c.JWT = tmi.TrustMarkJWT
-		exp := tmi.trustmark.ExpiresAt
-		if exp != nil {
-			c.expiration = *exp
-		} else {
-			c.expiration = unixtime.Unixtime{}
+			return "", nil, err
 		}
-		return nil
+		return info.TrustMarkJWT, info.trustmark.ExpiresAt, nil
 	}
 
 	var endpoint string
@@ -122,11 +211,11 @@ func (c *EntityConfigurationTrustMarkConfig) refresh() error {
 	} else {
 		tmi, err := GetEntityConfiguration(c.TrustMarkIssuer)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 		if tmi.Metadata == nil || tmi.Metadata.FederationEntity == nil || tmi.Metadata.
 			FederationEntity.FederationTrustMarkEndpoint == "" {
-			return errors.New("could not obtain trust mark endpoint of trust mark issuer")
+			return "", nil, errors.New("could not obtain trust mark endpoint of trust mark issuer")
 		}
 		endpoint = tmi.Metadata.FederationEntity.FederationTrustMarkEndpoint
 	}
@@ -135,20 +224,14 @@ func (c *EntityConfigurationTrustMarkConfig) refresh() error {
 	params.Add("sub", c.sub)
 	res, errRes, err := http.Get(endpoint, params, nil)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	if errRes != nil {
-		return errRes.Err()
+		return "", nil, errRes.Err()
 	}
 	tm, err := ParseTrustMark(res.Body())
 	if err != nil {
-		return err
+		return "", nil, err
 	}
-	c.JWT = string(tm.jwtMsg.RawJWT)
-	if tm.ExpiresAt != nil {
-		c.expiration = *tm.ExpiresAt
-	} else {
-		c.expiration = unixtime.Unixtime{}
-	}
-	return nil
+	return string(tm.jwtMsg.RawJWT), tm.ExpiresAt, nil
 }
@@ -0,0 +1,218 @@
+package oidfed
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal"
+)
+
+// TrustMarkCatalogue lists the trust mark types recognised by a trust anchor,
+// i.e. the trust mark types for which the trust anchor defines the allowed
+// issuers ('trust_mark_issuers') or the owner ('trust_mark_owners'), together
+// with display information about the trust mark types and their issuers and
+// owners
+type TrustMarkCatalogue struct {
+	TrustAnchor    string              `json:"trust_anchor"`
+	TrustMarkTypes []TrustMarkTypeInfo `json:"trust_mark_types"`
+}
+
+// TrustMarkTypeInfo describes a trust mark type in a TrustMarkCatalogue
+type TrustMarkTypeInfo struct {
+	TrustMarkType string `json:"trust_mark_type"`
+	// Ref and LogoURI are the 'ref' and 'logo_uri' claims that the issuers
+	// include in the trust marks of this type, see
+	// TrustMarkCatalogue.AddTrustMarkTypeDisplayInfo
+	Ref     string `json:"ref,omitempty"`
+	LogoURI string `json:"logo_uri,omitempty"`
+	// Issuers are the trust mark issuers allowed by the trust anchor; if
+	// empty, the trust anchor does not restrict the issuers of this type
+	Issuers []TrustMarkEntityInfo `json:"trust_mark_issuers,omitempty"`
+	Owner   *TrustMarkEntityInfo  `json:"trust_mark_owner,omitempty"`
+}
+
+// TrustMarkEntityInfo holds display information about a trust mark issuer or
+// owner; it is taken from the federation_entity metadata of the entity's
+// entity configuration, if it can be obtained
+type TrustMarkEntityInfo struct {
+	EntityID         string `json:"entity_id"`
+	DisplayName      string `json:"display_name,omitempty"`
+	OrganizationName string `json:"organization_name,omitempty"`
+	LogoURI          string `json:"logo_uri,omitempty"`
+	InformationURI   string `json:"information_uri,omitempty"`
+	OrganizationURI  string `json:"organization_uri,omitempty"`
+}
+
+// GetTrustMarkCatalogue obtains the entity configuration of the passed trust
+// anchor and returns its TrustMarkCatalogue, see NewTrustMarkCatalogue
+func GetTrustMarkCatalogue(trustAnchor string) (*TrustMarkCatalogue, error) {
+	ta, err := GetEntityConfiguration(trustAnchor)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not obtain trust anchor entity configuration")
+	}
+	return NewTrustMarkCatalogue(&ta.EntityStatementPayload), nil
+}
+
+// NewTrustMarkCatalogue creates the TrustMarkCatalogue for the passed trust
+// anchor entity configuration; the trust mark types are sorted. The display
+// information of issuers and owners is obtained from their entity
+// configurations; if an entity configuration cannot be obtained, only the
+// entity id is included. The 'ref' and 'logo_uri' of the trust mark types
+// are not set, see TrustMarkCatalogue.AddTrustMarkTypeDisplayInfo.
+func NewTrustMarkCatalogue(ta *EntityStatementPayload) *TrustMarkCatalogue {
+	types := slices.Concat(
+		slices.Collect(maps.Keys(ta.TrustMarkIssuers)), slices.Collect(maps.Keys(ta.TrustMarkOwners)),
+	)
+	slices.Sort(types)
+	types = slices.Compact(types)
+
+	infos := make(map[string]TrustMarkEntityInfo)
+	entityInfo := func(entityID string) TrustMarkEntityInfo {
+		info, ok := infos[entityID]
+		if !ok {
+			info = getTrustMarkEntityInfo(entityID)
+			infos[entityID] = info
+		}
+		return info
+	}
+
+	catalogue := &TrustMarkCatalogue{
+		TrustAnchor:    ta.Subject,
+		TrustMarkTypes: make([]TrustMarkTypeInfo, len(types)),
+	}
+	for i, trustMarkType := range types {
+		typeInfo := TrustMarkTypeInfo{TrustMarkType: trustMarkType}
+		for _, issuer := range ta.TrustMarkIssuers[trustMarkType] {
+			typeInfo.Issuers = append(typeInfo.Issuers, entityInfo(issuer))
+		}
+		if owner, ok := ta.TrustMarkOwners[trustMarkType]; ok {
+			ownerInfo := entityInfo(owner.ID)
+			typeInfo.Owner = &ownerInfo
+		}
+		catalogue.TrustMarkTypes[i] = typeInfo
+	}
+	return catalogue
+}
+
+func getTrustMarkEntityInfo(entityID string) TrustMarkEntityInfo {
+	info := TrustMarkEntityInfo{EntityID: entityID}
+	ec, err := GetEntityConfiguration(entityID)
+	if err != nil {
+		internal.Logf("Could not obtain entity configuration of '%s': %s", entityID, err.Error())
+		return info
+	}
+	if ec.Metadata == nil || ec.Metadata.FederationEntity == nil {
+		return info
+	}
+	fe := ec.Metadata.FederationEntity
+	info.DisplayName = fe.DisplayName
+	info.OrganizationName = fe.OrganizationName
+	info.LogoURI = fe.LogoURI
+	info.InformationURI = fe.InformationURI
+	info.OrganizationURI = fe.OrganizationURI
+	return info
+}
+
+// TrustMarkType returns the TrustMarkTypeInfo for the passed trust mark type,
+// or nil if the trust mark type is not in the TrustMarkCatalogue
+func (c TrustMarkCatalogue) TrustMarkType(trustMarkType string) *TrustMarkTypeInfo {
+	for _, info := range c.TrustMarkTypes {
+		if info.TrustMarkType == trustMarkType {
+			return &info
+		}
+	}
+	return nil
+}
+
+// AddTrustMarkTypeDisplayInfo sets the 'ref' and 'logo_uri' of the trust
+// mark types in the TrustMarkCatalogue from the passed TrustMarkInfos, e.g.
+// the CollectedEntity.TrustMarks; the TrustMarkInfos should already be
+// verified. Only trust marks issued by an allowed issuer of the type are
+// used and values that are already set are not overwritten.
+func (c *TrustMarkCatalogue) AddTrustMarkTypeDisplayInfo(trustMarks TrustMarkInfos) {
+	for i := range trustMarks {
+		tm, err := trustMarks[i].TrustMark()
+		if err != nil {
+			continue
+		}
+		j := slices.IndexFunc(
+			c.TrustMarkTypes, func(info TrustMarkTypeInfo) bool {
+				return info.TrustMarkType == tm.TrustMarkType
+			},
+		)
+		if j < 0 {
+			continue
+		}
+		typeInfo := &c.TrustMarkTypes[j]
+		if len(typeInfo.Issuers) > 0 && !slices.ContainsFunc(
+			typeInfo.Issuers, func(e TrustMarkEntityInfo) bool {
+				return e.EntityID == tm.Issuer
+			},
+		) {
+			continue
+		}
+		if typeInfo.Ref == "" {
+			typeInfo.Ref = tm.Ref
+		}
+		if typeInfo.LogoURI == "" {
+			typeInfo.LogoURI = tm.LogoURI
+		}
+	}
+}
+
+// EnrichTrustMarks adds display information from the TrustMarkCatalogue to
+// the Extra claims of the passed TrustMarkInfos, e.g. the CollectedEntity.
+// TrustMarks:
+//   - 'ref' and 'logo_uri' of the trust mark or, if the trust mark does not
+//     have them, of the trust mark type; existing claims are not overwritten
+//   - 'trust_mark_issuer', the TrustMarkEntityInfo of the trust mark's issuer
+//   - 'trust_mark_owner', the TrustMarkEntityInfo of the trust mark type's
+//     owner, if the trust anchor defines one
+//
+// TrustMarkInfos whose trust mark cannot be parsed are not enriched.
+func (c TrustMarkCatalogue) EnrichTrustMarks(trustMarks TrustMarkInfos) {
+	for i := range trustMarks {
+		tm, err := trustMarks[i].TrustMark()
+		if err != nil {
+			continue
+		}
+		extra := maps.Clone(trustMarks[i].Extra)
+		if extra == nil {
+			extra = make(map[string]any)
+		}
+		ref, logoURI := tm.Ref, tm.LogoURI
+		var issuer *TrustMarkEntityInfo
+		if typeInfo := c.TrustMarkType(tm.TrustMarkType); typeInfo != nil {
+			if ref == "" {
+				ref = typeInfo.Ref
+			}
+			if logoURI == "" {
+				logoURI = typeInfo.LogoURI
+			}
+			if j := slices.IndexFunc(
+				typeInfo.Issuers, func(e TrustMarkEntityInfo) bool {
+					return e.EntityID == tm.Issuer
+				},
+			); j >= 0 {
+				issuer = &typeInfo.Issuers[j]
+			}
+			if typeInfo.Owner != nil {
+				extra["trust_mark_owner"] = *typeInfo.Owner
+			}
+		}
+		if issuer == nil {
+			info := getTrustMarkEntityInfo(tm.Issuer)
+			issuer = &info
+		}
+		if _, ok := extra["ref"]; !ok && ref != "" {
+			extra["ref"] = ref
+		}
+		if _, ok := extra["logo_uri"]; !ok && logoURI != "" {
+			extra["logo_uri"] = logoURI
+		}
+		extra["trust_mark_issuer"] = *issuer
+		trustMarks[i].Extra = extra
+	}
+}
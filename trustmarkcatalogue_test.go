@@ -0,0 +1,218 @@
+package oidfed
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lionick/oidfed-lib/apimodel"
+)
+
+func TestGetTrustMarkCatalogue(t *testing.T) {
+	catalogue, err := GetTrustMarkCatalogue(taWithTmo.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalogue.TrustAnchor != taWithTmo.EntityID {
+		t.Errorf("unexpected trust anchor '%s'", catalogue.TrustAnchor)
+	}
+	var types []string
+	for _, info := range catalogue.TrustMarkTypes {
+		types = append(types, info.TrustMarkType)
+	}
+	expectedTypes := []string{
+		"https://trustmarks.org/other",
+		"https://trustmarks.org/test",
+		"https://trustmarks.org/tm-delegated",
+		"https://trustmarks.org/tm1",
+		"https://trustmarks.org/tm4",
+	}
+	if !reflect.DeepEqual(types, expectedTypes) {
+		t.Fatalf("expected trust mark types %v, but got %v", expectedTypes, types)
+	}
+
+	tests := []struct {
+		name            string
+		trustMarkType   string
+		expectedIssuers []string
+		expectedOwner   string
+	}{
+		{
+			name:            "issuers",
+			trustMarkType:   "https://trustmarks.org/tm1",
+			expectedIssuers: []string{tmi1.EntityID, "https://tmi2.example.org"},
+		},
+		{
+			name:            "issuers and owner",
+			trustMarkType:   "https://trustmarks.org/tm-delegated",
+			expectedIssuers: []string{tmi1.EntityID},
+			expectedOwner:   tmo.EntityID,
+		},
+		{
+			name:          "owner only",
+			trustMarkType: "https://trustmarks.org/other",
+			expectedOwner: "https://other.owner.org",
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				info := catalogue.TrustMarkType(test.trustMarkType)
+				if info == nil {
+					t.Fatalf("trust mark type not in catalogue")
+				}
+				var issuers []string
+				for _, issuer := range info.Issuers {
+					issuers = append(issuers, issuer.EntityID)
+					if (issuer.EntityID == tmi1.EntityID) != (issuer.OrganizationName != "") {
+						t.Errorf("unexpected display information for '%s': %+v", issuer.EntityID, issuer)
+					}
+				}
+				if !reflect.DeepEqual(issuers, test.expectedIssuers) {
+					t.Errorf("expected issuers %v, but got %v", test.expectedIssuers, issuers)
+				}
+				var owner string
+				if info.Owner != nil {
+					owner = info.Owner.EntityID
+				}
+				if owner != test.expectedOwner {
+					t.Errorf("expected owner '%s', but got '%s'", test.expectedOwner, owner)
+				}
+			},
+		)
+	}
+	if catalogue.TrustMarkType("https://trustmarks.org/unknown") != nil {
+		t.Errorf("unknown trust mark type must not be in catalogue")
+	}
+}
+
+func TestTrustMarkCatalogue_EnrichTrustMarks(t *testing.T) {
+	const sub = "https://enriched.catalogue.example.org"
+	catalogue, err := GetTrustMarkCatalogue(taWithTmo.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withRef, err := tmi1.IssueTrustMark("https://trustmarks.org/tm2", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delegated, err := tmi1.IssueTrustMark("https://trustmarks.org/test", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustMarks := TrustMarkInfos{*withRef, *delegated}
+	catalogue.EnrichTrustMarks(trustMarks)
+
+	if ref := trustMarks[0].Extra["ref"]; ref != "https://trustmarks.org/tm2/info" {
+		t.Errorf("unexpected ref '%v'", ref)
+	}
+	if logo := trustMarks[0].Extra["logo_uri"]; logo != "https://trustmarks.org/tm2/logo" {
+		t.Errorf("unexpected logo_uri '%v'", logo)
+	}
+	if _, ok := trustMarks[0].Extra["trust_mark_owner"]; ok {
+		t.Errorf("trust mark without owner must not have owner information")
+	}
+	for _, tm := range trustMarks {
+		issuer, ok := tm.Extra["trust_mark_issuer"].(TrustMarkEntityInfo)
+		if !ok || issuer.EntityID != tmi1.EntityID || issuer.OrganizationName == "" {
+			t.Errorf("unexpected issuer information: %+v", tm.Extra["trust_mark_issuer"])
+		}
+	}
+	owner, ok := trustMarks[1].Extra["trust_mark_owner"].(TrustMarkEntityInfo)
+	if !ok || owner.EntityID != tmo.EntityID {
+		t.Errorf("unexpected owner information: %+v", trustMarks[1].Extra["trust_mark_owner"])
+	}
+	if withRef.Extra != nil {
+		t.Errorf("enriching must not modify the extra claims of the original trust mark infos")
+	}
+}
+
+func TestEnrichTrustMarks(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/test"
+	op := newMockOP("https://op.catalogue.example.org", &OpenIDProviderMetadata{})
+	info, err := tmi1.IssueTrustMark(trustMarkType, op.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tmi1 is shared with the trust mark list tests
+	t.Cleanup(
+		func() {
+			_ = tmi1.Revoke(trustMarkType, op.EntityID)
+		},
+	)
+	op.trustMarks = TrustMarkInfos{*info}
+
+	req := apimodel.EntityCollectionRequest{
+		TrustAnchor: taWithTmo.EntityID,
+		Claims:      []string{"trust_marks"},
+	}
+	collected, _ := (&SimpleEntityCollector{}).collectEntity(
+		req, op.EntityID, &lazyEntityConfiguration{entityID: taWithTmo.EntityID},
+	)
+	if collected == nil || len(collected.TrustMarks) != 1 {
+		t.Fatalf("expected collected entity with trust mark, but got %+v", collected)
+	}
+	enrichTrustMarks(taWithTmo.EntityID, []*CollectedEntity{collected})
+	owner, ok := collected.TrustMarks[0].Extra["trust_mark_owner"].(TrustMarkEntityInfo)
+	if !ok || owner.EntityID != tmo.EntityID {
+		t.Errorf("unexpected owner information: %+v", collected.TrustMarks[0].Extra)
+	}
+}
+
+func TestTrustMarkCatalogue_AddTrustMarkTypeDisplayInfo(t *testing.T) {
+	const sub = "https://display.catalogue.example.org"
+	catalogue, err := GetTrustMarkCatalogue(taWithTmo.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := catalogue.TrustMarkType("https://trustmarks.org/tm1"); info.Ref != "" || info.LogoURI != "" {
+		t.Fatalf("trust mark type display information must only be set from trust marks: %+v", info)
+	}
+	// tmi2 is not an allowed issuer of tm1 for the trust anchor
+	notAllowed, err := tmi2.IssueTrustMark("https://trustmarks.org/tm1", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutRef, err := tmi1.IssueTrustMark("https://trustmarks.org/tm1", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tmi1 is shared with the trust mark list tests
+	t.Cleanup(
+		func() {
+			_ = tmi1.Revoke("https://trustmarks.org/tm1", sub)
+		},
+	)
+	catalogue.AddTrustMarkTypeDisplayInfo(TrustMarkInfos{*notAllowed, *withoutRef})
+	info := catalogue.TrustMarkType("https://trustmarks.org/tm1")
+	if info.Ref != "" || info.LogoURI != "" {
+		t.Errorf("display information of a not allowed issuer must not be used: %+v", info)
+	}
+
+	catalogue.TrustMarkTypes = append(
+		catalogue.TrustMarkTypes, TrustMarkTypeInfo{TrustMarkType: "https://trustmarks.org/tm2"},
+	)
+	withRef, err := tmi2.IssueTrustMark("https://trustmarks.org/tm2", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogue.AddTrustMarkTypeDisplayInfo(TrustMarkInfos{*withRef})
+	info = catalogue.TrustMarkType("https://trustmarks.org/tm2")
+	if info.Ref != "https://trustmarks.org/tm2/info" || info.LogoURI != "https://trustmarks.org/tm2/logo" {
+		t.Errorf("unexpected display information: %+v", info)
+	}
+
+	catalogue.TrustMarkTypes[slices.IndexFunc(
+		catalogue.TrustMarkTypes, func(info TrustMarkTypeInfo) bool {
+			return info.TrustMarkType == "https://trustmarks.org/tm1"
+		},
+	)].Ref = "https://trustmarks.org/tm1/info"
+	trustMarks := TrustMarkInfos{*withoutRef}
+	catalogue.EnrichTrustMarks(trustMarks)
+	if ref := trustMarks[0].Extra["ref"]; ref != "https://trustmarks.org/tm1/info" {
+		t.Errorf("expected ref of the trust mark type, but got '%v'", ref)
+	}
+	if _, ok := trustMarks[0].Extra["logo_uri"]; ok {
+		t.Errorf("unexpected logo_uri '%v'", trustMarks[0].Extra["logo_uri"])
+	}
+}
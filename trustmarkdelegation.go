@@ -0,0 +1,296 @@
+package oidfed
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal"
+	ihttp "github.com/lionick/oidfed-lib/internal/http"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// DelegationHandler returns a http.Handler for the trust mark delegation
+// endpoint of the TrustMarkOwner; it accepts GET requests with the required
+// 'trust_mark_type' and 'sub' query parameters and responds with a
+// DelegationJWT for the subject. A delegation the handler issued before is
+// returned again while it is active and less than half of its lifetime has
+// passed, so that repeated requests do not issue and record a new
+// delegation each time.
+// Delegations are only issued to the DelegatedIssuers of the OwnedTrustMark
+// whose delegation was not revoked, see RevokeDelegation.
+// The requester is not authenticated, since a DelegationJWT can only be
+// used by its subject.
+func (tmo *TrustMarkOwner) DelegationHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !allowMethods(w, r, http.MethodGet) {
+				return
+			}
+			trustMarkType := r.URL.Query().Get("trust_mark_type")
+			sub := r.URL.Query().Get("sub")
+			if trustMarkType == "" || sub == "" {
+				writeErrorResponse(
+					w, http.StatusBadRequest,
+					ErrorInvalidRequest("required parameters 'trust_mark_type' and 'sub' not given"),
+				)
+				return
+			}
+			spec, ok := tmo.ownedTrustMarks[trustMarkType]
+			if !ok {
+				writeErrorResponse(w, http.StatusNotFound, ErrorNotFound("unknown trust mark type"))
+				return
+			}
+			if !slices.Contains(spec.DelegatedIssuers, sub) {
+				writeErrorResponse(
+					w, http.StatusForbidden,
+					ErrorInvalidSubject("subject is not a delegated issuer for this trust mark type"),
+				)
+				return
+			}
+			if tmo.DelegationStore != nil {
+				revoked, err := tmo.DelegationStore.Revoked(trustMarkType, sub)
+				if err != nil {
+					internal.Log(err)
+					writeErrorResponse(
+						w, http.StatusInternalServerError, ErrorServerError("could not check delegation status"),
+					)
+					return
+				}
+				if revoked {
+					writeErrorResponse(
+						w, http.StatusForbidden,
+						ErrorInvalidSubject("delegation for this subject and trust mark type was revoked"),
+					)
+					return
+				}
+			}
+			jwt, err := tmo.currentDelegation(trustMarkType, sub)
+			if err != nil {
+				internal.Log(err)
+				writeErrorResponse(
+					w, http.StatusInternalServerError, ErrorServerError("could not issue delegation jwt"),
+				)
+				return
+			}
+			writeJWTResponse(w, oidfedconst.ContentTypeTrustMarkDelegation, jwt)
+		},
+	)
+}
+
+// currentDelegation returns the DelegationJWT of the passed type the
+// DelegationHandler last issued to the passed subject, if it is still active
+// and less than half of its lifetime has passed; otherwise a new delegation
+// is issued
+func (tmo *TrustMarkOwner) currentDelegation(trustMarkType, sub string) ([]byte, error) {
+	if jwt, ok := tmo.current.get(trustMarkType, sub, tmo.DelegationStore); ok {
+		return jwt, nil
+	}
+	delegation, jwt, err := tmo.issueDelegation(trustMarkType, sub)
+	if err != nil {
+		return nil, err
+	}
+	tmo.current.set(
+		trustMarkType, sub, currentJWT{
+			jwt:       jwt,
+			jti:       delegation.JWTID,
+			issuedAt:  delegation.IssuedAt.Time,
+			expiresAt: delegation.ExpiresAt,
+		},
+	)
+	return jwt, nil
+}
+
+// FetchDelegationJWT obtains a DelegationJWT for the passed trust mark type
+// and subject from a trust mark owner's delegation endpoint; it checks that
+// the obtained delegation is about the requested trust mark type and subject
+// and that it is not expired. The signature is not verified, since the
+// trust mark owner's keys must be obtained from a trust anchor, see
+// TrustMarkSpec.DelegationTrustAnchor.
+func FetchDelegationJWT(delegationEndpoint, trustMarkType, sub string) (*DelegationJWT, error) {
+	params := url.Values{}
+	params.Set("trust_mark_type", trustMarkType)
+	params.Set("sub", sub)
+	res, errRes, err := ihttp.Get(delegationEndpoint, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if errRes != nil {
+		return nil, errRes.Err()
+	}
+	if res.IsError() {
+		return nil, errors.Errorf("delegation endpoint returned status code %d", res.StatusCode())
+	}
+	delegation, err := parseDelegationJWT(res.Body())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse delegation jwt")
+	}
+	if delegation.TrustMarkType != trustMarkType || delegation.Subject != sub {
+		return nil, errors.New("delegation jwt is not about the requested trust mark type and subject")
+	}
+	if delegation.expired(time.Now()) {
+		return nil, errors.New("obtained delegation jwt is expired")
+	}
+	return delegation, nil
+}
+
+func (djwt DelegationJWT) expired(t time.Time) bool {
+	return djwt.ExpiresAt != nil && !djwt.ExpiresAt.IsZero() && !djwt.ExpiresAt.After(t)
+}
+
+// trustMarkDelegations holds the DelegationJWTs a TrustMarkIssuer obtained
+// from delegation endpoints; concurrent requests for the same trust mark type
+// share a single fetch
+type trustMarkDelegations struct {
+	mutex       sync.Mutex
+	delegations map[string]*DelegationJWT
+	fetching    map[string]*delegationFetch
+}
+
+// delegationFetch is an ongoing fetch of a DelegationJWT; done is closed
+// when delegation and err are set
+type delegationFetch struct {
+	done       chan struct{}
+	delegation *DelegationJWT
+	err        error
+}
+
+func newTrustMarkDelegations() *trustMarkDelegations {
+	return &trustMarkDelegations{
+		delegations: make(map[string]*DelegationJWT),
+		fetching:    make(map[string]*delegationFetch),
+	}
+}
+
+func (d *trustMarkDelegations) reset(trustMarkType string) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.delegations, trustMarkType)
+}
+
+// fetch obtains a DelegationJWT with the passed function and stores it; if a
+// fetch for the trust mark type is already ongoing, its result is returned
+// instead
+func (d *trustMarkDelegations) fetch(
+	trustMarkType string, fetch func() (*DelegationJWT, error),
+) (*DelegationJWT, error) {
+	d.mutex.Lock()
+	if f, ok := d.fetching[trustMarkType]; ok {
+		d.mutex.Unlock()
+		<-f.done
+		return f.delegation, f.err
+	}
+	f := &delegationFetch{done: make(chan struct{})}
+	d.fetching[trustMarkType] = f
+	d.mutex.Unlock()
+
+	f.delegation, f.err = fetch()
+
+	d.mutex.Lock()
+	if f.err == nil {
+		d.delegations[trustMarkType] = f.delegation
+	}
+	delete(d.fetching, trustMarkType)
+	d.mutex.Unlock()
+	close(f.done)
+	return f.delegation, f.err
+}
+
+func (d *trustMarkDelegations) current(trustMarkType string) *DelegationJWT {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.delegations[trustMarkType]
+}
+
+// needsRenewal checks if the delegation should be renewed, i.e. if its
+// remaining lifetime is shorter than the renewal period; if no renewal
+// period is given a quarter of the delegation's lifetime is used
+func (djwt DelegationJWT) needsRenewal(renewalPeriod time.Duration) bool {
+	if djwt.ExpiresAt == nil || djwt.ExpiresAt.IsZero() {
+		return false
+	}
+	if renewalPeriod == 0 {
+		renewalPeriod = djwt.ExpiresAt.Sub(djwt.IssuedAt.Time) / 4
+	}
+	return unixtime.Until(*djwt.ExpiresAt) < renewalPeriod
+}
+
+// delegation returns the DelegationJWT that should be embedded in trust marks
+// issued for the passed TrustMarkSpec, or nil if the trust mark is not
+// delegated.
+// If the TrustMarkSpec has a DelegationEndpoint, the delegation is obtained
+// from there, verified with the keys of the trust mark owner published by the
+// DelegationTrustAnchor, and renewed before it expires; if the renewal fails,
+// the current delegation is used as long as it is valid.
+func (tmi TrustMarkIssuer) delegation(spec TrustMarkSpec) (*DelegationJWT, error) {
+	now := time.Now()
+	if spec.DelegationEndpoint == "" {
+		if spec.DelegationJWT == "" {
+			return nil, nil
+		}
+		delegation, err := parseDelegationJWT([]byte(spec.DelegationJWT))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse delegation jwt")
+		}
+		if delegation.expired(now) {
+			return nil, errors.Errorf("delegation jwt for trust mark '%s' is expired", spec.TrustMarkType)
+		}
+		return delegation, nil
+	}
+
+	fetch := func() (*DelegationJWT, error) {
+		return tmi.fetchVerifiedDelegationJWT(spec)
+	}
+	if tmi.delegations == nil {
+		return fetch()
+	}
+	current := tmi.delegations.current(spec.TrustMarkType)
+	if current != nil && !current.expired(now) && !current.needsRenewal(spec.DelegationRenewalPeriod.Duration) {
+		return current, nil
+	}
+	delegation, err := tmi.delegations.fetch(spec.TrustMarkType, fetch)
+	if err != nil {
+		if current != nil && !current.expired(now) {
+			internal.Logf("Could not renew delegation jwt, using current one: %s", err.Error())
+			return current, nil
+		}
+		return nil, errors.Wrap(err, "could not obtain delegation jwt")
+	}
+	return delegation, nil
+}
+
+// fetchVerifiedDelegationJWT obtains a DelegationJWT for the passed
+// TrustMarkSpec from its DelegationEndpoint and verifies it with the keys of
+// the trust mark owner from the 'trust_mark_owners' of the
+// DelegationTrustAnchor
+func (tmi TrustMarkIssuer) fetchVerifiedDelegationJWT(spec TrustMarkSpec) (*DelegationJWT, error) {
+	if spec.DelegationTrustAnchor == "" {
+		return nil, errors.Errorf(
+			"no delegation trust anchor to verify delegation jwts for trust mark '%s'", spec.TrustMarkType,
+		)
+	}
+	ta, err := GetEntityConfiguration(spec.DelegationTrustAnchor)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not obtain trust anchor entity configuration")
+	}
+	delegation, err := FetchDelegationJWT(spec.DelegationEndpoint, spec.TrustMarkType, tmi.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := ta.TrustMarkOwners[spec.TrustMarkType]
+	if !ok || owner.ID != delegation.Issuer {
+		return nil, errors.New("delegation jwt was not issued by the trust mark owner known to the trust anchor")
+	}
+	if err = delegation.VerifyFederation(&ta.EntityStatementPayload); err != nil {
+		return nil, err
+	}
+	return delegation, nil
+}
@@ -0,0 +1,293 @@
+package oidfed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+const testDelegationEndpoint = "https://tmo.example.eu/delegation"
+
+// newTestDelegatingOwner returns a TrustMarkOwner with the key of tmo, so
+// that its delegations are accepted by taWithTmo, and mocks its delegation
+// endpoint; if withDelegationStore is set, the owner records its delegations
+// in a DelegationStore and its delegation status endpoint is mocked as well
+func newTestDelegatingOwner(lifetime time.Duration, withDelegationStore bool) *TrustMarkOwner {
+	owner := NewTrustMarkOwner(
+		tmo.EntityID, tmo.TrustMarkDelegationSigner, []OwnedTrustMark{
+			{
+				ID:                 "https://trustmarks.org/tm-delegated",
+				DelegationLifetime: lifetime,
+				DelegatedIssuers:   []string{tmi1.EntityID},
+			},
+		},
+	)
+	mockHandler(http.MethodGet, testDelegationEndpoint, owner.DelegationHandler())
+	if withDelegationStore {
+		owner.DelegationStore = NewInMemoryTrustMarkIssuanceStore()
+		mockHandler(http.MethodPost, testDelegationStatusEndpoint, owner.DelegationStatusHandler())
+	}
+	return owner
+}
+
+func newTestDelegatedIssuer(renewalPeriod time.Duration) *TrustMarkIssuer {
+	return NewTrustMarkIssuer(
+		tmi1.EntityID, tmi1.TrustMarkSigner, []TrustMarkSpec{
+			{
+				TrustMarkType:           "https://trustmarks.org/tm-delegated",
+				Lifetime:                unixtime.DurationInSeconds{Duration: time.Hour},
+				DelegationEndpoint:      testDelegationEndpoint,
+				DelegationRenewalPeriod: unixtime.DurationInSeconds{Duration: renewalPeriod},
+				DelegationTrustAnchor:   taWithTmo.EntityID,
+			},
+		},
+	)
+}
+
+func TestTrustMarkOwner_DelegationHandler(t *testing.T) {
+	owner := newTestDelegatingOwner(time.Hour, false)
+	handler := owner.DelegationHandler()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "delegated issuer",
+			query:          "trust_mark_type=https://trustmarks.org/tm-delegated&sub=" + tmi1.EntityID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not a delegated issuer",
+			query:          "trust_mark_type=https://trustmarks.org/tm-delegated&sub=https://other.example.org",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown type",
+			query:          "trust_mark_type=https://trustmarks.org/unknown&sub=" + tmi1.EntityID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing sub",
+			query:          "trust_mark_type=https://trustmarks.org/tm-delegated",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/delegation?"+test.query, nil))
+				if rec.Code != test.expectedStatus {
+					t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+				}
+				if test.expectedStatus != http.StatusOK {
+					return
+				}
+				if ct := rec.Header().Get("Content-Type"); ct != oidfedconst.ContentTypeTrustMarkDelegation {
+					t.Errorf("unexpected content type '%s'", ct)
+				}
+				delegation, err := parseDelegationJWT(rec.Body.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if delegation.Subject != tmi1.EntityID || delegation.Issuer != owner.EntityID {
+					t.Errorf("unexpected delegation: %+v", delegation)
+				}
+			},
+		)
+	}
+}
+
+func TestTrustMarkOwner_DelegationHandler_Current(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	owner := newTestDelegatingOwner(time.Hour, true)
+	defer newTestDelegatingOwner(time.Hour, false)
+
+	first, err := FetchDelegationJWT(testDelegationEndpoint, trustMarkType, tmi1.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := FetchDelegationJWT(testDelegationEndpoint, trustMarkType, tmi1.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.JWTID == "" || second.JWTID != first.JWTID {
+		t.Errorf("expected the current delegation to be returned again, but got '%s' and '%s'", first.JWTID, second.JWTID)
+	}
+
+	if err = owner.RevokeDelegationJTI(first.JWTID); err != nil {
+		t.Fatal(err)
+	}
+	third, err := FetchDelegationJWT(testDelegationEndpoint, trustMarkType, tmi1.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.JWTID == first.JWTID {
+		t.Errorf("revoked delegation must not be returned again")
+	}
+}
+
+func TestFetchDelegationJWT(t *testing.T) {
+	newTestDelegatingOwner(time.Hour, false)
+	delegation, err := FetchDelegationJWT(testDelegationEndpoint, "https://trustmarks.org/tm-delegated", tmi1.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delegation.TrustMarkType != "https://trustmarks.org/tm-delegated" || delegation.Subject != tmi1.EntityID {
+		t.Errorf("unexpected delegation: %+v", delegation)
+	}
+	if _, err = FetchDelegationJWT(
+		testDelegationEndpoint, "https://trustmarks.org/tm-delegated", "https://other.example.org",
+	); err == nil {
+		t.Errorf("expected error for subject that is not a delegated issuer")
+	}
+}
+
+func TestTrustMarkIssuer_IssueTrustMark_DelegationEndpoint(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	const sub = "https://delegated.example.org"
+	newTestDelegatingOwner(2*time.Second, false)
+	tmi := newTestDelegatedIssuer(0)
+
+	issue := func() *TrustMark {
+		t.Helper()
+		info, err := tmi.IssueTrustMark(trustMarkType, sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = info.VerifyFederation(taWithTmo.EntityStatementPayload()); err != nil {
+			t.Fatalf("issued trust mark not valid: %v", err)
+		}
+		tm, err := info.TrustMark()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	first := issue()
+	if first.DelegationJWT == "" {
+		t.Fatalf("trust mark must embed a delegation jwt")
+	}
+	delegation, err := first.Delegation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ExpiresAt == nil || first.ExpiresAt.After(delegation.ExpiresAt.Time) {
+		t.Errorf("trust mark must not outlive its delegation")
+	}
+	if second := issue(); second.DelegationJWT != first.DelegationJWT {
+		t.Errorf("delegation jwt must be reused until renewal")
+	}
+	time.Sleep(1600 * time.Millisecond)
+	if third := issue(); third.DelegationJWT == first.DelegationJWT {
+		t.Errorf("delegation jwt must be renewed before it expires")
+	}
+}
+
+func TestTrustMarkIssuer_IssueTrustMark_DelegationRenewalFails(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	const sub = "https://delegated.example.org"
+	newTestDelegatingOwner(time.Hour, false)
+	// renewal is attempted on every issuance
+	tmi := newTestDelegatedIssuer(2 * time.Hour)
+
+	first, err := tmi.IssueTrustMark(trustMarkType, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpmock.RegisterResponder(
+		http.MethodGet, testDelegationEndpoint, httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+	)
+	defer newTestDelegatingOwner(time.Hour, false)
+	second, err := tmi.IssueTrustMark(trustMarkType, sub)
+	if err != nil {
+		t.Fatalf("current delegation must be used if renewal fails: %v", err)
+	}
+	if first.trustmark.DelegationJWT != second.trustmark.DelegationJWT {
+		t.Errorf("expected current delegation jwt")
+	}
+	if _, err = newTestDelegatedIssuer(0).IssueTrustMark(trustMarkType, sub); err == nil {
+		t.Errorf("expected error if no delegation jwt can be obtained")
+	}
+}
+
+func TestTrustMarkIssuer_IssueTrustMark_DelegationNotVerified(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	forged := NewTrustMarkOwner(
+		tmo.EntityID, newTestJWTSigner(t).TrustMarkDelegationSigner(), []OwnedTrustMark{
+			{
+				ID:               trustMarkType,
+				DelegatedIssuers: []string{tmi1.EntityID},
+			},
+		},
+	)
+	mockHandler(http.MethodGet, testDelegationEndpoint, forged.DelegationHandler())
+	defer newTestDelegatingOwner(time.Hour, false)
+	if _, err := newTestDelegatedIssuer(0).IssueTrustMark(trustMarkType, "https://forged.example.org"); err == nil {
+		t.Errorf("expected error for delegation jwt not signed with the trust mark owner's keys")
+	}
+
+	newTestDelegatingOwner(time.Hour, false)
+	tmi := newTestDelegatedIssuer(0)
+	spec := tmi.trustMarks[trustMarkType]
+	spec.DelegationTrustAnchor = ""
+	tmi.AddTrustMark(spec)
+	if _, err := tmi.IssueTrustMark(trustMarkType, "https://forged.example.org"); err == nil {
+		t.Errorf("expected error if delegation jwts cannot be verified")
+	}
+}
+
+func TestTrustMarkIssuer_IssueTrustMark_DelegationConcurrent(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	newTestDelegatingOwner(time.Hour, false)
+	tmi := newTestDelegatedIssuer(0)
+	var wg sync.WaitGroup
+	delegations := make([]string, 10)
+	for i := range delegations {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			info, err := tmi.IssueTrustMark(trustMarkType, "https://concurrent.example.org")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			delegations[i] = info.trustmark.DelegationJWT
+		}(i)
+	}
+	wg.Wait()
+	for _, d := range delegations {
+		if d != delegations[0] {
+			t.Errorf("concurrent issuances must share one delegation jwt")
+		}
+	}
+}
+
+func TestTrustMarkIssuer_IssueTrustMark_ExpiredDelegation(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	expired, err := tmo.DelegationJWT(trustMarkType, tmi1.EntityID, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmi := NewTrustMarkIssuer(
+		tmi1.EntityID, tmi1.TrustMarkSigner, []TrustMarkSpec{
+			{
+				TrustMarkType: trustMarkType,
+				DelegationJWT: string(expired),
+			},
+		},
+	)
+	if _, err = tmi.IssueTrustMark(trustMarkType, "https://delegated.example.org"); err == nil {
+		t.Errorf("expected error for expired delegation jwt")
+	}
+}
@@ -0,0 +1,284 @@
+package oidfed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/cache"
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/internal/jwx"
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// RevokeDelegation revokes all delegations of the passed trust mark type the
+// TrustMarkOwner issued to the passed trust mark issuer.
+// Afterwards, the DelegationHandler does not issue delegations of this type
+// to the issuer anymore until it is reinstated with ReinstateDelegation.
+func (tmo TrustMarkOwner) RevokeDelegation(trustMarkType, sub string) error {
+	if tmo.DelegationStore == nil {
+		return errors.New("trust mark owner has no delegation store")
+	}
+	return tmo.DelegationStore.Revoke(trustMarkType, sub)
+}
+
+// ReinstateDelegation allows the DelegationHandler to issue delegations of
+// the passed trust mark type to the passed trust mark issuer again after they
+// were revoked with RevokeDelegation
+func (tmo TrustMarkOwner) ReinstateDelegation(trustMarkType, sub string) error {
+	if tmo.DelegationStore == nil {
+		return errors.New("trust mark owner has no delegation store")
+	}
+	return tmo.DelegationStore.Reinstate(trustMarkType, sub)
+}
+
+// RevokeDelegationJTI revokes the delegation with the passed jti
+func (tmo TrustMarkOwner) RevokeDelegationJTI(jti string) error {
+	if tmo.DelegationStore == nil {
+		return errors.New("trust mark owner has no delegation store")
+	}
+	return tmo.DelegationStore.RevokeJTI(jti)
+}
+
+// DelegatedIssuers returns the trust mark issuers that hold an active
+// delegation of the passed trust mark type issued by this TrustMarkOwner
+func (tmo TrustMarkOwner) DelegatedIssuers(trustMarkType string) ([]string, error) {
+	if tmo.DelegationStore == nil {
+		return nil, errors.New("trust mark owner has no delegation store")
+	}
+	if _, ok := tmo.ownedTrustMarks[trustMarkType]; !ok {
+		return nil, errors.Errorf("unknown trustmark '%s'", trustMarkType)
+	}
+	return tmo.DelegationStore.Subjects(trustMarkType)
+}
+
+// DelegationStatus evaluates the status of the passed delegation jwt and
+// returns one of the oidfedconst.TrustMarkStatus values.
+// A delegation is invalid if it was not issued and signed by this
+// TrustMarkOwner or if it was not recorded in the DelegationStore. An error
+// is returned if there is no DelegationStore or it cannot be queried.
+func (tmo TrustMarkOwner) DelegationStatus(delegationJWT []byte) (string, error) {
+	if tmo.DelegationStore == nil {
+		return "", errors.New("trust mark owner has no delegation store")
+	}
+	delegation, err := parseDelegationJWT(delegationJWT)
+	if err != nil || delegation.Issuer != tmo.EntityID {
+		return oidfedconst.TrustMarkStatusInvalid, nil
+	}
+	if _, err = delegation.jwtMsg.VerifyWithSet(tmo.JWKS()); err != nil {
+		return oidfedconst.TrustMarkStatusInvalid, nil
+	}
+	if delegation.JWTID == "" {
+		return oidfedconst.TrustMarkStatusInvalid, nil
+	}
+	record, err := tmo.DelegationStore.Get(delegation.JWTID)
+	if err != nil {
+		return "", err
+	}
+	if record == nil || record.TrustMarkType != delegation.TrustMarkType || record.Subject != delegation.Subject {
+		return oidfedconst.TrustMarkStatusInvalid, nil
+	}
+	if record.Revoked {
+		return oidfedconst.TrustMarkStatusRevoked, nil
+	}
+	if delegation.expired(time.Now()) {
+		return oidfedconst.TrustMarkStatusExpired, nil
+	}
+	return oidfedconst.TrustMarkStatusActive, nil
+}
+
+// DelegationStatusResponse evaluates the status of the passed delegation jwt
+// and returns the signed delegation status response
+func (tmo TrustMarkOwner) DelegationStatusResponse(delegationJWT []byte) ([]byte, error) {
+	status, err := tmo.DelegationStatus(delegationJWT)
+	if err != nil {
+		return nil, err
+	}
+	return tmo.GeneralJWTSigner.DelegationStatusResponseSigner().JWT(
+		DelegationStatusResponse{
+			Issuer:     tmo.EntityID,
+			IssuedAt:   unixtime.Now(),
+			Delegation: string(delegationJWT),
+			Status:     status,
+		},
+	)
+}
+
+// DelegationStatusHandler returns a http.Handler for the delegation status
+// endpoint of the TrustMarkOwner; it accepts POST requests with the
+// 'delegation' form parameter and responds with the signed delegation status
+// response
+func (tmo *TrustMarkOwner) DelegationStatusHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !allowMethods(w, r, http.MethodPost) {
+				return
+			}
+			delegation := r.PostFormValue("delegation")
+			if delegation == "" {
+				writeErrorResponse(
+					w, http.StatusBadRequest, ErrorInvalidRequest("required parameter 'delegation' not given"),
+				)
+				return
+			}
+			jwt, err := tmo.DelegationStatusResponse([]byte(delegation))
+			if err != nil {
+				internal.Log(err)
+				writeErrorResponse(
+					w, http.StatusInternalServerError, ErrorServerError("could not evaluate delegation status"),
+				)
+				return
+			}
+			writeJWTResponse(w, oidfedconst.ContentTypeDelegationStatusResponse, jwt)
+		},
+	)
+}
+
+// DelegationStatusResponse is the payload of a delegation status response
+type DelegationStatusResponse struct {
+	Issuer     string                 `json:"iss"`
+	IssuedAt   unixtime.Unixtime      `json:"iat"`
+	Delegation string                 `json:"delegation"`
+	Status     string                 `json:"status"`
+	Extra      map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// It also marshals extra fields.
+func (r DelegationStatusResponse) MarshalJSON() ([]byte, error) {
+	type delegationStatusResponse DelegationStatusResponse
+	explicitFields, err := json.Marshal(delegationStatusResponse(r))
+	if err != nil {
+		return nil, err
+	}
+	return extraMarshalHelper(explicitFields, r.Extra)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// It also unmarshalls additional fields into the Extra claim.
+func (r *DelegationStatusResponse) UnmarshalJSON(data []byte) error {
+	type delegationStatusResponse DelegationStatusResponse
+	rr := delegationStatusResponse(*r)
+	extra, err := unmarshalWithExtra(data, &rr)
+	if err != nil {
+		return err
+	}
+	rr.Extra = extra
+	*r = DelegationStatusResponse(rr)
+	return nil
+}
+
+// ParseDelegationStatusResponse parses a delegation status response jwt and
+// verifies it for the passed trust mark owner, i.e. the jwt type, the
+// signature with the owner's keys, the 'iss' claim, and that the 'iat' claim
+// is recent, see verifyStatusResponseIssuedAt
+func ParseDelegationStatusResponse(
+	data []byte, trustMarkOwner string, ownerKeys jwks.JWKS,
+) (*DelegationStatusResponse, error) {
+	m, err := jwx.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse delegation status response")
+	}
+	if !m.VerifyType(oidfedconst.JWTTypeDelegationStatusResponse) {
+		return nil, errors.Errorf(
+			"delegation status response does not have '%s' JWT type", oidfedconst.JWTTypeDelegationStatusResponse,
+		)
+	}
+	payload, err := m.VerifyWithSet(ownerKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not verify delegation status response")
+	}
+	var r DelegationStatusResponse
+	if err = json.Unmarshal(payload, &r); err != nil {
+		return nil, errors.Wrap(err, "could not parse delegation status response")
+	}
+	if r.Issuer != trustMarkOwner {
+		return nil, errors.Errorf("delegation status response was not issued by '%s'", trustMarkOwner)
+	}
+	if err = verifyStatusResponseIssuedAt(r.IssuedAt); err != nil {
+		return nil, errors.Wrap(err, "invalid delegation status response")
+	}
+	return &r, nil
+}
+
+// VerifyStatus verifies the status of the DelegationJWT at the
+// DelegationStatusEndpoint of the passed TrustMarkOwnerSpec; the signed
+// status response is verified with the owner's keys from the spec.
+// The obtained status is cached for TrustMarkStatusCheckInterval; if the
+// status cannot be obtained, the verification fails, see
+// TrustMarkStatusVerifier to fail open instead.
+// VerifyStatus only checks the status, it does not verify the DelegationJWT
+// itself, see VerifyExternal for this.
+func (djwt DelegationJWT) VerifyStatus(tmo TrustMarkOwnerSpec) error {
+	return TrustMarkStatusVerifier{}.VerifyDelegationStatus(djwt, tmo)
+}
+
+// VerifyDelegationStatus verifies the status of the passed DelegationJWT like
+// DelegationJWT.VerifyStatus, but fails open if configured
+func (v TrustMarkStatusVerifier) VerifyDelegationStatus(djwt DelegationJWT, tmo TrustMarkOwnerSpec) error {
+	if djwt.jwtMsg == nil {
+		return errors.New("verify delegation status: delegation jwt not available")
+	}
+	if tmo.DelegationStatusEndpoint == "" {
+		return errors.Errorf("verify delegation status: trust mark owner '%s' has no status endpoint", tmo.ID)
+	}
+	status, err := delegationStatus(string(djwt.jwtMsg.RawJWT), tmo)
+	if err != nil {
+		if v.failOpen(err) {
+			return nil
+		}
+		return errors.Wrap(err, "verify delegation status")
+	}
+	if status != oidfedconst.TrustMarkStatusActive {
+		return errors.Errorf("verify delegation status: delegation is '%s'", status)
+	}
+	return nil
+}
+
+func delegationStatusCacheKey(rawJWT string) string {
+	hash := sha256.Sum256([]byte(rawJWT))
+	return cache.Key(cache.KeyDelegationStatus, hex.EncodeToString(hash[:]))
+}
+
+// delegationStatus returns the status of the passed delegation jwt, either
+// from the cache or from the trust mark owner's delegation status endpoint
+func delegationStatus(rawJWT string, tmo TrustMarkOwnerSpec) (string, error) {
+	cacheKey := delegationStatusCacheKey(rawJWT)
+	var status string
+	set, err := cache.Get(cacheKey, &status)
+	if err != nil {
+		internal.Log(err)
+	} else if set {
+		internal.Log("Obtained delegation status from cache")
+		return status, nil
+	}
+	status, err = fetchDelegationStatus(rawJWT, tmo)
+	if err != nil {
+		return "", err
+	}
+	if err = cache.Set(cacheKey, status, TrustMarkStatusCheckInterval); err != nil {
+		internal.Log(err)
+	}
+	return status, nil
+}
+
+func fetchDelegationStatus(rawJWT string, tmo TrustMarkOwnerSpec) (string, error) {
+	body, err := postStatusRequest(tmo.DelegationStatusEndpoint, "delegation", rawJWT)
+	if err != nil {
+		return "", err
+	}
+	r, err := ParseDelegationStatusResponse(body, tmo.ID, tmo.JWKS)
+	if err != nil {
+		return "", err
+	}
+	if r.Delegation != rawJWT {
+		return "", errors.New("delegation status response is not about the requested delegation")
+	}
+	return r.Status, nil
+}
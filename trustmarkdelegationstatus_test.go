@@ -0,0 +1,249 @@
+package oidfed
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+const testDelegationStatusEndpoint = "https://tmo.example.eu/delegation-status"
+
+func TestTrustMarkOwner_DelegationStatus(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	const revokedIssuer = "https://revoked.tmi.example.org"
+	owner := newTestDelegatingOwner(time.Hour, true)
+	issue := func(sub string, lifetime ...time.Duration) []byte {
+		t.Helper()
+		jwt, err := owner.DelegationJWT(trustMarkType, sub, lifetime...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jwt
+	}
+	active := issue(tmi1.EntityID)
+	revoked := issue(revokedIssuer)
+	expired := issue(tmi2.EntityID, -time.Minute)
+	if err := owner.RevokeDelegation(trustMarkType, revokedIssuer); err != nil {
+		t.Fatal(err)
+	}
+	notRecorded, err := tmo.DelegationJWT(trustMarkType, tmi1.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		delegation     []byte
+		expectedStatus string
+	}{
+		{
+			name:           "active",
+			delegation:     active,
+			expectedStatus: oidfedconst.TrustMarkStatusActive,
+		},
+		{
+			name:           "revoked",
+			delegation:     revoked,
+			expectedStatus: oidfedconst.TrustMarkStatusRevoked,
+		},
+		{
+			name:           "expired",
+			delegation:     expired,
+			expectedStatus: oidfedconst.TrustMarkStatusExpired,
+		},
+		{
+			name:           "not recorded",
+			delegation:     notRecorded,
+			expectedStatus: oidfedconst.TrustMarkStatusInvalid,
+		},
+		{
+			name:           "not a jwt",
+			delegation:     []byte("invalid"),
+			expectedStatus: oidfedconst.TrustMarkStatusInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(
+					http.MethodPost, "/delegation-status",
+					strings.NewReader(url.Values{"delegation": {string(test.delegation)}}.Encode()),
+				)
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				owner.DelegationStatusHandler().ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+				}
+				if ct := rec.Header().Get("Content-Type"); ct != oidfedconst.ContentTypeDelegationStatusResponse {
+					t.Errorf("unexpected content type '%s'", ct)
+				}
+				res, err := ParseDelegationStatusResponse(rec.Body.Bytes(), owner.EntityID, owner.JWKS())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Status != test.expectedStatus {
+					t.Errorf("expected status '%s', but got '%s'", test.expectedStatus, res.Status)
+				}
+				if res.Delegation != string(test.delegation) {
+					t.Errorf("status response is not about the requested delegation")
+				}
+			},
+		)
+	}
+
+	issuers, err := owner.DelegatedIssuers(trustMarkType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{tmi1.EntityID}; !reflect.DeepEqual(issuers, expected) {
+		t.Errorf("expected delegated issuers %v, but got %v", expected, issuers)
+	}
+}
+
+func TestParseDelegationStatusResponse(t *testing.T) {
+	signer := newTestJWTSigner(t)
+	for name, iat := range issuedAtTestCases() {
+		t.Run(
+			name, func(t *testing.T) {
+				data, err := signer.DelegationStatusResponseSigner().JWT(
+					DelegationStatusResponse{
+						Issuer:     tmo.EntityID,
+						IssuedAt:   iat.iat,
+						Delegation: "eyJ...",
+						Status:     oidfedconst.TrustMarkStatusActive,
+					},
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = ParseDelegationStatusResponse(data, tmo.EntityID, signer.JWKS())
+				if (err != nil) != iat.errExpected {
+					t.Errorf("unexpected error: %v", err)
+				}
+			},
+		)
+	}
+}
+
+func TestTrustMarkOwner_RevokeDelegation_Renewal(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	owner := newTestDelegatingOwner(time.Hour, true)
+	defer newTestDelegatingOwner(time.Hour, false)
+	if _, err := newTestDelegatedIssuer(0).IssueTrustMark(trustMarkType, "https://renewal.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.RevokeDelegation(trustMarkType, tmi1.EntityID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchDelegationJWT(testDelegationEndpoint, trustMarkType, tmi1.EntityID); err == nil {
+		t.Errorf("revoked delegation must not be renewed from the delegation endpoint")
+	}
+	if _, err := newTestDelegatedIssuer(0).IssueTrustMark(trustMarkType, "https://renewal.example.org"); err == nil {
+		t.Errorf("expected error for trust mark issuer with revoked delegation")
+	}
+
+	if _, err := owner.DelegationJWT(trustMarkType, tmi1.EntityID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchDelegationJWT(testDelegationEndpoint, trustMarkType, tmi1.EntityID); err == nil {
+		t.Errorf("issuing a delegation must not reinstate a revoked delegation")
+	}
+	if err := owner.ReinstateDelegation(trustMarkType, tmi1.EntityID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchDelegationJWT(testDelegationEndpoint, trustMarkType, tmi1.EntityID); err != nil {
+		t.Errorf("delegation must be renewable after it was reinstated: %v", err)
+	}
+}
+
+func TestTrustMark_VerifyFederation_DelegationStatus(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm-delegated"
+	ta := taWithTmo.EntityStatementPayload()
+	ta.TrustMarkOwners = maps.Clone(ta.TrustMarkOwners)
+	spec := ta.TrustMarkOwners[trustMarkType]
+	spec.DelegationStatusEndpoint = testDelegationStatusEndpoint
+	ta.TrustMarkOwners[trustMarkType] = spec
+
+	owner := newTestDelegatingOwner(time.Hour, true)
+	issue := func(sub string) *TrustMarkInfo {
+		t.Helper()
+		delegation, err := owner.DelegationJWT(trustMarkType, tmi1.EntityID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmi := NewTrustMarkIssuer(
+			tmi1.EntityID, tmi1.TrustMarkSigner, []TrustMarkSpec{
+				{
+					TrustMarkType: trustMarkType,
+					Lifetime:      unixtime.DurationInSeconds{Duration: time.Hour},
+					DelegationJWT: string(delegation),
+				},
+			},
+		)
+		info, err := tmi.IssueTrustMark(trustMarkType, sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	active := issue("https://active.delegation-status.example.org")
+	if err := active.VerifyFederation(ta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	revoked := issue("https://revoked.delegation-status.example.org")
+	if err := owner.RevokeDelegation(trustMarkType, tmi1.EntityID); err != nil {
+		t.Fatal(err)
+	}
+	if err := revoked.VerifyFederation(ta); err == nil {
+		t.Errorf("expected error for trust mark with revoked delegation")
+	}
+	if err := active.VerifyFederation(ta); err != nil {
+		t.Errorf("delegation status must be cached until the next check interval: %v", err)
+	}
+	if err := revoked.VerifyFederation(taWithTmo.EntityStatementPayload()); err != nil {
+		t.Errorf("delegation status must not be checked without status endpoint: %v", err)
+	}
+
+	httpmock.RegisterResponder(
+		http.MethodPost, testDelegationStatusEndpoint, httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+	)
+	unavailable := issue("https://unavailable.delegation-status.example.org")
+	if err := unavailable.VerifyFederation(ta); err == nil {
+		t.Errorf("expected error if delegation status cannot be obtained")
+	}
+	// the trust marks are not recorded by tmi1, so only the delegation
+	// status is verified
+	failOpen := TrustMarkStatusVerifier{FailOpen: true}
+	verifyFailOpen := func(info *TrustMarkInfo) error {
+		t.Helper()
+		tm, err := info.TrustMark()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm.verifyFederation(ta, failOpen)
+	}
+	if err := verifyFailOpen(unavailable); err != nil {
+		t.Errorf("unexpected error when failing open: %v", err)
+	}
+
+	httpmock.RegisterResponder(
+		http.MethodPost, testDelegationStatusEndpoint, httpmock.NewStringResponder(http.StatusOK, "invalid"),
+	)
+	invalid := issue("https://invalid.delegation-status.example.org")
+	if err := verifyFailOpen(invalid); err == nil {
+		t.Errorf("expected error for delegation status response that cannot be verified")
+	}
+}
@@ -0,0 +1,251 @@
+package oidfed
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	arrays "github.com/adam-hanna/arrayOperations"
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal"
+)
+
+// TrustMarkEligibilityReason is the reason why an entity is not eligible for
+// a trust mark
+type TrustMarkEligibilityReason string
+
+// Constants for TrustMarkEligibilityReason
+const (
+	TrustMarkEligibilityReasonNoTrustChain     TrustMarkEligibilityReason = "no_valid_trust_chain"
+	TrustMarkEligibilityReasonEntityType       TrustMarkEligibilityReason = "entity_type_not_allowed"
+	TrustMarkEligibilityReasonMissingTrustMark TrustMarkEligibilityReason = "required_trust_mark_missing"
+	TrustMarkEligibilityReasonNotAllowListed   TrustMarkEligibilityReason = "not_allow_listed"
+	TrustMarkEligibilityReasonNotEligible      TrustMarkEligibilityReason = "not_eligible"
+	// TrustMarkEligibilityReasonRevoked is used if the trust marks of the
+	// type were revoked for the entity, see TrustMarkIssuer.Revoke
+	TrustMarkEligibilityReasonRevoked TrustMarkEligibilityReason = "revoked"
+	// TrustMarkEligibilityReasonCheckFailed is used if the eligibility could
+	// not be checked, e.g. because of an internal error
+	TrustMarkEligibilityReasonCheckFailed TrustMarkEligibilityReason = "check_failed"
+)
+
+// TrustMarkEligibilityError is the error returned if an entity is not
+// eligible for a trust mark
+type TrustMarkEligibilityError struct {
+	Reason      TrustMarkEligibilityReason
+	Description string
+}
+
+// NewTrustMarkEligibilityError creates a new TrustMarkEligibilityError
+func NewTrustMarkEligibilityError(
+	reason TrustMarkEligibilityReason, format string, args ...any,
+) *TrustMarkEligibilityError {
+	return &TrustMarkEligibilityError{
+		Reason:      reason,
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error implements the error interface
+func (e *TrustMarkEligibilityError) Error() string {
+	return fmt.Sprintf("not eligible for trust mark: %s: %s", e.Reason, e.Description)
+}
+
+// ErrorResponse returns the http status code and the Error that should be
+// returned by the trust mark endpoint
+func (e *TrustMarkEligibilityError) ErrorResponse() (int, Error) {
+	if e.Reason == TrustMarkEligibilityReasonCheckFailed {
+		return http.StatusInternalServerError, ErrorServerError("could not check eligibility for trust mark")
+	}
+	return http.StatusNotFound, ErrorNotFound(e.Error())
+}
+
+// TrustMarkEligibilityChecker checks if an entity is eligible for a trust mark
+type TrustMarkEligibilityChecker interface {
+	// CheckEligibility returns nil if the entity is eligible for the trust
+	// mark; otherwise it should return a *TrustMarkEligibilityError; other
+	// errors are treated as TrustMarkEligibilityReasonCheckFailed
+	CheckEligibility(trustMarkType, sub string) error
+}
+
+// TrustMarkEligibilityCheckerFunc is a function implementing the
+// TrustMarkEligibilityChecker interface
+type TrustMarkEligibilityCheckerFunc func(trustMarkType, sub string) error
+
+// CheckEligibility implements the TrustMarkEligibilityChecker interface
+func (f TrustMarkEligibilityCheckerFunc) CheckEligibility(trustMarkType, sub string) error {
+	return f(trustMarkType, sub)
+}
+
+// CheckEligibility checks if the passed entity is eligible for a trust mark
+// of the passed type by using the EligibilityCheckers of the
+// TrustMarkIssuer and of the TrustMarkSpec; if the entity is not eligible a
+// *TrustMarkEligibilityError is returned
+func (tmi TrustMarkIssuer) CheckEligibility(trustMarkType, sub string) error {
+	spec, ok := tmi.trustMarks[trustMarkType]
+	if !ok {
+		return errors.Errorf("unknown trustmark '%s'", trustMarkType)
+	}
+	for _, checker := range slices.Concat(tmi.EligibilityCheckers, spec.EligibilityCheckers) {
+		err := checker.CheckEligibility(trustMarkType, sub)
+		if err == nil {
+			continue
+		}
+		var eligibilityErr *TrustMarkEligibilityError
+		if errors.As(err, &eligibilityErr) {
+			return eligibilityErr
+		}
+		internal.Log(errors.Wrapf(err, "could not check eligibility of '%s' for trust mark '%s'", sub, trustMarkType))
+		return NewTrustMarkEligibilityError(TrustMarkEligibilityReasonCheckFailed, "%s", err.Error())
+	}
+	return nil
+}
+
+// TrustChainEligibilityChecker is a TrustMarkEligibilityChecker that checks
+// that the entity resolves to a valid trust chain under one of the
+// TrustAnchors
+type TrustChainEligibilityChecker struct {
+	TrustAnchors TrustAnchors
+}
+
+// CheckEligibility implements the TrustMarkEligibilityChecker interface
+func (c TrustChainEligibilityChecker) CheckEligibility(_, sub string) error {
+	resolver := TrustResolver{
+		TrustAnchors:   c.TrustAnchors,
+		StartingEntity: sub,
+	}
+	if len(resolver.ResolveToValidChains()) == 0 {
+		return NewTrustMarkEligibilityError(
+			TrustMarkEligibilityReasonNoTrustChain, "no valid trust chain found for '%s'", sub,
+		)
+	}
+	return nil
+}
+
+// EntityTypeEligibilityChecker is a TrustMarkEligibilityChecker that checks
+// that the entity has at least one of the EntityTypes
+type EntityTypeEligibilityChecker struct {
+	EntityTypes []string
+}
+
+// CheckEligibility implements the TrustMarkEligibilityChecker interface
+func (c EntityTypeEligibilityChecker) CheckEligibility(_, sub string) error {
+	ec, err := GetEntityConfiguration(sub)
+	if err != nil {
+		return NewTrustMarkEligibilityError(
+			TrustMarkEligibilityReasonCheckFailed, "could not obtain entity configuration: %s", err.Error(),
+		)
+	}
+	if ec.Metadata == nil || len(arrays.Intersect(ec.Metadata.GuessEntityTypes(), c.EntityTypes)) == 0 {
+		return NewTrustMarkEligibilityError(
+			TrustMarkEligibilityReasonEntityType, "entity must have one of the entity types %v", c.EntityTypes,
+		)
+	}
+	return nil
+}
+
+// TrustMarkHolderEligibilityChecker is a TrustMarkEligibilityChecker that
+// checks that the entity already holds valid trust marks of all
+// TrustMarkTypes in its entity configuration; the trust marks are verified
+// with the TrustAnchor
+type TrustMarkHolderEligibilityChecker struct {
+	TrustMarkTypes []string
+	TrustAnchor    string
+}
+
+// CheckEligibility implements the TrustMarkEligibilityChecker interface
+func (c TrustMarkHolderEligibilityChecker) CheckEligibility(_, sub string) error {
+	ec, err := GetEntityConfiguration(sub)
+	if err != nil {
+		return NewTrustMarkEligibilityError(
+			TrustMarkEligibilityReasonCheckFailed, "could not obtain entity configuration: %s", err.Error(),
+		)
+	}
+	ta, err := GetEntityConfiguration(c.TrustAnchor)
+	if err != nil {
+		return errors.Wrap(err, "could not obtain trust anchor entity configuration")
+	}
+	for _, trustMarkType := range c.TrustMarkTypes {
+		info := ec.TrustMarks.FindByID(trustMarkType)
+		if info == nil {
+			return NewTrustMarkEligibilityError(
+				TrustMarkEligibilityReasonMissingTrustMark, "entity does not hold trust mark '%s'", trustMarkType,
+			)
+		}
+		if err = info.VerifyFederation(&ta.EntityStatementPayload); err != nil {
+			return NewTrustMarkEligibilityError(
+				TrustMarkEligibilityReasonMissingTrustMark, "trust mark '%s' is not valid: %s", trustMarkType,
+				err.Error(),
+			)
+		}
+	}
+	return nil
+}
+
+// AllowListFileEligibilityChecker is a TrustMarkEligibilityChecker that
+// checks that the entity is listed in an allow-list file; the file contains
+// one entity id per line, empty lines and lines starting with '#' are
+// ignored. The file is reloaded when it is modified.
+type AllowListFileEligibilityChecker struct {
+	Path    string
+	mutex   sync.Mutex
+	modTime time.Time
+	allowed map[string]struct{}
+}
+
+// NewAllowListFileEligibilityChecker creates a new
+// AllowListFileEligibilityChecker for the passed file
+func NewAllowListFileEligibilityChecker(path string) *AllowListFileEligibilityChecker {
+	return &AllowListFileEligibilityChecker{Path: path}
+}
+
+// CheckEligibility implements the TrustMarkEligibilityChecker interface
+func (c *AllowListFileEligibilityChecker) CheckEligibility(_, sub string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.load(); err != nil {
+		return err
+	}
+	if _, ok := c.allowed[sub]; !ok {
+		return NewTrustMarkEligibilityError(
+			TrustMarkEligibilityReasonNotAllowListed, "entity '%s' is not on the allow list", sub,
+		)
+	}
+	return nil
+}
+
+func (c *AllowListFileEligibilityChecker) load() error {
+	info, err := os.Stat(c.Path)
+	if err != nil {
+		return errors.Wrap(err, "could not read allow list")
+	}
+	if c.allowed != nil && info.ModTime().Equal(c.modTime) {
+		return nil
+	}
+	file, err := os.Open(c.Path)
+	if err != nil {
+		return errors.Wrap(err, "could not read allow list")
+	}
+	defer file.Close()
+	allowed := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowed[line] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return errors.Wrap(err, "could not read allow list")
+	}
+	c.allowed = allowed
+	c.modTime = info.ModTime()
+	return nil
+}
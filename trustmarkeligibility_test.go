@@ -0,0 +1,308 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/oidfedconst"
+)
+
+func TestTrustMarkIssuer_CheckEligibility(t *testing.T) {
+	const allowed = "https://allowed.example.org"
+	const denied = "https://denied.example.org"
+	denyChecker := TrustMarkEligibilityCheckerFunc(
+		func(_, sub string) error {
+			if sub == denied {
+				return NewTrustMarkEligibilityError(TrustMarkEligibilityReasonNotEligible, "denied")
+			}
+			return nil
+		},
+	)
+	failingChecker := TrustMarkEligibilityCheckerFunc(
+		func(_, _ string) error {
+			return errors.New("backend not available")
+		},
+	)
+
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	tmi.EligibilityCheckers = []TrustMarkEligibilityChecker{denyChecker}
+	spec := tmi.trustMarks["https://trustmarks.org/b"]
+	spec.EligibilityCheckers = []TrustMarkEligibilityChecker{failingChecker}
+	tmi.AddTrustMark(spec)
+
+	tests := []struct {
+		name           string
+		trustMarkType  string
+		sub            string
+		expectedReason TrustMarkEligibilityReason
+	}{
+		{
+			name:          "eligible",
+			trustMarkType: "https://trustmarks.org/a",
+			sub:           allowed,
+		},
+		{
+			name:           "issuer checker",
+			trustMarkType:  "https://trustmarks.org/a",
+			sub:            denied,
+			expectedReason: TrustMarkEligibilityReasonNotEligible,
+		},
+		{
+			name:           "spec checker failing",
+			trustMarkType:  "https://trustmarks.org/b",
+			sub:            allowed,
+			expectedReason: TrustMarkEligibilityReasonCheckFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				info, err := tmi.IssueTrustMark(test.trustMarkType, test.sub)
+				if test.expectedReason == "" {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if info == nil {
+						t.Fatalf("expected trust mark")
+					}
+					return
+				}
+				var eligibilityErr *TrustMarkEligibilityError
+				if !errors.As(err, &eligibilityErr) {
+					t.Fatalf("expected eligibility error, but got %v", err)
+				}
+				if eligibilityErr.Reason != test.expectedReason {
+					t.Errorf("expected reason '%s', but got '%s'", test.expectedReason, eligibilityErr.Reason)
+				}
+				entities, _ := tmi.TrustMarkedEntities(test.trustMarkType)
+				for _, e := range entities {
+					if e == test.sub {
+						t.Errorf("trust mark must not be issued to ineligible entity")
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestBuiltinEligibilityCheckers(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm1"
+	holder := newMockOP("https://holder.eligibility.example.org", &OpenIDProviderMetadata{})
+	info, err := tmi1.IssueTrustMark(trustMarkType, holder.EntityID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder.trustMarks = TrustMarkInfos{*info}
+
+	tests := []struct {
+		name           string
+		checker        TrustMarkEligibilityChecker
+		sub            string
+		expectedReason TrustMarkEligibilityReason
+	}{
+		{
+			name:    "trust chain valid",
+			checker: TrustChainEligibilityChecker{TrustAnchors: NewTrustAnchorsFromEntityIDs(ta1.EntityID)},
+			sub:     op1.EntityID,
+		},
+		{
+			name:           "trust chain unknown entity",
+			checker:        TrustChainEligibilityChecker{TrustAnchors: NewTrustAnchorsFromEntityIDs(ta1.EntityID)},
+			sub:            "https://unknown.eligibility.example.org",
+			expectedReason: TrustMarkEligibilityReasonNoTrustChain,
+		},
+		{
+			name:    "entity type matching",
+			checker: EntityTypeEligibilityChecker{EntityTypes: []string{"openid_provider"}},
+			sub:     op1.EntityID,
+		},
+		{
+			name:           "entity type not matching",
+			checker:        EntityTypeEligibilityChecker{EntityTypes: []string{"openid_relying_party"}},
+			sub:            op1.EntityID,
+			expectedReason: TrustMarkEligibilityReasonEntityType,
+		},
+		{
+			name:           "entity type unknown entity",
+			checker:        EntityTypeEligibilityChecker{EntityTypes: []string{"openid_provider"}},
+			sub:            "https://unknown.eligibility.example.org",
+			expectedReason: TrustMarkEligibilityReasonCheckFailed,
+		},
+		{
+			name: "holds trust mark",
+			checker: TrustMarkHolderEligibilityChecker{
+				TrustMarkTypes: []string{trustMarkType},
+				TrustAnchor:    taWithTmo.EntityID,
+			},
+			sub: holder.EntityID,
+		},
+		{
+			name: "does not hold trust mark",
+			checker: TrustMarkHolderEligibilityChecker{
+				TrustMarkTypes: []string{trustMarkType},
+				TrustAnchor:    taWithTmo.EntityID,
+			},
+			sub:            op1.EntityID,
+			expectedReason: TrustMarkEligibilityReasonMissingTrustMark,
+		},
+		{
+			name: "trust mark not valid under trust anchor",
+			checker: TrustMarkHolderEligibilityChecker{
+				TrustMarkTypes: []string{trustMarkType},
+				TrustAnchor:    ta1.EntityID,
+			},
+			sub:            holder.EntityID,
+			expectedReason: TrustMarkEligibilityReasonMissingTrustMark,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := test.checker.CheckEligibility(trustMarkType, test.sub)
+				if test.expectedReason == "" {
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				var eligibilityErr *TrustMarkEligibilityError
+				if !errors.As(err, &eligibilityErr) {
+					t.Fatalf("expected eligibility error, but got %v", err)
+				}
+				if eligibilityErr.Reason != test.expectedReason {
+					t.Errorf("expected reason '%s', but got '%s'", test.expectedReason, eligibilityErr.Reason)
+				}
+			},
+		)
+	}
+}
+
+func TestAllowListFileEligibilityChecker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allow.list")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checker := NewAllowListFileEligibilityChecker(path)
+	if err := checker.CheckEligibility("", "https://rp1.example.org"); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+
+	now := time.Now()
+	write("# allowed entities\nhttps://rp1.example.org\n\n  https://rp2.example.org  \n", now)
+	for sub, eligible := range map[string]bool{
+		"https://rp1.example.org": true,
+		"https://rp2.example.org": true,
+		"https://rp3.example.org": false,
+		"# allowed entities":      false,
+	} {
+		if err := checker.CheckEligibility("", sub); (err == nil) != eligible {
+			t.Errorf("unexpected eligibility for '%s': %v", sub, err)
+		}
+	}
+
+	write("https://rp3.example.org\n", now.Add(time.Second))
+	if err := checker.CheckEligibility("", "https://rp3.example.org"); err != nil {
+		t.Errorf("allow list must be reloaded: %v", err)
+	}
+	if err := checker.CheckEligibility("", "https://rp1.example.org"); err == nil {
+		t.Errorf("removed entity must not be eligible")
+	}
+}
+
+func TestTrustMarkIssuer_TrustMarkHandler(t *testing.T) {
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	tmi.EligibilityCheckers = []TrustMarkEligibilityChecker{
+		TrustMarkEligibilityCheckerFunc(
+			func(_, sub string) error {
+				switch sub {
+				case "https://denied.example.org":
+					return NewTrustMarkEligibilityError(TrustMarkEligibilityReasonNotAllowListed, "denied")
+				case "https://failing.example.org":
+					return errors.New("backend not available")
+				}
+				return nil
+			},
+		),
+	}
+	handler := tmi.TrustMarkHandler()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "eligible",
+			query:          "trust_mark_type=https://trustmarks.org/a&sub=https://rp.example.org",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not eligible",
+			query:          "trust_mark_type=https://trustmarks.org/a&sub=https://denied.example.org",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  NotFound,
+		},
+		{
+			name:           "check failed",
+			query:          "trust_mark_type=https://trustmarks.org/a&sub=https://failing.example.org",
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  ServerError,
+		},
+		{
+			name:           "unknown type",
+			query:          "trust_mark_type=https://trustmarks.org/unknown&sub=https://rp.example.org",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  NotFound,
+		},
+		{
+			name:           "missing sub",
+			query:          "trust_mark_type=https://trustmarks.org/a",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  InvalidRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trustmark?"+test.query, nil))
+				if rec.Code != test.expectedStatus {
+					t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+				}
+				if test.expectedError == "" {
+					if ct := rec.Header().Get("Content-Type"); ct != oidfedconst.ContentTypeTrustMark {
+						t.Errorf("unexpected content type '%s'", ct)
+					}
+					tm, err := ParseTrustMark(rec.Body.Bytes())
+					if err != nil {
+						t.Fatal(err)
+					}
+					if tm.Subject != "https://rp.example.org" {
+						t.Errorf("unexpected subject '%s'", tm.Subject)
+					}
+					return
+				}
+				var e Error
+				if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+					t.Fatal(err)
+				}
+				if e.Error != test.expectedError {
+					t.Errorf("expected error '%s', but got '%s'", test.expectedError, e.Error)
+				}
+			},
+		)
+	}
+}
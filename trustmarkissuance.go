@@ -0,0 +1,511 @@
+package oidfed
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// IssuedTrustMark is the record of a trust mark issued by a TrustMarkIssuer
+type IssuedTrustMark struct {
+	JWTID         string             `json:"jti"`
+	TrustMarkType string             `json:"trust_mark_type"`
+	Subject       string             `json:"sub"`
+	IssuedAt      unixtime.Unixtime  `json:"iat"`
+	ExpiresAt     *unixtime.Unixtime `json:"exp,omitempty"`
+	Revoked       bool               `json:"revoked,omitempty"`
+}
+
+// Expired checks if the IssuedTrustMark is expired
+func (r IssuedTrustMark) Expired() bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.IsZero() && r.ExpiresAt.Before(time.Now())
+}
+
+// Active checks if the IssuedTrustMark is neither revoked nor expired
+func (r IssuedTrustMark) Active() bool {
+	return !r.Revoked && !r.Expired()
+}
+
+// TrustMarkIssuanceStore is an interface for storing the trust marks issued
+// by a TrustMarkIssuer
+type TrustMarkIssuanceStore interface {
+	// Add records an issued trust mark
+	Add(record IssuedTrustMark) error
+	// Get returns the record for the passed jti, or nil if there is none
+	Get(jti string) (*IssuedTrustMark, error)
+	// RevokeJTI revokes the trust mark with the passed jti
+	RevokeJTI(jti string) error
+	// Revoke revokes all trust marks of the passed type issued to the passed
+	// subject
+	Revoke(trustMarkType, sub string) error
+	// Subjects returns the subjects that hold an active trust mark of the
+	// passed type
+	Subjects(trustMarkType string) ([]string, error)
+	// Revoked checks if the trust marks of the passed type were revoked for
+	// the passed subject with Revoke and the subject was not reinstated with
+	// Reinstate since
+	Revoked(trustMarkType, sub string) (bool, error)
+	// Reinstate allows issuing trust marks of the passed type to the passed
+	// subject again after they were revoked with Revoke; trust marks revoked
+	// before stay revoked
+	Reinstate(trustMarkType, sub string) error
+}
+
+// InMemoryTrustMarkIssuanceStore is a TrustMarkIssuanceStore that holds the
+// records in memory; it is safe for concurrent use.
+// Expired records and superseded records without expiration are kept until
+// they are removed with Prune.
+type InMemoryTrustMarkIssuanceStore struct {
+	mutex   sync.RWMutex
+	records map[string]*IssuedTrustMark
+	revoked map[trustMarkSubject]struct{}
+}
+
+type trustMarkSubject struct {
+	trustMarkType string
+	sub           string
+}
+
+// NewInMemoryTrustMarkIssuanceStore creates a new
+// InMemoryTrustMarkIssuanceStore
+func NewInMemoryTrustMarkIssuanceStore() *InMemoryTrustMarkIssuanceStore {
+	return &InMemoryTrustMarkIssuanceStore{
+		records: make(map[string]*IssuedTrustMark),
+		revoked: make(map[trustMarkSubject]struct{}),
+	}
+}
+
+// Add implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) Add(record IssuedTrustMark) error {
+	if record.JWTID == "" {
+		return errors.New("issued trust mark has no jti")
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, found := s.records[record.JWTID]; found {
+		return errors.Errorf("trust mark with jti '%s' already recorded", record.JWTID)
+	}
+	s.records[record.JWTID] = &record
+	return nil
+}
+
+// Get implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) Get(jti string) (*IssuedTrustMark, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	record, found := s.records[jti]
+	if !found {
+		return nil, nil
+	}
+	r := *record
+	return &r, nil
+}
+
+// RevokeJTI implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) RevokeJTI(jti string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record, found := s.records[jti]
+	if !found {
+		return errors.Errorf("no trust mark with jti '%s'", jti)
+	}
+	record.Revoked = true
+	return nil
+}
+
+// Revoke implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) Revoke(trustMarkType, sub string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, record := range s.records {
+		if record.TrustMarkType == trustMarkType && record.Subject == sub {
+			record.Revoked = true
+		}
+	}
+	s.revoked[trustMarkSubject{trustMarkType, sub}] = struct{}{}
+	return nil
+}
+
+// Revoked implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) Revoked(trustMarkType, sub string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, revoked := s.revoked[trustMarkSubject{trustMarkType, sub}]
+	return revoked, nil
+}
+
+// Reinstate implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) Reinstate(trustMarkType, sub string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.revoked, trustMarkSubject{trustMarkType, sub})
+	return nil
+}
+
+// Prune removes the records of trust marks that expired more than the
+// passed retention ago and returns the number of removed records.
+// Until a record is removed, the status of its trust mark is reported as
+// 'expired', afterwards as 'invalid'.
+// Records of trust marks without expiration are kept while they are the
+// latest record of their trust mark type and subject; once a newer record
+// was issued more than the passed retention ago, they are removed as well,
+// i.e. a subject must use its latest trust mark without expiration.
+func (s *InMemoryTrustMarkIssuanceStore) Prune(retention time.Duration) int {
+	threshold := time.Now().Add(-retention)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	latest := make(map[trustMarkSubject]time.Time)
+	for _, record := range s.records {
+		key := trustMarkSubject{record.TrustMarkType, record.Subject}
+		if t, ok := latest[key]; !ok || record.IssuedAt.After(t) {
+			latest[key] = record.IssuedAt.Time
+		}
+	}
+	pruned := 0
+	for jti, record := range s.records {
+		var remove bool
+		if record.ExpiresAt != nil && !record.ExpiresAt.IsZero() {
+			remove = record.ExpiresAt.Before(threshold)
+		} else {
+			newest := latest[trustMarkSubject{record.TrustMarkType, record.Subject}]
+			remove = record.IssuedAt.Before(newest) && newest.Before(threshold)
+		}
+		if remove {
+			delete(s.records, jti)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Subjects implements the TrustMarkIssuanceStore interface
+func (s *InMemoryTrustMarkIssuanceStore) Subjects(trustMarkType string) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var subjects []string
+	for _, record := range s.records {
+		if record.TrustMarkType == trustMarkType && record.Active() && !slices.Contains(subjects, record.Subject) {
+			subjects = append(subjects, record.Subject)
+		}
+	}
+	slices.Sort(subjects)
+	return subjects, nil
+}
+
+// currentJWTs holds the latest jwt a handler issued for each trust mark type
+// and subject, so that repeated requests do not issue and record a new jwt
+// each time; it is safe for concurrent use
+type currentJWTs struct {
+	mutex sync.Mutex
+	jwts  map[trustMarkSubject]currentJWT
+}
+
+type currentJWT struct {
+	jwt       []byte
+	jti       string
+	issuedAt  time.Time
+	expiresAt *unixtime.Unixtime
+}
+
+func newCurrentJWTs() *currentJWTs {
+	return &currentJWTs{jwts: make(map[trustMarkSubject]currentJWT)}
+}
+
+// reusable checks if the jwt can still be handed out, i.e. if less than half
+// of its lifetime has passed
+func (c currentJWT) reusable(now time.Time) bool {
+	if c.expiresAt == nil || c.expiresAt.IsZero() {
+		return true
+	}
+	return now.Before(c.issuedAt.Add(c.expiresAt.Sub(c.issuedAt) / 2))
+}
+
+// get returns the current jwt for the passed trust mark type and subject, if
+// it is reusable and its record in the passed store, if any, is still active
+func (c *currentJWTs) get(trustMarkType, sub string, store TrustMarkIssuanceStore) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.Lock()
+	current, ok := c.jwts[trustMarkSubject{trustMarkType, sub}]
+	c.mutex.Unlock()
+	if !ok || !current.reusable(time.Now()) {
+		return nil, false
+	}
+	if store != nil && current.jti != "" {
+		record, err := store.Get(current.jti)
+		if err != nil {
+			internal.Log(err)
+			return nil, false
+		}
+		if record == nil || !record.Active() {
+			return nil, false
+		}
+	}
+	return current.jwt, true
+}
+
+func (c *currentJWTs) set(trustMarkType, sub string, current currentJWT) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.jwts[trustMarkSubject{trustMarkType, sub}] = current
+}
+
+// reset drops the current jwts of the passed trust mark type, e.g. because
+// its specification changed
+func (c *currentJWTs) reset(trustMarkType string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key := range c.jwts {
+		if key.trustMarkType == trustMarkType {
+			delete(c.jwts, key)
+		}
+	}
+}
+
+// currentTrustMark returns the trust mark of the passed type the
+// TrustMarkHandler last issued to the passed subject, if it is still active
+// and less than half of its lifetime has passed; otherwise a new trust mark
+// is issued with IssueTrustMark
+func (tmi *TrustMarkIssuer) currentTrustMark(trustMarkType, sub string) ([]byte, error) {
+	if jwt, ok := tmi.current.get(trustMarkType, sub, tmi.IssuanceStore); ok {
+		return jwt, nil
+	}
+	info, err := tmi.IssueTrustMark(trustMarkType, sub)
+	if err != nil {
+		return nil, err
+	}
+	jwt := []byte(info.TrustMarkJWT)
+	tmi.current.set(
+		trustMarkType, sub, currentJWT{
+			jwt:       jwt,
+			jti:       info.trustmark.JWTID,
+			issuedAt:  info.trustmark.IssuedAt.Time,
+			expiresAt: info.trustmark.ExpiresAt,
+		},
+	)
+	return jwt, nil
+}
+
+// Revoke revokes all trust marks of the passed type the TrustMarkIssuer issued
+// to the passed subject
+func (tmi TrustMarkIssuer) Revoke(trustMarkType, sub string) error {
+	if tmi.IssuanceStore == nil {
+		return errors.New("trust mark issuer has no issuance store")
+	}
+	return tmi.IssuanceStore.Revoke(trustMarkType, sub)
+}
+
+// Reinstate allows the TrustMarkIssuer to issue trust marks of the passed
+// type to the passed subject again after they were revoked with Revoke
+func (tmi TrustMarkIssuer) Reinstate(trustMarkType, sub string) error {
+	if tmi.IssuanceStore == nil {
+		return errors.New("trust mark issuer has no issuance store")
+	}
+	return tmi.IssuanceStore.Reinstate(trustMarkType, sub)
+}
+
+// RevokeJTI revokes the trust mark with the passed jti
+func (tmi TrustMarkIssuer) RevokeJTI(jti string) error {
+	if tmi.IssuanceStore == nil {
+		return errors.New("trust mark issuer has no issuance store")
+	}
+	return tmi.IssuanceStore.RevokeJTI(jti)
+}
+
+// TrustMarkStatus evaluates the status of the passed trust mark jwt and
+// returns one of the oidfedconst.TrustMarkStatus values.
+// A trust mark is invalid if it was not issued and signed by this
+// TrustMarkIssuer; if an IssuanceStore is set, trust marks that were not
+// recorded in it are also invalid. An error is only returned if the
+// IssuanceStore cannot be queried.
+func (tmi TrustMarkIssuer) TrustMarkStatus(trustMarkJWT []byte) (string, error) {
+	tm, err := ParseTrustMark(trustMarkJWT)
+	if err != nil || tm.Issuer != tmi.EntityID {
+		return oidfedconst.TrustMarkStatusInvalid, nil
+	}
+	if _, err = tm.jwtMsg.VerifyWithSet(tmi.JWKS()); err != nil {
+		return oidfedconst.TrustMarkStatusInvalid, nil
+	}
+	if tmi.IssuanceStore != nil {
+		if tm.JWTID == "" {
+			return oidfedconst.TrustMarkStatusInvalid, nil
+		}
+		record, err := tmi.IssuanceStore.Get(tm.JWTID)
+		if err != nil {
+			return "", err
+		}
+		if record == nil || record.TrustMarkType != tm.TrustMarkType || record.Subject != tm.Subject {
+			return oidfedconst.TrustMarkStatusInvalid, nil
+		}
+		if record.Revoked {
+			return oidfedconst.TrustMarkStatusRevoked, nil
+		}
+	}
+	if tm.ExpiresAt != nil && !tm.ExpiresAt.IsZero() && tm.ExpiresAt.Before(time.Now()) {
+		return oidfedconst.TrustMarkStatusExpired, nil
+	}
+	return oidfedconst.TrustMarkStatusActive, nil
+}
+
+// TrustMarkStatusResponse evaluates the status of the passed trust mark jwt
+// and returns the signed trust mark status response
+func (tmi TrustMarkIssuer) TrustMarkStatusResponse(trustMarkJWT []byte) ([]byte, error) {
+	status, err := tmi.TrustMarkStatus(trustMarkJWT)
+	if err != nil {
+		return nil, err
+	}
+	return tmi.GeneralJWTSigner.TrustMarkStatusResponseSigner().JWT(
+		TrustMarkStatusResponse{
+			Issuer:    tmi.EntityID,
+			IssuedAt:  unixtime.Now(),
+			TrustMark: string(trustMarkJWT),
+			Status:    status,
+		},
+	)
+}
+
+// TrustMarkedEntities returns the entities that hold an active trust mark of
+// the passed type issued by this TrustMarkIssuer
+func (tmi TrustMarkIssuer) TrustMarkedEntities(trustMarkType string) ([]string, error) {
+	if tmi.IssuanceStore == nil {
+		return nil, errors.New("trust mark issuer has no issuance store")
+	}
+	if _, ok := tmi.trustMarks[trustMarkType]; !ok {
+		return nil, errors.Errorf("unknown trustmark '%s'", trustMarkType)
+	}
+	return tmi.IssuanceStore.Subjects(trustMarkType)
+}
+
+// TrustMarkHandler returns a http.Handler for the trust mark endpoint; it
+// accepts GET requests with the required 'trust_mark_type' and 'sub' query
+// parameters and responds with a trust mark for the subject. A trust mark
+// the handler issued before is returned again while it is active and less
+// than half of its lifetime has passed, so that repeated requests do not
+// issue and record a new trust mark each time. If the subject is not
+// eligible for the trust mark, the TrustMarkEligibilityError is returned as
+// an error response.
+func (tmi *TrustMarkIssuer) TrustMarkHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !allowMethods(w, r, http.MethodGet) {
+				return
+			}
+			trustMarkType := r.URL.Query().Get("trust_mark_type")
+			sub := r.URL.Query().Get("sub")
+			if trustMarkType == "" || sub == "" {
+				writeErrorResponse(
+					w, http.StatusBadRequest,
+					ErrorInvalidRequest("required parameters 'trust_mark_type' and 'sub' not given"),
+				)
+				return
+			}
+			if _, ok := tmi.trustMarks[trustMarkType]; !ok {
+				writeErrorResponse(w, http.StatusNotFound, ErrorNotFound("unknown trust mark type"))
+				return
+			}
+			jwt, err := tmi.currentTrustMark(trustMarkType, sub)
+			if err != nil {
+				var eligibilityErr *TrustMarkEligibilityError
+				if errors.As(err, &eligibilityErr) {
+					status, e := eligibilityErr.ErrorResponse()
+					if status >= http.StatusInternalServerError {
+						internal.Log(err)
+					}
+					writeErrorResponse(w, status, e)
+					return
+				}
+				internal.Log(err)
+				writeErrorResponse(w, http.StatusInternalServerError, ErrorServerError("could not issue trust mark"))
+				return
+			}
+			writeJWTResponse(w, oidfedconst.ContentTypeTrustMark, jwt)
+		},
+	)
+}
+
+// TrustMarkStatusHandler returns a http.Handler for the trust mark status
+// endpoint; it accepts POST requests with the 'trust_mark' form parameter and
+// responds with the signed trust mark status response
+func (tmi *TrustMarkIssuer) TrustMarkStatusHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !allowMethods(w, r, http.MethodPost) {
+				return
+			}
+			trustMark := r.PostFormValue("trust_mark")
+			if trustMark == "" {
+				writeErrorResponse(
+					w, http.StatusBadRequest, ErrorInvalidRequest("required parameter 'trust_mark' not given"),
+				)
+				return
+			}
+			jwt, err := tmi.TrustMarkStatusResponse([]byte(trustMark))
+			if err != nil {
+				internal.Log(err)
+				writeErrorResponse(
+					w, http.StatusInternalServerError, ErrorServerError("could not evaluate trust mark status"),
+				)
+				return
+			}
+			writeJWTResponse(w, oidfedconst.ContentTypeTrustMarkStatusResponse, jwt)
+		},
+	)
+}
+
+// TrustMarkedEntitiesHandler returns a http.Handler for the trust marked
+// entities listing endpoint; it accepts GET requests with the required
+// 'trust_mark_type' and the optional 'sub' query parameter and responds with
+// the json array of the entities holding an active trust mark of that type
+func (tmi *TrustMarkIssuer) TrustMarkedEntitiesHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !allowMethods(w, r, http.MethodGet) {
+				return
+			}
+			trustMarkType := r.URL.Query().Get("trust_mark_type")
+			if trustMarkType == "" {
+				writeErrorResponse(
+					w, http.StatusBadRequest, ErrorInvalidRequest("required parameter 'trust_mark_type' not given"),
+				)
+				return
+			}
+			if _, ok := tmi.trustMarks[trustMarkType]; !ok {
+				writeErrorResponse(w, http.StatusNotFound, ErrorNotFound("unknown trust mark type"))
+				return
+			}
+			entities, err := tmi.TrustMarkedEntities(trustMarkType)
+			if err != nil {
+				internal.Log(err)
+				writeErrorResponse(
+					w, http.StatusInternalServerError, ErrorServerError("could not list trust marked entities"),
+				)
+				return
+			}
+			if sub := r.URL.Query().Get("sub"); sub != "" {
+				if slices.Contains(entities, sub) {
+					entities = []string{sub}
+				} else {
+					entities = nil
+				}
+			}
+			if entities == nil {
+				entities = []string{}
+			}
+			writeJSONResponse(w, http.StatusOK, entities)
+		},
+	)
+}
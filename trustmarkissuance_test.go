@@ -0,0 +1,498 @@
+package oidfed
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+func newTestTrustMarkIssuer(t *testing.T, entityID string) *TrustMarkIssuer {
+	t.Helper()
+	tmi := NewTrustMarkIssuer(
+		entityID, newTestJWTSigner(t).TrustMarkSigner(), []TrustMarkSpec{
+			{
+				TrustMarkType: "https://trustmarks.org/a",
+				Lifetime:      unixtime.DurationInSeconds{Duration: time.Hour},
+			},
+			{
+				TrustMarkType: "https://trustmarks.org/b",
+				Lifetime:      unixtime.DurationInSeconds{Duration: time.Hour},
+			},
+		},
+	)
+	tmi.IssuanceStore = NewInMemoryTrustMarkIssuanceStore()
+	return tmi
+}
+
+func TestInMemoryTrustMarkIssuanceStore(t *testing.T) {
+	store := NewInMemoryTrustMarkIssuanceStore()
+	past := unixtime.Unixtime{Time: time.Now().Add(-time.Minute)}
+	records := []IssuedTrustMark{
+		{JWTID: "1", TrustMarkType: "a", Subject: "https://rp1.example.org"},
+		{JWTID: "2", TrustMarkType: "a", Subject: "https://rp2.example.org"},
+		{JWTID: "3", TrustMarkType: "a", Subject: "https://rp2.example.org"},
+		{JWTID: "4", TrustMarkType: "a", Subject: "https://rp3.example.org", ExpiresAt: &past},
+		{JWTID: "5", TrustMarkType: "b", Subject: "https://rp1.example.org"},
+	}
+	for _, r := range records {
+		if err := store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Add(records[0]); err == nil {
+		t.Errorf("expected error for duplicate jti")
+	}
+	if err := store.Add(IssuedTrustMark{TrustMarkType: "a"}); err == nil {
+		t.Errorf("expected error for missing jti")
+	}
+
+	subjects, _ := store.Subjects("a")
+	if expected := []string{"https://rp1.example.org", "https://rp2.example.org"}; !reflect.DeepEqual(
+		subjects, expected,
+	) {
+		t.Errorf("unexpected subjects: %v", subjects)
+	}
+
+	if err := store.RevokeJTI("2"); err != nil {
+		t.Fatal(err)
+	}
+	subjects, _ = store.Subjects("a")
+	if len(subjects) != 2 {
+		t.Errorf("subject with another active trust mark must still be listed: %v", subjects)
+	}
+	if err := store.Revoke("a", "https://rp2.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	subjects, _ = store.Subjects("a")
+	if !reflect.DeepEqual(subjects, []string{"https://rp1.example.org"}) {
+		t.Errorf("unexpected subjects after revocation: %v", subjects)
+	}
+	if r, _ := store.Get("3"); r == nil || !r.Revoked {
+		t.Errorf("trust mark must be revoked: %+v", r)
+	}
+	if r, _ := store.Get("5"); r == nil || r.Revoked {
+		t.Errorf("trust mark of other type must not be revoked: %+v", r)
+	}
+	if revoked, _ := store.Revoked("a", "https://rp2.example.org"); !revoked {
+		t.Errorf("subject must be revoked")
+	}
+	if err := store.Add(IssuedTrustMark{JWTID: "6", TrustMarkType: "a", Subject: "https://rp2.example.org"}); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := store.Revoked("a", "https://rp2.example.org"); !revoked {
+		t.Errorf("recording a trust mark must not reinstate a revoked subject")
+	}
+	if err := store.Reinstate("a", "https://rp2.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := store.Revoked("a", "https://rp2.example.org"); revoked {
+		t.Errorf("reinstated subject must not be revoked")
+	}
+	if r, _ := store.Get("3"); r == nil || !r.Revoked {
+		t.Errorf("reinstating must not reactivate revoked trust marks: %+v", r)
+	}
+	if r, _ := store.Get("unknown"); r != nil {
+		t.Errorf("expected no record for unknown jti")
+	}
+	if err := store.RevokeJTI("unknown"); err == nil {
+		t.Errorf("expected error for unknown jti")
+	}
+
+	if pruned := store.Prune(time.Hour); pruned != 0 {
+		t.Errorf("records expired within the retention must not be pruned, but %d were", pruned)
+	}
+	if pruned := store.Prune(0); pruned != 1 {
+		t.Errorf("expected one pruned record, but got %d", pruned)
+	}
+	if r, _ := store.Get("4"); r != nil {
+		t.Errorf("expired record must be pruned: %+v", r)
+	}
+	if r, _ := store.Get("3"); r == nil {
+		t.Errorf("revoked record without expiration must not be pruned")
+	}
+
+	old := unixtime.Unixtime{Time: time.Now().Add(-2 * time.Hour)}
+	newer := unixtime.Unixtime{Time: time.Now().Add(-time.Hour)}
+	for _, r := range []IssuedTrustMark{
+		{JWTID: "7", TrustMarkType: "c", Subject: "https://rp1.example.org", IssuedAt: old},
+		{JWTID: "8", TrustMarkType: "c", Subject: "https://rp1.example.org", IssuedAt: newer},
+		{JWTID: "9", TrustMarkType: "c", Subject: "https://rp2.example.org", IssuedAt: old},
+	} {
+		if err := store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pruned := store.Prune(2 * time.Hour); pruned != 0 {
+		t.Errorf("records superseded within the retention must not be pruned, but %d were", pruned)
+	}
+	if pruned := store.Prune(time.Minute); pruned != 1 {
+		t.Errorf("expected one pruned superseded record, but got %d", pruned)
+	}
+	if r, _ := store.Get("7"); r != nil {
+		t.Errorf("superseded record without expiration must be pruned: %+v", r)
+	}
+	for _, jti := range []string{"8", "9"} {
+		if r, _ := store.Get(jti); r == nil {
+			t.Errorf("latest record without expiration must not be pruned")
+		}
+	}
+}
+
+func TestTrustMarkIssuer_TrustMarkStatus(t *testing.T) {
+	const sub = "https://rp.example.org"
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	other := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	withoutStore := *tmi
+	withoutStore.IssuanceStore = nil
+
+	issue := func(tmi *TrustMarkIssuer, trustMarkType string, lifetime ...time.Duration) *TrustMark {
+		info, err := tmi.IssueTrustMark(trustMarkType, sub, lifetime...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm, err := info.TrustMark()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	active := issue(tmi, "https://trustmarks.org/a")
+	if active.JWTID == "" {
+		t.Fatalf("issued trust mark must have a jti")
+	}
+	revokedByJTI := issue(tmi, "https://trustmarks.org/a")
+	if err := tmi.RevokeJTI(revokedByJTI.JWTID); err != nil {
+		t.Fatal(err)
+	}
+	revokedByType := issue(tmi, "https://trustmarks.org/b")
+	if err := tmi.Revoke("https://trustmarks.org/b", sub); err != nil {
+		t.Fatal(err)
+	}
+	expired := issue(tmi, "https://trustmarks.org/a", -time.Minute)
+	notRecorded := issue(&withoutStore, "https://trustmarks.org/a")
+	foreign := issue(other, "https://trustmarks.org/a")
+
+	tests := []struct {
+		name     string
+		tmi      *TrustMarkIssuer
+		jwt      []byte
+		expected string
+	}{
+		{
+			name:     "active",
+			tmi:      tmi,
+			jwt:      active.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusActive,
+		},
+		{
+			name:     "revoked by jti",
+			tmi:      tmi,
+			jwt:      revokedByJTI.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusRevoked,
+		},
+		{
+			name:     "revoked by type and subject",
+			tmi:      tmi,
+			jwt:      revokedByType.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusRevoked,
+		},
+		{
+			name:     "expired",
+			tmi:      tmi,
+			jwt:      expired.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusExpired,
+		},
+		{
+			name:     "not recorded",
+			tmi:      tmi,
+			jwt:      notRecorded.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusInvalid,
+		},
+		{
+			name:     "signed by other key",
+			tmi:      tmi,
+			jwt:      foreign.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusInvalid,
+		},
+		{
+			name:     "not a trust mark",
+			tmi:      tmi,
+			jwt:      []byte("foobar"),
+			expected: oidfedconst.TrustMarkStatusInvalid,
+		},
+		{
+			name:     "without store",
+			tmi:      &withoutStore,
+			jwt:      notRecorded.jwtMsg.RawJWT,
+			expected: oidfedconst.TrustMarkStatusActive,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				status, err := test.tmi.TrustMarkStatus(test.jwt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if status != test.expected {
+					t.Errorf("expected status '%s', but got '%s'", test.expected, status)
+				}
+			},
+		)
+	}
+
+	if err := withoutStore.Revoke("https://trustmarks.org/a", sub); err == nil {
+		t.Errorf("expected error for revocation without store")
+	}
+}
+
+func TestTrustMarkIssuer_TrustMarkStatusHandler(t *testing.T) {
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	info, err := tmi.IssueTrustMark("https://trustmarks.org/a", "https://rp.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := tmi.TrustMarkStatusHandler()
+
+	form := url.Values{}
+	form.Set("trust_mark", info.TrustMarkJWT)
+	req := httptest.NewRequest(http.MethodPost, "/status", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != oidfedconst.ContentTypeTrustMarkStatusResponse {
+		t.Errorf("unexpected content type '%s'", ct)
+	}
+	r, err := ParseTrustMarkStatusResponse(rec.Body.Bytes(), tmi.EntityID, tmi.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != oidfedconst.TrustMarkStatusActive || r.TrustMark != info.TrustMarkJWT {
+		t.Errorf("unexpected status response: %+v", r)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/status", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status %d without trust mark", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status %d for GET", rec.Code)
+	}
+}
+
+func TestTrustMarkIssuer_TrustMarkedEntitiesHandler(t *testing.T) {
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	for _, sub := range []string{"https://rp2.example.org", "https://rp1.example.org", "https://rp3.example.org"} {
+		if _, err := tmi.IssueTrustMark("https://trustmarks.org/a", sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tmi.Revoke("https://trustmarks.org/a", "https://rp3.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	handler := tmi.TrustMarkedEntitiesHandler()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       []string
+	}{
+		{
+			name:           "all",
+			query:          "trust_mark_type=https://trustmarks.org/a",
+			expectedStatus: http.StatusOK,
+			expected:       []string{"https://rp1.example.org", "https://rp2.example.org"},
+		},
+		{
+			name:           "sub",
+			query:          "trust_mark_type=https://trustmarks.org/a&sub=https://rp2.example.org",
+			expectedStatus: http.StatusOK,
+			expected:       []string{"https://rp2.example.org"},
+		},
+		{
+			name:           "revoked sub",
+			query:          "trust_mark_type=https://trustmarks.org/a&sub=https://rp3.example.org",
+			expectedStatus: http.StatusOK,
+			expected:       []string{},
+		},
+		{
+			name:           "no trust marked entities",
+			query:          "trust_mark_type=https://trustmarks.org/b",
+			expectedStatus: http.StatusOK,
+			expected:       []string{},
+		},
+		{
+			name:           "unknown type",
+			query:          "trust_mark_type=https://trustmarks.org/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing type",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/list?"+test.query, nil))
+				if rec.Code != test.expectedStatus {
+					t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+				}
+				if test.expected == nil {
+					return
+				}
+				var entities []string
+				if err := json.Unmarshal(rec.Body.Bytes(), &entities); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(entities, test.expected) {
+					t.Errorf("expected %v, but got %v", test.expected, entities)
+				}
+			},
+		)
+	}
+}
+
+func TestTrustMarkIssuer_TrustMarkHandler_Revoked(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/a"
+	const sub = "https://revoked.example.org"
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	if _, err := tmi.IssueTrustMark(trustMarkType, sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmi.Revoke(trustMarkType, sub); err != nil {
+		t.Fatal(err)
+	}
+	handler := tmi.TrustMarkHandler()
+	request := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(
+			rec, httptest.NewRequest(
+				http.MethodGet, "/trustmark?"+url.Values{
+					"trust_mark_type": {trustMarkType},
+					"sub":             {sub},
+				}.Encode(), nil,
+			),
+		)
+		return rec
+	}
+
+	rec := request()
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected revoked subject to be refused, but got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var errRes Error
+	if err := json.Unmarshal(rec.Body.Bytes(), &errRes); err != nil {
+		t.Fatal(err)
+	}
+	if errRes.Error != NotFound {
+		t.Errorf("unexpected error '%s'", errRes.Error)
+	}
+	if subjects, _ := tmi.TrustMarkedEntities(trustMarkType); len(subjects) != 0 {
+		t.Errorf("revoked subject must not be listed: %v", subjects)
+	}
+
+	if err := tmi.Reinstate(trustMarkType, sub); err != nil {
+		t.Fatal(err)
+	}
+	if rec = request(); rec.Code != http.StatusOK {
+		t.Errorf("expected reinstated subject to obtain a trust mark, but got status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTrustMarkIssuer_TrustMarkHandler_Current(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/a"
+	tmi := newTestTrustMarkIssuer(t, "https://tmi.example.org")
+	handler := tmi.TrustMarkHandler()
+	request := func() *TrustMark {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(
+			rec, httptest.NewRequest(
+				http.MethodGet, "/trustmark?"+url.Values{
+					"trust_mark_type": {trustMarkType},
+					"sub":             {"https://rp.example.org"},
+				}.Encode(), nil,
+			),
+		)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+		}
+		tm, err := ParseTrustMark(rec.Body.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	first := request()
+	if second := request(); second.JWTID != first.JWTID {
+		t.Errorf("expected the current trust mark to be returned again, but got '%s' and '%s'", first.JWTID, second.JWTID)
+	}
+	if err := tmi.RevokeJTI(first.JWTID); err != nil {
+		t.Fatal(err)
+	}
+	if third := request(); third.JWTID == first.JWTID {
+		t.Errorf("revoked trust mark must not be returned again")
+	}
+}
+
+func TestCurrentJWT_reusable(t *testing.T) {
+	now := time.Now()
+	exp := func(d time.Duration) *unixtime.Unixtime {
+		return &unixtime.Unixtime{Time: now.Add(d)}
+	}
+	tests := []struct {
+		name     string
+		current  currentJWT
+		reusable bool
+	}{
+		{
+			name:     "no expiration",
+			current:  currentJWT{issuedAt: now.Add(-24 * time.Hour)},
+			reusable: true,
+		},
+		{
+			name:     "first half of lifetime",
+			current:  currentJWT{issuedAt: now.Add(-10 * time.Minute), expiresAt: exp(50 * time.Minute)},
+			reusable: true,
+		},
+		{
+			name:    "second half of lifetime",
+			current: currentJWT{issuedAt: now.Add(-40 * time.Minute), expiresAt: exp(20 * time.Minute)},
+		},
+		{
+			name:    "expired",
+			current: currentJWT{issuedAt: now.Add(-2 * time.Hour), expiresAt: exp(-time.Hour)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				if reusable := test.current.reusable(now); reusable != test.reusable {
+					t.Errorf("reusable is %t, but %t expected", reusable, test.reusable)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,107 @@
+package oidfed
+
+import (
+	"net/url"
+	"time"
+
+	arrays "github.com/adam-hanna/arrayOperations"
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/cache"
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/internal/http"
+)
+
+// DefaultTrustMarkedEntitiesCacheDuration is the duration for which the
+// entities obtained from a trust mark list endpoint are cached
+var DefaultTrustMarkedEntitiesCacheDuration = 10 * time.Minute
+
+// FetchTrustMarkedEntities asks the trust mark issuer for all entities that
+// hold an active trust mark of the passed type; if sub is not empty, the
+// result is limited to that entity.
+// The trust mark list endpoint is discovered from the issuer's entity
+// configuration; the result is cached for
+// DefaultTrustMarkedEntitiesCacheDuration.
+func FetchTrustMarkedEntities(trustMarkIssuer, trustMarkType, sub string) ([]string, error) {
+	cacheKey := cache.Key(cache.KeyTrustMarkedEntities, trustMarkIssuer+"|"+trustMarkType+"|"+sub)
+	var entities []string
+	set, err := cache.Get(cacheKey, &entities)
+	if err != nil {
+		internal.Log(err)
+	} else if set {
+		internal.Log("Obtained trust marked entities from cache")
+		return entities, nil
+	}
+	ec, err := GetEntityConfiguration(trustMarkIssuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not obtain trust mark issuer entity configuration")
+	}
+	if ec.Metadata == nil || ec.Metadata.FederationEntity == nil ||
+		ec.Metadata.FederationEntity.FederationTrustMarkListEndpoint == "" {
+		return nil, errors.Errorf("trust mark issuer '%s' does not publish a trust mark list endpoint", trustMarkIssuer)
+	}
+	entities, err = httpFetchTrustMarkedEntities(
+		ec.Metadata.FederationEntity.FederationTrustMarkListEndpoint, trustMarkType, sub,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err = cache.Set(cacheKey, entities, DefaultTrustMarkedEntitiesCacheDuration); err != nil {
+		internal.Log(err)
+	}
+	return entities, nil
+}
+
+func httpFetchTrustMarkedEntities(listEndpoint, trustMarkType, sub string) ([]string, error) {
+	params := url.Values{}
+	params.Set("trust_mark_type", trustMarkType)
+	if sub != "" {
+		params.Set("sub", sub)
+	}
+	resp, errRes, err := http.Get(listEndpoint, params, &[]string{})
+	if err != nil {
+		return nil, err
+	}
+	if errRes != nil {
+		return nil, errRes.Err()
+	}
+	if resp.IsError() {
+		return nil, errors.Errorf("trust mark list endpoint returned status code %d", resp.StatusCode())
+	}
+	entities, ok := resp.Result().(*[]string)
+	if !ok || entities == nil {
+		return nil, errors.New("unexpected response type")
+	}
+	return *entities, nil
+}
+
+// trustMarkedEntityCandidates uses the trust mark list endpoints of the trust
+// mark issuers that are allowed by the trust anchor to obtain the entities
+// that hold trust marks of all the passed types.
+// The second return value is false, if the candidates cannot be determined
+// this way, i.e. if the trust anchor does not restrict the issuers of one of
+// the types or if a list endpoint cannot be queried.
+func trustMarkedEntityCandidates(ta *EntityStatementPayload, trustMarkTypes []string) ([]string, bool) {
+	var candidates []string
+	for i, trustMarkType := range trustMarkTypes {
+		issuers := ta.TrustMarkIssuers[trustMarkType]
+		if len(issuers) == 0 {
+			return nil, false
+		}
+		var entities []string
+		for _, issuer := range issuers {
+			ids, err := FetchTrustMarkedEntities(issuer, trustMarkType, "")
+			if err != nil {
+				internal.Logf("Could not fetch trust marked entities from %s: %s", issuer, err.Error())
+				return nil, false
+			}
+			entities = append(entities, ids...)
+		}
+		if i == 0 {
+			candidates = arrays.Distinct(entities)
+		} else {
+			candidates = arrays.Intersect(candidates, entities)
+		}
+	}
+	return candidates, true
+}
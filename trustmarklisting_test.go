@@ -0,0 +1,176 @@
+package oidfed
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lionick/oidfed-lib/apimodel"
+)
+
+func TestFetchTrustMarkedEntities(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm3"
+	subs := []string{
+		"https://rp1.listing.example.org",
+		"https://rp2.listing.example.org",
+		"https://revoked.listing.example.org",
+	}
+	const later = "https://rp3.listing.example.org"
+	// tmi1 is shared with other tests, so only the subjects of this test are
+	// compared
+	ownSubjects := func(entities []string) []string {
+		return slices.DeleteFunc(
+			slices.Clone(entities), func(e string) bool {
+				return !slices.Contains(subs, e) && e != later
+			},
+		)
+	}
+	for _, sub := range subs {
+		if _, err := tmi1.IssueTrustMark(trustMarkType, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tmi1.Revoke(trustMarkType, subs[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		issuer      string
+		sub         string
+		expected    []string
+		errExpected bool
+	}{
+		{
+			name:     "all",
+			issuer:   tmi1.EntityID,
+			expected: subs[:2],
+		},
+		{
+			name:     "sub",
+			issuer:   tmi1.EntityID,
+			sub:      subs[1],
+			expected: subs[1:2],
+		},
+		{
+			name:     "revoked sub",
+			issuer:   tmi1.EntityID,
+			sub:      subs[2],
+			expected: []string{},
+		},
+		{
+			name:        "no list endpoint",
+			issuer:      taWithTmo.EntityID,
+			errExpected: true,
+		},
+		{
+			name:        "unknown issuer",
+			issuer:      "https://unknown.listing.example.org",
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				entities, err := FetchTrustMarkedEntities(test.issuer, trustMarkType, test.sub)
+				if err != nil {
+					if !test.errExpected {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				if test.errExpected {
+					t.Fatalf("expected error, but got entities: %v", entities)
+				}
+				if entities = ownSubjects(entities); !reflect.DeepEqual(entities, test.expected) {
+					t.Errorf("expected %v, but got %v", test.expected, entities)
+				}
+			},
+		)
+	}
+
+	if _, err := tmi1.IssueTrustMark(trustMarkType, later); err != nil {
+		t.Fatal(err)
+	}
+	entities, err := FetchTrustMarkedEntities(tmi1.EntityID, trustMarkType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entities = ownSubjects(entities); !reflect.DeepEqual(entities, subs[:2]) {
+		t.Errorf("trust marked entities must be cached, but got %v", entities)
+	}
+}
+
+func TestSimpleEntityCollector_UseTrustMarkLists(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/test"
+	newTrustMarkedOP := func(entityID string, revoke bool) *mockOP {
+		op := newMockOP(entityID, &OpenIDProviderMetadata{})
+		info, err := tmi1.IssueTrustMark(trustMarkType, entityID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		op.trustMarks = TrustMarkInfos{*info}
+		if revoke {
+			if err = tmi1.Revoke(trustMarkType, entityID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return op
+	}
+	// The ops are not subordinates of the trust anchor, so they can only be
+	// found through the trust mark list endpoint
+	listed := newTrustMarkedOP("https://op.listing.example.org", false)
+	newTrustMarkedOP("https://revoked-op.listing.example.org", true)
+	// listed, but without trust mark in its entity configuration
+	if _, err := tmi1.IssueTrustMark(trustMarkType, op1.EntityID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		useTrustMarkLists bool
+		trustMarkTypes    []string
+		expected          []string
+	}{
+		{
+			name:              "trust mark lists",
+			useTrustMarkLists: true,
+			trustMarkTypes:    []string{trustMarkType},
+			expected:          []string{listed.EntityID},
+		},
+		{
+			name:           "crawling",
+			trustMarkTypes: []string{trustMarkType},
+		},
+		{
+			name:              "issuers not restricted",
+			useTrustMarkLists: true,
+			trustMarkTypes:    []string{"https://trustmarks.org/tm2"},
+		},
+		{
+			name:              "list endpoint not available",
+			useTrustMarkLists: true,
+			trustMarkTypes:    []string{trustMarkType, "https://trustmarks.org/tm4"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				collected := (&SimpleEntityCollector{UseTrustMarkLists: test.useTrustMarkLists}).CollectEntities(
+					apimodel.EntityCollectionRequest{
+						TrustAnchor:    taWithTmo.EntityID,
+						TrustMarkTypes: test.trustMarkTypes,
+					},
+				)
+				var entityIDs []string
+				for _, e := range collected {
+					entityIDs = append(entityIDs, e.EntityID)
+				}
+				slices.Sort(entityIDs)
+				if !reflect.DeepEqual(entityIDs, test.expected) {
+					t.Errorf("expected %v, but got %v", test.expected, entityIDs)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,310 @@
+package oidfed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lionick/oidfed-lib/cache"
+	"github.com/lionick/oidfed-lib/internal"
+	"github.com/lionick/oidfed-lib/internal/http"
+	"github.com/lionick/oidfed-lib/internal/jwx"
+	"github.com/lionick/oidfed-lib/jwks"
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+// TrustMarkStatusVerifier verifies the status of trust marks at the status
+// endpoints of their issuers and the status of delegations at the delegation
+// status endpoints of the trust mark owners. The zero value fails closed.
+type TrustMarkStatusVerifier struct {
+	// FailOpen determines the outcome of a status verification if the status
+	// endpoint cannot be reached or responds with a server error (5xx).
+	// If set to true, the trust mark or delegation is considered active in
+	// that case; otherwise the verification fails.
+	// A response that cannot be verified and a status other than 'active'
+	// always fail the verification.
+	FailOpen bool
+}
+
+// statusUnavailableError is returned if a status endpoint cannot be reached
+// or responds with a server error
+type statusUnavailableError struct {
+	err error
+}
+
+func (e statusUnavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e statusUnavailableError) Unwrap() error {
+	return e.err
+}
+
+// failOpen checks if the passed error from obtaining a status can be ignored
+// because the TrustMarkStatusVerifier fails open
+func (v TrustMarkStatusVerifier) failOpen(err error) bool {
+	var unavailable statusUnavailableError
+	if !v.FailOpen || !errors.As(err, &unavailable) {
+		return false
+	}
+	internal.Logf("status endpoint unavailable, failing open: %v", err)
+	return true
+}
+
+// TrustMarkStatusCheckInterval is the duration for which the status of a
+// trust mark obtained from the trust mark issuer (or of a delegation obtained
+// from the trust mark owner) is cached before it is checked again
+var TrustMarkStatusCheckInterval = 5 * time.Minute
+
+// StatusResponseClockSkew is the tolerated clock skew when checking the 'iat'
+// claim of trust mark and delegation status responses
+var StatusResponseClockSkew = time.Minute
+
+// verifyStatusResponseIssuedAt checks that the 'iat' claim of a status
+// response is set, not in the future, and not older than
+// TrustMarkStatusCheckInterval, both with StatusResponseClockSkew tolerance,
+// so that old responses cannot be replayed
+func verifyStatusResponseIssuedAt(iat unixtime.Unixtime) error {
+	if iat.IsZero() {
+		return errors.New("status response has no 'iat' claim")
+	}
+	now := time.Now()
+	if iat.After(now.Add(StatusResponseClockSkew)) {
+		return errors.New("status response was issued in the future")
+	}
+	if iat.Before(now.Add(-TrustMarkStatusCheckInterval - StatusResponseClockSkew)) {
+		return errors.New("status response is too old")
+	}
+	return nil
+}
+
+// TrustMarkStatusResponse is the payload of a trust mark status response
+type TrustMarkStatusResponse struct {
+	Issuer    string                 `json:"iss"`
+	IssuedAt  unixtime.Unixtime      `json:"iat"`
+	TrustMark string                 `json:"trust_mark"`
+	Status    string                 `json:"status"`
+	Extra     map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// It also marshals extra fields.
+func (r TrustMarkStatusResponse) MarshalJSON() ([]byte, error) {
+	type trustMarkStatusResponse TrustMarkStatusResponse
+	explicitFields, err := json.Marshal(trustMarkStatusResponse(r))
+	if err != nil {
+		return nil, err
+	}
+	return extraMarshalHelper(explicitFields, r.Extra)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// It also unmarshalls additional fields into the Extra claim.
+func (r *TrustMarkStatusResponse) UnmarshalJSON(data []byte) error {
+	type trustMarkStatusResponse TrustMarkStatusResponse
+	rr := trustMarkStatusResponse(*r)
+	extra, err := unmarshalWithExtra(data, &rr)
+	if err != nil {
+		return err
+	}
+	rr.Extra = extra
+	*r = TrustMarkStatusResponse(rr)
+	return nil
+}
+
+// ParseTrustMarkStatusResponse parses a trust mark status response jwt and
+// verifies it for the passed trust mark issuer, i.e. the jwt type, the
+// signature with the issuer's keys, the 'iss' claim, and that the 'iat' claim
+// is recent, see verifyStatusResponseIssuedAt
+func ParseTrustMarkStatusResponse(
+	data []byte, trustMarkIssuer string, issuerKeys jwks.JWKS,
+) (*TrustMarkStatusResponse, error) {
+	m, err := jwx.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse trust mark status response")
+	}
+	if !m.VerifyType(oidfedconst.JWTTypeTrustMarkStatusResponse) {
+		return nil, errors.Errorf(
+			"trust mark status response does not have '%s' JWT type", oidfedconst.JWTTypeTrustMarkStatusResponse,
+		)
+	}
+	payload, err := m.VerifyWithSet(issuerKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not verify trust mark status response")
+	}
+	var r TrustMarkStatusResponse
+	if err = json.Unmarshal(payload, &r); err != nil {
+		return nil, errors.Wrap(err, "could not parse trust mark status response")
+	}
+	if r.Issuer != trustMarkIssuer {
+		return nil, errors.Errorf("trust mark status response was not issued by '%s'", trustMarkIssuer)
+	}
+	if err = verifyStatusResponseIssuedAt(r.IssuedAt); err != nil {
+		return nil, errors.Wrap(err, "invalid trust mark status response")
+	}
+	return &r, nil
+}
+
+// VerifyStatus verifies the status of the TrustMark at the status endpoint of
+// its issuer, which is discovered from the issuer's entity configuration; the
+// signed status response is verified with the issuer's keys obtained through
+// the passed trust anchor.
+// The obtained status is cached for TrustMarkStatusCheckInterval; if the
+// status cannot be obtained, the verification fails, see
+// TrustMarkStatusVerifier to fail open instead.
+// VerifyStatus only checks the status, it does not verify the TrustMark
+// itself, see VerifyFederation for this.
+func (tm *TrustMark) VerifyStatus(ta *EntityStatementPayload) error {
+	return TrustMarkStatusVerifier{}.VerifyStatus(tm, ta)
+}
+
+// VerifyStatus verifies the status of the passed TrustMark like
+// TrustMark.VerifyStatus, but fails open if configured
+func (v TrustMarkStatusVerifier) VerifyStatus(tm *TrustMark, ta *EntityStatementPayload) error {
+	if tm.jwtMsg == nil {
+		return errors.New("verify trust mark status: trust mark jwt not available")
+	}
+	rawJWT := string(tm.jwtMsg.RawJWT)
+	status, err := trustMarkStatus(tm.Issuer, rawJWT, ta)
+	if err != nil {
+		if v.failOpen(err) {
+			return nil
+		}
+		return errors.Wrap(err, "verify trust mark status")
+	}
+	if status != oidfedconst.TrustMarkStatusActive {
+		return errors.Errorf("verify trust mark status: trust mark is '%s'", status)
+	}
+	return nil
+}
+
+// VerifyWithStatus verifies the TrustMarkInfo by using the passed trust anchor
+// and additionally verifies its status at the trust mark issuer's status
+// endpoint, see TrustMark.VerifyStatus
+func (tm *TrustMarkInfo) VerifyWithStatus(ta *EntityStatementPayload) error {
+	return TrustMarkStatusVerifier{}.VerifyWithStatus(tm, ta)
+}
+
+// VerifyWithStatus verifies the passed TrustMarkInfo like
+// TrustMarkInfo.VerifyWithStatus, but fails open if configured; this also
+// applies to the status of the trust mark's delegation
+func (v TrustMarkStatusVerifier) VerifyWithStatus(tm *TrustMarkInfo, ta *EntityStatementPayload) error {
+	mark, err := tm.TrustMark()
+	if err != nil {
+		return err
+	}
+	if mark.TrustMarkType != tm.TrustMarkType {
+		return errors.Errorf("trust mark object claim 'trust_mark_type' does not match JWT claim")
+	}
+	if err = mark.verifyFederation(ta, v); err != nil {
+		return err
+	}
+	return v.VerifyStatus(mark, ta)
+}
+
+// VerifiedWithStatus verifies all TrustMarkInfos by using the passed trust
+// anchor and the status endpoints of the trust mark issuers and returns only
+// the valid and active TrustMarkInfos
+func (tms TrustMarkInfos) VerifiedWithStatus(ta *EntityStatementPayload) TrustMarkInfos {
+	return TrustMarkStatusVerifier{}.VerifiedWithStatus(tms, ta)
+}
+
+// VerifiedWithStatus returns the valid and active TrustMarkInfos like
+// TrustMarkInfos.VerifiedWithStatus, but fails open if configured
+func (v TrustMarkStatusVerifier) VerifiedWithStatus(
+	tms TrustMarkInfos, ta *EntityStatementPayload,
+) (verified TrustMarkInfos) {
+	for _, tm := range tms {
+		if err := v.VerifyWithStatus(&tm, ta); err == nil {
+			verified = append(verified, tm)
+		}
+	}
+	return
+}
+
+func trustMarkStatusCacheKey(rawJWT string) string {
+	hash := sha256.Sum256([]byte(rawJWT))
+	return cache.Key(cache.KeyTrustMarkStatus, hex.EncodeToString(hash[:]))
+}
+
+// trustMarkStatus returns the status of the passed trust mark jwt, either
+// from the cache or from the trust mark issuer's status endpoint
+func trustMarkStatus(trustMarkIssuer, rawJWT string, ta *EntityStatementPayload) (string, error) {
+	cacheKey := trustMarkStatusCacheKey(rawJWT)
+	var status string
+	set, err := cache.Get(cacheKey, &status)
+	if err != nil {
+		internal.Log(err)
+	} else if set {
+		internal.Log("Obtained trust mark status from cache")
+		return status, nil
+	}
+	status, err = fetchTrustMarkStatus(trustMarkIssuer, rawJWT, ta)
+	if err != nil {
+		return "", err
+	}
+	if err = cache.Set(cacheKey, status, TrustMarkStatusCheckInterval); err != nil {
+		internal.Log(err)
+	}
+	return status, nil
+}
+
+func fetchTrustMarkStatus(trustMarkIssuer, rawJWT string, ta *EntityStatementPayload) (string, error) {
+	ec, err := GetEntityConfiguration(trustMarkIssuer)
+	if err != nil {
+		return "", errors.Wrap(err, "could not obtain trust mark issuer entity configuration")
+	}
+	if ec.Metadata == nil || ec.Metadata.FederationEntity == nil ||
+		ec.Metadata.FederationEntity.FederationTrustMarkStatusEndpoint == "" {
+		return "", errors.Errorf("trust mark issuer '%s' does not publish a status endpoint", trustMarkIssuer)
+	}
+	endpoint := ec.Metadata.FederationEntity.FederationTrustMarkStatusEndpoint
+	keys, err := getTrustMarkIssuerJWKS(trustMarkIssuer, ta)
+	if err != nil {
+		return "", err
+	}
+	body, err := postStatusRequest(endpoint, "trust_mark", rawJWT)
+	if err != nil {
+		return "", err
+	}
+	r, err := ParseTrustMarkStatusResponse(body, trustMarkIssuer, keys)
+	if err != nil {
+		return "", err
+	}
+	if r.TrustMark != rawJWT {
+		return "", errors.New("trust mark status response is not about the requested trust mark")
+	}
+	return r.Status, nil
+}
+
+// postStatusRequest posts the passed jwt as the passed form parameter to a
+// status endpoint and returns the response body; if the endpoint cannot be
+// reached or responds with a server error, a statusUnavailableError is
+// returned
+func postStatusRequest(endpoint, param, rawJWT string) ([]byte, error) {
+	params := url.Values{}
+	params.Set(param, rawJWT)
+	res, errRes, err := http.PostForm(endpoint, params, nil)
+	if err != nil {
+		return nil, statusUnavailableError{err}
+	}
+	if errRes != nil {
+		if errRes.Status >= 500 {
+			return nil, statusUnavailableError{errRes.Err()}
+		}
+		return nil, errRes.Err()
+	}
+	if res.IsError() {
+		err = errors.Errorf("status endpoint '%s' returned status code %d", endpoint, res.StatusCode())
+		if res.StatusCode() >= 500 {
+			return nil, statusUnavailableError{err}
+		}
+		return nil, err
+	}
+	return res.Body(), nil
+}
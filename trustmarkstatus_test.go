@@ -0,0 +1,241 @@
+package oidfed
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+
+	"github.com/lionick/oidfed-lib/oidfedconst"
+	"github.com/lionick/oidfed-lib/unixtime"
+)
+
+func TestTrustMark_VerifyStatus(t *testing.T) {
+	const trustMarkType = "https://trustmarks.org/tm1"
+	ta := taWithTmo.EntityStatementPayload()
+
+	unavailable := func() {
+		httpmock.RegisterResponder(
+			"POST", tmi1.statusEndpoint(), httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+		)
+	}
+	invalidResponse := func() {
+		httpmock.RegisterResponder(
+			"POST", tmi1.statusEndpoint(), httpmock.NewStringResponder(http.StatusOK, "invalid"),
+		)
+	}
+	clientError := func() {
+		httpmock.RegisterResponder(
+			"POST", tmi1.statusEndpoint(), httpmock.NewStringResponder(http.StatusBadRequest, ""),
+		)
+	}
+	restore := func() {
+		mockTrustMarkStatusEndpoint(tmi1.statusEndpoint(), tmi1)
+	}
+
+	tests := []struct {
+		name        string
+		sub         string
+		revoke      bool
+		failOpen    bool
+		setup       func()
+		errExpected bool
+	}{
+		{
+			name: "active",
+			sub:  "https://active.example.org",
+		},
+		{
+			name:        "revoked",
+			sub:         "https://revoked.example.org",
+			revoke:      true,
+			errExpected: true,
+		},
+		{
+			name:        "endpoint unavailable fail closed",
+			sub:         "https://unavailable-closed.example.org",
+			setup:       unavailable,
+			errExpected: true,
+		},
+		{
+			name:     "endpoint unavailable fail open",
+			sub:      "https://unavailable-open.example.org",
+			failOpen: true,
+			setup:    unavailable,
+		},
+		{
+			name:        "invalid response fail open",
+			sub:         "https://invalid-response-open.example.org",
+			failOpen:    true,
+			setup:       invalidResponse,
+			errExpected: true,
+		},
+		{
+			name:        "client error fail open",
+			sub:         "https://client-error-open.example.org",
+			failOpen:    true,
+			setup:       clientError,
+			errExpected: true,
+		},
+		{
+			name:        "revoked fail open",
+			sub:         "https://revoked-open.example.org",
+			revoke:      true,
+			failOpen:    true,
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				if test.setup != nil {
+					test.setup()
+					defer restore()
+				}
+				info, err := tmi1.IssueTrustMark(trustMarkType, test.sub)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if test.revoke {
+					if err = tmi1.Revoke(trustMarkType, test.sub); err != nil {
+						t.Fatal(err)
+					}
+				}
+				err = TrustMarkStatusVerifier{FailOpen: test.failOpen}.VerifyWithStatus(info, ta)
+				if err != nil && !test.errExpected {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if err == nil && test.errExpected {
+					t.Errorf("expected error, but no error returned")
+				}
+			},
+		)
+	}
+}
+
+func TestTrustMark_VerifyStatus_Cached(t *testing.T) {
+	const sub = "https://cached.example.org"
+	const trustMarkType = "https://trustmarks.org/tm1"
+	ta := taWithTmo.EntityStatementPayload()
+	info, err := tmi1.IssueTrustMark(trustMarkType, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tmi1.IssueTrustMark(trustMarkType, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = info.VerifyWithStatus(ta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = tmi1.Revoke(trustMarkType, sub); err != nil {
+		t.Fatal(err)
+	}
+	if err = info.VerifyWithStatus(ta); err != nil {
+		t.Errorf("status must be cached until the next check interval: %v", err)
+	}
+	if err = other.VerifyWithStatus(ta); err == nil {
+		t.Errorf("expected revoked status for a trust mark that was not checked before")
+	}
+}
+
+func TestTrustMarkInfos_VerifiedWithStatus(t *testing.T) {
+	const revoked = "https://revoked-infos.example.org"
+	var infos TrustMarkInfos
+	for _, sub := range []string{"https://active-infos.example.org", revoked} {
+		info, err := tmi1.IssueTrustMark("https://trustmarks.org/tm1", sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, *info)
+	}
+	if err := tmi1.Revoke("https://trustmarks.org/tm1", revoked); err != nil {
+		t.Fatal(err)
+	}
+	verified := infos.VerifiedWithStatus(taWithTmo.EntityStatementPayload())
+	if len(verified) != 1 || verified[0].TrustMarkJWT != infos[0].TrustMarkJWT {
+		t.Errorf("expected only the active trust mark, but got %d trust marks", len(verified))
+	}
+}
+
+func TestParseTrustMarkStatusResponse(t *testing.T) {
+	signer := newTestJWTSigner(t)
+	payload := TrustMarkStatusResponse{
+		Issuer:    "https://tmi.example.org",
+		IssuedAt:  unixtime.Now(),
+		TrustMark: "eyJ...",
+		Status:    oidfedconst.TrustMarkStatusActive,
+	}
+	data, err := signer.TrustMarkStatusResponseSigner().JWT(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseTrustMarkStatusResponse(data, payload.Issuer, signer.JWKS())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Status != payload.Status || r.TrustMark != payload.TrustMark {
+		t.Errorf("unexpected status response: %+v", r)
+	}
+	if _, err = ParseTrustMarkStatusResponse(data, "https://other.example.org", signer.JWKS()); err == nil {
+		t.Errorf("expected error for wrong issuer")
+	}
+	if _, err = ParseTrustMarkStatusResponse(data, payload.Issuer, newTestJWTSigner(t).JWKS()); err == nil {
+		t.Errorf("expected error for wrong keys")
+	}
+	wrongType, err := signer.TrustMarkSigner().JWT(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseTrustMarkStatusResponse(wrongType, payload.Issuer, signer.JWKS()); err == nil {
+		t.Errorf("expected error for wrong jwt type")
+	}
+
+	for name, iat := range issuedAtTestCases() {
+		t.Run(
+			name, func(t *testing.T) {
+				p := payload
+				p.IssuedAt = iat.iat
+				data, err := signer.TrustMarkStatusResponseSigner().JWT(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = ParseTrustMarkStatusResponse(data, p.Issuer, signer.JWKS())
+				if (err != nil) != iat.errExpected {
+					t.Errorf("unexpected error: %v", err)
+				}
+			},
+		)
+	}
+}
+
+type issuedAtTestCase struct {
+	iat         unixtime.Unixtime
+	errExpected bool
+}
+
+// issuedAtTestCases returns the test cases for checking the 'iat' claim of
+// trust mark and delegation status responses
+func issuedAtTestCases() map[string]issuedAtTestCase {
+	now := time.Now()
+	return map[string]issuedAtTestCase{
+		"recent iat": {
+			iat: unixtime.Unixtime{Time: now.Add(-time.Minute)},
+		},
+		"iat within clock skew": {
+			iat: unixtime.Unixtime{Time: now.Add(StatusResponseClockSkew / 2)},
+		},
+		"missing iat": {
+			errExpected: true,
+		},
+		"stale iat": {
+			iat:         unixtime.Unixtime{Time: now.Add(-TrustMarkStatusCheckInterval - 2*StatusResponseClockSkew)},
+			errExpected: true,
+		},
+		"future iat": {
+			iat:         unixtime.Unixtime{Time: now.Add(2 * StatusResponseClockSkew)},
+			errExpected: true,
+		},
+	}
+}
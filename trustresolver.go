@@ -143,7 +143,13 @@ type TrustResolver struct {
 	TrustAnchors   []TrustAnchor
 	StartingEntity string
 	Types          []string
-	trustTree      trustTree
+	// PolicyEngine is used to verify the metadata policies;
+	// if not set, the DefaultPolicyEngine is used
+	PolicyEngine *PolicyEngine
+	// ValidateMetadata indicates that the final Metadata of the TrustChains
+	// returned by ResolveToValidChains must also pass Metadata.Validate
+	ValidateMetadata bool
+	trustTree        trustTree
 }
 
 func (r TrustResolver) hash() ([]byte, error) {
@@ -169,13 +175,25 @@ func (r TrustResolver) hash() ([]byte, error) {
 }
 
 // ResolveToValidChains starts the trust chain resolution process, building an internal trust tree,
-// verifies the signatures, integrity, expirations, and metadata policies and returns all possible valid TrustChains
+// verifies the signatures, integrity, expirations, and metadata policies and returns all possible valid TrustChains.
+// If ValidateMetadata is set, the final Metadata is also validated.
 func (r *TrustResolver) ResolveToValidChains() TrustChains {
 	chains := r.ResolveToValidChainsWithoutVerifyingMetadata()
 	if chains == nil {
 		return nil
 	}
-	return chains.Filter(TrustChainsFilterValidMetadata)
+	chains = chains.Filter(r.PolicyEngine.TrustChainsFilterValidMetadata())
+	if r.ValidateMetadata {
+		chains = chains.Filter(
+			NewTrustChainsFilterFromCheckerFnc(
+				func(chain TrustChain) bool {
+					m, err := r.PolicyEngine.TrustChainMetadata(chain)
+					return err == nil && m.Validate() == nil
+				},
+			),
+		)
+	}
+	return chains
 }
 
 // ResolveToValidChainsWithoutVerifyingMetadata starts the trust chain